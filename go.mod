@@ -84,6 +84,7 @@ require (
 	github.com/robfig/cron/v3 v3.0.1 // @grafana/backend-platform
 	github.com/russellhaering/goxmldsig v1.4.0 // @grafana/backend-platform
 	github.com/scottlepp/go-duck v0.0.15 // @grafana/grafana-app-platform-squad
+	github.com/spyzhov/ajson v0.9.0 // @grafana/grafana-app-platform-squad
 	github.com/stretchr/testify v1.8.4 // @grafana/backend-platform
 	github.com/teris-io/shortid v0.0.0-20171029131806-771a37caa5cf // @grafana/backend-platform
 	github.com/ua-parser/uap-go v0.0.0-20211112212520-00c877edfe0f // @grafana/backend-platform
@@ -450,8 +451,6 @@ require (
 
 require k8s.io/code-generator v0.29.1 // @grafana/grafana-app-platform-squad
 
-require github.com/spyzhov/ajson v0.9.0 // @grafana/grafana-app-platform-squad
-
 require github.com/fullstorydev/grpchan v1.1.1 // @grafana/backend-platform
 
 // This needs to be here for other projects that import grafana/grafana
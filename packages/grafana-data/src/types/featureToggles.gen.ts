@@ -179,4 +179,6 @@ export interface FeatureToggles {
   scopeFilters?: boolean;
   emailVerificationEnforcement?: boolean;
   ssoSettingsSAML?: boolean;
+  alertingSaveStateIncremental?: boolean;
+  azureMonitorMetricsBatchQueries?: boolean;
 }
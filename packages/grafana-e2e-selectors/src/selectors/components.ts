@@ -74,6 +74,8 @@ export const Components = {
         incrementalQuerying: 'prometheus-incremental-querying', // id for switch component
         queryOverlapWindow: 'data-testid query overlap window',
         disableRecordingRules: 'disable-recording-rules', // id for switch component
+        queryConcurrency: 'data-testid query concurrency',
+        querySplitInterval: 'data-testid query split interval',
         customQueryParameters: 'data-testid custom query parameters',
         httpMethod: 'data-testid http method',
         exemplarsAddButton: 'data-testid Add exemplar config button',
@@ -49,6 +49,8 @@ export interface PromOptions extends DataSourceJsonData {
   incrementalQuerying?: boolean;
   incrementalQueryOverlapWindow?: string;
   disableRecordingRules?: boolean;
+  queryConcurrency?: number;
+  querySplitInterval?: string;
   sigV4Auth?: boolean;
   oauthPassThru?: boolean;
 }
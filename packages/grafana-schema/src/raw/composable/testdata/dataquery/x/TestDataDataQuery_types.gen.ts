@@ -34,6 +34,7 @@ export enum TestDataQueryType {
   RandomWalkTable = 'random_walk_table',
   RandomWalkWithError = 'random_walk_with_error',
   RawFrame = 'raw_frame',
+  Replay = 'replay',
   ServerError500 = 'server_error_500',
   Simulation = 'simulation',
   SlowQuery = 'slow_query',
@@ -127,6 +128,10 @@ export interface TestDataDataQuery extends common.DataQuery {
   points?: Array<Array<(string | number)>>;
   pulseWave?: PulseWaveQuery;
   rawFrameContent?: string;
+  /**
+   * A recorded query response to replay, in the format of the query API with the recorded time range
+   */
+  recording?: string;
   scenarioId?: TestDataQueryType;
   seriesCount?: number;
   sim?: SimulationQuery;
@@ -132,6 +132,30 @@ export function QueryHeader({
           options={QUERY_FORMAT_OPTIONS}
         />
 
+        {dialect !== 'influx' && query.format === QueryFormat.Table && (
+          <Tooltip content="Show the rows while the query runs, they are sent in batches over Grafana Live">
+            <InlineSwitch
+              id={`sql-stream-${uuidv4()}}`}
+              label="Stream"
+              transparent={true}
+              showLabel={true}
+              value={Boolean(query.stream)}
+              onChange={(ev) => {
+                if (!(ev.target instanceof HTMLInputElement)) {
+                  return;
+                }
+
+                reportInteraction('grafana_sql_stream_toggled', {
+                  datasource: query.datasource?.type,
+                  displayed: ev.target.checked,
+                });
+
+                onChange({ ...query, stream: ev.target.checked });
+              }}
+            />
+          </Tooltip>
+        )}
+
         {editorMode === EditorMode.Builder && (
           <>
             <InlineSwitch
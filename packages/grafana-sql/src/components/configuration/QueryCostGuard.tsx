@@ -0,0 +1,93 @@
+import React from 'react';
+
+import { DataSourceSettings, SelectableValue } from '@grafana/data';
+import { ConfigSubSection, Stack } from '@grafana/experimental';
+import { Field, Icon, Label, RadioButtonGroup, Tooltip } from '@grafana/ui';
+
+import { CostGuardMode, SQLOptions } from '../../types';
+
+import { NumberInput } from './NumberInput';
+
+interface Props {
+  onOptionsChange: Function;
+  options: DataSourceSettings<SQLOptions>;
+}
+
+// DefaultCostGuardMaxRows in pkg/tsdb/sqleng/cost_guard.go.
+const DEFAULT_MAX_ROWS = 1000000;
+
+const modeOptions: Array<SelectableValue<CostGuardMode>> = [
+  { label: 'Off', value: '', description: 'Queries are executed without explaining them' },
+  { label: 'Warn', value: 'warn', description: 'Queries are executed and show a warning' },
+  { label: 'Reject', value: 'reject', description: 'Queries fail without being executed' },
+];
+
+export const QueryCostGuard = (props: Props) => {
+  const { onOptionsChange, options } = props;
+  const jsonData = options.jsonData;
+
+  const updateJsonData = (values: Partial<SQLOptions>) => {
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...jsonData,
+        ...values,
+      },
+    });
+  };
+
+  const labelWidth = 40;
+
+  return (
+    <ConfigSubSection title="Query cost guard">
+      <Field
+        label={
+          <Label>
+            <Stack gap={0.5}>
+              <span>Mode</span>
+              <Tooltip
+                content={
+                  <span>
+                    Queries are explained before they are executed. If their plan contains a full scan of a table that
+                    is estimated to read more rows than <i>Max scanned rows</i>, they either show a warning or fail.
+                    Queries that cannot be explained are executed.
+                  </span>
+                }
+              >
+                <Icon name="info-circle" size="sm" />
+              </Tooltip>
+            </Stack>
+          </Label>
+        }
+      >
+        <RadioButtonGroup
+          options={modeOptions}
+          value={jsonData.costGuardMode ?? ''}
+          onChange={(costGuardMode) => updateJsonData({ costGuardMode })}
+        />
+      </Field>
+
+      {jsonData.costGuardMode && (
+        <Field
+          label={
+            <Label>
+              <Stack gap={0.5}>
+                <span>Max scanned rows</span>
+                <Tooltip content={<span>The number of rows a full scan of a table may read.</span>}>
+                  <Icon name="info-circle" size="sm" />
+                </Tooltip>
+              </Stack>
+            </Label>
+          }
+        >
+          <NumberInput
+            value={jsonData.costGuardMaxRows ?? DEFAULT_MAX_ROWS}
+            defaultValue={DEFAULT_MAX_ROWS}
+            onChange={(costGuardMaxRows) => updateJsonData({ costGuardMaxRows })}
+            width={labelWidth}
+          />
+        </Field>
+      )}
+    </ConfigSubSection>
+  );
+};
@@ -1,5 +1,5 @@
-import { lastValueFrom, Observable, throwError } from 'rxjs';
-import { map } from 'rxjs/operators';
+import { defer, lastValueFrom, merge, Observable, throwError } from 'rxjs';
+import { map, mergeMap } from 'rxjs/operators';
 
 import {
   getDefaultTimeRange,
@@ -16,6 +16,7 @@ import {
   LegacyMetricFindQueryOptions,
   VariableWithMultiSupport,
   TimeRange,
+  LiveChannelScope,
 } from '@grafana/data';
 import { EditorMode } from '@grafana/experimental';
 import {
@@ -27,6 +28,7 @@ import {
   toDataQueryResponse,
   TemplateSrv,
   reportInteraction,
+  getGrafanaLiveSrv,
 } from '@grafana/runtime';
 
 import { ResponseParser } from '../ResponseParser';
@@ -34,6 +36,7 @@ import { SqlQueryEditor } from '../components/QueryEditor';
 import { MACRO_NAMES } from '../constants';
 import { DB, SQLQuery, SQLOptions, SqlQueryModel, QueryFormat } from '../types';
 import migrateAnnotation from '../utils/migration';
+import { getStreamPath, SqlStreamQuery } from '../utils/streaming';
 
 import { isSqlDatasourceDatabaseSelectionFeatureFlagEnabled } from './../components/QueryEditorFeatureFlag.utils';
 
@@ -149,7 +152,54 @@ export abstract class SqlDatasource extends DataSourceWithBackend<SQLQuery, SQLO
       });
     });
 
-    return super.query(request);
+    const streamed = request.targets.filter((target) => this.isStreamed(target));
+    if (!streamed.length) {
+      return super.query(request);
+    }
+
+    const streams = streamed.map((target) => this.streamQuery(target, request));
+    const targets = request.targets.filter((target) => !this.isStreamed(target));
+    if (targets.length) {
+      streams.push(super.query({ ...request, targets }));
+    }
+    return merge(...streams);
+  }
+
+  // Only table queries can be streamed, time series are built from the complete result.
+  private isStreamed(target: SQLQuery): boolean {
+    return Boolean(target.stream) && target.format === QueryFormat.Table && !target.hide && Boolean(target.rawSql);
+  }
+
+  private streamQuery(target: SQLQuery, request: DataQueryRequest<SQLQuery>): Observable<DataQueryResponse> {
+    const query = this.applyTemplateVariables(target, request.scopedVars);
+    const data: SqlStreamQuery = {
+      refId: query.refId,
+      rawSql: query.rawSql ?? '',
+      format: QueryFormat.Table,
+      timeRange: {
+        from: request.range.from.valueOf(),
+        to: request.range.to.valueOf(),
+      },
+      intervalMs: Math.round(request.intervalMs),
+    };
+
+    return defer(() => getStreamPath(data)).pipe(
+      mergeMap((path) =>
+        getGrafanaLiveSrv().getDataStream({
+          key: `${request.requestId}.${query.refId}`,
+          addr: {
+            scope: LiveChannelScope.DataSource,
+            namespace: this.uid,
+            path,
+            data,
+          },
+          // The rows are appended until the query finished, the backend limits the number of rows and bytes.
+          buffer: {
+            maxLength: Infinity,
+          },
+        })
+      )
+    );
   }
 
   private checkForDatabaseIssue(request: DataQueryRequest<SQLQuery>) {
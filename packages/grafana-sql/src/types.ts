@@ -43,8 +43,13 @@ export interface SQLOptions extends SQLConnectionLimits, DataSourceJsonData {
   database: string;
   url: string;
   timeInterval: string;
+  costGuardMode?: CostGuardMode;
+  costGuardMaxRows?: number;
 }
 
+// CostGuardMode is what happens to queries whose plan contains a full scan of more than costGuardMaxRows rows.
+export type CostGuardMode = '' | 'warn' | 'reject';
+
 export enum QueryFormat {
   Timeseries = 'time_series',
   Table = 'table',
@@ -59,6 +64,8 @@ export interface SQLQuery extends DataQuery {
   sql?: SQLExpression;
   editorMode?: EditorMode;
   rawQuery?: boolean;
+  // stream sends the rows of table queries in batches over Grafana Live while the query runs.
+  stream?: boolean;
 }
 
 export interface NameValue {
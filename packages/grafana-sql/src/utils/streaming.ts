@@ -0,0 +1,37 @@
+import { config } from '@grafana/runtime';
+
+import { QueryFormat } from '../types';
+
+// The data of the channel of a streamed query, the backend runs the query with the data of the first subscriber.
+export interface SqlStreamQuery {
+  refId: string;
+  rawSql: string;
+  format: QueryFormat;
+  timeRange: {
+    from: number;
+    to: number;
+  };
+  intervalMs: number;
+}
+
+// The path of the channel is a hash of the organization, the user and the query, which the backend checks when
+// subscribing. It has to match `streamKey` in pkg/tsdb/sqleng/stream.go.
+export async function getStreamPath(query: SqlStreamQuery): Promise<string> {
+  const { orgId, login } = config.bootData.user;
+  const str = [
+    orgId,
+    login,
+    query.refId,
+    query.format,
+    query.timeRange.from,
+    query.timeRange.to,
+    query.intervalMs,
+    query.rawSql,
+  ].join('\n');
+
+  const hashBuffer = await crypto.subtle.digest('SHA-256', new TextEncoder().encode(str));
+  const hash = Array.from(new Uint8Array(hashBuffer))
+    .map((b) => b.toString(16).padStart(2, '0'))
+    .join('');
+  return `query/${hash}`;
+}
@@ -79,6 +79,9 @@ type MetricRequest struct {
 	Queries []*simplejson.Json `json:"queries"`
 	// required: false
 	Debug bool `json:"debug"`
+	// Record returns the response with the time range of the request, so it can be replayed with the replay scenario of the TestData data source.
+	// required: false
+	Record bool `json:"record"`
 }
 
 func (mr *MetricRequest) GetUniqueDatasourceTypes() []string {
@@ -17,6 +17,7 @@ import (
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/query"
 	"github.com/grafana/grafana/pkg/util/errutil/errhttp"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -82,10 +83,23 @@ func (hs *HTTPServer) QueryMetricsV2(c *contextmodel.ReqContext) response.Respon
 	if err != nil {
 		return hs.handleQueryMetricsError(err)
 	}
+	if reqDTO.Record {
+		recording, err := query.NewRecording(reqDTO, resp)
+		if err != nil {
+			return response.Error(http.StatusInternalServerError, "Failed to record the query response", err)
+		}
+		return response.JSON(hs.queryDataStatus(c.Req.Context(), resp), recording)
+	}
 	return hs.toJsonStreamingResponse(c.Req.Context(), resp)
 }
 
 func (hs *HTTPServer) toJsonStreamingResponse(ctx context.Context, qdr *backend.QueryDataResponse) response.Response {
+	return response.JSONStreaming(hs.queryDataStatus(ctx, qdr), qdr)
+}
+
+// queryDataStatus returns the status of the response of a query request, the request fails when one of the queries
+// has an error, unless the multi status responses are enabled.
+func (hs *HTTPServer) queryDataStatus(ctx context.Context, qdr *backend.QueryDataResponse) int {
 	statusWhenError := http.StatusBadRequest
 	if hs.Features.IsEnabled(ctx, featuremgmt.FlagDatasourceQueryMultiStatus) {
 		statusWhenError = http.StatusMultiStatus
@@ -103,7 +117,7 @@ func (hs *HTTPServer) toJsonStreamingResponse(ctx context.Context, qdr *backend.
 		requestmeta.WithDownstreamStatusSource(ctx)
 	}
 
-	return response.JSONStreaming(statusCode, qdr)
+	return statusCode
 }
 
 // swagger:parameters queryMetricsWithExpressions
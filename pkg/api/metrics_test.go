@@ -107,6 +107,19 @@ func TestAPIEndpoint_Metrics_QueryMetricsV2(t *testing.T) {
 		require.NoError(t, resp.Body.Close())
 		require.Equal(t, http.StatusMultiStatus, resp.StatusCode)
 	})
+
+	t.Run("Status code of a recording is 400 when data source response has an error and feature toggle is disabled", func(t *testing.T) {
+		body := strings.Replace(reqValid, `"from"`, `"record": true, "from"`, 1)
+		req := serverFeatureDisabled.NewPostRequest("/api/ds/query", strings.NewReader(body))
+		webtest.RequestWithSignedInUser(req, &user.SignedInUser{UserID: 1, OrgID: 1, Permissions: map[int64]map[string][]string{1: {datasources.ActionQuery: []string{datasources.ScopeAll}}}})
+		resp, err := serverFeatureDisabled.SendJSON(req)
+		require.NoError(t, err)
+		var recording query.Recording
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&recording))
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		require.Contains(t, recording.Results, "A")
+	})
 }
 
 func TestAPIEndpoint_Metrics_PluginDecryptionFailure(t *testing.T) {
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/lint"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// lintAlertRulesCommand analyzes the alert rules of an organization and prints the report as JSON.
+// It fails if a problem of the severity set by the flag fail-on or higher is found, so it can be used to gate CI pipelines.
+func lintAlertRulesCommand(c utils.CommandLine, runner server.Runner) error {
+	ctx := context.Background()
+	orgID := int64(c.Int("org-id"))
+	failOn := apimodels.RuleLintSeverity(c.String("fail-on"))
+	if failOn.Level() == 0 {
+		return fmt.Errorf("invalid value of flag fail-on: %s", failOn)
+	}
+
+	dbStore := store.DBstore{
+		Cfg:      runner.Cfg.UnifiedAlerting,
+		SQLStore: runner.SQLStore,
+		Logger:   log.New("ngalert.dbstore"),
+	}
+	rules, err := dbStore.ListAlertRules(ctx, &ngmodels.ListAlertRulesQuery{OrgID: orgID})
+	if err != nil {
+		return fmt.Errorf("failed to list alert rules: %w", err)
+	}
+
+	folderUIDs := make([]string, 0)
+	seen := make(map[string]struct{})
+	for _, rule := range rules {
+		if _, ok := seen[rule.NamespaceUID]; !ok {
+			seen[rule.NamespaceUID] = struct{}{}
+			folderUIDs = append(folderUIDs, rule.NamespaceUID)
+		}
+	}
+	opts := lint.Options{FolderTitles: make(map[string]string, len(folderUIDs))}
+	if len(folderUIDs) > 0 {
+		folders, err := runner.DashboardService.GetDashboards(ctx, &dashboards.GetDashboardsQuery{OrgID: orgID, DashboardUIDs: folderUIDs})
+		if err != nil {
+			return fmt.Errorf("failed to get folders: %w", err)
+		}
+		for _, f := range folders {
+			opts.FolderTitles[f.UID] = f.Title
+		}
+	}
+
+	amConfig, err := dbStore.GetLatestAlertmanagerConfiguration(ctx, orgID)
+	if err != nil && !errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+		return fmt.Errorf("failed to get latest Alertmanager configuration: %w", err)
+	}
+	if amConfig != nil {
+		amCfg, err := notifier.Load([]byte(amConfig.AlertmanagerConfiguration))
+		if err != nil {
+			return fmt.Errorf("failed to parse Alertmanager configuration: %w", err)
+		}
+		opts.Route = amCfg.AlertmanagerConfig.Route
+	}
+
+	report := lint.Analyze(rules, opts)
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	logger.Info(string(b))
+
+	failed := 0
+	for severity, count := range report.Summary {
+		if severity.Level() >= failOn.Level() {
+			failed += count
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("found %d problems of severity %s or higher", failed, failOn)
+	}
+	return nil
+}
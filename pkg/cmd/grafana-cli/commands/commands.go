@@ -146,6 +146,23 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:   "lint-alert-rules",
+		Usage:  "Analyzes the alert rules of an organization and reports common problems as JSON. Returns an error if problems of the severity set by --fail-on or higher are found.",
+		Action: runRunnerCommand(lintAlertRulesCommand),
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "org-id",
+				Usage: "The ID of the organization whose alert rules are analyzed",
+				Value: 1,
+			},
+			&cli.StringFlag{
+				Name:  "fail-on",
+				Usage: "The lowest severity of the problems that make the command fail: error, warning or info",
+				Value: "error",
+			},
+		},
+	},
 	{
 		Name:  "secrets-migration",
 		Usage: "Runs a script that migrates secrets in your database",
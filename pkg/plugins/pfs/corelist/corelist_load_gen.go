@@ -39,6 +39,7 @@ func corePlugins(rt *thema.Runtime) []pfs.ParsedPlugin {
 		parsePluginOrPanic("public/app/plugins/datasource/dashboard", "dashboard", rt),
 		parsePluginOrPanic("public/app/plugins/datasource/elasticsearch", "elasticsearch", rt),
 		parsePluginOrPanic("public/app/plugins/datasource/grafana", "grafana", rt),
+		parsePluginOrPanic("public/app/plugins/datasource/grafana-httpjson-datasource", "grafana_httpjson_datasource", rt),
 		parsePluginOrPanic("public/app/plugins/datasource/grafana-postgresql-datasource", "grafana_postgresql_datasource", rt),
 		parsePluginOrPanic("public/app/plugins/datasource/grafana-pyroscope-datasource", "grafana_pyroscope_datasource", rt),
 		parsePluginOrPanic("public/app/plugins/datasource/grafana-testdata-datasource", "grafana_testdata_datasource", rt),
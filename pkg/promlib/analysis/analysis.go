@@ -0,0 +1,324 @@
+// Package analysis finds common mistakes in PromQL queries without running them.
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+const (
+	CheckRateOverGauge      = "rate-over-gauge"
+	CheckCounterWithoutRate = "counter-without-rate"
+	CheckUnboundedRegex     = "unbounded-regex"
+	CheckSumWithoutBy       = "sum-without-by"
+
+	// DefaultHighCardinalityThreshold is the number of series above which an aggregation without grouping is reported.
+	DefaultHighCardinalityThreshold = 1000
+)
+
+type Severity string
+
+const (
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Problem is a mistake found in a query. Start and End are the positions of the part of the query it is found in.
+type Problem struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Start    int      `json:"start"`
+	End      int      `json:"end"`
+}
+
+// Selector is a series selector of a query and the number of series it selects, if it is known. Truncated is set
+// if the series were not counted further, so the selector selects at least Series series.
+type Selector struct {
+	Selector  string `json:"selector"`
+	Series    *int   `json:"series,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type Result struct {
+	Problems  []Problem  `json:"problems"`
+	Selectors []Selector `json:"selectors"`
+}
+
+// Options contain the information about the metrics of the query that is fetched from Prometheus.
+type Options struct {
+	// MetricTypes maps metric names to their type as returned by the metadata API, such as counter or gauge.
+	MetricTypes map[string]string
+	// SeriesCount maps the selectors returned by Selectors to the number of series they select.
+	SeriesCount map[string]int
+	// HighCardinalityThreshold is the number of series above which aggregating without grouping is reported.
+	HighCardinalityThreshold int
+}
+
+// counterFunctions are the functions that calculate the rate of counters.
+var counterFunctions = map[string]bool{"rate": true, "irate": true, "increase": true, "resets": true}
+
+// counterSafeFunctions are the functions and aggregations that may be applied to the raw value of a counter.
+var counterSafeFunctions = map[string]bool{"absent": true, "timestamp": true, "count": true, "group": true, "count_values": true}
+
+// Selectors returns the distinct series selectors of the query without offset and @ modifiers, which can be passed
+// to the series API of Prometheus.
+func Selectors(query string) ([]string, error) {
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]struct{}{}
+	var selectors []string
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok {
+			s := selectorString(vs)
+			if _, ok := seen[s]; !ok {
+				seen[s] = struct{}{}
+				selectors = append(selectors, s)
+			}
+		}
+		return nil
+	})
+	return selectors, nil
+}
+
+// MetricNames returns the distinct metric names the query selects by equality.
+func MetricNames(query string) ([]string, error) {
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]struct{}{}
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok {
+			if name := metricName(vs); name != "" {
+				seen[name] = struct{}{}
+			}
+		}
+		return nil
+	})
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Analyze parses the query and returns the problems found. It returns an error if the query cannot be parsed.
+func Analyze(query string, opts Options) (*Result, error) {
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return nil, err
+	}
+	if opts.HighCardinalityThreshold <= 0 {
+		opts.HighCardinalityThreshold = DefaultHighCardinalityThreshold
+	}
+
+	a := analyzer{opts: opts}
+	parser.Inspect(expr, func(node parser.Node, path []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.Call:
+			a.checkRate(n)
+		case *parser.VectorSelector:
+			a.checkCounter(n, path)
+			a.checkMatchers(n)
+		case *parser.AggregateExpr:
+			a.checkAggregation(n)
+		}
+		return nil
+	})
+
+	result := &Result{Problems: a.problems, Selectors: []Selector{}}
+	if result.Problems == nil {
+		result.Problems = []Problem{}
+	}
+	selectors, _ := Selectors(query)
+	for _, s := range selectors {
+		selector := Selector{Selector: s}
+		if count, ok := opts.SeriesCount[s]; ok {
+			selector.Series = &count
+		}
+		result.Selectors = append(result.Selectors, selector)
+	}
+	return result, nil
+}
+
+type analyzer struct {
+	opts     Options
+	problems []Problem
+}
+
+func (a *analyzer) report(node parser.Node, check string, severity Severity, format string, args ...any) {
+	pos := node.PositionRange()
+	a.problems = append(a.problems, Problem{
+		Check:    check,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+		Start:    int(pos.Start),
+		End:      int(pos.End),
+	})
+}
+
+// checkRate reports rates calculated over gauges, which only make sense for values that never decrease.
+func (a *analyzer) checkRate(call *parser.Call) {
+	if !counterFunctions[call.Func.Name] {
+		return
+	}
+	for _, arg := range call.Args {
+		ms, ok := unwrap(arg).(*parser.MatrixSelector)
+		if !ok {
+			continue
+		}
+		vs, ok := ms.VectorSelector.(*parser.VectorSelector)
+		if !ok {
+			continue
+		}
+		if name := metricName(vs); name != "" && a.opts.MetricTypes[name] == "gauge" {
+			a.report(call, CheckRateOverGauge, SeverityWarning,
+				"%s is a gauge, %s() should only be used with counters; use deriv() or delta() instead", name, call.Func.Name)
+		}
+	}
+}
+
+// checkCounter reports counters that are used without calculating their rate, their raw value depends on when
+// the process that exposes them was restarted.
+func (a *analyzer) checkCounter(vs *parser.VectorSelector, path []parser.Node) {
+	name := metricName(vs)
+	if name == "" || !a.isCounter(name) {
+		return
+	}
+	// Counters in range selectors are passed to a function.
+	if len(path) > 0 {
+		if _, ok := path[len(path)-1].(*parser.MatrixSelector); ok {
+			return
+		}
+	}
+	for _, node := range path {
+		switch n := node.(type) {
+		case *parser.Call:
+			if counterSafeFunctions[n.Func.Name] {
+				return
+			}
+		case *parser.AggregateExpr:
+			if counterSafeFunctions[n.Op.String()] {
+				return
+			}
+		case *parser.SubqueryExpr:
+			// Subqueries are passed to a function over time, such as rate(counter[5m:1m]).
+			return
+		}
+	}
+	a.report(vs, CheckCounterWithoutRate, SeverityWarning,
+		"%s is a counter, its value should be used with rate() or increase()", name)
+}
+
+func (a *analyzer) isCounter(name string) bool {
+	if t, ok := a.opts.MetricTypes[name]; ok {
+		return t == "counter"
+	}
+	// The metadata API returns the type of histograms and summaries for their name without suffix.
+	for _, suffix := range []string{"_bucket", "_count", "_sum"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			if t := a.opts.MetricTypes[base]; t == "histogram" || t == "summary" {
+				return true
+			}
+		}
+	}
+	return strings.HasSuffix(name, "_total")
+}
+
+// checkMatchers reports regex matchers that match every value and selectors that select every metric.
+func (a *analyzer) checkMatchers(vs *parser.VectorSelector) {
+	hasEquality := false
+	for _, m := range vs.LabelMatchers {
+		switch m.Type {
+		case labels.MatchEqual:
+			if m.Value != "" {
+				hasEquality = true
+			}
+		case labels.MatchRegexp:
+			switch m.Value {
+			case ".*":
+				a.report(vs, CheckUnboundedRegex, SeverityInfo,
+					"%s matches every value, remove it from the selector", m.String())
+			case ".+":
+				a.report(vs, CheckUnboundedRegex, SeverityInfo,
+					"%s matches every value, use %s!=\"\" instead", m.String(), m.Name)
+			default:
+				if strings.HasPrefix(m.Value, ".*") || strings.HasPrefix(m.Value, ".+") {
+					a.report(vs, CheckUnboundedRegex, SeverityWarning,
+						"%s starts with a wildcard, which has to be matched against every value of %s", m.String(), m.Name)
+				}
+			}
+		}
+	}
+	if !hasEquality {
+		a.report(vs, CheckUnboundedRegex, SeverityWarning,
+			"%s has no equality matcher, which may select series of many metrics", selectorString(vs))
+	}
+}
+
+// checkAggregation reports sums without grouping over many series, which usually hide what is going on.
+func (a *analyzer) checkAggregation(agg *parser.AggregateExpr) {
+	if agg.Op != parser.SUM || agg.Without || len(agg.Grouping) > 0 || a.opts.SeriesCount == nil {
+		return
+	}
+	series := 0
+	parser.Inspect(agg.Expr, func(node parser.Node, _ []parser.Node) error {
+		if vs, ok := node.(*parser.VectorSelector); ok {
+			series += a.opts.SeriesCount[selectorString(vs)]
+		}
+		return nil
+	})
+	if series > a.opts.HighCardinalityThreshold {
+		a.report(agg, CheckSumWithoutBy, SeverityWarning,
+			"sum without by() aggregates %d series into one, group it by the labels you want to see", series)
+	}
+}
+
+func unwrap(expr parser.Expr) parser.Expr {
+	for {
+		switch e := expr.(type) {
+		case *parser.ParenExpr:
+			expr = e.Expr
+		case *parser.StepInvariantExpr:
+			expr = e.Expr
+		default:
+			return expr
+		}
+	}
+}
+
+func metricName(vs *parser.VectorSelector) string {
+	if vs.Name != "" {
+		return vs.Name
+	}
+	for _, m := range vs.LabelMatchers {
+		if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+			return m.Value
+		}
+	}
+	return ""
+}
+
+// selectorString formats the selector without offset and @ modifiers.
+func selectorString(vs *parser.VectorSelector) string {
+	matchers := make([]string, 0, len(vs.LabelMatchers))
+	for _, m := range vs.LabelMatchers {
+		if vs.Name != "" && m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+			continue
+		}
+		matchers = append(matchers, m.String())
+	}
+	if len(matchers) == 0 {
+		return vs.Name
+	}
+	return vs.Name + "{" + strings.Join(matchers, ",") + "}"
+}
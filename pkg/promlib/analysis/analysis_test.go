@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func checks(t *testing.T, query string, opts Options) []string {
+	t.Helper()
+	result, err := Analyze(query, opts)
+	require.NoError(t, err)
+	var found []string
+	for _, p := range result.Problems {
+		found = append(found, p.Check+":"+query[p.Start:p.End])
+	}
+	return found
+}
+
+func TestAnalyze(t *testing.T) {
+	t.Run("returns parse errors", func(t *testing.T) {
+		_, err := Analyze("sum(rate(up[5m])", Options{})
+		require.Error(t, err)
+	})
+
+	t.Run("valid queries have no problems", func(t *testing.T) {
+		opts := Options{MetricTypes: map[string]string{"http_requests_total": "counter", "memory_bytes": "gauge"}}
+		require.Empty(t, checks(t, `sum by (job) (rate(http_requests_total{job="api"}[5m]))`, opts))
+		require.Empty(t, checks(t, `deriv(memory_bytes[5m]) > 0`, opts))
+		require.Empty(t, checks(t, `count(http_requests_total) or absent(http_requests_total)`, opts))
+		require.Empty(t, checks(t, `max_over_time(rate(http_requests_total[1m])[1h:1m])`, opts))
+	})
+
+	t.Run("reports rates over gauges", func(t *testing.T) {
+		opts := Options{MetricTypes: map[string]string{"memory_bytes": "gauge"}}
+		require.Equal(t, []string{"rate-over-gauge:rate(memory_bytes[5m])"}, checks(t, `rate(memory_bytes[5m])`, opts))
+	})
+
+	t.Run("reports counters without rate", func(t *testing.T) {
+		require.Equal(t, []string{"counter-without-rate:http_requests_total"}, checks(t, `http_requests_total > 100`, Options{}))
+
+		opts := Options{MetricTypes: map[string]string{"request_duration_seconds": "histogram", "errors": "counter"}}
+		require.Equal(t, []string{
+			"counter-without-rate:request_duration_seconds_count",
+			"counter-without-rate:errors",
+		}, checks(t, `request_duration_seconds_count + errors`, opts))
+	})
+
+	t.Run("reports unbounded regex matchers", func(t *testing.T) {
+		require.Equal(t, []string{
+			`unbounded-regex:up{job=~".*",instance=~".*:9090"}`,
+			`unbounded-regex:up{job=~".*",instance=~".*:9090"}`,
+		}, checks(t, `up{job=~".*",instance=~".*:9090"}`, Options{}))
+		require.Equal(t, []string{`unbounded-regex:{__name__=~"http_.*"}`}, checks(t, `{__name__=~"http_.*"}`, Options{}))
+	})
+
+	t.Run("reports sums without grouping over many series", func(t *testing.T) {
+		query := `sum(rate(http_requests_total{job="api"}[5m]))`
+		opts := Options{SeriesCount: map[string]int{`http_requests_total{job="api"}`: 5000}}
+		require.Equal(t, []string{"sum-without-by:" + query}, checks(t, query, opts))
+
+		opts.HighCardinalityThreshold = 10000
+		require.Empty(t, checks(t, query, opts))
+		require.Empty(t, checks(t, `sum by (code) (rate(http_requests_total{job="api"}[5m]))`, opts))
+	})
+
+	t.Run("returns the selectors with their series count", func(t *testing.T) {
+		count := 3
+		result, err := Analyze(`rate(http_requests_total{job="api"}[5m] offset 1h) / rate(http_requests_total{job="api"}[5m]) + on() up`,
+			Options{SeriesCount: map[string]int{`http_requests_total{job="api"}`: count}})
+		require.NoError(t, err)
+		require.Equal(t, []Selector{
+			{Selector: `http_requests_total{job="api"}`, Series: &count},
+			{Selector: "up"},
+		}, result.Selectors)
+	})
+}
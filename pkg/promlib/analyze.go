@@ -0,0 +1,217 @@
+package promlib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+
+	"github.com/grafana/grafana/pkg/promlib/analysis"
+)
+
+const (
+	// analyzeResourcePath is the resource path of the query analysis, the request body is an AnalyzeQueryRequest.
+	analyzeResourcePath = "analyze"
+	// maxSeriesCount is the number of series above which the series of a selector are not counted further.
+	maxSeriesCount = 10000
+	// maxSeriesRange is the longest time range the series are counted in, ending at the end of the request.
+	maxSeriesRange = time.Hour
+)
+
+type AnalyzeQueryRequest struct {
+	Query string `json:"query"`
+	// Cardinality enables the preview of the number of series each selector of the query selects.
+	Cardinality bool `json:"cardinality"`
+	// Start and End are the time range the series are counted in, the last hour by default. Ranges longer than an
+	// hour are shortened to their last hour.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type metadataResponse struct {
+	Data map[string][]struct {
+		Type string `json:"type"`
+	} `json:"data"`
+}
+
+type seriesResponse struct {
+	Data []json.RawMessage `json:"data"`
+}
+
+// analyzeQuery finds common mistakes in a PromQL query. The metric types are read from the metadata API of
+// Prometheus and the series counts from its series API.
+func analyzeQuery(ctx context.Context, i *instance, req AnalyzeQueryRequest) (*analysis.Result, error) {
+	logger := logger.FromContext(ctx)
+
+	names, err := analysis.MetricNames(req.Query)
+	if err != nil {
+		return nil, err
+	}
+	opts := analysis.Options{MetricTypes: map[string]string{}}
+	checked := map[string]struct{}{}
+	for _, name := range names {
+		// histograms and summaries are reported without the suffix of their series
+		for _, metric := range []string{name, trimHistogramSuffix(name)} {
+			if _, ok := checked[metric]; ok {
+				continue
+			}
+			checked[metric] = struct{}{}
+			t, err := getMetricType(ctx, i, metric)
+			if err != nil {
+				logger.Debug("Failed to get metric metadata", "metric", metric, "err", err)
+				continue
+			}
+			if t != "" {
+				opts.MetricTypes[metric] = t
+			}
+		}
+	}
+
+	selectorErrors := map[string]string{}
+	truncated := map[string]bool{}
+	if req.Cardinality {
+		end := req.End
+		if end.IsZero() {
+			end = time.Now()
+		}
+		start := req.Start
+		if start.IsZero() || end.Sub(start) > maxSeriesRange {
+			start = end.Add(-maxSeriesRange)
+		}
+		selectors, err := analysis.Selectors(req.Query)
+		if err != nil {
+			return nil, err
+		}
+		opts.SeriesCount = map[string]int{}
+		for _, selector := range selectors {
+			count, err := countSeries(ctx, i, selector, start, end)
+			if err != nil {
+				selectorErrors[selector] = err.Error()
+				continue
+			}
+			opts.SeriesCount[selector] = count
+			truncated[selector] = count >= maxSeriesCount
+		}
+	}
+
+	result, err := analysis.Analyze(req.Query, opts)
+	if err != nil {
+		return nil, err
+	}
+	for j, selector := range result.Selectors {
+		result.Selectors[j].Error = selectorErrors[selector.Selector]
+		result.Selectors[j].Truncated = truncated[selector.Selector]
+	}
+	return result, nil
+}
+
+func getMetricType(ctx context.Context, i *instance, metric string) (string, error) {
+	q := url.Values{}
+	q.Set("metric", metric)
+	q.Set("limit", "1")
+	var res metadataResponse
+	if err := getResource(ctx, i, "api/v1/metadata", q, &res); err != nil {
+		return "", err
+	}
+	if metadata := res.Data[metric]; len(metadata) > 0 {
+		return metadata[0].Type, nil
+	}
+	return "", nil
+}
+
+// countSeries returns the number of series the selector selects, at most maxSeriesCount.
+func countSeries(ctx context.Context, i *instance, selector string, start, end time.Time) (int, error) {
+	q := url.Values{}
+	q.Set("match[]", selector)
+	q.Set("limit", strconv.Itoa(maxSeriesCount))
+	q.Set("start", strconv.FormatInt(start.Unix(), 10))
+	q.Set("end", strconv.FormatInt(end.Unix(), 10))
+	var res seriesResponse
+	if err := getResource(ctx, i, "api/v1/series", q, &res); err != nil {
+		return 0, err
+	}
+	return min(len(res.Data), maxSeriesCount), nil
+}
+
+func getResource(ctx context.Context, i *instance, path string, query url.Values, v any) error {
+	resp, err := i.resource.Execute(ctx, &backend.CallResourceRequest{
+		Method: http.MethodGet,
+		Path:   path,
+		URL:    path + "?" + query.Encode(),
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Status != http.StatusOK {
+		return fmt.Errorf("unexpected response %d", resp.Status)
+	}
+	if err := json.Unmarshal(resp.Body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	return nil
+}
+
+func trimHistogramSuffix(name string) string {
+	for _, suffix := range []string{"_bucket", "_count", "_sum"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			return base
+		}
+	}
+	return name
+}
+
+func handleAnalyzeResource(ctx context.Context, i *instance, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	var analyzeReq AnalyzeQueryRequest
+	if err := json.Unmarshal(req.Body, &analyzeReq); err != nil {
+		return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %s", err)})
+	}
+	result, err := analyzeQuery(withForwardedHeaders(ctx, req), i, analyzeReq)
+	if err != nil {
+		return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return sendJSON(sender, http.StatusOK, result)
+}
+
+// withForwardedHeaders forwards the credentials of the user sent by the caller to the requests of the analysis, like
+// they are forwarded to the requests of the queries.
+func withForwardedHeaders(ctx context.Context, req *backend.CallResourceRequest) context.Context {
+	headers := http.Header{}
+	for _, name := range []string{backend.OAuthIdentityTokenHeaderName, backend.OAuthIdentityIDTokenHeaderName, backend.CookiesHeaderName} {
+		if v := req.GetHTTPHeader(name); v != "" {
+			headers.Set(name, v)
+		}
+	}
+	if len(headers) == 0 {
+		return ctx
+	}
+	return sdkhttpclient.WithContextualMiddleware(ctx, sdkhttpclient.MiddlewareFunc(func(_ sdkhttpclient.Options, next http.RoundTripper) http.RoundTripper {
+		return sdkhttpclient.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			// Only set a header if it is not already set.
+			for k, v := range headers {
+				if r.Header.Get(k) == "" {
+					r.Header[k] = v
+				}
+			}
+			return next.RoundTrip(r)
+		})
+	}))
+}
+
+func sendJSON(sender backend.CallResourceResponseSender, status int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
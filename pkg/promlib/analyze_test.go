@@ -0,0 +1,128 @@
+package promlib
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/promlib/analysis"
+)
+
+// analyzeRoundTripper answers the metadata and series requests of the query analysis.
+type analyzeRoundTripper struct {
+	metadata      map[string]string
+	series        map[string]int
+	seriesQueries []url.Values
+	authorization []string
+}
+
+func (rt *analyzeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.authorization = append(rt.authorization, req.Header.Get("Authorization"))
+	body := `{"status":"success","data":{}}`
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/api/v1/metadata"):
+		metric := req.URL.Query().Get("metric")
+		if t, ok := rt.metadata[metric]; ok {
+			body = `{"status":"success","data":{"` + metric + `":[{"type":"` + t + `"}]}}`
+		}
+	case strings.HasSuffix(req.URL.Path, "/api/v1/series"):
+		rt.seriesQueries = append(rt.seriesQueries, req.URL.Query())
+		series := make([]map[string]string, rt.series[req.URL.Query().Get("match[]")])
+		b, _ := json.Marshal(map[string]any{"status": "success", "data": series})
+		body = string(b)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestService_AnalyzeQuery(t *testing.T) {
+	rt := &analyzeRoundTripper{
+		metadata: map[string]string{"memory_bytes": "gauge"},
+		series:   map[string]int{`memory_bytes{job="api"}`: 2000},
+	}
+	httpProvider := sdkhttpclient.NewProvider(sdkhttpclient.ProviderOptions{Middlewares: []sdkhttpclient.Middleware{
+		sdkhttpclient.ContextualMiddleware(),
+		sdkhttpclient.NamedMiddlewareFunc("mock", func(o sdkhttpclient.Options, next http.RoundTripper) http.RoundTripper {
+			return rt
+		}),
+	}})
+	s := &Service{
+		im: datasource.NewInstanceManager(newInstanceSettings(httpProvider, backend.NewLoggerWith("logger", "test"), mockExtendClientOpts, serviceOptions{})),
+	}
+
+	sender := &recordingSender{}
+	err := s.CallResource(context.Background(), &backend.CallResourceRequest{
+		PluginContext: getPluginContext(),
+		Path:          "analyze",
+		Method:        http.MethodPost,
+		Headers:       map[string][]string{backend.OAuthIdentityTokenHeaderName: {"Bearer token"}},
+		Body:          []byte(`{"query": "sum(rate(memory_bytes{job=\"api\"}[5m]))", "cardinality": true, "start": "2024-01-01T00:00:00Z", "end": "2024-01-02T00:00:00Z"}`),
+	}, sender)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, sender.res.Status)
+	var res analysis.Result
+	require.NoError(t, json.Unmarshal(sender.res.Body, &res))
+
+	var found []string
+	for _, p := range res.Problems {
+		found = append(found, p.Check)
+	}
+	require.Equal(t, []string{analysis.CheckSumWithoutBy, analysis.CheckRateOverGauge}, found)
+	require.Len(t, res.Selectors, 1)
+	require.Equal(t, 2000, *res.Selectors[0].Series)
+	require.False(t, res.Selectors[0].Truncated)
+
+	// The series are counted in the last hour of the time range and at most maxSeriesCount are returned.
+	require.Len(t, rt.seriesQueries, 1)
+	require.Equal(t, "1704150000", rt.seriesQueries[0].Get("start"))
+	require.Equal(t, "1704153600", rt.seriesQueries[0].Get("end"))
+	require.Equal(t, "10000", rt.seriesQueries[0].Get("limit"))
+
+	// The credentials of the user are forwarded to the metadata and series requests.
+	require.NotEmpty(t, rt.authorization)
+	for _, authorization := range rt.authorization {
+		require.Equal(t, "Bearer token", authorization)
+	}
+
+	t.Run("without cardinality", func(t *testing.T) {
+		err := s.CallResource(context.Background(), &backend.CallResourceRequest{
+			PluginContext: getPluginContext(),
+			Path:          "analyze",
+			Method:        http.MethodPost,
+			Body:          []byte(`{"query": "rate(memory_bytes[5m])"}`),
+		}, sender)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, sender.res.Status)
+		require.Contains(t, string(sender.res.Body), analysis.CheckRateOverGauge)
+
+		err = s.CallResource(context.Background(), &backend.CallResourceRequest{
+			PluginContext: getPluginContext(),
+			Path:          "analyze",
+			Method:        http.MethodPost,
+			Body:          []byte(`{"query": "rate(memory_bytes[5m]"}`),
+		}, sender)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, sender.res.Status)
+	})
+}
+
+type recordingSender struct {
+	res *backend.CallResourceResponse
+}
+
+func (s *recordingSender) Send(res *backend.CallResourceResponse) error {
+	s.res = res
+	return nil
+}
@@ -53,7 +53,7 @@ func Test_GetHeuristics(t *testing.T) {
 		}
 		httpProvider := newHeuristicsSDKProvider(rt)
 		s := &Service{
-			im: datasource.NewInstanceManager(newInstanceSettings(httpProvider, backend.NewLoggerWith("logger", "test"), mockExtendClientOpts)),
+			im: datasource.NewInstanceManager(newInstanceSettings(httpProvider, backend.NewLoggerWith("logger", "test"), mockExtendClientOpts, serviceOptions{})),
 		}
 
 		req := HeuristicsRequest{
@@ -73,7 +73,7 @@ func Test_GetHeuristics(t *testing.T) {
 		}
 		httpProvider := newHeuristicsSDKProvider(rt)
 		s := &Service{
-			im: datasource.NewInstanceManager(newInstanceSettings(httpProvider, backend.NewLoggerWith("logger", "test"), mockExtendClientOpts)),
+			im: datasource.NewInstanceManager(newInstanceSettings(httpProvider, backend.NewLoggerWith("logger", "test"), mockExtendClientOpts, serviceOptions{})),
 		}
 
 		req := HeuristicsRequest{
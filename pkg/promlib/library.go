@@ -34,17 +34,39 @@ type instance struct {
 
 type ExtendOptions func(ctx context.Context, settings backend.DataSourceInstanceSettings, clientOpts *sdkhttpclient.Options) error
 
-func NewService(httpClientProvider *sdkhttpclient.Provider, plog log.Logger, extendOptions ExtendOptions) *Service {
+// Option configures optional features of the Service.
+type Option func(o *serviceOptions)
+
+type serviceOptions struct {
+	resultsCacheStore querydata.CacheStore
+}
+
+// WithResultsCacheStore sets the store of the results cache of range queries. By default, the results are kept in
+// memory up to querydata.DefaultResultsCacheMaxBytes.
+func WithResultsCacheStore(store querydata.CacheStore) Option {
+	return func(o *serviceOptions) {
+		o.resultsCacheStore = store
+	}
+}
+
+func NewService(httpClientProvider *sdkhttpclient.Provider, plog log.Logger, extendOptions ExtendOptions, opts ...Option) *Service {
 	if httpClientProvider == nil {
 		httpClientProvider = sdkhttpclient.NewProvider()
 	}
+	options := serviceOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.resultsCacheStore == nil {
+		options.resultsCacheStore = querydata.NewMemoryCacheStore(querydata.DefaultResultsCacheMaxBytes)
+	}
 	return &Service{
-		im:     datasource.NewInstanceManager(newInstanceSettings(httpClientProvider, plog, extendOptions)),
+		im:     datasource.NewInstanceManager(newInstanceSettings(httpClientProvider, plog, extendOptions, options)),
 		logger: plog,
 	}
 }
 
-func newInstanceSettings(httpClientProvider *sdkhttpclient.Provider, log log.Logger, extendOptions ExtendOptions) datasource.InstanceFactoryFunc {
+func newInstanceSettings(httpClientProvider *sdkhttpclient.Provider, log log.Logger, extendOptions ExtendOptions, options serviceOptions) datasource.InstanceFactoryFunc {
 	return func(ctx context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
 		// Creates a http roundTripper.
 		opts, err := client.CreateTransportOptions(ctx, settings, log)
@@ -63,7 +85,7 @@ func newInstanceSettings(httpClientProvider *sdkhttpclient.Provider, log log.Log
 		}
 
 		// New version using custom client and better response parsing
-		qd, err := querydata.New(httpClient, settings, log)
+		qd, err := querydata.New(httpClient, settings, log, options.resultsCacheStore)
 		if err != nil {
 			return nil, err
 		}
@@ -121,6 +143,10 @@ func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceReq
 		return sender.Send(vResp)
 	}
 
+	if strings.EqualFold(req.Path, analyzeResourcePath) {
+		return handleAnalyzeResource(ctx, i, req, sender)
+	}
+
 	resp, err := i.resource.Execute(ctx, req)
 	if err != nil {
 		return err
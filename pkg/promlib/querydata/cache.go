@@ -0,0 +1,368 @@
+package querydata
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/utils/maputil"
+
+	"github.com/grafana/grafana/pkg/promlib/client"
+	"github.com/grafana/grafana/pkg/promlib/models"
+)
+
+const (
+	// DefaultResultsCacheMaxBytes is the default size limit of the results of range queries kept in memory.
+	DefaultResultsCacheMaxBytes = 100 * 1024 * 1024
+
+	defaultResultsCacheTTL          = time.Hour
+	defaultResultsCacheMaxFreshness = 10 * time.Minute
+)
+
+// ErrCacheItemNotFound is returned by a CacheStore if the key is not found.
+var ErrCacheItemNotFound = errors.New("cache item not found")
+
+// CacheStore stores the results of range queries. Its methods match the remote cache of Grafana, so it can be used
+// to share the results between Grafana instances.
+type CacheStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, expire time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// memoryCacheStore keeps the results of range queries in memory. The least recently used results are evicted when
+// the size of the results exceeds the limit.
+type memoryCacheStore struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	items    map[string]*list.Element
+	lru      *list.List
+	now      func() time.Time
+}
+
+type cacheItem struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCacheStore returns a CacheStore that keeps at most maxBytes of results in memory.
+func NewMemoryCacheStore(maxBytes int) CacheStore {
+	return newMemoryCacheStore(maxBytes)
+}
+
+func newMemoryCacheStore(maxBytes int) *memoryCacheStore {
+	return &memoryCacheStore{
+		maxBytes: maxBytes,
+		items:    map[string]*list.Element{},
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+func (c *memoryCacheStore) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, ErrCacheItemNotFound
+	}
+	item := e.Value.(*cacheItem)
+	if !c.now().Before(item.expires) {
+		c.remove(e)
+		return nil, ErrCacheItemNotFound
+	}
+	c.lru.MoveToFront(e)
+	return item.value, nil
+}
+
+func (c *memoryCacheStore) Set(_ context.Context, key string, value []byte, expire time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+	if len(value) > c.maxBytes {
+		return nil
+	}
+	c.items[key] = c.lru.PushFront(&cacheItem{key: key, value: value, expires: c.now().Add(expire)})
+	c.size += len(value)
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+	return nil
+}
+
+func (c *memoryCacheStore) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+	return nil
+}
+
+func (c *memoryCacheStore) remove(e *list.Element) {
+	item := c.lru.Remove(e).(*cacheItem)
+	delete(c.items, item.key)
+	c.size -= len(item.value)
+}
+
+// resultsCache caches the results of range queries per query, step and data source. The start and end of the cached
+// results are on the step grid of the query, so a refreshed query only needs to fetch the steps after the cached
+// results. The most recent steps are never cached because Prometheus may still ingest samples for them.
+type resultsCache struct {
+	store        CacheStore
+	keyPrefix    string
+	ttl          time.Duration
+	maxFreshness time.Duration
+	now          func() time.Time
+}
+
+// cachedResult is the value stored in the CacheStore.
+type cachedResult struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Frames [][]byte  `json:"frames"`
+}
+
+// newResultsCache reads the cache settings of the data source, it returns nil if caching is disabled.
+func newResultsCache(jsonData map[string]any, settings backend.DataSourceInstanceSettings, store CacheStore) (*resultsCache, error) {
+	enabled, err := maputil.GetBoolOptional(jsonData, "resultsCacheEnabled")
+	if err != nil {
+		return nil, err
+	}
+	if !enabled || store == nil {
+		return nil, nil
+	}
+
+	ttl, err := durationSetting(jsonData, "resultsCacheTTL", defaultResultsCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	maxFreshness, err := durationSetting(jsonData, "resultsCacheMaxFreshness", defaultResultsCacheMaxFreshness)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resultsCache{
+		store: store,
+		// Results cached before the data source was changed must not be used.
+		keyPrefix:    fmt.Sprintf("prometheus-results:%s:%d:", settings.UID, settings.Updated.UnixNano()),
+		ttl:          ttl,
+		maxFreshness: maxFreshness,
+		now:          time.Now,
+	}, nil
+}
+
+func durationSetting(jsonData map[string]any, key string, defaultValue time.Duration) (time.Duration, error) {
+	v, err := maputil.GetStringOptional(jsonData, key)
+	if err != nil {
+		return 0, err
+	}
+	if v == "" {
+		return defaultValue, nil
+	}
+	d, err := gtime.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid %s %s: must not be negative", key, v)
+	}
+	return d, nil
+}
+
+type forwardedCredentialsKey struct{}
+
+// withForwardedCredentials marks the queries of a request that forwards the credentials of the user. Their results
+// depend on the user, so they are neither read from nor written to the results cache.
+func withForwardedCredentials(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forwardedCredentialsKey{}, true)
+}
+
+func hasForwardedCredentials(ctx context.Context) bool {
+	forwarded, _ := ctx.Value(forwardedCredentialsKey{}).(bool)
+	return forwarded
+}
+
+// forwardsCredentials reports whether the request forwards the OAuth tokens or the cookies of the user.
+func forwardsCredentials(req *backend.QueryDataRequest) bool {
+	return req.GetHTTPHeader(backend.OAuthIdentityTokenHeaderName) != "" ||
+		req.GetHTTPHeader(backend.OAuthIdentityIDTokenHeaderName) != "" ||
+		req.GetHTTPHeader(backend.CookiesHeaderName) != ""
+}
+
+// key identifies everything that changes the result of a query except its time range.
+func (rc *resultsCache) key(q *models.Query, enablePrometheusDataplaneFlag bool) string {
+	matchers := make([]string, 0, len(q.Scope.Matchers))
+	for _, m := range q.Scope.Matchers {
+		matchers = append(matchers, m.String())
+	}
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\xff%d\xff%s\xff%d\xff%s\xff%s\xff%t",
+		q.Expr, q.Step, q.LegendFormat, q.UtcOffsetSec, q.RefId, strings.Join(matchers, ","), enablePrometheusDataplaneFlag)
+	return rc.keyPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// stableEnd returns the last step of the query that is old enough to be cached.
+func (rc *resultsCache) stableEnd(q *models.Query) time.Time {
+	end := q.End
+	if limit := rc.now().Add(-rc.maxFreshness); limit.Before(end) {
+		end = limit
+	}
+	if end.Before(q.Start) {
+		return end
+	}
+	return q.Start.Add(end.Sub(q.Start) / q.Step * q.Step)
+}
+
+func (rc *resultsCache) get(ctx context.Context, key string) (*cachedResult, data.Frames, error) {
+	b, err := rc.store.Get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	var result cachedResult
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, nil, err
+	}
+	frames, err := data.UnmarshalArrowFrames(result.Frames)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &result, frames, nil
+}
+
+func (rc *resultsCache) set(ctx context.Context, key string, start, end time.Time, frames data.Frames) error {
+	b, err := frames.MarshalArrow()
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(cachedResult{Start: start, End: end, Frames: b})
+	if err != nil {
+		return err
+	}
+	return rc.store.Set(ctx, key, value, rc.ttl)
+}
+
+// cachedRangeQuery serves the range query from the results cache and fetches only the steps that are not cached.
+func (s *QueryData) cachedRangeQuery(ctx context.Context, c *client.Client, q *models.Query, lim limiter, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+	logger := s.log.FromContext(ctx)
+	rc := s.resultsCache
+	key := rc.key(q, enablePrometheusDataplaneFlag)
+
+	fetch := q
+	var cachedFrames data.Frames
+	cached, frames, err := rc.get(ctx, key)
+	if err != nil && !errors.Is(err, ErrCacheItemNotFound) {
+		logger.Debug("Failed to read cached results", "query", q.Expr, "error", err)
+		if err := rc.store.Delete(ctx, key); err != nil {
+			logger.Debug("Failed to delete cached results", "query", q.Expr, "error", err)
+		}
+	}
+	// The cached results can only be used if they cover the start of the query and are on its step grid.
+	if err == nil && !cached.Start.After(q.Start) && !cached.End.Before(q.Start) && cached.End.Sub(q.Start)%q.Step == 0 {
+		if !cached.End.Before(q.End) {
+			if frames, ok := trimFrames(frames, q.Start, q.End); ok {
+				setExecutedQueryString(frames, q)
+				return backend.DataResponse{Frames: frames, Status: backend.StatusOK}
+			}
+		} else if trimmed, ok := trimFrames(frames, q.Start, cached.End); ok {
+			cachedFrames = trimmed
+			tail := *q
+			tail.Start = cached.End.Add(q.Step)
+			fetch = &tail
+		}
+	}
+
+	res := s.fetchRangeQuery(ctx, c, fetch, lim, enablePrometheusDataplaneFlag)
+	if res.Error != nil {
+		return res
+	}
+	if cachedFrames != nil {
+		res.Frames = mergeFrames([]data.Frames{cachedFrames, res.Frames})
+		setExecutedQueryString(res.Frames, q)
+	}
+
+	if end := rc.stableEnd(q); !end.Before(q.Start) && cacheableResponse(res) {
+		if frames, ok := trimFrames(res.Frames, q.Start, end); ok {
+			if err := rc.set(ctx, key, q.Start, end, frames); err != nil {
+				logger.Debug("Failed to cache results", "query", q.Expr, "error", err)
+			}
+		}
+	}
+	return res
+}
+
+// cacheableResponse reports whether the response is complete. Prometheus returns warnings for partial results.
+func cacheableResponse(res backend.DataResponse) bool {
+	if res.Error != nil {
+		return false
+	}
+	for _, frame := range res.Frames {
+		if frame.Meta != nil && len(frame.Meta.Notices) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// trimFrames returns copies of the frames with the rows in the time range [start, end].
+// It returns false if a frame has no time field.
+func trimFrames(frames data.Frames, start, end time.Time) (data.Frames, bool) {
+	trimmed := make(data.Frames, 0, len(frames))
+	for _, frame := range frames {
+		timeField := -1
+		for i, f := range frame.Fields {
+			if f.Type().Time() {
+				timeField = i
+				break
+			}
+		}
+		if timeField == -1 && len(frame.Fields) > 0 {
+			return nil, false
+		}
+
+		var rows []int
+		for row := 0; timeField >= 0 && row < frame.Fields[timeField].Len(); row++ {
+			t, ok := frame.Fields[timeField].ConcreteAt(row)
+			if !ok {
+				continue
+			}
+			if ts := t.(time.Time); !ts.Before(start) && !ts.After(end) {
+				rows = append(rows, row)
+			}
+		}
+
+		copied := &data.Frame{Name: frame.Name, RefID: frame.RefID, Meta: frame.Meta}
+		for _, f := range frame.Fields {
+			field := data.NewFieldFromFieldType(f.Type(), 0)
+			field.Name = f.Name
+			field.Labels = f.Labels
+			field.Config = f.Config
+			for _, row := range rows {
+				field.Append(f.CopyAt(row))
+			}
+			copied.Fields = append(copied.Fields, field)
+		}
+		trimmed = append(trimmed, copied)
+	}
+	return trimmed, true
+}
+
+func setExecutedQueryString(frames data.Frames, q *models.Query) {
+	if len(frames) > 0 && frames[0].Meta != nil {
+		frames[0].Meta.ExecutedQueryString = executedQueryString(q)
+	}
+}
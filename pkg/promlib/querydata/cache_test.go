@@ -0,0 +1,174 @@
+package querydata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/promlib/models"
+)
+
+// rangeQueryServer returns a sample for every step of the requested range and records the requested ranges.
+type rangeQueryServer struct {
+	requests [][2]int64
+}
+
+func (s *rangeQueryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	start, _ := strconv.ParseInt(r.Form.Get("start"), 10, 64)
+	end, _ := strconv.ParseInt(r.Form.Get("end"), 10, 64)
+	step, _ := strconv.ParseInt(r.Form.Get("step"), 10, 64)
+	s.requests = append(s.requests, [2]int64{start, end})
+
+	var values []string
+	for ts := start; ts <= end; ts += step {
+		values = append(values, fmt.Sprintf(`[%d,"%d"]`, ts, ts))
+	}
+	_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"job":"a"},"values":[%s]}]}}`, strings.Join(values, ","))
+}
+
+func TestQueryData_cachedRangeQuery(t *testing.T) {
+	srv := &rangeQueryServer{}
+	server := httptest.NewServer(srv)
+	t.Cleanup(server.Close)
+
+	qd, err := New(server.Client(), backend.DataSourceInstanceSettings{
+		UID:      "prometheus",
+		URL:      server.URL,
+		JSONData: json.RawMessage(`{"resultsCacheEnabled": true, "resultsCacheMaxFreshness": "2m"}`),
+	}, log.New(), NewMemoryCacheStore(DefaultResultsCacheMaxBytes))
+	require.NoError(t, err)
+	require.NotNil(t, qd.resultsCache)
+
+	now := time.Unix(1000, 0)
+	qd.resultsCache.now = func() time.Time { return now }
+
+	query := func(start, end int64) backend.DataResponse {
+		q := &models.Query{Expr: "up", Step: 60 * time.Second, Start: time.Unix(start, 0), End: time.Unix(end, 0), RangeQuery: true}
+		res := qd.rangeQuery(context.Background(), qd.client, q, newLimiter(1), false)
+		require.NoError(t, res.Error)
+		return res
+	}
+	values := func(res backend.DataResponse) []float64 {
+		require.Len(t, res.Frames, 1)
+		field := res.Frames[0].Fields[1]
+		result := make([]float64, field.Len())
+		for i := range result {
+			result[i], _ = field.FloatAt(i)
+		}
+		return result
+	}
+
+	// The steps after 880 are newer than the max freshness and must not be cached.
+	res := query(0, 960)
+	require.Len(t, values(res), 17)
+	require.Equal(t, [][2]int64{{0, 960}}, srv.requests)
+
+	now = time.Unix(1060, 0)
+	res = query(60, 1020)
+	require.Equal(t, []float64{60, 120, 180, 240, 300, 360, 420, 480, 540, 600, 660, 720, 780, 840, 900, 960, 1020}, values(res))
+	require.Equal(t, [2]int64{900, 1020}, srv.requests[1])
+	require.Contains(t, res.Frames[0].Meta.ExecutedQueryString, "Expr: up")
+
+	// A range that is completely cached does not send a request.
+	res = query(120, 900)
+	require.Len(t, values(res), 14)
+	require.Len(t, srv.requests, 2)
+
+	// A range that starts before the cached results is fetched completely.
+	query(0, 1020)
+	require.Equal(t, [2]int64{0, 1020}, srv.requests[2])
+}
+
+func TestQueryData_Execute_forwardedCredentials(t *testing.T) {
+	srv := &rangeQueryServer{}
+	server := httptest.NewServer(srv)
+	t.Cleanup(server.Close)
+
+	qd, err := New(server.Client(), backend.DataSourceInstanceSettings{
+		UID:      "prometheus",
+		URL:      server.URL,
+		JSONData: json.RawMessage(`{"resultsCacheEnabled": true, "resultsCacheMaxFreshness": "0s"}`),
+	}, log.New(), NewMemoryCacheStore(DefaultResultsCacheMaxBytes))
+	require.NoError(t, err)
+
+	execute := func(token string) {
+		req := &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				JSON:      json.RawMessage(`{"expr": "up", "range": true, "interval": "1m"}`),
+				TimeRange: backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(600, 0)},
+			}},
+		}
+		if token != "" {
+			req.SetHTTPHeader(backend.OAuthIdentityTokenHeaderName, token)
+		}
+		res, err := qd.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+	}
+
+	// The results of the queries that forward the credentials of the user are neither cached nor read from the cache.
+	execute("Bearer user1")
+	execute("Bearer user2")
+	require.Len(t, srv.requests, 2)
+	require.Empty(t, qd.resultsCache.store.(*memoryCacheStore).items)
+
+	execute("")
+	execute("")
+	require.Len(t, srv.requests, 3)
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(0, 0)
+	store := newMemoryCacheStore(10)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Set(ctx, "a", []byte("aaaa"), time.Minute))
+	require.NoError(t, store.Set(ctx, "b", []byte("bbbb"), time.Minute))
+	_, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+
+	// The least recently used item is evicted when the size limit is exceeded.
+	require.NoError(t, store.Set(ctx, "c", []byte("cccc"), time.Minute))
+	_, err = store.Get(ctx, "b")
+	require.ErrorIs(t, err, ErrCacheItemNotFound)
+	v, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("aaaa"), v)
+	require.Equal(t, 8, store.size)
+
+	// Items larger than the limit are not stored.
+	require.NoError(t, store.Set(ctx, "d", []byte("ddddddddddd"), time.Minute))
+	_, err = store.Get(ctx, "d")
+	require.ErrorIs(t, err, ErrCacheItemNotFound)
+
+	now = now.Add(time.Minute)
+	_, err = store.Get(ctx, "a")
+	require.ErrorIs(t, err, ErrCacheItemNotFound)
+	require.Equal(t, 4, store.size)
+
+	require.NoError(t, store.Delete(ctx, "c"))
+	require.Equal(t, 0, store.size)
+	require.Empty(t, store.items)
+}
+
+func TestResultsCache_stableEnd(t *testing.T) {
+	rc := &resultsCache{maxFreshness: 10 * time.Minute, now: func() time.Time { return time.Unix(3600, 0) }}
+	q := &models.Query{Start: time.Unix(30, 0), End: time.Unix(3600, 0), Step: time.Minute}
+	require.Equal(t, time.Unix(2970, 0), rc.stableEnd(q))
+
+	q.End = time.Unix(1230, 0)
+	require.Equal(t, q.End, rc.stableEnd(q))
+}
@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/data/utils/maputil"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
@@ -25,6 +27,9 @@ import (
 
 const legendFormatAuto = "__auto"
 
+// defaultQueryConcurrency is the number of requests sent to Prometheus at the same time for a single data request.
+const defaultQueryConcurrency = 4
+
 var legendFormatRegexp = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
 
 type ExemplarEvent struct {
@@ -44,12 +49,16 @@ type QueryData struct {
 	URL                string
 	TimeInterval       string
 	exemplarSampler    func() exemplar.Sampler
+	queryConcurrency   int
+	splitInterval      time.Duration
+	resultsCache       *resultsCache
 }
 
 func New(
 	httpClient *http.Client,
 	settings backend.DataSourceInstanceSettings,
 	plog log.Logger,
+	cacheStore CacheStore,
 ) (*QueryData, error) {
 	jsonData, err := utils.GetJsonData(settings)
 	if err != nil {
@@ -66,6 +75,36 @@ func New(
 		httpMethod = http.MethodPost
 	}
 
+	queryConcurrency := defaultQueryConcurrency
+	if v, ok := jsonData["queryConcurrency"]; ok && v != nil {
+		n, ok := v.(float64)
+		if !ok || n < 1 {
+			return nil, fmt.Errorf("invalid queryConcurrency %v: must be a positive number", v)
+		}
+		queryConcurrency = int(n)
+	}
+
+	// Range queries are split into parts of this length, splitting is disabled if it is empty.
+	splitIntervalStr, err := maputil.GetStringOptional(jsonData, "querySplitInterval")
+	if err != nil {
+		return nil, err
+	}
+	var splitInterval time.Duration
+	if splitIntervalStr != "" {
+		splitInterval, err = gtime.ParseDuration(splitIntervalStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid querySplitInterval: %w", err)
+		}
+		if splitInterval < 0 {
+			return nil, fmt.Errorf("invalid querySplitInterval %s: must not be negative", splitIntervalStr)
+		}
+	}
+
+	rc, err := newResultsCache(jsonData, settings, cacheStore)
+	if err != nil {
+		return nil, err
+	}
+
 	promClient := client.NewClient(httpClient, httpMethod, settings.URL)
 
 	// standard deviation sampler is the default for backwards compatibility
@@ -80,6 +119,9 @@ func New(
 		ID:                 settings.ID,
 		URL:                settings.URL,
 		exemplarSampler:    exemplarSampler,
+		queryConcurrency:   queryConcurrency,
+		splitInterval:      splitInterval,
+		resultsCache:       rc,
 	}, nil
 }
 
@@ -93,15 +135,37 @@ func (s *QueryData) Execute(ctx context.Context, req *backend.QueryDataRequest)
 	hasPromQLScopeFeatureFlag := cfg.FeatureToggles().IsEnabled("promQLScope")
 	hasPrometheusDataplaneFeatureFlag := cfg.FeatureToggles().IsEnabled("prometheusDataplane")
 
+	queries := make([]*models.Query, 0, len(req.Queries))
 	for _, q := range req.Queries {
 		query, err := models.Parse(q, s.TimeInterval, s.intervalCalculator, fromAlert, hasPromQLScopeFeatureFlag)
 		if err != nil {
 			return &result, err
 		}
+		queries = append(queries, query)
+	}
 
-		r := s.fetch(ctx, s.client, query, hasPrometheusDataplaneFeatureFlag)
+	if forwardsCredentials(req) {
+		ctx = withForwardedCredentials(ctx)
+	}
+
+	// Queries are fetched concurrently, the limiter bounds the number of requests sent to Prometheus.
+	lim := newLimiter(s.queryConcurrency)
+	responses := make([]*backend.DataResponse, len(queries))
+	g := errgroup.Group{}
+	g.SetLimit(s.queryConcurrency)
+	for i, query := range queries {
+		i, query := i, query
+		g.Go(func() error {
+			responses[i] = s.fetch(ctx, s.client, query, lim, hasPrometheusDataplaneFeatureFlag)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	for i, q := range req.Queries {
+		r := responses[i]
 		if r == nil {
-			s.log.FromContext(ctx).Debug("Received nil response from runQuery", "query", query.Expr)
+			s.log.FromContext(ctx).Debug("Received nil response from runQuery", "query", queries[i].Expr)
 			continue
 		}
 		result.Responses[q.RefID] = *r
@@ -110,7 +174,7 @@ func (s *QueryData) Execute(ctx context.Context, req *backend.QueryDataRequest)
 	return &result, nil
 }
 
-func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.Query, enablePrometheusDataplane bool) *backend.DataResponse {
+func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.Query, lim limiter, enablePrometheusDataplane bool) *backend.DataResponse {
 	traceCtx, end := s.trace(ctx, q)
 	defer end()
 
@@ -123,14 +187,14 @@ func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.
 	}
 
 	if q.InstantQuery {
-		res := s.instantQuery(traceCtx, client, q, enablePrometheusDataplane)
+		res := s.instantQuery(traceCtx, client, q, lim, enablePrometheusDataplane)
 		dr.Error = res.Error
 		dr.Frames = res.Frames
 		dr.Status = res.Status
 	}
 
 	if q.RangeQuery {
-		res := s.rangeQuery(traceCtx, client, q, enablePrometheusDataplane)
+		res := s.rangeQuery(traceCtx, client, q, lim, enablePrometheusDataplane)
 		if res.Error != nil {
 			if dr.Error == nil {
 				dr.Error = res.Error
@@ -145,7 +209,7 @@ func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.
 	}
 
 	if q.ExemplarQuery {
-		res := s.exemplarQuery(traceCtx, client, q, enablePrometheusDataplane)
+		res := s.exemplarQuery(traceCtx, client, q, lim, enablePrometheusDataplane)
 		if res.Error != nil {
 			// If exemplar query returns error, we want to only log it and
 			// continue with other results processing
@@ -157,7 +221,26 @@ func (s *QueryData) fetch(ctx context.Context, client *client.Client, q *models.
 	return dr
 }
 
-func (s *QueryData) rangeQuery(ctx context.Context, c *client.Client, q *models.Query, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+func (s *QueryData) rangeQuery(ctx context.Context, c *client.Client, q *models.Query, lim limiter, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+	if s.resultsCache != nil && q.Step > 0 && !hasForwardedCredentials(ctx) {
+		return s.cachedRangeQuery(ctx, c, q, lim, enablePrometheusDataplaneFlag)
+	}
+	return s.fetchRangeQuery(ctx, c, q, lim, enablePrometheusDataplaneFlag)
+}
+
+func (s *QueryData) fetchRangeQuery(ctx context.Context, c *client.Client, q *models.Query, lim limiter, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+	if parts := splitQuery(q, s.splitInterval); len(parts) > 1 {
+		return s.splitRangeQuery(ctx, c, q, parts, lim, enablePrometheusDataplaneFlag)
+	}
+	return s.singleRangeQuery(ctx, c, q, lim, enablePrometheusDataplaneFlag)
+}
+
+func (s *QueryData) singleRangeQuery(ctx context.Context, c *client.Client, q *models.Query, lim limiter, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+	if err := lim.acquire(ctx); err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	defer lim.release()
+
 	res, err := c.QueryRange(ctx, q)
 	if err != nil {
 		return backend.DataResponse{
@@ -176,7 +259,12 @@ func (s *QueryData) rangeQuery(ctx context.Context, c *client.Client, q *models.
 	return s.parseResponse(ctx, q, res, enablePrometheusDataplaneFlag)
 }
 
-func (s *QueryData) instantQuery(ctx context.Context, c *client.Client, q *models.Query, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+func (s *QueryData) instantQuery(ctx context.Context, c *client.Client, q *models.Query, lim limiter, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+	if err := lim.acquire(ctx); err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	defer lim.release()
+
 	res, err := c.QueryInstant(ctx, q)
 	if err != nil {
 		return backend.DataResponse{
@@ -202,7 +290,12 @@ func (s *QueryData) instantQuery(ctx context.Context, c *client.Client, q *model
 	return s.parseResponse(ctx, q, res, enablePrometheusDataplaneFlag)
 }
 
-func (s *QueryData) exemplarQuery(ctx context.Context, c *client.Client, q *models.Query, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+func (s *QueryData) exemplarQuery(ctx context.Context, c *client.Client, q *models.Query, lim limiter, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+	if err := lim.acquire(ctx); err != nil {
+		return backend.DataResponse{Error: err}
+	}
+	defer lim.release()
+
 	res, err := c.QueryExemplars(ctx, q)
 	if err != nil {
 		return backend.DataResponse{
@@ -446,7 +446,7 @@ func setup() (*testContext, error) {
 		return nil, err
 	}
 
-	queryData, _ := querydata.New(httpClient, settings, log.New())
+	queryData, _ := querydata.New(httpClient, settings, log.New(), nil)
 
 	return &testContext{
 		httpProvider: httpProvider,
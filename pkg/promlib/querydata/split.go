@@ -0,0 +1,145 @@
+package querydata
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/promlib/client"
+	"github.com/grafana/grafana/pkg/promlib/models"
+)
+
+// limiter bounds the number of requests sent to Prometheus at the same time.
+type limiter chan struct{}
+
+func newLimiter(size int) limiter {
+	if size < 1 {
+		size = 1
+	}
+	return make(limiter, size)
+}
+
+func (l limiter) acquire(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l limiter) release() {
+	<-l
+}
+
+// splitQuery splits the time range of a range query into consecutive queries that each cover at most one interval.
+// The boundaries are aligned to multiples of the interval, so the same parts are requested when a dashboard is
+// refreshed, and every part starts on the step grid of the original query so no samples are duplicated or lost.
+func splitQuery(q *models.Query, interval time.Duration) []*models.Query {
+	if interval <= 0 || q.Step <= 0 || q.End.Sub(q.Start) <= interval {
+		return []*models.Query{q}
+	}
+
+	var parts []*models.Query
+	for start := q.Start; !start.After(q.End); {
+		boundary := start.Truncate(interval).Add(interval)
+		// the last step of the part is the last one before the boundary
+		steps := (boundary.Sub(start) - 1) / q.Step
+		end := start.Add(steps * q.Step)
+		if end.After(q.End) {
+			end = q.End
+		}
+
+		part := *q
+		part.Start = start
+		part.End = end
+		parts = append(parts, &part)
+
+		start = end.Add(q.Step)
+	}
+	return parts
+}
+
+// splitRangeQuery runs the parts of a range query concurrently and merges their results.
+func (s *QueryData) splitRangeQuery(ctx context.Context, c *client.Client, q *models.Query, parts []*models.Query, lim limiter, enablePrometheusDataplaneFlag bool) backend.DataResponse {
+	responses := make([]backend.DataResponse, len(parts))
+	// The parts wait for the limiter anyway, there is no need for more goroutines than requests that can be sent.
+	g := errgroup.Group{}
+	g.SetLimit(cap(lim))
+	for i, part := range parts {
+		i, part := i, part
+		g.Go(func() error {
+			responses[i] = s.singleRangeQuery(ctx, c, part, lim, enablePrometheusDataplaneFlag)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	frames := make([]data.Frames, 0, len(responses))
+	for _, res := range responses {
+		if res.Error != nil {
+			return res
+		}
+		frames = append(frames, res.Frames)
+	}
+
+	merged := mergeFrames(frames)
+	setExecutedQueryString(merged, q)
+	return backend.DataResponse{
+		Frames: merged,
+		Status: responses[0].Status,
+	}
+}
+
+// mergeFrames joins the frames of the same series returned for consecutive time ranges.
+// Frames are expected to be sorted by time within each time range.
+func mergeFrames(parts []data.Frames) data.Frames {
+	var merged data.Frames
+	byKey := map[string]*data.Frame{}
+	for _, frames := range parts {
+		for _, frame := range frames {
+			// Empty frames only carry metadata, they are kept if none of the parts returned data.
+			if frame.Rows() == 0 {
+				continue
+			}
+			key := frameKey(frame)
+			if existing, ok := byKey[key]; ok {
+				appendRows(existing, frame)
+				continue
+			}
+			byKey[key] = frame
+			merged = append(merged, frame)
+		}
+	}
+	if len(merged) == 0 && len(parts) > 0 {
+		return parts[0]
+	}
+	return merged
+}
+
+// frameKey identifies the series a frame contains.
+func frameKey(frame *data.Frame) string {
+	var sb strings.Builder
+	sb.WriteString(frame.Name)
+	for _, f := range frame.Fields {
+		sb.WriteString("\xff")
+		sb.WriteString(f.Name)
+		sb.WriteString("\xfe")
+		sb.WriteString(f.Type().ItemTypeString())
+		sb.WriteString("\xfe")
+		sb.WriteString(f.Labels.String())
+	}
+	return sb.String()
+}
+
+func appendRows(dst, src *data.Frame) {
+	for i, f := range src.Fields {
+		for row := 0; row < f.Len(); row++ {
+			dst.Fields[i].Append(f.At(row))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package querydata
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/promlib/models"
+)
+
+func TestNew_splitSettings(t *testing.T) {
+	newQueryData := func(jsonData string) (*QueryData, error) {
+		return New(http.DefaultClient, backend.DataSourceInstanceSettings{JSONData: json.RawMessage(jsonData)}, log.New(), nil)
+	}
+
+	qd, err := newQueryData(`{}`)
+	require.NoError(t, err)
+	require.Equal(t, defaultQueryConcurrency, qd.queryConcurrency)
+	require.Zero(t, qd.splitInterval)
+
+	qd, err = newQueryData(`{"queryConcurrency": 8, "querySplitInterval": "1d"}`)
+	require.NoError(t, err)
+	require.Equal(t, 8, qd.queryConcurrency)
+	require.Equal(t, 24*time.Hour, qd.splitInterval)
+
+	_, err = newQueryData(`{"queryConcurrency": 0}`)
+	require.Error(t, err)
+	_, err = newQueryData(`{"querySplitInterval": "one day"}`)
+	require.Error(t, err)
+}
+
+func TestSplitQuery(t *testing.T) {
+	start := time.Date(2024, 1, 1, 20, 0, 30, 0, time.UTC)
+
+	t.Run("does not split ranges shorter than the interval", func(t *testing.T) {
+		q := &models.Query{Start: start, End: start.Add(time.Hour), Step: time.Minute}
+		require.Equal(t, []*models.Query{q}, splitQuery(q, 24*time.Hour))
+		require.Equal(t, []*models.Query{q}, splitQuery(q, 0))
+	})
+
+	t.Run("splits at interval boundaries and keeps the step grid", func(t *testing.T) {
+		q := &models.Query{Expr: "up", Start: start, End: start.Add(48 * time.Hour), Step: time.Minute}
+		parts := splitQuery(q, 24*time.Hour)
+		require.Len(t, parts, 3)
+
+		require.Equal(t, start, parts[0].Start)
+		require.Equal(t, time.Date(2024, 1, 1, 23, 59, 30, 0, time.UTC), parts[0].End)
+		require.Equal(t, time.Date(2024, 1, 2, 0, 0, 30, 0, time.UTC), parts[1].Start)
+		require.Equal(t, time.Date(2024, 1, 2, 23, 59, 30, 0, time.UTC), parts[1].End)
+		require.Equal(t, time.Date(2024, 1, 3, 0, 0, 30, 0, time.UTC), parts[2].Start)
+		require.Equal(t, q.End, parts[2].End)
+
+		for _, p := range parts {
+			require.Equal(t, "up", p.Expr)
+			require.Zero(t, p.Start.Sub(q.Start)%q.Step)
+		}
+	})
+
+	t.Run("starts a part on a boundary if the step is aligned", func(t *testing.T) {
+		begin := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+		q := &models.Query{Start: begin, End: begin.Add(2 * time.Hour), Step: time.Hour}
+		parts := splitQuery(q, time.Hour)
+		require.Len(t, parts, 3)
+		for i, p := range parts {
+			require.Equal(t, begin.Add(time.Duration(i)*time.Hour), p.Start)
+			require.Equal(t, p.Start, p.End)
+		}
+	})
+}
+
+func TestMergeFrames(t *testing.T) {
+	series := func(name string, labels data.Labels, from int64, values ...float64) *data.Frame {
+		times := make([]time.Time, len(values))
+		for i := range values {
+			times[i] = time.Unix(from+int64(i), 0)
+		}
+		return data.NewFrame(name,
+			data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+			data.NewField(data.TimeSeriesValueFieldName, labels, values),
+		)
+	}
+
+	t.Run("appends rows of the same series", func(t *testing.T) {
+		merged := mergeFrames([]data.Frames{
+			{series("up", data.Labels{"job": "a"}, 0, 1, 2), series("up", data.Labels{"job": "b"}, 0, 3)},
+			{series("up", data.Labels{"job": "b"}, 2, 4), series("up", data.Labels{"job": "a"}, 2, 5)},
+			{series("up", data.Labels{"job": "c"}, 4, 6)},
+		})
+		require.Len(t, merged, 3)
+		require.Equal(t, data.Labels{"job": "a"}, merged[0].Fields[1].Labels)
+		require.Equal(t, 3, merged[0].Rows())
+		require.Equal(t, 5.0, merged[0].Fields[1].At(2))
+		require.Equal(t, time.Unix(2, 0), merged[0].Fields[0].At(2))
+		require.Equal(t, 2, merged[1].Rows())
+		require.Equal(t, 1, merged[2].Rows())
+	})
+
+	t.Run("keeps the first part if no part has data", func(t *testing.T) {
+		empty := data.NewFrame("", data.NewField("Value", nil, []float64{}))
+		merged := mergeFrames([]data.Frames{{empty}, {data.NewFrame("")}})
+		require.Equal(t, data.Frames{empty}, merged)
+	})
+}
@@ -1199,6 +1199,20 @@ var (
 			HideFromDocs:      true,
 			HideFromAdminPage: true,
 		},
+		{
+			Name:         "alertingSaveStateIncremental",
+			Description:  "Writes only the changed alert instances to the database in batches, asynchronous to rule evaluation",
+			Stage:        FeatureStageExperimental,
+			FrontendOnly: false,
+			Owner:        grafanaAlertingSquad,
+		},
+		{
+			Name:         "azureMonitorMetricsBatchQueries",
+			Description:  "Runs Azure Monitor metrics queries of many resources with the Azure Monitor metrics batch API",
+			Stage:        FeatureStageExperimental,
+			FrontendOnly: false,
+			Owner:        grafanaPartnerPluginsSquad,
+		},
 	}
 )
 
@@ -650,4 +650,12 @@ const (
 	// FlagSsoSettingsSAML
 	// Use the new SSO Settings API to configure the SAML connector
 	FlagSsoSettingsSAML = "ssoSettingsSAML"
+
+	// FlagAlertingSaveStateIncremental
+	// Writes only the changed alert instances to the database in batches, asynchronous to rule evaluation
+	FlagAlertingSaveStateIncremental = "alertingSaveStateIncremental"
+
+	// FlagAzureMonitorMetricsBatchQueries
+	// Runs Azure Monitor metrics queries of many resources with the Azure Monitor metrics batch API
+	FlagAzureMonitorMetricsBatchQueries = "azureMonitorMetricsBatchQueries"
 )
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/plugins/httpresponsesender"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/lint"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// LintRules analyzes the alert rules the user has access to and returns a report of the problems found.
+// The rules can be limited to the folders specified in the query parameter folderUid.
+func (srv RulerSrv) LintRules(c *contextmodel.ReqContext) response.Response {
+	groups, err := srv.getRulesWithFolderTitleInFolders(c, c.QueryStrings("folderUid"))
+	if err != nil {
+		return errorToResponse(err)
+	}
+
+	opts := lint.Options{
+		FolderTitles: make(map[string]string, len(groups)),
+	}
+	var rules []*ngmodels.AlertRule
+	for _, group := range groups {
+		opts.FolderTitles[group.FolderUID] = group.FolderTitle
+		for i := range group.Rules {
+			rules = append(rules, &group.Rules[i])
+		}
+	}
+
+	amConfig, err := srv.amConfigStore.GetLatestAlertmanagerConfiguration(c.Req.Context(), c.SignedInUser.GetOrgID())
+	if err != nil && !errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get latest configuration")
+	}
+	if amConfig != nil {
+		cfg, err := notifier.Load([]byte(amConfig.AlertmanagerConfiguration))
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to parse configuration")
+		}
+		opts.Route = cfg.AlertmanagerConfig.Route
+	}
+	opts.MetricTypes = srv.prometheusMetricTypes(c, rules)
+
+	return response.JSON(http.StatusOK, lint.Analyze(rules, opts))
+}
+
+// prometheusMetricTypes reads the types of the metrics of the Prometheus data sources the rules query from their
+// metadata API, through the resource API of the data sources. The data sources that cannot be queried are skipped,
+// their metrics are recognized by name.
+func (srv RulerSrv) prometheusMetricTypes(c *contextmodel.ReqContext, rules []*ngmodels.AlertRule) map[string]map[string]string {
+	if srv.datasourceCache == nil || srv.pluginClient == nil || srv.pluginContextProvider == nil {
+		return nil
+	}
+	metricTypes := map[string]map[string]string{}
+	var prometheusDatasources []*datasources.DataSource
+	for _, rule := range rules {
+		for _, q := range rule.Data {
+			if _, ok := metricTypes[q.DatasourceUID]; ok || expr.IsDataSource(q.DatasourceUID) {
+				continue
+			}
+			metricTypes[q.DatasourceUID] = nil
+			ds, err := srv.datasourceCache.GetDatasourceByUID(c.Req.Context(), q.DatasourceUID, c.SignedInUser, false)
+			if err != nil || ds.Type != datasources.DS_PROMETHEUS {
+				continue
+			}
+			prometheusDatasources = append(prometheusDatasources, ds)
+		}
+	}
+
+	var mtx sync.Mutex
+	var wg sync.WaitGroup
+	for _, ds := range prometheusDatasources {
+		wg.Add(1)
+		go func(ds *datasources.DataSource) {
+			defer wg.Done()
+			types, err := srv.getMetricTypes(c.Req.Context(), c.SignedInUser, ds)
+			if err != nil {
+				srv.log.Debug("Failed to get metric metadata", "datasource", ds.UID, "error", err)
+				return
+			}
+			mtx.Lock()
+			defer mtx.Unlock()
+			metricTypes[ds.UID] = types
+		}(ds)
+	}
+	wg.Wait()
+	return metricTypes
+}
+
+func (srv RulerSrv) getMetricTypes(ctx context.Context, user identity.Requester, ds *datasources.DataSource) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	pCtx, err := srv.pluginContextProvider.GetWithDataSource(ctx, ds.Type, user, ds)
+	if err != nil {
+		return nil, err
+	}
+	req := &backend.CallResourceRequest{
+		PluginContext: pCtx,
+		Path:          "api/v1/metadata",
+		Method:        http.MethodGet,
+		URL:           "api/v1/metadata?limit_per_metric=1",
+	}
+	resp := response.CreateNormalResponse(make(http.Header), nil, 0)
+	if err := srv.pluginClient.CallResource(ctx, req, httpresponsesender.New(resp)); err != nil {
+		return nil, err
+	}
+	if resp.Status() != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response %d", resp.Status())
+	}
+
+	var metadata struct {
+		Data map[string][]struct {
+			Type string `json:"type"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body(), &metadata); err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(metadata.Data))
+	for metric, m := range metadata.Data {
+		if len(m) > 0 {
+			types[metric] = m[0].Type
+		}
+	}
+	return types, nil
+}
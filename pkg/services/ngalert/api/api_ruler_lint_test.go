@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type fakePluginContextProvider struct{}
+
+func (fakePluginContextProvider) GetWithDataSource(_ context.Context, pluginID string, _ identity.Requester, ds *datasources.DataSource) (backend.PluginContext, error) {
+	return backend.PluginContext{PluginID: pluginID, DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: ds.UID}}, nil
+}
+
+type fakeResourceClient struct {
+	requests atomic.Int32
+	handler  func(req *backend.CallResourceRequest) *backend.CallResourceResponse
+}
+
+func (f *fakeResourceClient) CallResource(_ context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	f.requests.Add(1)
+	return sender.Send(f.handler(req))
+}
+
+func TestPrometheusMetricTypes(t *testing.T) {
+	client := &fakeResourceClient{handler: func(req *backend.CallResourceRequest) *backend.CallResourceResponse {
+		if req.PluginContext.DataSourceInstanceSettings.UID == "failing" {
+			return &backend.CallResourceResponse{Status: http.StatusBadGateway}
+		}
+		assert.Equal(t, "api/v1/metadata", req.Path)
+		return &backend.CallResourceResponse{
+			Status: http.StatusOK,
+			Body:   []byte(`{"status":"success","data":{"memory_bytes":[{"type":"gauge"}],"http_requests_total":[{"type":"counter"}]}}`),
+		}
+	}}
+
+	srv := RulerSrv{
+		log: log.NewNopLogger(),
+		datasourceCache: &fakes.FakeCacheService{DataSources: []*datasources.DataSource{
+			{UID: "prometheus", Type: datasources.DS_PROMETHEUS},
+			{UID: "failing", Type: datasources.DS_PROMETHEUS},
+			{UID: "loki", Type: datasources.DS_LOKI},
+		}},
+		pluginClient:          client,
+		pluginContextProvider: fakePluginContextProvider{},
+	}
+	rule := func(uids ...string) *ngmodels.AlertRule {
+		r := &ngmodels.AlertRule{}
+		for _, uid := range uids {
+			r.Data = append(r.Data, ngmodels.AlertQuery{DatasourceUID: uid})
+		}
+		return r
+	}
+
+	types := srv.prometheusMetricTypes(createRequestContext(1, nil), []*ngmodels.AlertRule{
+		rule("prometheus", "__expr__"),
+		rule("loki", "prometheus", "failing"),
+		rule("missing"),
+	})
+	require.Equal(t, map[string]string{"memory_bytes": "gauge", "http_requests_total": "counter"}, types["prometheus"])
+	require.Nil(t, types["failing"])
+	require.Nil(t, types["loki"])
+	require.Nil(t, types["missing"])
+	require.Equal(t, int32(2), client.requests.Load())
+}
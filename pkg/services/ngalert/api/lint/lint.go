@@ -0,0 +1,475 @@
+// Package lint analyzes Grafana-managed alert rules and reports common mistakes, such as queries that do not cover
+// the evaluation interval, expressions that reference missing queries, or templates that use labels the alerts
+// do not have.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	alertingModels "github.com/grafana/alerting/models"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/expr/classic"
+	"github.com/grafana/grafana/pkg/promlib/analysis"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	CheckShortTimeRange   = "short-time-range"
+	CheckUnrouted         = "unrouted"
+	CheckReduceEmpty      = "reduce-empty-series"
+	CheckUnknownRefID     = "unknown-refid"
+	CheckUnusedRefID      = "unused-refid"
+	CheckDuplicateRule    = "duplicate-rule"
+	CheckUnknownLabel     = "template-unknown-label"
+	CheckInvalidQuery     = "invalid-query"
+	CheckPromQLPrefix     = "promql-"
+	templateLabelsPattern = `\$labels\.([a-zA-Z_][a-zA-Z0-9_]*)|\.Labels\.([a-zA-Z_][a-zA-Z0-9_]*)|index\s+\$labels\s+"([^"]+)"`
+)
+
+var templateLabelsRegexp = regexp.MustCompile(templateLabelsPattern)
+
+// Options contains the information about the organization the rules are analyzed in.
+type Options struct {
+	// Route is the root of the notification policy tree of the organization. If it is nil, routing is not checked.
+	Route *apimodels.Route
+	// FolderTitles maps the UID of the folders to their titles, which is the value of the grafana_folder label.
+	FolderTitles map[string]string
+	// MetricTypes maps the UID of Prometheus data sources to the types of their metrics, such as counter or gauge.
+	// Without them, counters are recognized by their name and rates over gauges are not reported.
+	MetricTypes map[string]map[string]string
+}
+
+// Analyze runs all checks against the rules and returns the problems found.
+func Analyze(rules []*ngmodels.AlertRule, opts Options) apimodels.RuleLintReport {
+	var route *dispatch.Route
+	if opts.Route != nil {
+		route = dispatch.NewRoute(opts.Route.AsAMRoute(), nil)
+	}
+
+	var problems []apimodels.RuleLintProblem
+	for _, rule := range rules {
+		r := ruleLinter{rule: rule, folderTitle: opts.FolderTitles[rule.NamespaceUID], metricTypes: opts.MetricTypes}
+		r.checkTimeRanges()
+		r.checkReferences()
+		r.checkPromQL()
+		r.checkTemplates()
+		if route != nil {
+			r.checkRouting(route)
+		}
+		problems = append(problems, r.problems...)
+	}
+	problems = append(problems, checkDuplicates(rules)...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if a.FolderUID != b.FolderUID {
+			return a.FolderUID < b.FolderUID
+		}
+		if a.RuleGroup != b.RuleGroup {
+			return a.RuleGroup < b.RuleGroup
+		}
+		if a.RuleTitle != b.RuleTitle {
+			return a.RuleTitle < b.RuleTitle
+		}
+		return a.RuleUID < b.RuleUID
+	})
+
+	report := apimodels.RuleLintReport{
+		Rules: len(rules),
+		Summary: map[apimodels.RuleLintSeverity]int{
+			apimodels.RuleLintSeverityError:   0,
+			apimodels.RuleLintSeverityWarning: 0,
+			apimodels.RuleLintSeverityInfo:    0,
+		},
+		Problems: make([]apimodels.RuleLintProblem, 0, len(problems)),
+	}
+	for _, p := range problems {
+		report.Summary[p.Severity]++
+		report.Problems = append(report.Problems, p)
+	}
+	return report
+}
+
+type ruleLinter struct {
+	rule        *ngmodels.AlertRule
+	folderTitle string
+	metricTypes map[string]map[string]string
+	problems    []apimodels.RuleLintProblem
+}
+
+func (r *ruleLinter) report(check string, severity apimodels.RuleLintSeverity, refID string, format string, args ...any) {
+	r.problems = append(r.problems, newProblem(r.rule, check, severity, refID, fmt.Sprintf(format, args...)))
+}
+
+func newProblem(rule *ngmodels.AlertRule, check string, severity apimodels.RuleLintSeverity, refID string, message string) apimodels.RuleLintProblem {
+	return apimodels.RuleLintProblem{
+		Check:     check,
+		Severity:  severity,
+		RuleUID:   rule.UID,
+		RuleTitle: rule.Title,
+		FolderUID: rule.NamespaceUID,
+		RuleGroup: rule.RuleGroup,
+		RefID:     refID,
+		Message:   message,
+	}
+}
+
+// checkTimeRanges reports the queries that look back less than the evaluation interval of the rule,
+// because changes that happen between two evaluations are never seen.
+func (r *ruleLinter) checkTimeRanges() {
+	interval := time.Duration(r.rule.IntervalSeconds) * time.Second
+	for _, q := range r.rule.Data {
+		if isExpression(q) {
+			continue
+		}
+		window := time.Duration(q.RelativeTimeRange.From) - time.Duration(q.RelativeTimeRange.To)
+		if window > 0 && window < interval {
+			r.report(CheckShortTimeRange, apimodels.RuleLintSeverityWarning, q.RefID,
+				"query looks back %s, which is shorter than the evaluation interval %s", window, interval)
+		}
+	}
+}
+
+// checkReferences reports the expressions that reference queries that do not exist, the queries and expressions
+// that are not used to compute the condition, and the reducers that turn series without data into NaN.
+func (r *ruleLinter) checkReferences() {
+	refIDs := make(map[string]struct{}, len(r.rule.Data))
+	for _, q := range r.rule.Data {
+		refIDs[q.RefID] = struct{}{}
+	}
+
+	dependencies := make(map[string][]string, len(r.rule.Data))
+	for _, q := range r.rule.Data {
+		if !isExpression(q) {
+			continue
+		}
+		cmd, err := parseExpression(q)
+		if err != nil {
+			r.report(CheckInvalidQuery, apimodels.RuleLintSeverityError, q.RefID, "failed to parse expression: %s", err)
+			continue
+		}
+		for _, v := range cmd.vars {
+			if _, ok := refIDs[v]; !ok {
+				r.report(CheckUnknownRefID, apimodels.RuleLintSeverityError, q.RefID, "expression references '%s', which is not a query or expression of the rule", v)
+			}
+		}
+		dependencies[q.RefID] = cmd.vars
+		if cmd.strictReduce {
+			r.report(CheckReduceEmpty, apimodels.RuleLintSeverityInfo, q.RefID,
+				"reducer '%s' returns NaN for series without data points in strict mode, consider dropping or replacing non-numeric values", cmd.reducer)
+		}
+	}
+
+	if _, ok := refIDs[r.rule.Condition]; !ok {
+		r.report(CheckUnknownRefID, apimodels.RuleLintSeverityError, r.rule.Condition, "condition '%s' is not a query or expression of the rule", r.rule.Condition)
+		return
+	}
+	used := map[string]struct{}{}
+	toVisit := []string{r.rule.Condition}
+	for len(toVisit) > 0 {
+		refID := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+		if _, ok := used[refID]; ok {
+			continue
+		}
+		used[refID] = struct{}{}
+		toVisit = append(toVisit, dependencies[refID]...)
+	}
+	for _, q := range r.rule.Data {
+		if _, ok := used[q.RefID]; !ok {
+			r.report(CheckUnusedRefID, apimodels.RuleLintSeverityWarning, q.RefID, "'%s' is not used to compute the condition '%s'", q.RefID, r.rule.Condition)
+		}
+	}
+}
+
+// checkPromQL reports common mistakes in the queries of Prometheus data sources, such as rates over gauges or
+// counters used without rate. The problems are reported with the name of the check prefixed by promql-.
+func (r *ruleLinter) checkPromQL() {
+	for _, q := range r.rule.Data {
+		if isExpression(q) {
+			continue
+		}
+		var model struct {
+			Expr       string `json:"expr"`
+			Datasource struct {
+				Type string `json:"type"`
+			} `json:"datasource"`
+		}
+		if err := json.Unmarshal(q.Model, &model); err != nil || model.Expr == "" || model.Datasource.Type != "prometheus" {
+			continue
+		}
+		result, err := analysis.Analyze(model.Expr, analysis.Options{MetricTypes: r.metricTypes[q.DatasourceUID]})
+		if err != nil {
+			r.report(CheckInvalidQuery, apimodels.RuleLintSeverityError, q.RefID, "failed to parse query: %s", err)
+			continue
+		}
+		for _, p := range result.Problems {
+			severity := apimodels.RuleLintSeverityWarning
+			if p.Severity == analysis.SeverityInfo {
+				severity = apimodels.RuleLintSeverityInfo
+			}
+			r.report(CheckPromQLPrefix+p.Check, severity, q.RefID, "%s", p.Message)
+		}
+	}
+}
+
+// checkTemplates reports the labels used in the templates of labels and annotations that are neither labels
+// of the rule nor mentioned in any of its queries, and therefore probably do not exist.
+func (r *ruleLinter) checkTemplates() {
+	known := map[string]struct{}{
+		model.AlertNameLabel:             {},
+		ngmodels.FolderTitleLabel:        {},
+		alertingModels.RuleUIDLabel:      {},
+		alertingModels.NamespaceUIDLabel: {},
+		ngmodels.AutogeneratedRouteLabel: {},
+	}
+	for k := range r.rule.Labels {
+		known[k] = struct{}{}
+	}
+	var queries strings.Builder
+	for _, q := range r.rule.Data {
+		if !isExpression(q) {
+			queries.Write(q.Model)
+			queries.WriteByte('\n')
+		}
+	}
+	queryText := queries.String()
+
+	check := func(kind, name, text string) {
+		reported := map[string]struct{}{}
+		for _, m := range templateLabelsRegexp.FindAllStringSubmatch(text, -1) {
+			label := m[1] + m[2] + m[3]
+			if _, ok := known[label]; ok {
+				continue
+			}
+			if _, ok := reported[label]; ok || containsWord(queryText, label) {
+				continue
+			}
+			reported[label] = struct{}{}
+			r.report(CheckUnknownLabel, apimodels.RuleLintSeverityWarning, "",
+				"%s '%s' references label '%s', which is not a label of the rule and does not appear in any of its queries", kind, name, label)
+		}
+	}
+	for _, k := range sortedKeys(r.rule.Annotations) {
+		check("annotation", k, r.rule.Annotations[k])
+	}
+	for _, k := range sortedKeys(r.rule.Labels) {
+		check("label", k, r.rule.Labels[k])
+	}
+}
+
+// checkRouting reports the rules whose labels do not match any notification policy, so their alerts are
+// only sent to the default contact point. Rules that select their contact point directly are skipped.
+func (r *ruleLinter) checkRouting(route *dispatch.Route) {
+	if len(r.rule.NotificationSettings) > 0 || len(route.Routes) == 0 {
+		return
+	}
+	lset := make(model.LabelSet, len(r.rule.Labels)+2)
+	for k, v := range r.rule.Labels {
+		lset[model.LabelName(k)] = model.LabelValue(v)
+	}
+	lset[model.AlertNameLabel] = model.LabelValue(r.rule.Title)
+	if r.folderTitle != "" {
+		lset[ngmodels.FolderTitleLabel] = model.LabelValue(r.folderTitle)
+	}
+	for _, matched := range route.Match(lset) {
+		if matched != route {
+			return
+		}
+	}
+	r.report(CheckUnrouted, apimodels.RuleLintSeverityWarning, "",
+		"labels of the rule do not match any notification policy, alerts are sent to the default contact point '%s'", route.RouteOpts.Receiver)
+}
+
+// checkDuplicates reports the rules that run the same queries and condition as other rules.
+func checkDuplicates(rules []*ngmodels.AlertRule) []apimodels.RuleLintProblem {
+	byKey := map[string][]*ngmodels.AlertRule{}
+	var keys []string
+	for _, rule := range rules {
+		key, err := queriesKey(rule)
+		if err != nil {
+			continue
+		}
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], rule)
+	}
+
+	var problems []apimodels.RuleLintProblem
+	for _, key := range keys {
+		duplicates := byKey[key]
+		if len(duplicates) < 2 {
+			continue
+		}
+		for _, rule := range duplicates {
+			others := make([]string, 0, len(duplicates)-1)
+			for _, other := range duplicates {
+				if other != rule {
+					others = append(others, fmt.Sprintf("'%s' (%s)", other.Title, other.UID))
+				}
+			}
+			problems = append(problems, newProblem(rule, CheckDuplicateRule, apimodels.RuleLintSeverityWarning, "",
+				"rule has the same queries and condition as "+strings.Join(others, ", ")))
+		}
+	}
+	return problems
+}
+
+// queriesKey returns a string that is the same for rules that have the same condition and queries.
+// Properties of the query models that do not change the result of the queries are ignored.
+func queriesKey(rule *ngmodels.AlertRule) (string, error) {
+	type query struct {
+		RefID         string
+		DatasourceUID string
+		From, To      ngmodels.Duration
+		Model         map[string]any
+	}
+	queries := make([]query, 0, len(rule.Data))
+	for _, q := range rule.Data {
+		m := map[string]any{}
+		if err := json.Unmarshal(q.Model, &m); err != nil {
+			return "", err
+		}
+		for _, k := range []string{"refId", "datasource", "intervalMs", "maxDataPoints", "hide"} {
+			delete(m, k)
+		}
+		queries = append(queries, query{
+			RefID:         q.RefID,
+			DatasourceUID: q.DatasourceUID,
+			From:          q.RelativeTimeRange.From,
+			To:            q.RelativeTimeRange.To,
+			Model:         m,
+		})
+	}
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].RefID < queries[j].RefID
+	})
+	// json.Marshal sorts the keys of maps, so equal models produce the same output.
+	b, err := json.Marshal(struct {
+		OrgID     int64
+		Condition string
+		Queries   []query
+	}{rule.OrgID, rule.Condition, queries})
+	return string(b), err
+}
+
+type expression struct {
+	vars         []string
+	reducer      string
+	strictReduce bool
+}
+
+// parseExpression returns the queries and expressions the expression depends on.
+func parseExpression(q ngmodels.AlertQuery) (expression, error) {
+	var common struct {
+		Type expr.QueryType `json:"type"`
+	}
+	if err := json.Unmarshal(q.Model, &common); err != nil {
+		return expression{}, err
+	}
+
+	switch common.Type {
+	case expr.QueryTypeMath:
+		var m expr.MathQuery
+		if err := json.Unmarshal(q.Model, &m); err != nil {
+			return expression{}, err
+		}
+		cmd, err := expr.NewMathCommand(q.RefID, m.Expression)
+		if err != nil {
+			return expression{}, err
+		}
+		return expression{vars: cmd.NeedsVars()}, nil
+	case expr.QueryTypeReduce:
+		var m expr.ReduceQuery
+		if err := json.Unmarshal(q.Model, &m); err != nil {
+			return expression{}, err
+		}
+		return expression{
+			vars:    []string{strings.TrimPrefix(m.Expression, "$")},
+			reducer: string(m.Reducer),
+			// count is the only reducer that returns a number for a series without data points.
+			strictReduce: (m.Settings == nil || m.Settings.Mode == "") && m.Reducer != "count",
+		}, nil
+	case expr.QueryTypeResample:
+		var m expr.ResampleQuery
+		if err := json.Unmarshal(q.Model, &m); err != nil {
+			return expression{}, err
+		}
+		return expression{vars: []string{strings.TrimPrefix(m.Expression, "$")}}, nil
+	case expr.QueryTypeThreshold:
+		var m expr.ThresholdQuery
+		if err := json.Unmarshal(q.Model, &m); err != nil {
+			return expression{}, err
+		}
+		return expression{vars: []string{strings.TrimPrefix(m.Expression, "$")}}, nil
+	case expr.QueryTypeClassic:
+		var m expr.ClassicQuery
+		if err := json.Unmarshal(q.Model, &m); err != nil {
+			return expression{}, err
+		}
+		cmd, err := classic.NewConditionCmd(q.RefID, m.Conditions)
+		if err != nil {
+			return expression{}, err
+		}
+		return expression{vars: cmd.NeedsVars()}, nil
+	case expr.QueryTypeSQL:
+		var m expr.SQLExpression
+		if err := json.Unmarshal(q.Model, &m); err != nil {
+			return expression{}, err
+		}
+		cmd, err := expr.NewSQLCommand(q.RefID, m.Expression)
+		if err != nil {
+			return expression{}, err
+		}
+		return expression{vars: cmd.NeedsVars()}, nil
+	default:
+		return expression{}, fmt.Errorf("unknown expression type '%s'", common.Type)
+	}
+}
+
+func isExpression(q ngmodels.AlertQuery) bool {
+	ok, _ := q.IsExpression()
+	return ok
+}
+
+// containsWord reports whether word appears in text and is not part of a longer word, like \b in a regular expression.
+func containsWord(text, word string) bool {
+	if word == "" {
+		return false
+	}
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		if (start == 0 || !isWordChar(text[start-1])) && (end == len(text) || !isWordChar(text[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
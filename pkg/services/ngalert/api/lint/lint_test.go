@@ -0,0 +1,183 @@
+package lint
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func dataQuery(refID string, from time.Duration, model string) ngmodels.AlertQuery {
+	return ngmodels.AlertQuery{
+		RefID:             refID,
+		DatasourceUID:     "prometheus",
+		RelativeTimeRange: ngmodels.RelativeTimeRange{From: ngmodels.Duration(from)},
+		Model:             json.RawMessage(model),
+	}
+}
+
+func expressionQuery(refID string, model string) ngmodels.AlertQuery {
+	return ngmodels.AlertQuery{
+		RefID:         refID,
+		DatasourceUID: "__expr__",
+		Model:         json.RawMessage(model),
+	}
+}
+
+func newRule(uid string, mutators ...func(r *ngmodels.AlertRule)) *ngmodels.AlertRule {
+	r := &ngmodels.AlertRule{
+		OrgID:           1,
+		UID:             uid,
+		Title:           uid,
+		NamespaceUID:    "folder",
+		RuleGroup:       "group",
+		IntervalSeconds: 60,
+		Condition:       "C",
+		Data: []ngmodels.AlertQuery{
+			dataQuery("A", 10*time.Minute, `{"expr": "up{job=\"`+uid+`\"}"}`),
+			expressionQuery("B", `{"type": "reduce", "expression": "A", "reducer": "last", "settings": {"mode": "dropNN"}}`),
+			expressionQuery("C", `{"type": "threshold", "expression": "B", "conditions": [{"evaluator": {"type": "gt", "params": [0]}}]}`),
+		},
+		Labels: map[string]string{"team": "a"},
+	}
+	for _, m := range mutators {
+		m(r)
+	}
+	return r
+}
+
+func checks(report apimodels.RuleLintReport) []string {
+	result := make([]string, 0, len(report.Problems))
+	for _, p := range report.Problems {
+		result = append(result, p.Check+":"+p.RuleUID+":"+p.RefID)
+	}
+	return result
+}
+
+func TestAnalyze(t *testing.T) {
+	t.Run("valid rules have no problems", func(t *testing.T) {
+		report := Analyze([]*ngmodels.AlertRule{newRule("a"), newRule("b")}, Options{})
+		require.Equal(t, 2, report.Rules)
+		require.Empty(t, report.Problems)
+		require.Equal(t, 0, report.Summary[apimodels.RuleLintSeverityError])
+	})
+
+	t.Run("reports queries shorter than the evaluation interval", func(t *testing.T) {
+		report := Analyze([]*ngmodels.AlertRule{newRule("a", func(r *ngmodels.AlertRule) {
+			r.Data[0].RelativeTimeRange.From = ngmodels.Duration(30 * time.Second)
+		})}, Options{})
+		require.Equal(t, []string{"short-time-range:a:A"}, checks(report))
+	})
+
+	t.Run("reports unknown and unused refIDs", func(t *testing.T) {
+		report := Analyze([]*ngmodels.AlertRule{newRule("a", func(r *ngmodels.AlertRule) {
+			r.Data = append(r.Data,
+				dataQuery("D", 10*time.Minute, `{"expr": "up"}`),
+				expressionQuery("E", `{"type": "math", "expression": "$D + $X"}`),
+			)
+		})}, Options{})
+		require.ElementsMatch(t, []string{"unknown-refid:a:E", "unused-refid:a:D", "unused-refid:a:E"}, checks(report))
+		require.Equal(t, 1, report.Summary[apimodels.RuleLintSeverityError])
+		require.Equal(t, 2, report.Summary[apimodels.RuleLintSeverityWarning])
+	})
+
+	t.Run("reports reducers in strict mode", func(t *testing.T) {
+		report := Analyze([]*ngmodels.AlertRule{newRule("a", func(r *ngmodels.AlertRule) {
+			r.Data[1] = expressionQuery("B", `{"type": "reduce", "expression": "A", "reducer": "mean"}`)
+		})}, Options{})
+		require.Equal(t, []string{"reduce-empty-series:a:B"}, checks(report))
+	})
+
+	t.Run("reports duplicate rules", func(t *testing.T) {
+		report := Analyze([]*ngmodels.AlertRule{
+			newRule("a"),
+			newRule("b", func(r *ngmodels.AlertRule) {
+				r.Data[0] = dataQuery("A", 10*time.Minute, `{"expr": "up{job=\"a\"}", "refId": "A", "intervalMs": 1000}`)
+			}),
+		}, Options{})
+		require.Equal(t, []string{"duplicate-rule:a:", "duplicate-rule:b:"}, checks(report))
+	})
+
+	t.Run("reports templates that reference unknown labels", func(t *testing.T) {
+		report := Analyze([]*ngmodels.AlertRule{newRule("a", func(r *ngmodels.AlertRule) {
+			r.Annotations = map[string]string{
+				"summary":     "{{ $labels.team }} {{ $labels.job }} {{ $labels.alertname }}",
+				"description": `{{ index $labels "instance" }}`,
+			}
+		})}, Options{})
+		require.Equal(t, []string{"template-unknown-label:a:"}, checks(report))
+		require.Contains(t, report.Problems[0].Message, "'instance'")
+	})
+
+	t.Run("reports mistakes in PromQL queries", func(t *testing.T) {
+		report := Analyze([]*ngmodels.AlertRule{
+			newRule("a", func(r *ngmodels.AlertRule) {
+				r.Data[0] = dataQuery("A", 10*time.Minute, `{"expr": "http_requests_total{job=\"a\"} > 10", "datasource": {"type": "prometheus"}}`)
+			}),
+			newRule("b", func(r *ngmodels.AlertRule) {
+				r.Data[0] = dataQuery("A", 10*time.Minute, `{"expr": "sum(rate(http_requests_total{job=\"b\"}[5m])", "datasource": {"type": "prometheus"}}`)
+			}),
+			newRule("c", func(r *ngmodels.AlertRule) {
+				r.Data[0] = dataQuery("A", 10*time.Minute, `{"expr": "http_requests_total{job=\"c\"}", "datasource": {"type": "loki"}}`)
+			}),
+		}, Options{})
+		require.Equal(t, []string{"promql-counter-without-rate:a:A", "invalid-query:b:A"}, checks(report))
+	})
+
+	t.Run("reports rates over gauges with the metric types of the data source", func(t *testing.T) {
+		rules := []*ngmodels.AlertRule{
+			newRule("a", func(r *ngmodels.AlertRule) {
+				r.Data[0] = dataQuery("A", 10*time.Minute, `{"expr": "rate(memory_bytes{job=\"a\"}[5m]) > 10", "datasource": {"type": "prometheus"}}`)
+			}),
+		}
+		require.Empty(t, checks(Analyze(rules, Options{})))
+
+		report := Analyze(rules, Options{MetricTypes: map[string]map[string]string{"prometheus": {"memory_bytes": "gauge"}}})
+		require.Equal(t, []string{"promql-rate-over-gauge:a:A"}, checks(report))
+	})
+
+	t.Run("reports rules that are only routed to the default policy", func(t *testing.T) {
+		matcher, err := labels.NewMatcher(labels.MatchEqual, "team", "a")
+		require.NoError(t, err)
+		route := &apimodels.Route{
+			Receiver: "default",
+			Routes: []*apimodels.Route{{
+				Receiver:       "team-a",
+				ObjectMatchers: apimodels.ObjectMatchers{matcher},
+			}},
+		}
+		report := Analyze([]*ngmodels.AlertRule{
+			newRule("a"),
+			newRule("b", func(r *ngmodels.AlertRule) {
+				r.Labels = map[string]string{"team": "b"}
+			}),
+			newRule("c", func(r *ngmodels.AlertRule) {
+				r.Labels = nil
+				r.NotificationSettings = []ngmodels.NotificationSettings{{Receiver: "team-a"}}
+			}),
+		}, Options{Route: route})
+		require.Equal(t, []string{"unrouted:b:"}, checks(report))
+	})
+}
+
+func TestContainsWord(t *testing.T) {
+	for _, tc := range []struct {
+		text     string
+		word     string
+		expected bool
+	}{
+		{text: `sum by (instance) (up)`, word: "instance", expected: true},
+		{text: `instance`, word: "instance", expected: true},
+		{text: `up{instance_name="a"}`, word: "instance", expected: false},
+		{text: `up{my_instance="a"}`, word: "instance", expected: false},
+		{text: `up{my_instance="a", instance="b"}`, word: "instance", expected: true},
+		{text: `up`, word: "", expected: false},
+	} {
+		require.Equalf(t, tc.expected, containsWord(tc.text, tc.word), "%q in %q", tc.word, tc.text)
+	}
+}
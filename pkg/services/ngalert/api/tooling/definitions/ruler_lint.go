@@ -0,0 +1,68 @@
+package definitions
+
+// swagger:route Get /ruler/grafana/api/v1/lint/rules ruler stable RouteGetRulesLint
+//
+// Analyze the rules of the organization and report common problems
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: RuleLintReport
+//       403: ForbiddenError
+
+// swagger:parameters RouteGetRulesLint
+type RuleLintParameters struct {
+	// UIDs of folders whose rules are analyzed. All folders are analyzed if empty.
+	// in:query
+	// required:false
+	FolderUID []string `json:"folderUid"`
+}
+
+// RuleLintSeverity is the severity of a problem found by the rule analyzer.
+// swagger:enum RuleLintSeverity
+type RuleLintSeverity string
+
+const (
+	RuleLintSeverityError   RuleLintSeverity = "error"
+	RuleLintSeverityWarning RuleLintSeverity = "warning"
+	RuleLintSeverityInfo    RuleLintSeverity = "info"
+)
+
+// Level returns a number that increases with the severity, used to compare severities.
+func (s RuleLintSeverity) Level() int {
+	switch s {
+	case RuleLintSeverityError:
+		return 3
+	case RuleLintSeverityWarning:
+		return 2
+	case RuleLintSeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// swagger:model
+type RuleLintReport struct {
+	// The number of rules that were analyzed.
+	Rules int `json:"rules"`
+	// The number of problems of each severity.
+	Summary map[RuleLintSeverity]int `json:"summary"`
+	// The problems found, sorted by folder, group and rule.
+	Problems []RuleLintProblem `json:"problems"`
+}
+
+// swagger:model
+type RuleLintProblem struct {
+	// The name of the check that reported the problem.
+	Check     string           `json:"check"`
+	Severity  RuleLintSeverity `json:"severity"`
+	RuleUID   string           `json:"ruleUid"`
+	RuleTitle string           `json:"ruleTitle"`
+	FolderUID string           `json:"folderUid"`
+	RuleGroup string           `json:"ruleGroup"`
+	// The RefID of the query or expression the problem is about, if any.
+	RefID   string `json:"refId,omitempty"`
+	Message string `json:"message"`
+}
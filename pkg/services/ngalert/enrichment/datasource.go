@@ -0,0 +1,164 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+)
+
+const (
+	defaultMaxLines  = 10
+	defaultTimeRange = 10 * time.Minute
+)
+
+// Querier runs queries against data sources.
+type Querier interface {
+	BuildPipeline(req *expr.Request) (expr.DataPipeline, error)
+	ExecutePipeline(ctx context.Context, now time.Time, pipeline expr.DataPipeline) (*backend.QueryDataResponse, error)
+}
+
+// datasourceEnricher runs a query built from the labels of the alert against a data source and
+// uses the first rows of the result, one per line, as the value of the annotation.
+type datasourceEnricher struct {
+	datasourceUID string
+	query         *template.Template
+	timeRange     time.Duration
+	maxLines      int
+
+	dsCache datasources.CacheService
+	querier Querier
+}
+
+func newDatasourceEnricher(cfg map[string]string, dsCache datasources.CacheService, querier Querier) (*datasourceEnricher, error) {
+	if cfg["datasource_uid"] == "" {
+		return nil, errors.New("setting 'datasource_uid' is required")
+	}
+	if cfg["query"] == "" {
+		return nil, errors.New("setting 'query' is required")
+	}
+	tmpl, err := parseTemplate("query", cfg["query"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query template: %w", err)
+	}
+	e := &datasourceEnricher{
+		datasourceUID: cfg["datasource_uid"],
+		query:         tmpl,
+		timeRange:     defaultTimeRange,
+		maxLines:      defaultMaxLines,
+		dsCache:       dsCache,
+		querier:       querier,
+	}
+	if v := cfg["time_range"]; v != "" {
+		e.timeRange, err = gtime.ParseDuration(v)
+		if err != nil || e.timeRange <= 0 {
+			return nil, fmt.Errorf("invalid value of setting 'time_range': %s", v)
+		}
+	}
+	if v := cfg["max_lines"]; v != "" {
+		e.maxLines, err = strconv.Atoi(v)
+		if err != nil || e.maxLines <= 0 {
+			return nil, fmt.Errorf("invalid value of setting 'max_lines': %s", v)
+		}
+	}
+	return e, nil
+}
+
+func (e *datasourceEnricher) Key(data TemplateData) (string, error) {
+	return executeTemplate(e.query, data)
+}
+
+func (e *datasourceEnricher) Enrich(ctx context.Context, orgID int64, data TemplateData) (string, error) {
+	query, err := e.Key(data)
+	if err != nil {
+		return "", err
+	}
+	if !json.Valid([]byte(query)) {
+		return "", errors.New("query is not a valid JSON object")
+	}
+
+	user := schedule.SchedulerUserFor(orgID)
+	ds, err := e.dsCache.GetDatasourceByUID(ctx, e.datasourceUID, user, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to get data source: %w", err)
+	}
+
+	req := &expr.Request{
+		OrgId: orgID,
+		User:  user,
+		Headers: map[string]string{
+			models.FromAlertHeaderName: "true",
+		},
+		Queries: []expr.Query{{
+			RefID:      "A",
+			DataSource: ds,
+			JSON:       json.RawMessage(query),
+			TimeRange: expr.RelativeTimeRange{
+				From: -e.timeRange,
+				To:   0,
+			},
+			Interval:      time.Second,
+			MaxDataPoints: int64(e.maxLines),
+		}},
+	}
+	pipeline, err := e.querier.BuildPipeline(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to build query: %w", err)
+	}
+	resp, err := e.querier.ExecutePipeline(ctx, time.Now(), pipeline)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute query: %w", err)
+	}
+	res, ok := resp.Responses["A"]
+	if !ok {
+		return "", nil
+	}
+	if res.Error != nil {
+		return "", res.Error
+	}
+	return strings.Join(framesToLines(res.Frames, e.maxLines), "\n"), nil
+}
+
+// framesToLines returns up to maxLines rows of the frames. If a frame contains log lines only the line
+// is returned, otherwise the values of all fields of the row are joined.
+func framesToLines(frames data.Frames, maxLines int) []string {
+	lines := make([]string, 0, maxLines)
+	for _, frame := range frames {
+		fields := frame.Fields
+		for _, f := range frame.Fields {
+			if f.Name == "Line" || f.Name == "line" {
+				fields = []*data.Field{f}
+				break
+			}
+		}
+		rows, _ := frame.RowLen()
+		for i := 0; i < rows; i++ {
+			if len(lines) == maxLines {
+				return lines
+			}
+			values := make([]string, 0, len(fields))
+			for _, f := range fields {
+				v, ok := f.ConcreteAt(i)
+				if !ok {
+					continue
+				}
+				values = append(values, fmt.Sprint(v))
+			}
+			lines = append(lines, strings.Join(values, " "))
+		}
+	}
+	return lines
+}
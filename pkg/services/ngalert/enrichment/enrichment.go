@@ -0,0 +1,351 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/common/model"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	TypeHTTP       = "http"
+	TypeDatasource = "datasource"
+)
+
+// failureCacheTTL is how long a failed lookup is cached, so an enricher that is down is not called for every alert.
+var failureCacheTTL = time.Minute
+
+const (
+	// workers is the number of alert rules whose alerts are enriched at the same time.
+	workers = 4
+	// queueSize is the number of batches of alerts each worker holds before its queued batches are sent without enrichment.
+	queueSize = 100
+)
+
+// AlertsSender is an interface for a service that is responsible for sending notifications to the end-user.
+type AlertsSender interface {
+	Send(ctx context.Context, key models.AlertRuleKey, alerts definitions.PostableAlerts)
+}
+
+// Enricher looks up additional information about an alert.
+type Enricher interface {
+	// Key returns a string that identifies the lookup made for the alert. Alerts with the same key share the cached result.
+	Key(data TemplateData) (string, error)
+	// Enrich returns the value of the annotation to add to the alert.
+	Enrich(ctx context.Context, orgID int64, data TemplateData) (string, error)
+}
+
+// TemplateData is the data available in the templates of the enrichers.
+type TemplateData struct {
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+type enricher struct {
+	name       string
+	annotation string
+	matchers   labels.Matchers
+	Enricher
+}
+
+// Sender adds annotations to the alerts using the configured enrichers before they are passed to the next sender.
+// The alerts are enriched in the background by Run, so the enrichers never delay the evaluation of the rules.
+// The alerts of a rule are always handled by the same worker and passed to the next sender in the order they were sent.
+// Every lookup is bounded by a timeout and its result is cached, so a slow or unavailable enricher delays the
+// notifications of a rule by at most the timeout. Alerts that could not be enriched are sent without the annotation.
+type Sender struct {
+	next           AlertsSender
+	enrichers      []enricher
+	timeout        time.Duration
+	cacheTTL       time.Duration
+	maxConcurrency int
+	cache          *localcache.CacheService
+	queues         []*alertsQueue
+	logger         log.Logger
+}
+
+type queuedAlerts struct {
+	ctx    context.Context
+	key    models.AlertRuleKey
+	alerts definitions.PostableAlerts
+	// enrich is false if the alerts must be passed to the next sender without being enriched.
+	enrich bool
+}
+
+// alertsQueue holds the alerts waiting for a worker. It is not bounded so the alerts of a rule are never passed to
+// the next sender ahead of its older alerts, but once it holds queueSize batches they are no longer enriched.
+type alertsQueue struct {
+	mtx   sync.Mutex
+	items []queuedAlerts
+	// ready is signalled when alerts are added to the queue.
+	ready chan struct{}
+}
+
+func newAlertsQueue() *alertsQueue {
+	return &alertsQueue{ready: make(chan struct{}, 1)}
+}
+
+// push adds the alerts to the queue. It returns false if the queue is full, in which case none of the queued alerts
+// are enriched anymore so the worker catches up as fast as possible.
+func (q *alertsQueue) push(item queuedAlerts) bool {
+	q.mtx.Lock()
+	full := len(q.items) >= queueSize
+	if full {
+		for i := range q.items {
+			q.items[i].enrich = false
+		}
+		item.enrich = false
+	}
+	q.items = append(q.items, item)
+	q.mtx.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return !full
+}
+
+// pop removes the oldest alerts from the queue. It returns false if the queue is empty.
+func (q *alertsQueue) pop() (queuedAlerts, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if len(q.items) == 0 {
+		return queuedAlerts{}, false
+	}
+	item := q.items[0]
+	q.items[0] = queuedAlerts{}
+	q.items = q.items[1:]
+	return item, true
+}
+
+// NewSender creates the enrichers from the configuration and returns a Sender that passes the enriched alerts to next.
+func NewSender(cfg setting.UnifiedAlertingEnrichmentSettings, next AlertsSender, dsCache datasources.CacheService, querier Querier, httpClientProvider httpclient.Provider, logger log.Logger) (*Sender, error) {
+	names := make([]string, 0, len(cfg.Enrichers))
+	for name := range cfg.Enrichers {
+		names = append(names, name)
+	}
+	// Enrichers are applied in a stable order so the last one wins if several of them set the same annotation.
+	sort.Strings(names)
+
+	enrichers := make([]enricher, 0, len(names))
+	for _, name := range names {
+		e, err := newEnricher(name, cfg.Enrichers[name], dsCache, querier, httpClientProvider)
+		if err != nil {
+			return nil, fmt.Errorf("invalid enricher '%s': %w", name, err)
+		}
+		enrichers = append(enrichers, e)
+	}
+
+	queues := make([]*alertsQueue, workers)
+	for i := range queues {
+		queues[i] = newAlertsQueue()
+	}
+
+	return &Sender{
+		next:           next,
+		enrichers:      enrichers,
+		timeout:        cfg.Timeout,
+		cacheTTL:       cfg.CacheTTL,
+		maxConcurrency: cfg.MaxConcurrency,
+		cache:          localcache.New(cfg.CacheTTL, 2*cfg.CacheTTL),
+		queues:         queues,
+		logger:         logger,
+	}, nil
+}
+
+func newEnricher(name string, cfg map[string]string, dsCache datasources.CacheService, querier Querier, httpClientProvider httpclient.Provider) (enricher, error) {
+	e := enricher{
+		name:       name,
+		annotation: cfg["annotation"],
+	}
+	if e.annotation == "" {
+		return e, errors.New("setting 'annotation' is required")
+	}
+	if m := cfg["matchers"]; m != "" {
+		matchers, err := labels.ParseMatchers(m)
+		if err != nil {
+			return e, fmt.Errorf("failed to parse matchers: %w", err)
+		}
+		e.matchers = matchers
+	}
+
+	var err error
+	switch t := cfg["type"]; t {
+	case TypeHTTP:
+		e.Enricher, err = newHTTPEnricher(cfg, httpClientProvider)
+	case TypeDatasource:
+		e.Enricher, err = newDatasourceEnricher(cfg, dsCache, querier)
+	default:
+		err = fmt.Errorf("unsupported type '%s'", t)
+	}
+	return e, err
+}
+
+// Send queues the alerts to be enriched and passed to the next sender. If the queue of the rule is full, the queued
+// alerts and these alerts are passed to the next sender in order without being enriched.
+func (s *Sender) Send(ctx context.Context, key models.AlertRuleKey, alerts definitions.PostableAlerts) {
+	if len(s.enrichers) == 0 || len(alerts.PostableAlerts) == 0 {
+		s.next.Send(ctx, key, alerts)
+		return
+	}
+	// The alerts are enriched after the evaluation has finished, and its context is cancelled.
+	item := queuedAlerts{ctx: context.WithoutCancel(ctx), key: key, alerts: alerts, enrich: true}
+	if !s.queues[queueIndex(key, len(s.queues))].push(item) {
+		logger := s.logger.FromContext(ctx).New(key.LogContext()...)
+		logger.Warn("Alert enrichment queue is full, sending queued alerts without enrichment", "count", len(alerts.PostableAlerts))
+	}
+}
+
+// Run enriches the queued alerts until the context is cancelled. The alerts still queued
+// when the context is cancelled are passed to the next sender without being enriched.
+func (s *Sender) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, queue := range s.queues {
+		wg.Add(1)
+		go func(queue *alertsQueue) {
+			defer wg.Done()
+			for {
+				item, ok := queue.pop()
+				if !ok {
+					if ctx.Err() != nil {
+						return
+					}
+					select {
+					case <-queue.ready:
+					case <-ctx.Done():
+					}
+					continue
+				}
+				if item.enrich && ctx.Err() == nil {
+					s.enrich(item.ctx, item.key, item.alerts)
+				}
+				s.next.Send(item.ctx, item.key, item.alerts)
+			}
+		}(queue)
+	}
+	wg.Wait()
+	return nil
+}
+
+func queueIndex(key models.AlertRuleKey, queues int) int {
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%d/%s", key.OrgID, key.UID)
+	return int(h.Sum32() % uint32(queues))
+}
+
+func (s *Sender) enrich(ctx context.Context, key models.AlertRuleKey, alerts definitions.PostableAlerts) {
+	logger := s.logger.FromContext(ctx).New(key.LogContext()...)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	// values[i][j] is the value returned by the enricher j for the alert i.
+	values := make([][]string, len(alerts.PostableAlerts))
+	g := errgroup.Group{}
+	g.SetLimit(s.maxConcurrency)
+	for i, alert := range alerts.PostableAlerts {
+		values[i] = make([]string, len(s.enrichers))
+		data := TemplateData{
+			Labels:      alert.Labels,
+			Annotations: alert.Annotations,
+		}
+		lset := toModelLabels(alert.Labels)
+		for j, e := range s.enrichers {
+			if !e.matchers.Matches(lset) {
+				continue
+			}
+			i, j, e := i, j, e
+			g.Go(func() error {
+				value, err := s.lookup(ctx, key.OrgID, e, data)
+				if err != nil {
+					logger.Warn("Failed to enrich alert", "enricher", e.name, "error", err)
+					return nil
+				}
+				values[i][j] = value
+				return nil
+			})
+		}
+	}
+	_ = g.Wait()
+
+	for i := range alerts.PostableAlerts {
+		alert := &alerts.PostableAlerts[i]
+		for j, e := range s.enrichers {
+			if values[i][j] == "" {
+				continue
+			}
+			if alert.Annotations == nil {
+				alert.Annotations = make(map[string]string)
+			}
+			alert.Annotations[e.annotation] = values[i][j]
+		}
+	}
+}
+
+type cachedResult struct {
+	value string
+	err   error
+}
+
+func (s *Sender) lookup(ctx context.Context, orgID int64, e enricher, data TemplateData) (string, error) {
+	lookupKey, err := e.Key(data)
+	if err != nil {
+		return "", err
+	}
+	if s.cacheTTL <= 0 {
+		return e.Enrich(ctx, orgID, data)
+	}
+	cacheKey := fmt.Sprintf("%s/%d/%s", e.name, orgID, lookupKey)
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		r := cached.(cachedResult)
+		return r.value, r.err
+	}
+
+	value, err := e.Enrich(ctx, orgID, data)
+	if err != nil {
+		// Do not remember that the lookup was cancelled because the evaluation was stopped.
+		if !errors.Is(err, context.Canceled) {
+			s.cache.Set(cacheKey, cachedResult{err: err}, min(failureCacheTTL, s.cacheTTL))
+		}
+		return "", err
+	}
+	s.cache.Set(cacheKey, cachedResult{value: value}, s.cacheTTL)
+	return value, nil
+}
+
+func toModelLabels(ls map[string]string) model.LabelSet {
+	result := make(model.LabelSet, len(ls))
+	for k, v := range ls {
+		result[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return result
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Option("missingkey=zero").Parse(text)
+}
+
+func executeTemplate(tmpl *template.Template, data TemplateData) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to expand template '%s': %w", tmpl.Name(), err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,218 @@
+package enrichment
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeSender struct {
+	mtx  sync.Mutex
+	sent []definitions.PostableAlerts
+}
+
+func (f *fakeSender) Send(_ context.Context, _ models.AlertRuleKey, alerts definitions.PostableAlerts) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.sent = append(f.sent, alerts)
+}
+
+// waitSent waits until the given number of batches of alerts have been sent and returns them.
+func (f *fakeSender) waitSent(t *testing.T, count int) []definitions.PostableAlerts {
+	t.Helper()
+	var sent []definitions.PostableAlerts
+	require.Eventually(t, func() bool {
+		f.mtx.Lock()
+		defer f.mtx.Unlock()
+		sent = f.sent
+		return len(sent) >= count
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, sent, count)
+	return sent
+}
+
+func newAlerts(labels ...map[string]string) definitions.PostableAlerts {
+	alerts := definitions.PostableAlerts{}
+	for _, ls := range labels {
+		alerts.PostableAlerts = append(alerts.PostableAlerts, amv2.PostableAlert{
+			Alert:       amv2.Alert{Labels: ls},
+			Annotations: amv2.LabelSet{"summary": "test"},
+		})
+	}
+	return alerts
+}
+
+func TestSender(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(time.Second)
+		case "/runbook":
+			_, _ = fmt.Fprintf(w, `{"url": "https://runbooks/%s", "owner": {"team": "a"}}`, r.URL.Query().Get("service"))
+		case "/team":
+			_, _ = w.Write([]byte("  team-" + r.URL.Query().Get("service") + "\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	newSender := func(t *testing.T, enrichers map[string]map[string]string) (*Sender, *fakeSender) {
+		t.Helper()
+		next := &fakeSender{}
+		s, err := NewSender(setting.UnifiedAlertingEnrichmentSettings{
+			Enabled:        true,
+			Timeout:        200 * time.Millisecond,
+			CacheTTL:       time.Minute,
+			MaxConcurrency: 2,
+			Enrichers:      enrichers,
+		}, next, nil, nil, httpclient.NewProvider(), log.NewNopLogger())
+		require.NoError(t, err)
+		return s, next
+	}
+	runSender := func(t *testing.T, s *Sender) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			_ = s.Run(ctx)
+			close(done)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+	}
+
+	t.Run("should add annotations from HTTP lookups", func(t *testing.T) {
+		s, next := newSender(t, map[string]map[string]string{
+			"runbook": {"type": "http", "annotation": "runbook_url", "field": "url", "url": srv.URL + "/runbook?service={{ .Labels.service | urlquery }}"},
+			"team":    {"type": "http", "annotation": "team", "url": srv.URL + "/team?service={{ .Labels.service }}", "matchers": `service="api"`},
+		})
+		runSender(t, s)
+
+		s.Send(context.Background(), models.AlertRuleKey{OrgID: 1, UID: "rule"}, newAlerts(
+			map[string]string{"service": "api"},
+			map[string]string{"service": "db"},
+		))
+
+		alerts := next.waitSent(t, 1)[0].PostableAlerts
+		require.Equal(t, amv2.LabelSet{"summary": "test", "runbook_url": "https://runbooks/api", "team": "team-api"}, alerts[0].Annotations)
+		require.Equal(t, amv2.LabelSet{"summary": "test", "runbook_url": "https://runbooks/db"}, alerts[1].Annotations)
+	})
+
+	t.Run("should cache the results of lookups", func(t *testing.T) {
+		s, next := newSender(t, map[string]map[string]string{
+			"team": {"type": "http", "annotation": "team", "url": srv.URL + "/team?service={{ .Labels.service }}"},
+		})
+		runSender(t, s)
+		before := requests.Load()
+
+		for i := 0; i < 3; i++ {
+			s.Send(context.Background(), models.AlertRuleKey{OrgID: 1, UID: "rule"}, newAlerts(map[string]string{"service": "cached"}))
+		}
+
+		sent := next.waitSent(t, 3)
+		require.Equal(t, int32(1), requests.Load()-before)
+		require.Equal(t, "team-cached", sent[2].PostableAlerts[0].Annotations["team"])
+	})
+
+	t.Run("should send alerts without annotations if the enricher times out or fails", func(t *testing.T) {
+		s, next := newSender(t, map[string]map[string]string{
+			"slow":    {"type": "http", "annotation": "slow", "url": srv.URL + "/slow"},
+			"missing": {"type": "http", "annotation": "missing", "url": srv.URL + "/missing"},
+		})
+		runSender(t, s)
+
+		start := time.Now()
+		s.Send(context.Background(), models.AlertRuleKey{OrgID: 1, UID: "rule"}, newAlerts(map[string]string{"service": "api"}))
+
+		sent := next.waitSent(t, 1)
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, amv2.LabelSet{"summary": "test"}, sent[0].PostableAlerts[0].Annotations)
+	})
+
+	t.Run("should not block the caller while the alerts are enriched", func(t *testing.T) {
+		s, next := newSender(t, map[string]map[string]string{
+			"slow": {"type": "http", "annotation": "slow", "url": srv.URL + "/slow"},
+		})
+		runSender(t, s)
+
+		start := time.Now()
+		s.Send(context.Background(), models.AlertRuleKey{OrgID: 1, UID: "rule"}, newAlerts(map[string]string{"service": "api"}))
+		require.Less(t, time.Since(start), 100*time.Millisecond)
+
+		next.waitSent(t, 1)
+	})
+
+	t.Run("should send the queued alerts without enrichment when stopped", func(t *testing.T) {
+		s, next := newSender(t, map[string]map[string]string{
+			"team": {"type": "http", "annotation": "team", "url": srv.URL + "/team?service={{ .Labels.service }}"},
+		})
+		for i := 0; i < 3; i++ {
+			s.Send(context.Background(), models.AlertRuleKey{OrgID: 1, UID: "rule"}, newAlerts(map[string]string{"service": "stopped"}))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, s.Run(ctx))
+
+		sent := next.waitSent(t, 3)
+		require.Equal(t, amv2.LabelSet{"summary": "test"}, sent[0].PostableAlerts[0].Annotations)
+	})
+
+	t.Run("should keep the order of the alerts without enrichment when the queue is full", func(t *testing.T) {
+		s, next := newSender(t, map[string]map[string]string{
+			"team": {"type": "http", "annotation": "team", "url": srv.URL + "/team?service={{ .Labels.service }}"},
+		})
+		count := queueSize + 1
+		for i := 0; i < count; i++ {
+			s.Send(context.Background(), models.AlertRuleKey{OrgID: 1, UID: "rule"}, newAlerts(map[string]string{"service": fmt.Sprint(i)}))
+		}
+		runSender(t, s)
+
+		sent := next.waitSent(t, count)
+		for i, alerts := range sent {
+			require.Equal(t, fmt.Sprint(i), alerts.PostableAlerts[0].Labels["service"])
+			require.Equal(t, amv2.LabelSet{"summary": "test"}, alerts.PostableAlerts[0].Annotations)
+		}
+	})
+
+	t.Run("should fail if the configuration is invalid", func(t *testing.T) {
+		for name, cfg := range map[string]map[string]string{
+			"unknown type":       {"type": "unknown", "annotation": "a"},
+			"missing annotation": {"type": "http", "url": "http://localhost"},
+			"missing url":        {"type": "http", "annotation": "a"},
+			"invalid matchers":   {"type": "http", "annotation": "a", "url": "http://localhost", "matchers": "a=~("},
+			"missing query":      {"type": "datasource", "annotation": "a", "datasource_uid": "uid"},
+		} {
+			_, err := NewSender(setting.UnifiedAlertingEnrichmentSettings{
+				Enrichers: map[string]map[string]string{"test": cfg},
+			}, &fakeSender{}, nil, nil, httpclient.NewProvider(), log.NewNopLogger())
+			require.Errorf(t, err, name)
+		}
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	require.Equal(t, "abc", truncate("abc", 5))
+	require.Equal(t, "ab", truncate("abc", 2))
+	require.Equal(t, "a", truncate("aé", 2))
+	require.Equal(t, "aé", truncate("aé", 3))
+}
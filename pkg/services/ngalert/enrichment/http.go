@@ -0,0 +1,117 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+)
+
+const defaultMaxResponseSize = 4096
+
+// httpEnricher makes a GET request to a URL built from the labels of the alert and uses the response,
+// or one of the fields of the JSON object it contains, as the value of the annotation.
+type httpEnricher struct {
+	url             *template.Template
+	field           string
+	maxResponseSize int
+	client          *http.Client
+}
+
+func newHTTPEnricher(cfg map[string]string, httpClientProvider httpclient.Provider) (*httpEnricher, error) {
+	if cfg["url"] == "" {
+		return nil, errors.New("setting 'url' is required")
+	}
+	tmpl, err := parseTemplate("url", cfg["url"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url template: %w", err)
+	}
+	maxResponseSize := defaultMaxResponseSize
+	if v := cfg["max_response_size"]; v != "" {
+		maxResponseSize, err = strconv.Atoi(v)
+		if err != nil || maxResponseSize <= 0 {
+			return nil, fmt.Errorf("invalid value of setting 'max_response_size': %s", v)
+		}
+	}
+	client, err := httpClientProvider.New(sdkhttpclient.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	return &httpEnricher{
+		url:             tmpl,
+		field:           cfg["field"],
+		maxResponseSize: maxResponseSize,
+		client:          client,
+	}, nil
+}
+
+func (e *httpEnricher) Key(data TemplateData) (string, error) {
+	return executeTemplate(e.url, data)
+}
+
+func (e *httpEnricher) Enrich(ctx context.Context, _ int64, data TemplateData) (string, error) {
+	url, err := e.Key(data)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	if e.field == "" {
+		b, err := io.ReadAll(io.LimitReader(resp.Body, int64(e.maxResponseSize)))
+		if err != nil {
+			return "", fmt.Errorf("failed to read response: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	switch v := body[e.field].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return truncate(v, e.maxResponseSize), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return truncate(string(b), e.maxResponseSize), nil
+	}
+}
+
+// truncate returns at most size bytes of s without splitting a multi-byte character.
+func truncate(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	for size > 0 && !utf8.RuneStart(s[size]) {
+		size--
+	}
+	return s[:size]
+}
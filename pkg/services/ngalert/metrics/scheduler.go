@@ -29,6 +29,8 @@ type Scheduler struct {
 	UpdateSchedulableAlertRulesDuration prometheus.Histogram
 	Ticker                              *ticker.Metrics
 	EvaluationMissed                    *prometheus.CounterVec
+	ShardedAlertRules                   prometheus.Gauge
+	ShardingMembers                     prometheus.Gauge
 }
 
 func NewSchedulerMetrics(r prometheus.Registerer) *Scheduler {
@@ -163,5 +165,21 @@ func NewSchedulerMetrics(r prometheus.Registerer) *Scheduler {
 			},
 			[]string{"org", "name"},
 		),
+		ShardedAlertRules: promauto.With(r).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "schedule_sharded_alert_rules",
+				Help:      "The number of alert rules assigned to this instance when alert rules are sharded across the HA cluster.",
+			},
+		),
+		ShardingMembers: promauto.With(r).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "schedule_sharding_members",
+				Help:      "The number of members of the HA cluster the alert rules are sharded across.",
+			},
+		),
 	}
 }
@@ -6,9 +6,13 @@ import (
 )
 
 type State struct {
-	StateUpdateDuration   prometheus.Histogram
-	StateFullSyncDuration prometheus.Histogram
-	r                     prometheus.Registerer
+	StateUpdateDuration              prometheus.Histogram
+	StateFullSyncDuration            prometheus.Histogram
+	StateIncrementalSyncDuration     prometheus.Histogram
+	StateIncrementalSyncBatchSize    prometheus.Histogram
+	StateIncrementalSyncLag          prometheus.Gauge
+	StateIncrementalSyncPendingTotal prometheus.Gauge
+	r                                prometheus.Registerer
 }
 
 // Registerer exposes the Prometheus register directly. The state package needs this as, it uses a collector to fetch the current alerts by state in the system.
@@ -37,5 +41,39 @@ func NewStateMetrics(r prometheus.Registerer) *State {
 				Buckets:   []float64{0.01, 0.1, 1, 2, 5, 10, 60},
 			},
 		),
+		StateIncrementalSyncDuration: promauto.With(r).NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "state_incremental_sync_duration_seconds",
+				Help:      "The duration of writing the changed alert instances to the database.",
+				Buckets:   []float64{0.01, 0.1, 1, 2, 5, 10, 60},
+			},
+		),
+		StateIncrementalSyncBatchSize: promauto.With(r).NewHistogram(
+			prometheus.HistogramOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "state_incremental_sync_batch_size",
+				Help:      "The number of alert instances written to or deleted from the database in a single batch.",
+				Buckets:   prometheus.ExponentialBuckets(1, 4, 7),
+			},
+		),
+		StateIncrementalSyncLag: promauto.With(r).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "state_incremental_sync_lag_seconds",
+				Help:      "The age of the oldest change of an alert instance that has not been written to the database yet.",
+			},
+		),
+		StateIncrementalSyncPendingTotal: promauto.With(r).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "state_incremental_sync_pending_total",
+				Help:      "The number of changed alert instances waiting to be written to the database.",
+			},
+		),
 	}
 }
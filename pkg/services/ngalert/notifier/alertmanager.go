@@ -32,7 +32,7 @@ const (
 	SilencesFilename        = "silences"
 
 	workingDir = "alerting"
-	// maintenanceNotificationAndSilences how often should we flush and garbage collect notifications
+	// notificationLogMaintenanceInterval how often should we flush and garbage collect notifications, unless configured otherwise
 	notificationLogMaintenanceInterval = 15 * time.Minute
 )
 
@@ -54,6 +54,7 @@ type alertmanager struct {
 	Settings            *setting.Cfg
 	Store               AlertingStore
 	fileStore           *FileStore
+	pendingAlerts       *pendingAlerts
 	NotificationService notifications.Service
 
 	decryptFn alertingNotify.GetDecryptedValueFn
@@ -93,6 +94,11 @@ func NewAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store A
 	workingPath := filepath.Join(cfg.DataPath, workingDir, strconv.Itoa(int(orgID)))
 	fileStore := NewFileStore(orgID, kvStore, workingPath)
 
+	persistInterval := cfg.UnifiedAlerting.NotificationStatePersistInterval
+	if persistInterval <= 0 {
+		persistInterval = notificationLogMaintenanceInterval
+	}
+
 	nflogFilepath, err := fileStore.FilepathFor(ctx, NotificationLogFilename)
 	if err != nil {
 		return nil, err
@@ -115,7 +121,7 @@ func NewAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store A
 	nflogOptions := maintenanceOptions{
 		filepath:             nflogFilepath,
 		retention:            retentionNotificationsAndSilences,
-		maintenanceFrequency: notificationLogMaintenanceInterval,
+		maintenanceFrequency: persistInterval,
 		maintenanceFunc: func(state alertingNotify.State) (int64, error) {
 			// Detached context here is to make sure that when the service is shut down the persist operation is executed.
 			return fileStore.Persist(context.Background(), NotificationLogFilename, state)
@@ -145,11 +151,13 @@ func NewAlertmanager(ctx context.Context, orgID int64, cfg *setting.Cfg, store A
 		orgID:               orgID,
 		decryptFn:           decryptFn,
 		fileStore:           fileStore,
+		pendingAlerts:       newPendingAlerts(fileStore, persistInterval, l),
 		logger:              l,
 
 		// TODO: Preferably, logic around autogen would be outside of the specific alertmanager implementation so that remote alertmanager will get it for free.
 		withAutogen: withAutogen,
 	}
+	am.pendingAlerts.Run()
 
 	return am, nil
 }
@@ -163,6 +171,12 @@ func (am *alertmanager) Ready() bool {
 
 func (am *alertmanager) StopAndWait() {
 	am.Base.StopAndWait()
+	// The alerts are persisted once the dispatcher has stopped, so the notifications in flight are either in the
+	// notification log or sent again after the restart.
+	// Detached context here is to make sure that when the service is shut down the persist operation is executed.
+	if err := am.pendingAlerts.StopAndPersist(context.Background()); err != nil {
+		am.logger.Error("Failed to persist pending alerts", "error", err)
+	}
 }
 
 // SaveAndApplyDefaultConfig saves the default configuration to the database and applies it to the Alertmanager.
@@ -334,6 +348,8 @@ func (am *alertmanager) applyConfig(cfg *apimodels.PostableUserConfig) (bool, er
 	}
 
 	am.logger.Info("Applying new configuration to Alertmanager", "configHash", fmt.Sprintf("%x", configHash))
+	// Alerts can only be put into the Alertmanager once it's been configured for the first time.
+	firstApply := am.Base.ConfigHash() == [16]byte{}
 	err = am.Base.ApplyConfig(AlertingConfiguration{
 		rawAlertmanagerConfig:    rawConfig,
 		configHash:               configHash,
@@ -350,9 +366,31 @@ func (am *alertmanager) applyConfig(cfg *apimodels.PostableUserConfig) (bool, er
 	}
 
 	am.updateConfigMetrics(cfg)
+	if firstApply {
+		am.restorePendingAlerts()
+	}
 	return true, nil
 }
 
+// restorePendingAlerts puts the alerts persisted by a previous run of the Alertmanager, or by another
+// instance of the HA cluster, back into the Alertmanager so their notifications resume being delivered.
+func (am *alertmanager) restorePendingAlerts() {
+	// Detached context here is to make sure the alerts are restored regardless of the request that applied the configuration.
+	alerts, err := am.pendingAlerts.Load(context.Background())
+	if err != nil {
+		am.logger.Error("Failed to load pending alerts", "error", err)
+		return
+	}
+	if len(alerts) == 0 {
+		return
+	}
+
+	am.logger.Info("Restoring pending alerts", "alerts", len(alerts))
+	if err := am.Base.PutAlerts(alerts); err != nil {
+		am.logger.Error("Failed to restore pending alerts", "error", err)
+	}
+}
+
 // applyAndMarkConfig applies a configuration and marks it as applied if no errors occur.
 func (am *alertmanager) applyAndMarkConfig(ctx context.Context, hash string, cfg *apimodels.PostableUserConfig) error {
 	configChanged, err := am.applyConfig(cfg)
@@ -415,6 +453,7 @@ func (am *alertmanager) PutAlerts(_ context.Context, postableAlerts apimodels.Po
 		})
 	}
 
+	am.pendingAlerts.Add(alerts)
 	return am.Base.PutAlerts(alerts)
 }
 
@@ -111,11 +111,34 @@ func (fileStore *FileStore) Persist(ctx context.Context, filename string, st ale
 		return size, err
 	}
 
-	if err = fileStore.kv.Set(ctx, filename, encode(bytes)); err != nil {
-		return size, err
+	return fileStore.Save(ctx, filename, bytes)
+}
+
+// Get reads a file from the database and returns its decoded content.
+// The boolean return value reports whether the file was found.
+func (fileStore *FileStore) Get(ctx context.Context, filename string) ([]byte, bool, error) {
+	content, exists, err := fileStore.kv.Get(ctx, filename)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading file '%s' from database: %w", filename, err)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+
+	bytes, err := decode(content)
+	if err != nil {
+		return nil, false, fmt.Errorf("error decoding file '%s': %w", filename, err)
+	}
+	return bytes, true, nil
+}
+
+// Save persists the given content to the database as a base64 encoded string.
+func (fileStore *FileStore) Save(ctx context.Context, filename string, content []byte) (int64, error) {
+	if err := fileStore.kv.Set(ctx, filename, encode(content)); err != nil {
+		return 0, err
 	}
 
-	return int64(len(bytes)), err
+	return int64(len(content)), nil
 }
 
 // WriteFileToDisk writes a file with the provided name and contents to the Alertmanager working directory with the default grafana permission.
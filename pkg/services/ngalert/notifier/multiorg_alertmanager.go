@@ -412,6 +412,49 @@ func (moa *MultiOrgAlertmanager) StopAndWait() {
 	}
 }
 
+// ClusterMembership returns the members of the cluster the Alertmanagers use to share their state.
+// It returns nil if high availability is not configured.
+func (moa *MultiOrgAlertmanager) ClusterMembership() *ClusterMembership {
+	switch moa.peer.(type) {
+	case *alertingCluster.Peer, *redisPeer:
+		return &ClusterMembership{peer: moa.peer}
+	default:
+		return nil
+	}
+}
+
+// ClusterMembership provides the names of the members of the HA cluster.
+type ClusterMembership struct {
+	peer alertingNotify.ClusterPeer
+}
+
+// Self returns the name of this instance in the cluster.
+func (c *ClusterMembership) Self() string {
+	switch p := c.peer.(type) {
+	case *alertingCluster.Peer:
+		return p.Name()
+	case *redisPeer:
+		return p.withPrefix(p.name)
+	}
+	return ""
+}
+
+// Members returns the names of the live members of the cluster, including this instance.
+func (c *ClusterMembership) Members() []string {
+	switch p := c.peer.(type) {
+	case *alertingCluster.Peer:
+		peers := p.Peers()
+		members := make([]string, 0, len(peers))
+		for _, peer := range peers {
+			members = append(members, peer.Name())
+		}
+		return members
+	case *redisPeer:
+		return p.Members()
+	}
+	return nil
+}
+
 // AlertmanagerFor returns the Alertmanager instance for the organization provided.
 // When the organization does not have an active Alertmanager, it returns a ErrNoAlertmanagerForOrg.
 // When the Alertmanager of the organization is not ready, it returns a ErrAlertmanagerNotReady.
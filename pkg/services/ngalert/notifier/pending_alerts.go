@@ -0,0 +1,195 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	alertingNotify "github.com/grafana/alerting/notify"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const PendingAlertsFilename = "alerts"
+
+// How long resolved alerts are kept after they've ended, so the resolved notification can still be delivered after a restart.
+var pendingAlertsResolvedRetention = 15 * time.Minute
+
+// pendingAlerts keeps track of the alerts received by the Alertmanager that haven't resolved yet.
+// They are persisted to the database in the background and put back into the Alertmanager when it starts,
+// so that an instance that restarts or takes over resumes delivery of their notifications:
+//   - the restored alerts keep their StartsAt, so the dispatcher flushes the groups whose group_wait has already
+//     elapsed right away instead of starting the wait over.
+//   - the group_interval and repeat_interval of the groups resume from the notification log, which also
+//     prevents the notifications that were already sent from being sent again.
+//   - the notifications in flight when the Alertmanager stops aren't in the notification log, so they're sent
+//     again after the restart.
+type pendingAlerts struct {
+	mtx    sync.Mutex
+	alerts map[model.Fingerprint]*alertingNotify.PostableAlert
+	// changed is set when alerts are received and reset when they are persisted, so unchanged alerts aren't written again.
+	changed bool
+
+	// persistMtx serializes the writes to the database, which are done without holding mtx.
+	persistMtx sync.Mutex
+
+	fileStore       *FileStore
+	persistInterval time.Duration
+	logger          log.Logger
+
+	stopc    chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func newPendingAlerts(fileStore *FileStore, persistInterval time.Duration, logger log.Logger) *pendingAlerts {
+	return &pendingAlerts{
+		alerts:          make(map[model.Fingerprint]*alertingNotify.PostableAlert),
+		fileStore:       fileStore,
+		persistInterval: persistInterval,
+		logger:          logger,
+		stopc:           make(chan struct{}),
+	}
+}
+
+// Add records the alerts, they're persisted by the next run of the background loop.
+func (p *pendingAlerts) Add(alerts alertingNotify.PostableAlerts) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for _, a := range alerts {
+		p.alerts[alertFingerprint(a)] = a
+	}
+	p.changed = true
+}
+
+// Run starts persisting the alerts every persist interval until StopAndPersist is called.
+func (p *pendingAlerts) Run() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.persistInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stopc:
+				return
+			case <-ticker.C:
+				// Detached context here is to make sure the persist operation isn't interrupted halfway.
+				if err := p.Persist(context.Background()); err != nil {
+					p.logger.Error("Failed to persist pending alerts", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// StopAndPersist stops the background loop and persists the alerts a last time. It can be called more than once.
+func (p *pendingAlerts) StopAndPersist(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stopc) })
+	p.wg.Wait()
+	return p.Persist(ctx)
+}
+
+// Persist writes the pending alerts to the database if they changed since the last time.
+func (p *pendingAlerts) Persist(ctx context.Context) error {
+	p.persistMtx.Lock()
+	defer p.persistMtx.Unlock()
+
+	alerts, changed := p.snapshot(time.Now())
+	if !changed {
+		return nil
+	}
+
+	b, err := json.Marshal(alerts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending alerts: %w", err)
+	}
+	size, err := p.fileStore.Save(ctx, PendingAlertsFilename, b)
+	if err != nil {
+		p.mtx.Lock()
+		p.changed = true
+		p.mtx.Unlock()
+		return err
+	}
+
+	p.logger.Debug("Persisted pending alerts", "alerts", len(alerts), "size", size)
+	return nil
+}
+
+// snapshot returns the alerts to persist and whether they changed since the last time, and marks them as persisted.
+func (p *pendingAlerts) snapshot(now time.Time) (alertingNotify.PostableAlerts, bool) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.gc(now) {
+		p.changed = true
+	}
+	if !p.changed {
+		return nil, false
+	}
+	p.changed = false
+
+	alerts := make(alertingNotify.PostableAlerts, 0, len(p.alerts))
+	for _, a := range p.alerts {
+		alerts = append(alerts, a)
+	}
+	return alerts, true
+}
+
+// Load reads the pending alerts from the database and returns the ones that still need to be delivered.
+func (p *pendingAlerts) Load(ctx context.Context) (alertingNotify.PostableAlerts, error) {
+	b, exists, err := p.fileStore.Get(ctx, PendingAlertsFilename)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	var alerts alertingNotify.PostableAlerts
+	if err := json.Unmarshal(b, &alerts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pending alerts: %w", err)
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := time.Now()
+	result := make(alertingNotify.PostableAlerts, 0, len(alerts))
+	for _, a := range alerts {
+		fp := alertFingerprint(a)
+		// Alerts received since the Alertmanager started are more recent than the persisted ones.
+		if _, ok := p.alerts[fp]; ok || isExpired(a, now) {
+			continue
+		}
+		p.alerts[fp] = a
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// gc removes the alerts that have been resolved for longer than the retention period and reports whether any was removed.
+func (p *pendingAlerts) gc(now time.Time) bool {
+	removed := false
+	for fp, a := range p.alerts {
+		if isExpired(a, now) {
+			delete(p.alerts, fp)
+			removed = true
+		}
+	}
+	return removed
+}
+
+func isExpired(a *alertingNotify.PostableAlert, now time.Time) bool {
+	endsAt := time.Time(a.EndsAt)
+	return !endsAt.IsZero() && now.Sub(endsAt) > pendingAlertsResolvedRetention
+}
+
+func alertFingerprint(a *alertingNotify.PostableAlert) model.Fingerprint {
+	ls := make(model.LabelSet, len(a.Labels))
+	for k, v := range a.Labels {
+		ls[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return ls.Fingerprint()
+}
@@ -0,0 +1,116 @@
+package notifier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	alertingNotify "github.com/grafana/alerting/notify"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+)
+
+func TestPendingAlerts(t *testing.T) {
+	newAlert := func(name string, endsAt time.Time) *alertingNotify.PostableAlert {
+		return &alertingNotify.PostableAlert{
+			StartsAt: strfmt.DateTime(endsAt.Add(-time.Hour)),
+			EndsAt:   strfmt.DateTime(endsAt),
+			Alert: amv2.Alert{
+				Labels: amv2.LabelSet{"alertname": name},
+			},
+		}
+	}
+
+	t.Run("alerts are persisted in the background and on stop", func(t *testing.T) {
+		kv := fakes.NewFakeKVStore(t)
+		p := newPendingAlerts(NewFileStore(1, kv, t.TempDir()), 10*time.Millisecond, log.NewNopLogger())
+		p.Run()
+
+		p.Add(alertingNotify.PostableAlerts{newAlert("a", time.Now().Add(time.Minute))})
+		require.Eventually(t, func() bool {
+			_, ok, err := kv.Get(context.Background(), 1, KVNamespace, PendingAlertsFilename)
+			return err == nil && ok
+		}, time.Second, 10*time.Millisecond)
+
+		p.Add(alertingNotify.PostableAlerts{newAlert("b", time.Now().Add(time.Minute))})
+		require.NoError(t, p.StopAndPersist(context.Background()))
+		require.NoError(t, p.StopAndPersist(context.Background()))
+
+		restarted := newPendingAlerts(NewFileStore(1, kv, t.TempDir()), time.Hour, log.NewNopLogger())
+		alerts, err := restarted.Load(context.Background())
+		require.NoError(t, err)
+		require.Len(t, alerts, 2)
+	})
+
+	t.Run("unchanged alerts are not written again", func(t *testing.T) {
+		kv := fakes.NewFakeKVStore(t)
+		p := newPendingAlerts(NewFileStore(1, kv, t.TempDir()), time.Hour, log.NewNopLogger())
+		p.Add(alertingNotify.PostableAlerts{newAlert("a", time.Now().Add(time.Minute))})
+		require.NoError(t, p.Persist(context.Background()))
+
+		require.NoError(t, kv.Del(context.Background(), 1, KVNamespace, PendingAlertsFilename))
+		require.NoError(t, p.Persist(context.Background()))
+		_, ok, err := kv.Get(context.Background(), 1, KVNamespace, PendingAlertsFilename)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("restored alerts keep their start time", func(t *testing.T) {
+		kv := fakes.NewFakeKVStore(t)
+		p := newPendingAlerts(NewFileStore(1, kv, t.TempDir()), time.Hour, log.NewNopLogger())
+		a := newAlert("a", time.Now().Add(time.Minute))
+		p.Add(alertingNotify.PostableAlerts{a})
+		require.NoError(t, p.Persist(context.Background()))
+
+		restarted := newPendingAlerts(NewFileStore(1, kv, t.TempDir()), time.Hour, log.NewNopLogger())
+		alerts, err := restarted.Load(context.Background())
+		require.NoError(t, err)
+		require.Len(t, alerts, 1)
+		require.WithinDuration(t, time.Time(a.StartsAt), time.Time(alerts[0].StartsAt), time.Millisecond)
+	})
+
+	t.Run("persisted alerts are loaded by a new instance", func(t *testing.T) {
+		kv := fakes.NewFakeKVStore(t)
+		p := newPendingAlerts(NewFileStore(1, kv, t.TempDir()), time.Hour, log.NewNopLogger())
+		p.Add(alertingNotify.PostableAlerts{
+			newAlert("firing", time.Now().Add(time.Minute)),
+			newAlert("recently resolved", time.Now().Add(-time.Minute)),
+			newAlert("resolved", time.Now().Add(-time.Hour)),
+		})
+		require.NoError(t, p.Persist(context.Background()))
+
+		restarted := newPendingAlerts(NewFileStore(1, kv, t.TempDir()), time.Hour, log.NewNopLogger())
+		alerts, err := restarted.Load(context.Background())
+		require.NoError(t, err)
+
+		names := make([]string, 0, len(alerts))
+		for _, a := range alerts {
+			names = append(names, a.Labels["alertname"])
+		}
+		require.ElementsMatch(t, []string{"firing", "recently resolved"}, names)
+	})
+
+	t.Run("alerts received after start take precedence over persisted ones", func(t *testing.T) {
+		kv := fakes.NewFakeKVStore(t)
+		p := newPendingAlerts(NewFileStore(1, kv, t.TempDir()), time.Hour, log.NewNopLogger())
+		p.Add(alertingNotify.PostableAlerts{newAlert("a", time.Now().Add(time.Minute))})
+		require.NoError(t, p.Persist(context.Background()))
+
+		restarted := newPendingAlerts(NewFileStore(1, kv, t.TempDir()), time.Hour, log.NewNopLogger())
+		restarted.Add(alertingNotify.PostableAlerts{newAlert("a", time.Now().Add(-time.Minute))})
+		alerts, err := restarted.Load(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, alerts)
+	})
+
+	t.Run("no alerts are loaded when nothing was persisted", func(t *testing.T) {
+		p := newPendingAlerts(NewFileStore(1, fakes.NewFakeKVStore(t), t.TempDir()), time.Hour, log.NewNopLogger())
+		alerts, err := p.Load(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, alerts)
+	})
+}
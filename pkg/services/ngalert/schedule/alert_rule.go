@@ -304,6 +304,12 @@ func (a *alertRule) Run(key ngmodels.AlertRuleKey) error {
 				states := a.stateManager.DeleteStateByRuleUID(ngmodels.WithRuleKey(ctx, key), key, ngmodels.StateReasonRuleDeleted)
 				a.notify(grafanaCtx, key, states)
 			}
+			// the instance the rule was reassigned to takes over its state, it must not be resolved here
+			if errors.Is(grafanaCtx.Err(), errRuleReassigned) {
+				ctx, cancelFunc := context.WithTimeout(context.Background(), time.Minute)
+				defer cancelFunc()
+				a.stateManager.ForgetRule(ngmodels.WithRuleKey(ctx, key), key)
+			}
 			logger.Debug("Stopping alert rule routine")
 			return nil
 		}
@@ -16,6 +16,7 @@ import (
 )
 
 var errRuleDeleted = errors.New("rule deleted")
+var errRuleReassigned = errors.New("rule reassigned to another instance")
 
 type ruleFactory interface {
 	new(context.Context) Rule
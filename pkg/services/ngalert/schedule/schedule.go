@@ -30,6 +30,10 @@ type ScheduleService interface {
 // retryDelay represents how long to wait between each failed rule evaluation.
 const retryDelay = 1 * time.Second
 
+// shardingHandoffTicks is the number of ticks an instance waits before it evaluates an alert rule that was assigned
+// to another instance, when the alert rules are sharded across the HA cluster.
+const shardingHandoffTicks = 3
+
 // AlertsSender is an interface for a service that is responsible for sending notifications to the end-user.
 //
 //go:generate mockery --name AlertsSender --structname AlertsSenderMock --inpackage --filename alerts_sender_mock.go --with-expecter
@@ -87,6 +91,15 @@ type schedule struct {
 	// last evaluated.
 	schedulableAlertRules alertRulesRegistry
 
+	// sharder decides which alert rules are evaluated by this instance when the alert rules are
+	// sharded across the HA cluster. It is nil if every instance evaluates every alert rule.
+	sharder *ruleSharder
+	// unownedRules contains the alert rules that were assigned to other instances in the last tick.
+	unownedRules map[ngmodels.AlertRuleKey]struct{}
+	// acquiredRules contains the tick at which the alert rules assigned to this instance, but not evaluated by it yet,
+	// were assigned to it.
+	acquiredRules map[ngmodels.AlertRuleKey]time.Time
+
 	tracer tracing.Tracer
 }
 
@@ -105,6 +118,9 @@ type SchedulerCfg struct {
 	AlertSender          AlertsSender
 	Tracer               tracing.Tracer
 	Log                  log.Logger
+	// ClusterMembership is used to shard the alert rules across the members of the HA cluster.
+	// If it is nil, all alert rules are evaluated by this instance.
+	ClusterMembership ClusterMembership
 }
 
 // NewScheduler returns a new scheduler.
@@ -132,6 +148,12 @@ func NewScheduler(cfg SchedulerCfg, stateManager *state.Manager) *schedule {
 		schedulableAlertRules: alertRulesRegistry{rules: make(map[ngmodels.AlertRuleKey]*ngmodels.AlertRule)},
 		alertsSender:          cfg.AlertSender,
 		tracer:                cfg.Tracer,
+		unownedRules:          make(map[ngmodels.AlertRuleKey]struct{}),
+		acquiredRules:         make(map[ngmodels.AlertRuleKey]time.Time),
+	}
+
+	if cfg.ClusterMembership != nil {
+		sch.sharder = newRuleSharder(cfg.ClusterMembership)
 	}
 
 	return &sch
@@ -232,6 +254,13 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 
 	sch.updateRulesMetrics(alertRules)
 
+	if sch.sharder != nil && sch.sharder.refresh() {
+		sch.log.Info("Members of the cluster have changed, rebalancing alert rules", "members", len(sch.sharder.members))
+		sch.metrics.ShardingMembers.Set(float64(len(sch.sharder.members)))
+	}
+	unownedRules := make(map[ngmodels.AlertRuleKey]struct{})
+	acquiredRules := make(map[ngmodels.AlertRuleKey]time.Time)
+
 	readyToRun := make([]readyToRunItem, 0)
 	updatedRules := make([]ngmodels.AlertRuleKeyWithVersion, 0, len(updated)) // this is needed for tests only
 	missingFolder := make(map[string][]string)
@@ -252,6 +281,26 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 	)
 	for _, item := range alertRules {
 		key := item.GetKey()
+		if sch.sharder != nil {
+			if !sch.sharder.owns(key) {
+				unownedRules[key] = struct{}{}
+				continue
+			}
+			// The members of the cluster don't see the changes of the cluster at the same time, the instance the
+			// alert rule was assigned to might still evaluate it. The evaluation of the alert rules that were assigned
+			// to another instance in the last tick starts after a delay, so that the previous instance has stopped and
+			// written the state of the alert rule. The other alert rules, e.g. on startup, are evaluated right away.
+			if !sch.registry.exists(key) {
+				acquiredAt, acquired := sch.acquiredRules[key]
+				if _, handedOff := sch.unownedRules[key]; handedOff {
+					acquiredAt, acquired = tick, true
+				}
+				if acquired && tick.Sub(acquiredAt) < shardingHandoffTicks*sch.baseInterval {
+					acquiredRules[key] = acquiredAt
+					continue
+				}
+			}
+		}
 		ruleRoutine, newRoutine := sch.registry.getOrCreate(ctx, key, ruleFactory)
 
 		// enforce minimum evaluation interval
@@ -263,7 +312,13 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 		invalidInterval := item.IntervalSeconds%int64(sch.baseInterval.Seconds()) != 0
 
 		if newRoutine && !invalidInterval {
+			// if the rule might have been evaluated by another instance, its state has to be loaded before the evaluation starts.
+			acquired := sch.sharder != nil
+			rule := item
 			dispatcherGroup.Go(func() error {
+				if acquired {
+					sch.stateManager.WarmRule(ngmodels.WithRuleKey(ctx, key), rule)
+				}
 				return ruleRoutine.Run(key)
 			})
 		}
@@ -343,6 +398,28 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 		})
 	}
 
+	// stop routines of the alert rules that are now evaluated by other instances, they write the state of
+	// the alert rules before they stop. The states of the other alert rules evaluated by other instances are
+	// only removed from the cache.
+	if sch.sharder != nil {
+		for key := range unownedRules {
+			if _, ok := registeredDefinitions[key]; ok {
+				delete(registeredDefinitions, key)
+				if ruleRoutine, ok := sch.registry.del(key); ok {
+					sch.log.Info("Alert rule has been reassigned to another instance", key.LogContext()...)
+					ruleRoutine.Stop(errRuleReassigned)
+				}
+				continue
+			}
+			if _, ok := sch.unownedRules[key]; !ok {
+				sch.stateManager.EvictRule(key)
+			}
+		}
+		sch.unownedRules = unownedRules
+		sch.acquiredRules = acquiredRules
+		sch.metrics.ShardedAlertRules.Set(float64(len(alertRules) - len(unownedRules)))
+	}
+
 	// unregister and stop routines of the deleted alert rules
 	toDelete := make([]ngmodels.AlertRuleKey, 0, len(registeredDefinitions))
 	for key := range registeredDefinitions {
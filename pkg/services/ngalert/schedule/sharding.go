@@ -0,0 +1,103 @@
+package schedule
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strconv"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// tokensPerMember is the number of positions each member takes on the hash ring.
+// More tokens spread the alert rules more evenly across members.
+const tokensPerMember = 128
+
+// ClusterMembership provides the members of the HA cluster the alert rules are sharded across.
+type ClusterMembership interface {
+	// Self returns the name of this instance in the cluster.
+	Self() string
+	// Members returns the names of the live members of the cluster.
+	Members() []string
+}
+
+// ruleSharder assigns each alert rule to exactly one member of the HA cluster using consistent hashing,
+// so adding or removing a member only moves the alert rules of a fraction of the members.
+// All members assign the same rules to the same member as long as they see the same set of members.
+type ruleSharder struct {
+	membership ClusterMembership
+
+	self    string
+	members []string
+	ring    []ringToken
+}
+
+type ringToken struct {
+	hash   uint32
+	member string
+}
+
+func newRuleSharder(membership ClusterMembership) *ruleSharder {
+	return &ruleSharder{membership: membership}
+}
+
+// refresh fetches the members of the cluster and rebuilds the hash ring if they changed.
+// It returns true if the ownership of the alert rules might have changed.
+func (s *ruleSharder) refresh() bool {
+	self := s.membership.Self()
+	members := slices.Clone(s.membership.Members())
+	// This instance is alive even if the cluster does not know about it yet.
+	if !slices.Contains(members, self) {
+		members = append(members, self)
+	}
+	sort.Strings(members)
+
+	if self == s.self && slices.Equal(members, s.members) {
+		return false
+	}
+
+	ring := make([]ringToken, 0, len(members)*tokensPerMember)
+	for _, member := range members {
+		for i := 0; i < tokensPerMember; i++ {
+			ring = append(ring, ringToken{hash: hashString(member + "-" + strconv.Itoa(i)), member: member})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		if ring[i].hash == ring[j].hash {
+			return ring[i].member < ring[j].member
+		}
+		return ring[i].hash < ring[j].hash
+	})
+
+	s.self = self
+	s.members = members
+	s.ring = ring
+	return true
+}
+
+// owner returns the member the alert rule is assigned to.
+func (s *ruleSharder) owner(key ngmodels.AlertRuleKey) string {
+	if len(s.ring) == 0 {
+		return s.self
+	}
+	h := hashString(fmt.Sprintf("%d/%s", key.OrgID, key.UID))
+	i := sort.Search(len(s.ring), func(i int) bool {
+		return s.ring[i].hash >= h
+	})
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].member
+}
+
+// owns returns true if the alert rule is assigned to this instance.
+func (s *ruleSharder) owns(key ngmodels.AlertRuleKey) bool {
+	return s.owner(key) == s.self
+}
+
+func hashString(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32()
+}
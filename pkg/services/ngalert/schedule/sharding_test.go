@@ -0,0 +1,159 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+type fakeClusterMembership struct {
+	self    string
+	members []string
+}
+
+func (f *fakeClusterMembership) Self() string      { return f.self }
+func (f *fakeClusterMembership) Members() []string { return f.members }
+
+func TestRuleSharder(t *testing.T) {
+	keys := make([]ngmodels.AlertRuleKey, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, ngmodels.AlertRuleKey{OrgID: int64(i%3 + 1), UID: fmt.Sprintf("rule-%d", i)})
+	}
+	newSharders := func(members ...string) []*ruleSharder {
+		sharders := make([]*ruleSharder, 0, len(members))
+		for _, m := range members {
+			s := newRuleSharder(&fakeClusterMembership{self: m, members: members})
+			require.True(t, s.refresh())
+			sharders = append(sharders, s)
+		}
+		return sharders
+	}
+
+	t.Run("every alert rule is owned by exactly one member", func(t *testing.T) {
+		sharders := newSharders("a", "b", "c")
+		perMember := make(map[string]int)
+		for _, key := range keys {
+			owners := 0
+			for _, s := range sharders {
+				if s.owns(key) {
+					owners++
+					perMember[s.self]++
+				}
+			}
+			require.Equal(t, 1, owners)
+		}
+		for member, count := range perMember {
+			require.Greaterf(t, count, 200, "member %s owns too few rules", member)
+		}
+	})
+
+	t.Run("a new member takes over only a part of the alert rules", func(t *testing.T) {
+		before := newSharders("a", "b", "c")[0]
+		after := newSharders("a", "b", "c", "d")[0]
+		moved := 0
+		for _, key := range keys {
+			if before.owner(key) != after.owner(key) {
+				require.Equal(t, "d", after.owner(key))
+				moved++
+			}
+		}
+		require.Less(t, moved, len(keys)/2)
+	})
+
+	t.Run("refresh adds self if the cluster does not know about it yet", func(t *testing.T) {
+		membership := &fakeClusterMembership{self: "a", members: []string{"b"}}
+		s := newRuleSharder(membership)
+		require.True(t, s.refresh())
+		require.Equal(t, []string{"a", "b"}, s.members)
+		require.False(t, s.refresh())
+
+		membership.members = []string{"a", "b", "c"}
+		require.True(t, s.refresh())
+	})
+}
+
+func TestProcessTicksRebalance(t *testing.T) {
+	ctx := context.Background()
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	ruleStore := newFakeRulesStore()
+	instanceStore := &state.FakeInstanceStore{}
+	sch := setupScheduler(t, ruleStore, instanceStore, nil, nil, nil)
+	membership := &fakeClusterMembership{self: "a", members: []string{"a"}}
+	sch.sharder = newRuleSharder(membership)
+	evalAppliedCh := make(chan evalAppliedInfo, 1)
+	sch.evalAppliedFunc = func(key ngmodels.AlertRuleKey, now time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: key, now: now}
+	}
+
+	// the alert rule is moved to the member "b" when it joins the cluster
+	after := newRuleSharder(&fakeClusterMembership{self: "a", members: []string{"a", "b"}})
+	require.True(t, after.refresh())
+	var rule *ngmodels.AlertRule
+	for rule == nil || after.owner(rule.GetKey()) != "b" {
+		rule = ngmodels.AlertRuleGen(ngmodels.WithOrgID(1), ngmodels.WithInterval(sch.baseInterval), withQueryForState(t, eval.Alerting))()
+	}
+	ruleStore.PutRule(ctx, rule)
+
+	countOps := func(match func(op any) bool) int {
+		count := 0
+		for _, op := range instanceStore.RecordedOps() {
+			if match(op) {
+				count++
+			}
+		}
+		return count
+	}
+	isList := func(op any) bool {
+		q, ok := op.(ngmodels.ListAlertInstancesQuery)
+		return ok && q.RuleUID == rule.UID
+	}
+
+	tick := time.Time{}
+	t.Run("alert rule should be evaluated right away on startup with its state loaded", func(t *testing.T) {
+		tick = tick.Add(sch.baseInterval)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 1)
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+		require.Equal(t, 1, countOps(isList))
+		require.NotEmpty(t, sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
+	})
+
+	t.Run("reassigned alert rule should be stopped and its state saved", func(t *testing.T) {
+		membership.members = []string{"a", "b"}
+		tick = tick.Add(sch.baseInterval)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Empty(t, scheduled)
+		require.False(t, sch.registry.exists(rule.GetKey()))
+		require.Eventually(t, func() bool {
+			return len(sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID)) == 0
+		}, time.Second, 10*time.Millisecond)
+		ops := instanceStore.RecordedOps()
+		last, ok := ops[len(ops)-1].(state.FakeInstanceStoreOp)
+		require.True(t, ok)
+		require.Equal(t, "SaveAlertInstances", last.Name)
+		require.NotEmpty(t, last.Args[1])
+	})
+
+	t.Run("alert rule assigned back should wait for the handoff delay", func(t *testing.T) {
+		membership.members = []string{"a"}
+		for i := 0; i < shardingHandoffTicks; i++ {
+			tick = tick.Add(sch.baseInterval)
+			scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+			require.Empty(t, scheduled)
+		}
+		tick = tick.Add(sch.baseInterval)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 1)
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+		require.Equal(t, 2, countOps(isList))
+	})
+}
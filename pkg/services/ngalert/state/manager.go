@@ -181,33 +181,8 @@ func (st *Manager) Warm(ctx context.Context, rulesReader RuleReader) {
 				orgStates[entry.RuleUID] = rulesStates
 			}
 
-			lbs := map[string]string(entry.Labels)
-			cacheID, err := entry.Labels.StringKey()
-			if err != nil {
-				st.log.Error("Error getting cacheId for entry", "error", err)
-			}
-			var resultFp data.Fingerprint
-			if entry.ResultFingerprint != "" {
-				fp, err := strconv.ParseUint(entry.ResultFingerprint, 16, 64)
-				if err != nil {
-					st.log.Error("Failed to parse result fingerprint of alert instance", "error", err, "ruleUID", entry.RuleUID)
-				}
-				resultFp = data.Fingerprint(fp)
-			}
-			rulesStates.states[cacheID] = &State{
-				AlertRuleUID:         entry.RuleUID,
-				OrgID:                entry.RuleOrgID,
-				CacheID:              cacheID,
-				Labels:               lbs,
-				State:                translateInstanceState(entry.CurrentState),
-				StateReason:          entry.CurrentReason,
-				LastEvaluationString: "",
-				StartsAt:             entry.CurrentStateSince,
-				EndsAt:               entry.CurrentStateEnd,
-				LastEvaluationTime:   entry.LastEvalTime,
-				Annotations:          ruleForEntry.Annotations,
-				ResultFingerprint:    resultFp,
-			}
+			s := st.stateFromInstance(entry, ruleForEntry)
+			rulesStates.states[s.CacheID] = s
 			statesCount++
 		}
 	}
@@ -215,6 +190,105 @@ func (st *Manager) Warm(ctx context.Context, rulesReader RuleReader) {
 	st.log.Info("State cache has been initialized", "states", statesCount, "duration", time.Since(startTime))
 }
 
+// WarmRule replaces the states of the alert rule in the cache with the ones persisted in the database.
+// It is used when the evaluation of the alert rule is taken over from another instance of the HA cluster.
+func (st *Manager) WarmRule(ctx context.Context, rule *ngModels.AlertRule) {
+	if st.instanceStore == nil {
+		return
+	}
+	logger := st.log.FromContext(ctx).New(rule.GetKey().LogContext()...)
+
+	alertInstances, err := st.instanceStore.ListAlertInstances(ctx, &ngModels.ListAlertInstancesQuery{
+		RuleOrgID: rule.OrgID,
+		RuleUID:   rule.UID,
+	})
+	if err != nil {
+		logger.Error("Unable to fetch previous state of the rule", "error", err)
+		return
+	}
+
+	st.cache.removeByRuleUID(rule.OrgID, rule.UID)
+	for _, entry := range alertInstances {
+		st.cache.set(st.stateFromInstance(entry, rule))
+	}
+	logger.Debug("State of the rule has been loaded", "states", len(alertInstances))
+}
+
+// ForgetRule writes the states of the alert rule to the database and removes them from the cache without resolving them.
+// It is used when the evaluation of the alert rule is handed over to another instance of the HA cluster, which loads
+// the written states with WarmRule. The states are removed from the cache even if they fail to be written, since this
+// instance must not evaluate the alert rule anymore.
+func (st *Manager) ForgetRule(ctx context.Context, key ngModels.AlertRuleKey) {
+	logger := st.log.FromContext(ctx).New(key.LogContext()...)
+	// The changes not written yet are older than the states written here, and must not overwrite the ones written
+	// by the instance that takes over the alert rule.
+	if f, ok := st.persister.(ruleForgetter); ok {
+		f.forgetRule(key)
+	}
+
+	if st.instanceStore != nil {
+		states := st.cache.getStatesForRuleUID(key.OrgID, key.UID, st.doNotSaveNormalState)
+		instances := make([]ngModels.AlertInstance, 0, len(states))
+		for _, s := range states {
+			instance, err := s.toAlertInstance()
+			if err != nil {
+				logger.Error("Failed to create a key for alert state to save it to database. The state will be ignored ", "cacheID", s.CacheID, "error", err, "labels", s.Labels.String())
+				continue
+			}
+			instances = append(instances, instance)
+		}
+		if err := st.instanceStore.SaveAlertInstances(ctx, instances...); err != nil {
+			logger.Error("Failed to save the state of the rule before handing it over", "error", err)
+		} else {
+			logger.Debug("State of the rule has been saved before handing it over", "states", len(instances))
+		}
+	}
+	st.cache.removeByRuleUID(key.OrgID, key.UID)
+}
+
+// EvictRule removes the states of the alert rule from the cache without writing them to the database.
+// It is used for the alert rules evaluated by other instances of the HA cluster, whose states in the cache are
+// the ones loaded when the cache was warmed up and become stale.
+func (st *Manager) EvictRule(key ngModels.AlertRuleKey) {
+	st.cache.removeByRuleUID(key.OrgID, key.UID)
+}
+
+// ruleForgetter is implemented by the state persisters that write the changes of the states later,
+// which have to drop the changes of the alert rules handed over to other instances.
+type ruleForgetter interface {
+	forgetRule(key ngModels.AlertRuleKey)
+}
+
+func (st *Manager) stateFromInstance(entry *ngModels.AlertInstance, rule *ngModels.AlertRule) *State {
+	lbs := map[string]string(entry.Labels)
+	cacheID, err := entry.Labels.StringKey()
+	if err != nil {
+		st.log.Error("Error getting cacheId for entry", "error", err)
+	}
+	var resultFp data.Fingerprint
+	if entry.ResultFingerprint != "" {
+		fp, err := strconv.ParseUint(entry.ResultFingerprint, 16, 64)
+		if err != nil {
+			st.log.Error("Failed to parse result fingerprint of alert instance", "error", err, "ruleUID", entry.RuleUID)
+		}
+		resultFp = data.Fingerprint(fp)
+	}
+	return &State{
+		AlertRuleUID:         entry.RuleUID,
+		OrgID:                entry.RuleOrgID,
+		CacheID:              cacheID,
+		Labels:               lbs,
+		State:                translateInstanceState(entry.CurrentState),
+		StateReason:          entry.CurrentReason,
+		LastEvaluationString: "",
+		StartsAt:             entry.CurrentStateSince,
+		EndsAt:               entry.CurrentStateEnd,
+		LastEvaluationTime:   entry.LastEvalTime,
+		Annotations:          rule.Annotations,
+		ResultFingerprint:    resultFp,
+	}
+}
+
 func (st *Manager) Get(orgID int64, alertRuleUID, stateId string) *State {
 	return st.cache.get(orgID, alertRuleUID, stateId)
 }
@@ -2139,3 +2139,59 @@ func mergeLabels(a, b data.Labels) data.Labels {
 	}
 	return result
 }
+
+func TestForgetAndEvictRule(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+
+	store := &state.FakeInstanceStore{}
+	cfg := state.ManagerCfg{
+		Metrics:       metrics.NewNGAlert(prometheus.NewPedanticRegistry()).GetStateMetrics(),
+		InstanceStore: store,
+		Images:        &state.NoopImageService{},
+		Clock:         clk,
+		Historian:     &state.FakeHistorian{},
+		Tracer:        tracing.InitializeTracerForTest(),
+		Log:           log.New("ngalert.state.manager"),
+	}
+	st := state.NewManager(cfg, state.NewNoopPersister())
+
+	results := eval.Results{
+		eval.ResultGen(eval.WithState(eval.Alerting), eval.WithEvaluatedAt(clk.Now()))(),
+		eval.ResultGen(eval.WithState(eval.Alerting), eval.WithEvaluatedAt(clk.Now()))(),
+	}
+
+	t.Run("ForgetRule should save the states and remove them from the cache", func(t *testing.T) {
+		rule := models.AlertRuleGen(models.WithFor(0))()
+		st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil)
+		require.Len(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID), 2)
+
+		st.ForgetRule(ctx, rule.GetKey())
+
+		require.Empty(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID))
+		ops := store.RecordedOps()
+		require.Len(t, ops, 1)
+		op, ok := ops[0].(state.FakeInstanceStoreOp)
+		require.True(t, ok)
+		require.Equal(t, "SaveAlertInstances", op.Name)
+		instances := op.Args[1].([]models.AlertInstance)
+		require.Len(t, instances, 2)
+		for _, instance := range instances {
+			require.Equal(t, rule.UID, instance.RuleUID)
+			require.Equal(t, models.InstanceStateFiring, instance.CurrentState)
+		}
+	})
+
+	t.Run("EvictRule should remove the states from the cache without saving them", func(t *testing.T) {
+		rule := models.AlertRuleGen(models.WithFor(0))()
+		st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil)
+		require.Len(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID), 2)
+		opsBefore := len(store.RecordedOps())
+
+		st.EvictRule(rule.GetKey())
+
+		require.Empty(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID))
+		require.Len(t, store.RecordedOps(), opsBefore)
+	})
+}
@@ -0,0 +1,297 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	defaultIncrementalSaveBatchSize = 200
+	// incrementalRefreshTicks is the number of ticks after which the alert instances that didn't change state are
+	// written again, so that their last evaluation time and result fingerprint aren't too old after a restart.
+	incrementalRefreshTicks = 6
+)
+
+// IncrementalStatePersister records the alert instances that changed state during rule evaluation
+// and writes them to the database in batches on every tick, instead of writing every instance on
+// each evaluation or rewriting all instances periodically.
+//
+// Changes are compacted before they are written: only the last change of each alert instance is kept.
+// Pending changes are written when the scheduler shuts down, so the state loaded by Manager.Warm on the
+// next start is the same as the one that was in memory.
+//
+// The alert instances that didn't change state are written again too, but less often:
+//   - the firing instances are written before the end time in the database lapses, so they don't resolve when
+//     they are loaded after a restart.
+//   - the other instances are written once every few ticks, to update their last evaluation time and result fingerprint.
+type IncrementalStatePersister struct {
+	log   log.Logger
+	store InstanceStore
+	// doNotSaveNormalState controls whether eval.Normal state is persisted to the database and returned by get methods.
+	doNotSaveNormalState bool
+	batchSize            int
+	ticker               *clock.Ticker
+	interval             time.Duration
+	clock                clock.Clock
+	metrics              *metrics.State
+
+	mtx sync.Mutex
+	// pending contains the last change of each alert instance that hasn't been written yet.
+	pending map[ngModels.AlertInstanceKey]instanceChange
+	// written contains what was last recorded for each alert instance, to decide when the unchanged ones are written again.
+	written map[ngModels.AlertInstanceKey]writtenInstance
+}
+
+type writtenInstance struct {
+	at     time.Time
+	endsAt time.Time
+}
+
+// instanceChange is a change of an alert instance to write to the database. A nil instance means that the
+// alert instance has to be deleted.
+type instanceChange struct {
+	instance *ngModels.AlertInstance
+	at       time.Time
+}
+
+func NewIncrementalStatePersister(log log.Logger, ticker *clock.Ticker, interval time.Duration, batchSize int, cfg ManagerCfg) StatePersister {
+	if batchSize <= 0 {
+		batchSize = defaultIncrementalSaveBatchSize
+	}
+	clk := cfg.Clock
+	if clk == nil {
+		clk = clock.New()
+	}
+	return &IncrementalStatePersister{
+		log:                  log,
+		store:                cfg.InstanceStore,
+		doNotSaveNormalState: cfg.DoNotSaveNormalState,
+		batchSize:            batchSize,
+		ticker:               ticker,
+		interval:             interval,
+		clock:                clk,
+		metrics:              cfg.Metrics,
+		pending:              make(map[ngModels.AlertInstanceKey]instanceChange),
+		written:              make(map[ngModels.AlertInstanceKey]writtenInstance),
+	}
+}
+
+func (p *IncrementalStatePersister) Async(ctx context.Context, _ *cache) {
+	for {
+		select {
+		case <-p.ticker.C:
+			p.flush(ctx)
+		case <-ctx.Done():
+			p.log.Info("Scheduler is shutting down, writing pending state changes.")
+			p.flush(context.Background())
+			p.ticker.Stop()
+			p.log.Info("State incremental worker is shut down.")
+			return
+		}
+	}
+}
+
+// Sync records the changed and stale alert instances, and the unchanged ones that need to be written again.
+// They are written to the database on the next tick.
+func (p *IncrementalStatePersister) Sync(_ context.Context, span trace.Span, states, staleStates []StateTransition) {
+	now := p.clock.Now()
+	recorded := 0
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for _, s := range staleStates {
+		key, err := s.GetAlertInstanceKey()
+		if err != nil {
+			p.log.Error("Failed to delete alert instance with invalid labels", "cacheID", s.CacheID, "error", err)
+			continue
+		}
+		p.record(key, instanceChange{at: now})
+		delete(p.written, key)
+		recorded++
+	}
+
+	for _, s := range states {
+		key, err := s.GetAlertInstanceKey()
+		if err != nil {
+			p.log.Error("Failed to create a key for alert state to save it to database. The state will be ignored ", "cacheID", s.CacheID, "error", err, "labels", s.Labels.String())
+			continue
+		}
+		if p.doNotSaveNormalState && IsNormalStateWithNoReason(s.State) {
+			if s.Changed() {
+				p.record(key, instanceChange{at: now})
+				delete(p.written, key)
+				recorded++
+			}
+			continue
+		}
+		if !s.Changed() && !p.needsRefresh(key, s.State, now) {
+			continue
+		}
+		p.record(key, instanceChange{
+			instance: &ngModels.AlertInstance{
+				AlertInstanceKey:  key,
+				Labels:            ngModels.InstanceLabels(s.Labels),
+				CurrentState:      ngModels.InstanceStateType(s.State.State.String()),
+				CurrentReason:     s.StateReason,
+				LastEvalTime:      s.LastEvaluationTime,
+				CurrentStateSince: s.StartsAt,
+				CurrentStateEnd:   s.EndsAt,
+				ResultFingerprint: s.ResultFingerprint.String(),
+			},
+			at: now,
+		})
+		p.written[key] = writtenInstance{at: now, endsAt: s.EndsAt}
+		recorded++
+	}
+
+	if p.metrics != nil {
+		p.metrics.StateIncrementalSyncPendingTotal.Set(float64(len(p.pending)))
+	}
+	if span != nil && recorded > 0 {
+		span.AddEvent("recorded state changes", trace.WithAttributes(
+			attribute.Int64("state_changes", int64(recorded)),
+		))
+	}
+}
+
+// forgetRule drops the changes of the alert rule that haven't been written yet.
+func (p *IncrementalStatePersister) forgetRule(key ngModels.AlertRuleKey) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for k := range p.pending {
+		if k.RuleOrgID == key.OrgID && k.RuleUID == key.UID {
+			delete(p.pending, k)
+		}
+	}
+	for k := range p.written {
+		if k.RuleOrgID == key.OrgID && k.RuleUID == key.UID {
+			delete(p.written, k)
+		}
+	}
+}
+
+// needsRefresh reports whether an alert instance that didn't change state has to be written again.
+func (p *IncrementalStatePersister) needsRefresh(key ngModels.AlertInstanceKey, s *State, now time.Time) bool {
+	w, ok := p.written[key]
+	if s.State == eval.Alerting {
+		// The end time is written again before it lapses, with a margin of a tick for the write to happen.
+		return !ok || w.endsAt.Before(now.Add(2*p.interval))
+	}
+	if !ok {
+		// The instance was loaded from the database when the state was warmed up.
+		p.written[key] = writtenInstance{at: now, endsAt: s.EndsAt}
+		return false
+	}
+	return now.Sub(w.at) >= incrementalRefreshTicks*p.interval
+}
+
+// record stores the change of an alert instance, replacing any previous change that hasn't been written yet.
+// It keeps the time of the previous change so the lag is measured from the first unwritten change.
+func (p *IncrementalStatePersister) record(key ngModels.AlertInstanceKey, change instanceChange) {
+	if prev, ok := p.pending[key]; ok {
+		change.at = prev.at
+	}
+	p.pending[key] = change
+}
+
+// flush writes the pending changes to the database in batches.
+// Changes that fail to be written are kept, unless the alert instance changed again in the meantime.
+func (p *IncrementalStatePersister) flush(ctx context.Context) {
+	p.mtx.Lock()
+	pending := p.pending
+	p.pending = make(map[ngModels.AlertInstanceKey]instanceChange)
+	p.mtx.Unlock()
+
+	if p.metrics != nil {
+		p.metrics.StateIncrementalSyncLag.Set(p.lag(pending).Seconds())
+	}
+	if p.store == nil || len(pending) == 0 {
+		return
+	}
+
+	startTime := p.clock.Now()
+	p.log.Debug("Incremental state sync start", "changes", len(pending))
+
+	toSave := make([]ngModels.AlertInstance, 0, len(pending))
+	toDelete := make([]ngModels.AlertInstanceKey, 0)
+	for key, change := range pending {
+		if change.instance == nil {
+			toDelete = append(toDelete, key)
+			continue
+		}
+		toSave = append(toSave, *change.instance)
+	}
+
+	failed := make(map[ngModels.AlertInstanceKey]instanceChange)
+	for start := 0; start < len(toDelete); start += p.batchSize {
+		batch := toDelete[start:min(start+p.batchSize, len(toDelete))]
+		if err := p.store.DeleteAlertInstances(ctx, batch...); err != nil {
+			p.log.Error("Failed to delete stale states", "count", len(batch), "error", err)
+			for _, key := range batch {
+				failed[key] = pending[key]
+			}
+			continue
+		}
+		p.observeBatch(len(batch))
+	}
+	for start := 0; start < len(toSave); start += p.batchSize {
+		batch := toSave[start:min(start+p.batchSize, len(toSave))]
+		if err := p.store.SaveAlertInstances(ctx, batch...); err != nil {
+			p.log.Error("Failed to save alert states", "count", len(batch), "error", err)
+			for _, instance := range batch {
+				failed[instance.AlertInstanceKey] = pending[instance.AlertInstanceKey]
+			}
+			continue
+		}
+		p.observeBatch(len(batch))
+	}
+
+	p.mtx.Lock()
+	for key, change := range failed {
+		// The alert instance changed again since the flush started, the newer change takes precedence.
+		if newer, ok := p.pending[key]; ok {
+			newer.at = change.at
+			p.pending[key] = newer
+			continue
+		}
+		p.pending[key] = change
+	}
+	remaining := len(p.pending)
+	p.mtx.Unlock()
+
+	p.log.Debug("Incremental state sync done", "duration", p.clock.Since(startTime), "saved", len(toSave), "deleted", len(toDelete), "failed", len(failed))
+	if p.metrics != nil {
+		p.metrics.StateIncrementalSyncDuration.Observe(p.clock.Since(startTime).Seconds())
+		p.metrics.StateIncrementalSyncPendingTotal.Set(float64(remaining))
+	}
+}
+
+// lag returns how long ago the oldest of the changes happened.
+func (p *IncrementalStatePersister) lag(changes map[ngModels.AlertInstanceKey]instanceChange) time.Duration {
+	var oldest time.Time
+	for _, change := range changes {
+		if oldest.IsZero() || change.at.Before(oldest) {
+			oldest = change.at
+		}
+	}
+	if oldest.IsZero() {
+		return 0
+	}
+	return p.clock.Since(oldest)
+}
+
+func (p *IncrementalStatePersister) observeBatch(size int) {
+	if p.metrics != nil {
+		p.metrics.StateIncrementalSyncBatchSize.Observe(float64(size))
+	}
+}
@@ -0,0 +1,210 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestIncrementalStatePersister(t *testing.T) {
+	transition := func(uid string, from, to eval.State) StateTransition {
+		return StateTransition{
+			State: &State{
+				OrgID:        1,
+				AlertRuleUID: uid,
+				State:        to,
+				Labels:       data.Labels{"rule": uid},
+			},
+			PreviousState: from,
+		}
+	}
+	savedInstances := func(store *FakeInstanceStore) []ngmodels.AlertInstance {
+		var result []ngmodels.AlertInstance
+		for _, op := range store.RecordedOps() {
+			if op, ok := op.(FakeInstanceStoreOp); ok && op.Name == "SaveAlertInstances" {
+				result = append(result, op.Args[1].([]ngmodels.AlertInstance)...)
+			}
+		}
+		return result
+	}
+	deletedKeys := func(store *FakeInstanceStore) []ngmodels.AlertInstanceKey {
+		var result []ngmodels.AlertInstanceKey
+		for _, op := range store.RecordedOps() {
+			if op, ok := op.(FakeInstanceStoreOp); ok && op.Name == "DeleteAlertInstances" {
+				result = append(result, op.Args[1].([]ngmodels.AlertInstanceKey)...)
+			}
+		}
+		return result
+	}
+
+	t.Run("should write only changed instances on tick", func(t *testing.T) {
+		mockClock := clock.NewMock()
+		store := &FakeInstanceStore{}
+		persister := NewIncrementalStatePersister(log.NewNopLogger(), mockClock.Ticker(time.Second), time.Second, 10, ManagerCfg{
+			InstanceStore: store,
+			Clock:         mockClock,
+		})
+
+		persister.Sync(context.Background(), nil, []StateTransition{
+			transition("changed", eval.Normal, eval.Alerting),
+			transition("unchanged", eval.Normal, eval.Normal),
+		}, []StateTransition{
+			transition("stale", eval.Alerting, eval.Normal),
+		})
+		require.Empty(t, store.RecordedOps())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go persister.Async(ctx, newCache())
+		mockClock.Add(time.Second)
+
+		require.Eventually(t, func() bool {
+			return len(savedInstances(store)) == 1 && len(deletedKeys(store)) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		require.Equal(t, "changed", savedInstances(store)[0].RuleUID)
+		require.Equal(t, "stale", deletedKeys(store)[0].RuleUID)
+	})
+
+	t.Run("should keep only the last change of an instance", func(t *testing.T) {
+		mockClock := clock.NewMock()
+		store := &FakeInstanceStore{}
+		persister := NewIncrementalStatePersister(log.NewNopLogger(), mockClock.Ticker(time.Second), time.Second, 10, ManagerCfg{
+			InstanceStore: store,
+			Clock:         mockClock,
+		}).(*IncrementalStatePersister)
+
+		persister.Sync(context.Background(), nil, []StateTransition{transition("rule", eval.Normal, eval.Pending)}, nil)
+		persister.Sync(context.Background(), nil, []StateTransition{transition("rule", eval.Pending, eval.Alerting)}, nil)
+		persister.flush(context.Background())
+
+		saved := savedInstances(store)
+		require.Len(t, saved, 1)
+		require.Equal(t, ngmodels.InstanceStateFiring, saved[0].CurrentState)
+
+		persister.Sync(context.Background(), nil, []StateTransition{transition("rule", eval.Alerting, eval.Normal)}, nil)
+		persister.Sync(context.Background(), nil, nil, []StateTransition{transition("rule", eval.Normal, eval.Normal)})
+		persister.flush(context.Background())
+
+		require.Len(t, savedInstances(store), 1)
+		require.Len(t, deletedKeys(store), 1)
+	})
+
+	t.Run("should write firing instances again before their end time lapses", func(t *testing.T) {
+		mockClock := clock.NewMock()
+		store := &FakeInstanceStore{}
+		persister := NewIncrementalStatePersister(log.NewNopLogger(), mockClock.Ticker(time.Second), time.Second, 10, ManagerCfg{
+			InstanceStore: store,
+			Clock:         mockClock,
+		}).(*IncrementalStatePersister)
+		firing := func(from eval.State, endsAt time.Time) StateTransition {
+			tr := transition("rule", from, eval.Alerting)
+			tr.LastEvaluationTime = mockClock.Now()
+			tr.EndsAt = endsAt
+			return tr
+		}
+
+		persister.Sync(context.Background(), nil, []StateTransition{firing(eval.Normal, mockClock.Now().Add(10*time.Second))}, nil)
+		persister.flush(context.Background())
+		require.Len(t, savedInstances(store), 1)
+
+		mockClock.Add(time.Second)
+		persister.Sync(context.Background(), nil, []StateTransition{firing(eval.Alerting, mockClock.Now().Add(10*time.Second))}, nil)
+		persister.flush(context.Background())
+		require.Len(t, savedInstances(store), 1)
+
+		mockClock.Add(8 * time.Second)
+		persister.Sync(context.Background(), nil, []StateTransition{firing(eval.Alerting, mockClock.Now().Add(10*time.Second))}, nil)
+		persister.flush(context.Background())
+		saved := savedInstances(store)
+		require.Len(t, saved, 2)
+		require.Equal(t, mockClock.Now().Add(10*time.Second), saved[1].CurrentStateEnd)
+		require.Equal(t, mockClock.Now(), saved[1].LastEvalTime)
+	})
+
+	t.Run("should refresh the unchanged instances every few ticks", func(t *testing.T) {
+		mockClock := clock.NewMock()
+		store := &FakeInstanceStore{}
+		persister := NewIncrementalStatePersister(log.NewNopLogger(), mockClock.Ticker(time.Second), time.Second, 10, ManagerCfg{
+			InstanceStore: store,
+			Clock:         mockClock,
+		}).(*IncrementalStatePersister)
+		normal := func() StateTransition {
+			tr := transition("rule", eval.Normal, eval.Normal)
+			tr.LastEvaluationTime = mockClock.Now()
+			tr.ResultFingerprint = data.Fingerprint(mockClock.Now().Unix())
+			return tr
+		}
+
+		for i := 0; i < incrementalRefreshTicks; i++ {
+			persister.Sync(context.Background(), nil, []StateTransition{normal()}, nil)
+			persister.flush(context.Background())
+			mockClock.Add(time.Second)
+		}
+		require.Empty(t, savedInstances(store))
+
+		persister.Sync(context.Background(), nil, []StateTransition{normal()}, nil)
+		persister.flush(context.Background())
+		saved := savedInstances(store)
+		require.Len(t, saved, 1)
+		require.Equal(t, mockClock.Now(), saved[0].LastEvalTime)
+		require.Equal(t, data.Fingerprint(mockClock.Now().Unix()).String(), saved[0].ResultFingerprint)
+	})
+
+	t.Run("should split changes in batches", func(t *testing.T) {
+		store := &FakeInstanceStore{}
+		persister := NewIncrementalStatePersister(log.NewNopLogger(), clock.NewMock().Ticker(time.Second), time.Second, 2, ManagerCfg{
+			InstanceStore: store,
+		}).(*IncrementalStatePersister)
+
+		persister.Sync(context.Background(), nil, []StateTransition{
+			transition("1", eval.Normal, eval.Alerting),
+			transition("2", eval.Normal, eval.Alerting),
+			transition("3", eval.Normal, eval.Alerting),
+			transition("4", eval.Normal, eval.Alerting),
+			transition("5", eval.Normal, eval.Alerting),
+		}, nil)
+		persister.flush(context.Background())
+
+		require.Len(t, store.RecordedOps(), 3)
+		require.Len(t, savedInstances(store), 5)
+	})
+
+	t.Run("should delete instances that transition to Normal if doNotSaveNormalState is true", func(t *testing.T) {
+		store := &FakeInstanceStore{}
+		persister := NewIncrementalStatePersister(log.NewNopLogger(), clock.NewMock().Ticker(time.Second), time.Second, 10, ManagerCfg{
+			InstanceStore:        store,
+			DoNotSaveNormalState: true,
+		}).(*IncrementalStatePersister)
+
+		persister.Sync(context.Background(), nil, []StateTransition{transition("rule", eval.Alerting, eval.Normal)}, nil)
+		persister.flush(context.Background())
+
+		require.Empty(t, savedInstances(store))
+		require.Len(t, deletedKeys(store), 1)
+	})
+
+	t.Run("should write pending changes on context done", func(t *testing.T) {
+		store := &FakeInstanceStore{}
+		persister := NewIncrementalStatePersister(log.NewNopLogger(), clock.NewMock().Ticker(time.Second), time.Second, 10, ManagerCfg{
+			InstanceStore: store,
+		})
+
+		persister.Sync(context.Background(), nil, []StateTransition{transition("rule", eval.Normal, eval.Alerting)}, nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go persister.Async(ctx, newCache())
+		cancel()
+
+		require.Eventually(t, func() bool {
+			return len(savedInstances(store)) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -89,6 +89,24 @@ func (a *State) GetAlertInstanceKey() (models.AlertInstanceKey, error) {
 	return models.AlertInstanceKey{RuleOrgID: a.OrgID, RuleUID: a.AlertRuleUID, LabelsHash: labelsHash}, nil
 }
 
+// toAlertInstance returns the alert instance that persists the state.
+func (a *State) toAlertInstance() (models.AlertInstance, error) {
+	key, err := a.GetAlertInstanceKey()
+	if err != nil {
+		return models.AlertInstance{}, err
+	}
+	return models.AlertInstance{
+		AlertInstanceKey:  key,
+		Labels:            models.InstanceLabels(a.Labels),
+		CurrentState:      models.InstanceStateType(a.State.String()),
+		CurrentReason:     a.StateReason,
+		LastEvalTime:      a.LastEvaluationTime,
+		CurrentStateSince: a.StartsAt,
+		CurrentStateEnd:   a.EndsAt,
+		ResultFingerprint: a.ResultFingerprint.String(),
+	}, nil
+}
+
 // SetAlerting sets the state to Alerting. It changes both the start and end time.
 func (a *State) SetAlerting(reason string, startsAt, endsAt time.Time) {
 	a.State = eval.Alerting
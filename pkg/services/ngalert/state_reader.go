@@ -0,0 +1,118 @@
+package ngalert
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/folder"
+	ac "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+var errAlertingDisabled = errors.New("unified alerting is disabled")
+
+// StateReader gives read access to the alert states and the state history of unified alerting. It does not depend
+// on AlertNG, so it can be used by the services AlertNG depends on, like the Grafana data source, and it is
+// connected to AlertNG once unified alerting is initialized.
+type StateReader struct {
+	mtx sync.RWMutex
+	ng  *AlertNG
+}
+
+func ProvideStateReader() *StateReader {
+	return &StateReader{}
+}
+
+func (r *StateReader) setAlertNG(ng *AlertNG) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.ng = ng
+}
+
+func (r *StateReader) alertNG() (*AlertNG, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	if r.ng == nil {
+		return nil, errAlertingDisabled
+	}
+	return r.ng, nil
+}
+
+// GetAlertStates returns the current state of the alert instances of the org of the user. Like the ruler API, it
+// only returns the instances of the rules in the folders the user can read and whose queries the user can run.
+func (r *StateReader) GetAlertStates(ctx context.Context, user identity.Requester) ([]*state.State, error) {
+	ng, err := r.alertNG()
+	if err != nil {
+		return nil, err
+	}
+	return readableStates(ctx, user, ng.store, ac.NewRuleService(ng.accesscontrol), ng.stateManager.GetAll(user.GetOrgID()))
+}
+
+type visibleRuleStore interface {
+	GetUserVisibleNamespaces(ctx context.Context, orgID int64, user identity.Requester) (map[string]*folder.Folder, error)
+	ListAlertRules(ctx context.Context, query *models.ListAlertRulesQuery) (models.RulesGroup, error)
+}
+
+// readableStates returns the states of the rules the user can read.
+func readableStates(ctx context.Context, user identity.Requester, ruleStore visibleRuleStore, authz *ac.RuleService, states []*state.State) ([]*state.State, error) {
+	namespaceMap, err := ruleStore.GetUserVisibleNamespaces(ctx, user.GetOrgID(), user)
+	if err != nil {
+		return nil, err
+	}
+	if len(namespaceMap) == 0 {
+		return nil, nil
+	}
+	namespaceUIDs := make([]string, 0, len(namespaceMap))
+	for k := range namespaceMap {
+		namespaceUIDs = append(namespaceUIDs, k)
+	}
+
+	rules, err := ruleStore.ListAlertRules(ctx, &models.ListAlertRulesQuery{
+		OrgID:         user.GetOrgID(),
+		NamespaceUIDs: namespaceUIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	groupedRules := make(map[models.AlertRuleGroupKey]models.RulesGroup)
+	for _, rule := range rules {
+		groupKey := rule.GetGroupKey()
+		groupedRules[groupKey] = append(groupedRules[groupKey], rule)
+	}
+
+	readable := make(map[string]bool, len(rules))
+	for _, group := range groupedRules {
+		ok, err := authz.HasAccessToRuleGroup(ctx, user, group)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		for _, rule := range group {
+			readable[rule.UID] = true
+		}
+	}
+
+	var result []*state.State
+	for _, st := range states {
+		if readable[st.AlertRuleUID] {
+			result = append(result, st)
+		}
+	}
+	return result, nil
+}
+
+// QueryStateHistory returns the state history of the alert rules matching the query, like the state history API.
+func (r *StateReader) QueryStateHistory(ctx context.Context, query models.HistoryQuery) (*data.Frame, error) {
+	ng, err := r.alertNG()
+	if err != nil {
+		return nil, err
+	}
+	return ng.api.Historian.Query(ctx, query)
+}
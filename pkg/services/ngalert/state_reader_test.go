@@ -0,0 +1,60 @@
+package ngalert
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/folder"
+	ac "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestReadableStates(t *testing.T) {
+	const orgID = 1
+	visible := &folder.Folder{UID: "visible", Title: "Visible"}
+	hidden := &folder.Folder{UID: "hidden", Title: "Hidden"}
+	newRule := func(f *folder.Folder, datasourceUID string) *models.AlertRule {
+		return models.AlertRuleGen(
+			models.WithOrgID(orgID),
+			models.WithNamespace(f),
+			models.WithQuery(models.AlertQuery{RefID: "A", DatasourceUID: datasourceUID}),
+		)()
+	}
+	readable := newRule(visible, "ds1")
+	notQueryable := newRule(visible, "ds2")
+	inHiddenFolder := newRule(hidden, "ds1")
+
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.PutRule(context.Background(), readable, notQueryable, inHiddenFolder)
+	ruleStore.Folders[orgID] = []*folder.Folder{visible}
+
+	states := []*state.State{
+		{OrgID: orgID, AlertRuleUID: readable.UID, CacheID: "1"},
+		{OrgID: orgID, AlertRuleUID: notQueryable.UID, CacheID: "2"},
+		{OrgID: orgID, AlertRuleUID: inHiddenFolder.UID, CacheID: "3"},
+		{OrgID: orgID, AlertRuleUID: readable.UID, CacheID: "4"},
+	}
+	usr := &user.SignedInUser{OrgID: orgID, Permissions: map[int64]map[string][]string{
+		orgID: {datasources.ActionQuery: {datasources.ScopeProvider.GetResourceScopeUID("ds1")}},
+	}}
+	authz := ac.NewRuleService(acimpl.ProvideAccessControl(setting.NewCfg()))
+
+	result, err := readableStates(context.Background(), usr, ruleStore, authz, states)
+	require.NoError(t, err)
+	require.Equal(t, []*state.State{states[0], states[3]}, result)
+
+	t.Run("returns no states without visible folders", func(t *testing.T) {
+		ruleStore.Folders[orgID] = nil
+		result, err := readableStates(context.Background(), usr, ruleStore, authz, states)
+		require.NoError(t, err)
+		require.Empty(t, result)
+	})
+}
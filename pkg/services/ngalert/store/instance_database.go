@@ -70,6 +70,38 @@ func (st DBstore) SaveAlertInstance(ctx context.Context, alertInstance models.Al
 	})
 }
 
+// SaveAlertInstances saves the provided alert instances in a single transaction.
+func (st DBstore) SaveAlertInstances(ctx context.Context, instances ...models.AlertInstance) error {
+	if len(instances) == 0 {
+		return nil
+	}
+
+	upsertSQL := st.SQLStore.GetDialect().UpsertSQL(
+		"alert_instance",
+		[]string{"rule_org_id", "rule_uid", "labels_hash"},
+		[]string{"rule_org_id", "rule_uid", "labels", "labels_hash", "current_state", "current_reason", "current_state_since", "current_state_end", "last_eval_time", "result_fingerprint"})
+
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		for _, alertInstance := range instances {
+			if err := models.ValidateAlertInstance(alertInstance); err != nil {
+				st.Logger.Warn("Failed to validate alert instance, skipping", "err", err, "rule_uid", alertInstance.RuleUID)
+				continue
+			}
+			labelTupleJSON, err := alertInstance.Labels.StringKey()
+			if err != nil {
+				st.Logger.Warn("Failed to generate alert instance labels key, skipping", "err", err, "rule_uid", alertInstance.RuleUID)
+				continue
+			}
+
+			params := append(make([]any, 0), upsertSQL, alertInstance.RuleOrgID, alertInstance.RuleUID, labelTupleJSON, alertInstance.LabelsHash, alertInstance.CurrentState, alertInstance.CurrentReason, alertInstance.CurrentStateSince.Unix(), alertInstance.CurrentStateEnd.Unix(), alertInstance.LastEvalTime.Unix(), alertInstance.ResultFingerprint)
+			if _, err := sess.Exec(params...); err != nil {
+				return fmt.Errorf("failed to upsert alert instance: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
 func (st DBstore) FetchOrgIds(ctx context.Context) ([]int64, error) {
 	orgIds := []int64{}
 
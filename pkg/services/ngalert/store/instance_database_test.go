@@ -371,6 +371,47 @@ func TestIntegrationFullSync(t *testing.T) {
 	})
 }
 
+func TestIntegrationSaveAlertInstances(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	orgID := int64(1)
+	instances := []models.AlertInstance{
+		generateTestAlertInstance(orgID, "a"),
+		generateTestAlertInstance(orgID, "b"),
+	}
+
+	t.Run("Should insert new instances", func(t *testing.T) {
+		err := dbstore.SaveAlertInstances(ctx, instances...)
+		require.NoError(t, err)
+
+		res, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{
+			RuleOrgID: orgID,
+		})
+		require.NoError(t, err)
+		require.Len(t, res, len(instances))
+	})
+
+	t.Run("Should update existing instances", func(t *testing.T) {
+		updated := instances[0]
+		updated.CurrentState = models.InstanceStateNormal
+		err := dbstore.SaveAlertInstances(ctx, updated)
+		require.NoError(t, err)
+
+		res, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{
+			RuleOrgID: orgID,
+			RuleUID:   updated.RuleUID,
+		})
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Equal(t, models.InstanceStateNormal, res[0].CurrentState)
+		require.Equal(t, updated.ResultFingerprint, res[0].ResultFingerprint)
+	})
+}
+
 func generateTestAlertInstance(orgID int64, ruleID string) models.AlertInstance {
 	return models.AlertInstance{
 		AlertInstanceKey: models.AlertInstanceKey{
@@ -0,0 +1,42 @@
+package query
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/tsdb/legacydata"
+)
+
+// Recording is a query response recorded with the time range of the request. The replay scenario of the TestData
+// data source replays the recorded frames, shifted from the recorded time range to the time range of the replay.
+// The results have the format of the query API, so a recording can be read as a regular response.
+type Recording struct {
+	From    time.Time                  `json:"from"`
+	To      time.Time                  `json:"to"`
+	Results map[string]json.RawMessage `json:"results"`
+}
+
+// NewRecording records the response of a request.
+func NewRecording(reqDTO dtos.MetricRequest, resp *backend.QueryDataResponse) (*Recording, error) {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var results struct {
+		Results map[string]json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, err
+	}
+
+	timeRange := legacydata.NewDataTimeRange(reqDTO.From, reqDTO.To)
+	return &Recording{
+		From:    timeRange.GetFromAsTimeUTC(),
+		To:      timeRange.GetToAsTimeUTC(),
+		Results: results.Results,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package query
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+)
+
+func TestNewRecording(t *testing.T) {
+	resp := backend.NewQueryDataResponse()
+	resp.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+		data.NewFrame("", data.NewField("time", nil, []time.Time{time.Unix(1, 0)})),
+	}}
+	resp.Responses["B"] = backend.ErrDataResponse(backend.StatusBadRequest, "bad query")
+
+	rec, err := NewRecording(dtos.MetricRequest{From: "1704106800000", To: "1704110400000", Record: true}, resp)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC), rec.From)
+	require.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), rec.To)
+
+	// the recording can be read as a regular response
+	body, err := json.Marshal(rec)
+	require.NoError(t, err)
+	var results struct {
+		Results map[string]json.RawMessage `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(body, &results))
+	var replayed backend.QueryDataResponse
+	b, err := json.Marshal(results)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &replayed))
+	require.Len(t, replayed.Responses["A"].Frames, 1)
+	require.EqualError(t, replayed.Responses["B"].Error, "bad query")
+}
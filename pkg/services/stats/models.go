@@ -110,6 +110,26 @@ type AdminStats struct {
 
 type GetAdminStatsQuery struct{}
 
+type OrgStats struct {
+	Dashboards      int64 `json:"dashboards"`
+	Folders         int64 `json:"folders"`
+	Snapshots       int64 `json:"snapshots"`
+	Datasources     int64 `json:"datasources"`
+	Playlists       int64 `json:"playlists"`
+	Alerts          int64 `json:"alerts"`
+	Teams           int64 `json:"teams"`
+	Users           int64 `json:"users"`
+	Admins          int64 `json:"admins"`
+	Editors         int64 `json:"editors"`
+	Viewers         int64 `json:"viewers"`
+	ActiveUsers     int64 `json:"activeUsers"`
+	ServiceAccounts int64 `json:"serviceAccounts"`
+}
+
+type GetOrgStatsQuery struct {
+	OrgID int64
+}
+
 type SystemUserCountStats struct {
 	Count int64
 }
@@ -6,6 +6,7 @@ import (
 
 type Service interface {
 	GetAdminStats(ctx context.Context, query *GetAdminStatsQuery) (*AdminStats, error)
+	GetOrgStats(ctx context.Context, query *GetOrgStatsQuery) (*OrgStats, error)
 	GetAlertNotifiersUsageStats(ctx context.Context, query *GetAlertNotifierUsageStatsQuery) ([]*NotifierUsageStats, error)
 	GetDataSourceStats(ctx context.Context, query *GetDataSourceStatsQuery) ([]*DataSourceStats, error)
 	GetDataSourceAccessStats(ctx context.Context, query *GetDataSourceAccessStatsQuery) ([]*DataSourceAccessStats, error)
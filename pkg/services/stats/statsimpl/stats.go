@@ -228,6 +228,47 @@ func (ss *sqlStatsService) GetAdminStats(ctx context.Context, query *stats.GetAd
 	return result, err
 }
 
+func (ss *sqlStatsService) GetOrgStats(ctx context.Context, query *stats.GetOrgStatsQuery) (result *stats.OrgStats, err error) {
+	err = ss.db.WithDbSession(ctx, func(dbSession *db.Session) error {
+		dialect := ss.db.GetDialect()
+		activeEndDate := time.Now().Add(-activeUserTimeLimit)
+
+		alertsTable := dialect.Quote("alert")
+		if ss.IsUnifiedAlertingEnabled() {
+			alertsTable = dialect.Quote("alert_rule")
+		}
+		orgUsers := ` FROM ` + dialect.Quote("org_user") + ` INNER JOIN ` + dialect.Quote("user") + ` ON ` +
+			dialect.Quote("user") + `.id = ` + dialect.Quote("org_user") + `.user_id WHERE ` + dialect.Quote("org_user") + `.org_id = ? AND `
+
+		sb := &db.SQLBuilder{}
+		sb.Write("SELECT ")
+		sb.Write(`(SELECT COUNT(id) FROM `+dialect.Quote("dashboard")+` WHERE org_id = ? AND is_folder = `+dialect.BooleanStr(false)+`) AS dashboards,`, query.OrgID)
+		sb.Write(`(SELECT COUNT(id) FROM `+dialect.Quote("dashboard")+` WHERE org_id = ? AND is_folder = `+dialect.BooleanStr(true)+`) AS folders,`, query.OrgID)
+		sb.Write(`(SELECT COUNT(id) FROM `+dialect.Quote("dashboard_snapshot")+` WHERE org_id = ?) AS snapshots,`, query.OrgID)
+		sb.Write(`(SELECT COUNT(id) FROM `+dialect.Quote("data_source")+` WHERE org_id = ?) AS datasources,`, query.OrgID)
+		sb.Write(`(SELECT COUNT(id) FROM `+dialect.Quote("playlist")+` WHERE org_id = ?) AS playlists,`, query.OrgID)
+		sb.Write(`(SELECT COUNT(id) FROM `+alertsTable+` WHERE org_id = ?) AS alerts,`, query.OrgID)
+		sb.Write(`(SELECT COUNT(id) FROM `+dialect.Quote("team")+` WHERE org_id = ?) AS teams,`, query.OrgID)
+		sb.Write(`(SELECT COUNT(*)`+orgUsers+notServiceAccount(dialect)+`) AS users,`, query.OrgID)
+		orgRole := ` AND ` + dialect.Quote("org_user") + `.role = ?`
+		sb.Write(`(SELECT COUNT(*)`+orgUsers+notServiceAccount(dialect)+orgRole+`) AS admins,`, query.OrgID, org.RoleAdmin)
+		sb.Write(`(SELECT COUNT(*)`+orgUsers+notServiceAccount(dialect)+orgRole+`) AS editors,`, query.OrgID, org.RoleEditor)
+		sb.Write(`(SELECT COUNT(*)`+orgUsers+notServiceAccount(dialect)+orgRole+`) AS viewers,`, query.OrgID, org.RoleViewer)
+		sb.Write(`(SELECT COUNT(*)`+orgUsers+notServiceAccount(dialect)+` AND last_seen_at > ?) AS active_users,`, query.OrgID, activeEndDate)
+		sb.Write(`(SELECT COUNT(id) FROM `+dialect.Quote("user")+` WHERE org_id = ? AND is_service_account = `+dialect.BooleanStr(true)+`) AS service_accounts`, query.OrgID)
+
+		var stats stats.OrgStats
+		_, err := dbSession.SQL(sb.GetSQLString(), sb.GetParams()...).Get(&stats)
+		if err != nil {
+			return err
+		}
+
+		result = &stats
+		return nil
+	})
+	return result, err
+}
+
 func (ss *sqlStatsService) GetSystemUserCountStats(ctx context.Context, query *stats.GetSystemUserCountStatsQuery) (result *stats.SystemUserCountStats, err error) {
 	err = ss.db.WithDbSession(ctx, func(sess *db.Session) error {
 		var rawSQL = `SELECT COUNT(id) AS Count FROM ` + ss.db.GetDialect().Quote("user")
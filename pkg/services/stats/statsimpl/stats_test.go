@@ -79,6 +79,22 @@ func TestIntegrationStatsDataAccess(t *testing.T) {
 		_, err := statsService.GetAdminStats(context.Background(), &query)
 		assert.NoError(t, err)
 	})
+
+	t.Run("Get org stats should only count the org", func(t *testing.T) {
+		orgService, err := orgimpl.ProvideService(db, db.Cfg, quotatest.New(false, nil))
+		require.NoError(t, err)
+		o, err := orgService.GetByName(context.Background(), &org.GetOrgByNameQuery{Name: "Org #0"})
+		require.NoError(t, err)
+
+		query := stats.GetOrgStatsQuery{OrgID: o.ID}
+		result, err := statsService.GetOrgStats(context.Background(), &query)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), result.Users)
+		assert.Equal(t, int64(1), result.Admins)
+		assert.Equal(t, int64(1), result.Editors)
+		assert.Equal(t, int64(1), result.Viewers)
+		assert.Equal(t, int64(0), result.Datasources)
+	})
 }
 
 func populateDB(t *testing.T, sqlStore *sqlstore.SQLStore) {
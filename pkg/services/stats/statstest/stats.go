@@ -8,6 +8,7 @@ import (
 
 type FakeService struct {
 	ExpectedAdminStats             *stats.AdminStats
+	ExpectedOrgStats               *stats.OrgStats
 	ExpectedSystemStats            *stats.SystemStats
 	ExpectedDataSourceStats        []*stats.DataSourceStats
 	ExpectedDataSourcesAccessStats []*stats.DataSourceAccessStats
@@ -24,6 +25,10 @@ func (s *FakeService) GetAdminStats(ctx context.Context, query *stats.GetAdminSt
 	return s.ExpectedAdminStats, s.ExpectedError
 }
 
+func (s *FakeService) GetOrgStats(ctx context.Context, query *stats.GetOrgStatsQuery) (*stats.OrgStats, error) {
+	return s.ExpectedOrgStats, s.ExpectedError
+}
+
 func (s *FakeService) GetAlertNotifiersUsageStats(ctx context.Context, query *stats.GetAlertNotifierUsageStatsQuery) ([]*stats.NotifierUsageStats, error) {
 	return s.ExpectedNotifierUsageStats, s.ExpectedError
 }
@@ -342,6 +342,10 @@ type Cfg struct {
 	SqlDatasourceMaxIdleConnsDefault    int
 	SqlDatasourceMaxConnLifetimeDefault int
 
+	// Prometheus data sources
+	PrometheusResultsCacheStore    string
+	PrometheusResultsCacheMaxBytes int
+
 	// Snapshots
 	SnapshotEnabled      bool
 	ExternalSnapshotUrl  string
@@ -1207,6 +1211,7 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 	cfg.readDataSourcesSettings()
 	cfg.readDataSourceSecuritySettings()
 	cfg.readSqlDataSourceSettings()
+	cfg.readPrometheusDataSourceSettings()
 
 	cfg.Storage = readStorageSettings(iniFile)
 	cfg.Search = readSearchSettings(iniFile)
@@ -1945,6 +1950,12 @@ func (cfg *Cfg) readSqlDataSourceSettings() {
 	cfg.SqlDatasourceMaxConnLifetimeDefault = sqlDatasources.Key("max_conn_lifetime_default").MustInt(14400)
 }
 
+func (cfg *Cfg) readPrometheusDataSourceSettings() {
+	prometheusDatasources := cfg.Raw.Section("prometheus_datasources")
+	cfg.PrometheusResultsCacheStore = prometheusDatasources.Key("results_cache_store").In("memory", []string{"memory", "remote_cache"})
+	cfg.PrometheusResultsCacheMaxBytes = prometheusDatasources.Key("results_cache_max_bytes").MustInt(100 * 1024 * 1024)
+}
+
 func GetAllowedOriginGlobs(originPatterns []string) ([]glob.Glob, error) {
 	allowedOrigins := originPatterns
 	originGlobs := make([]glob.Glob, 0, len(allowedOrigins))
@@ -19,6 +19,7 @@ const (
 	alertmanagerDefaultGossipInterval     = alertingCluster.DefaultGossipInterval
 	alertmanagerDefaultPushPullInterval   = alertingCluster.DefaultPushPullInterval
 	alertmanagerDefaultConfigPollInterval = time.Minute
+	alertmanagerDefaultPersistInterval    = 15 * time.Minute
 	alertmanagerRedisDefaultMaxConns      = 5
 	// To start, the alertmanager needs at least one route defined.
 	// TODO: we should move this to Grafana settings and define this as the default.
@@ -62,6 +63,12 @@ const (
 	stateHistoryDefaultEnabled    = true
 )
 
+const (
+	enrichmentDefaultTimeout        = 2 * time.Second
+	enrichmentDefaultCacheTTL       = 5 * time.Minute
+	enrichmentDefaultMaxConcurrency = 10
+)
+
 type UnifiedAlertingSettings struct {
 	AdminConfigPollInterval        time.Duration
 	AlertmanagerConfigPollInterval time.Duration
@@ -96,10 +103,22 @@ type UnifiedAlertingSettings struct {
 	ReservedLabels                UnifiedAlertingReservedLabelSettings
 	StateHistory                  UnifiedAlertingStateHistorySettings
 	RemoteAlertmanager            RemoteAlertmanagerSettings
+	Enrichment                    UnifiedAlertingEnrichmentSettings
 	// MaxStateSaveConcurrency controls the number of goroutines (per rule) that can save alert state in parallel.
 	MaxStateSaveConcurrency   int
 	StatePeriodicSaveInterval time.Duration
-	RulesPerRuleGroupLimit    int64
+	// StateIncrementalSaveBatchSize is the maximum number of alert instances written to the database in a single
+	// transaction when only the changed alert instances are saved.
+	StateIncrementalSaveBatchSize int
+	RulesPerRuleGroupLimit        int64
+
+	// NotificationStatePersistInterval controls how often the notification log and the alerts pending
+	// delivery are persisted to the database, so they can be recovered after a restart or an HA failover.
+	NotificationStatePersistInterval time.Duration
+
+	// HAEvaluationSharding splits the evaluation of the alert rules between the members of the HA cluster
+	// instead of evaluating every alert rule on every member.
+	HAEvaluationSharding bool
 }
 
 // RemoteAlertmanagerSettings contains the configuration needed
@@ -139,6 +158,17 @@ type UnifiedAlertingStateHistorySettings struct {
 	ExternalLabels        map[string]string
 }
 
+// UnifiedAlertingEnrichmentSettings contains the configuration of the stage that adds annotations
+// to the alerts before they are sent to the Alertmanager.
+type UnifiedAlertingEnrichmentSettings struct {
+	Enabled        bool
+	Timeout        time.Duration
+	CacheTTL       time.Duration
+	MaxConcurrency int
+	// Enrichers maps the name of each enricher to its settings, read from the sections [unified_alerting.enrichment.<name>].
+	Enrichers map[string]map[string]string
+}
+
 // IsEnabled returns true if UnifiedAlertingSettings.Enabled is either nil or true.
 // It hides the implementation details of the Enabled and simplifies its usage.
 func (u *UnifiedAlertingSettings) IsEnabled() bool {
@@ -210,6 +240,13 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	if err != nil {
 		return err
 	}
+	uaCfg.NotificationStatePersistInterval, err = gtime.ParseDuration(valueAsString(ua, "notification_state_persist_interval", (alertmanagerDefaultPersistInterval).String()))
+	if err != nil {
+		return err
+	}
+	if uaCfg.NotificationStatePersistInterval <= 0 {
+		return fmt.Errorf("value of setting 'notification_state_persist_interval' should be greater than 0")
+	}
 	uaCfg.HAListenAddr = ua.Key("ha_listen_address").MustString(alertmanagerDefaultClusterAddr)
 	uaCfg.HAAdvertiseAddr = ua.Key("ha_advertise_address").MustString("")
 	uaCfg.HALabel = ua.Key("ha_label").MustString("")
@@ -368,6 +405,35 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	}
 	uaCfg.StateHistory = uaCfgStateHistory
 
+	enrichment := iniFile.Section("unified_alerting.enrichment")
+	uaCfgEnrichment := UnifiedAlertingEnrichmentSettings{
+		Enabled:        enrichment.Key("enabled").MustBool(false),
+		MaxConcurrency: enrichment.Key("max_concurrency").MustInt(enrichmentDefaultMaxConcurrency),
+		Enrichers:      make(map[string]map[string]string),
+	}
+	uaCfgEnrichment.Timeout, err = gtime.ParseDuration(valueAsString(enrichment, "timeout", enrichmentDefaultTimeout.String()))
+	if err != nil {
+		return err
+	}
+	if uaCfgEnrichment.Timeout <= 0 {
+		return fmt.Errorf("value of setting 'timeout' in section 'unified_alerting.enrichment' should be greater than 0")
+	}
+	uaCfgEnrichment.CacheTTL, err = gtime.ParseDuration(valueAsString(enrichment, "cache_ttl", enrichmentDefaultCacheTTL.String()))
+	if err != nil {
+		return err
+	}
+	if uaCfgEnrichment.MaxConcurrency <= 0 {
+		return fmt.Errorf("value of setting 'max_concurrency' in section 'unified_alerting.enrichment' should be greater than 0")
+	}
+	for _, section := range iniFile.Sections() {
+		name, ok := strings.CutPrefix(section.Name(), "unified_alerting.enrichment.")
+		if !ok || name == "" {
+			continue
+		}
+		uaCfgEnrichment.Enrichers[name] = section.KeysHash()
+	}
+	uaCfg.Enrichment = uaCfgEnrichment
+
 	uaCfg.MaxStateSaveConcurrency = ua.Key("max_state_save_concurrency").MustInt(1)
 
 	uaCfg.StatePeriodicSaveInterval, err = gtime.ParseDuration(valueAsString(ua, "state_periodic_save_interval", (time.Minute * 5).String()))
@@ -375,6 +441,13 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		return err
 	}
 
+	uaCfg.HAEvaluationSharding = ua.Key("ha_evaluation_sharding").MustBool(false)
+
+	uaCfg.StateIncrementalSaveBatchSize = ua.Key("state_incremental_save_batch_size").MustInt(200)
+	if uaCfg.StateIncrementalSaveBatchSize <= 0 {
+		return fmt.Errorf("value of setting 'state_incremental_save_batch_size' should be greater than 0")
+	}
+
 	cfg.UnifiedAlerting = uaCfg
 	return nil
 }
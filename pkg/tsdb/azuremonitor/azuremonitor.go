@@ -131,6 +131,17 @@ func NewInstanceSettings(clientProvider *httpclient.Provider, executors map[stri
 			model.Services[routeName] = service
 		}
 
+		// The metrics batch API has its own endpoint and token audience
+		if _, ok := executors[azureMonitor]; ok {
+			if _, ok := routesForModel[azureMonitorMetricsBatch]; ok {
+				service, err := getDatasourceService(ctx, &settings, azureSettings, clientProvider, model, azureMonitorMetricsBatch, logger)
+				if err != nil {
+					return nil, err
+				}
+				model.Services[azureMonitorMetricsBatch] = service
+			}
+		}
+
 		return model, nil
 	}
 }
@@ -47,6 +47,11 @@ var testRoutes = map[string]types.AzRoute{
 	azurePortal: {
 		URL: "https://portal.azure.com",
 	},
+	azureMonitorMetricsBatch: {
+		URL:     "https://{region}.metrics.monitor.azure.com",
+		Scopes:  []string{"https://metrics.monitor.azure.com/.default"},
+		Headers: map[string]string{"x-ms-app": "Grafana"},
+	},
 }
 
 func TestNewInstanceSettings(t *testing.T) {
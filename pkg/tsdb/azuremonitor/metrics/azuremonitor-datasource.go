@@ -56,6 +56,14 @@ func (e *AzureMonitorDatasource) ExecuteTimeSeriesQuery(ctx context.Context, ori
 		return nil, err
 	}
 
+	if batchService, ok := dsInfo.Services[metricsBatchRoute]; ok && isMetricsBatchEnabled(ctx) {
+		var batches []*metricsBatch
+		batches, queries = groupBatchQueries(queries)
+		for refID, res := range e.executeBatches(ctx, batches, dsInfo, client, batchService.HTTPClient, batchService.URL) {
+			result.Responses[refID] = res
+		}
+	}
+
 	for _, query := range queries {
 		res, err := e.executeQuery(ctx, query, dsInfo, client, url)
 		if err != nil {
@@ -192,15 +200,16 @@ func (e *AzureMonitorDatasource) buildQueries(queries []backend.DataQuery, dsInf
 		}
 
 		query := &types.AzureMonitorQuery{
-			URL:          azureURL,
-			Target:       target,
-			Params:       params,
-			RefID:        query.RefID,
-			Alias:        alias,
-			TimeRange:    query.TimeRange,
-			Dimensions:   azJSONModel.DimensionFilters,
-			Resources:    resourceMap,
-			Subscription: sub,
+			URL:             azureURL,
+			Target:          target,
+			Params:          params,
+			RefID:           query.RefID,
+			Alias:           alias,
+			TimeRange:       query.TimeRange,
+			Dimensions:      azJSONModel.DimensionFilters,
+			Resources:       resourceMap,
+			Subscription:    sub,
+			DimensionFilter: dimSB.String(),
 		}
 		if filterString != "" {
 			if filterInBody {
@@ -0,0 +1,340 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/types"
+)
+
+const (
+	AzureMonitorMetricsBatchAPIVersion = "2023-10-01"
+
+	// FlagAzureMonitorMetricsBatchQueries enables the metrics batch API for the metrics queries
+	FlagAzureMonitorMetricsBatchQueries = "azureMonitorMetricsBatchQueries"
+
+	metricsBatchRoute = "Azure Monitor Metrics Batch"
+	// maxBatchResources is the number of resources the metrics batch API accepts in a request
+	maxBatchResources = 50
+	// maxConcurrentBatches bounds the number of batch requests running at the same time
+	maxConcurrentBatches = 10
+)
+
+// metricsBatch is a request to the metrics batch API, which queries the same metrics of up to 50 resources
+// of a subscription and region.
+type metricsBatch struct {
+	subscription string
+	region       string
+	params       url.Values
+	resourceIDs  []string
+	// queries are the queries of at least one of the resources of the batch
+	queries []*types.AzureMonitorQuery
+}
+
+func isMetricsBatchEnabled(ctx context.Context) bool {
+	return backend.GrafanaConfigFromContext(ctx).FeatureToggles().IsEnabled(FlagAzureMonitorMetricsBatchQueries)
+}
+
+// groupBatchQueries groups the queries requesting the same metrics, in the same way, of resources in the same
+// subscription and region into batches. The queries that can't use the metrics batch API are returned as they are.
+func groupBatchQueries(queries []*types.AzureMonitorQuery) ([]*metricsBatch, []*types.AzureMonitorQuery) {
+	remaining := []*types.AzureMonitorQuery{}
+	groups := map[string][]*types.AzureMonitorQuery{}
+	groupParams := map[string]url.Values{}
+	keys := []string{}
+
+	for _, query := range queries {
+		subscription, params, ok := getBatchParams(query)
+		if !ok {
+			remaining = append(remaining, query)
+			continue
+		}
+		key := path.Join(subscription, query.Params.Get("region"), params.Encode())
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+			groupParams[key] = params
+		}
+		groups[key] = append(groups[key], query)
+	}
+
+	batches := []*metricsBatch{}
+	for _, key := range keys {
+		group := groups[key]
+		resourceIDs := []string{}
+		seen := map[string]bool{}
+		for _, query := range group {
+			for _, resourceID := range sortedResourceIDs(query) {
+				if !seen[strings.ToLower(resourceID)] {
+					seen[strings.ToLower(resourceID)] = true
+					resourceIDs = append(resourceIDs, resourceID)
+				}
+			}
+		}
+
+		for start := 0; start < len(resourceIDs); start += maxBatchResources {
+			end := min(start+maxBatchResources, len(resourceIDs))
+			batch := &metricsBatch{
+				subscription: getResourceSubscription(resourceIDs[0]),
+				region:       group[0].Params.Get("region"),
+				params:       groupParams[key],
+				resourceIDs:  resourceIDs[start:end],
+			}
+			for _, query := range group {
+				if hasAnyResource(query, batch.resourceIDs) {
+					batch.queries = append(batch.queries, query)
+				}
+			}
+			batches = append(batches, batch)
+		}
+	}
+
+	return batches, remaining
+}
+
+// getBatchParams returns the subscription and the parameters of the metrics batch API for a query. The query
+// needs a region and resources of a single subscription.
+func getBatchParams(query *types.AzureMonitorQuery) (string, url.Values, bool) {
+	if query.Params.Get("region") == "" || query.Params.Get("metricnames") == "" || len(query.Resources) == 0 {
+		return "", nil, false
+	}
+
+	subscription := ""
+	for resourceID := range query.Resources {
+		resourceSubscription := getResourceSubscription(resourceID)
+		if resourceSubscription == "" || (subscription != "" && !strings.EqualFold(subscription, resourceSubscription)) {
+			return "", nil, false
+		}
+		subscription = resourceSubscription
+	}
+
+	params := url.Values{}
+	params.Add("api-version", AzureMonitorMetricsBatchAPIVersion)
+	params.Add("starttime", query.TimeRange.From.UTC().Format(time.RFC3339))
+	params.Add("endtime", query.TimeRange.To.UTC().Format(time.RFC3339))
+	for _, name := range []string{"interval", "aggregation", "metricnames", "metricnamespace", "top"} {
+		if value := query.Params.Get(name); value != "" {
+			params.Add(name, value)
+		}
+	}
+	if query.DimensionFilter != "" {
+		params.Add("filter", query.DimensionFilter)
+	}
+
+	return strings.ToLower(subscription), params, true
+}
+
+// executeBatches runs the batches with a bounded concurrency and splits their responses back to the queries
+func (e *AzureMonitorDatasource) executeBatches(ctx context.Context, batches []*metricsBatch, dsInfo types.DatasourceInfo, cli *http.Client, batchCli *http.Client, batchURL string) map[string]backend.DataResponse {
+	results := make([]map[string]types.AzureMonitorResponse, len(batches))
+	errs := make([]error, len(batches))
+
+	g := errgroup.Group{}
+	g.SetLimit(maxConcurrentBatches)
+	for i, batch := range batches {
+		i, batch := i, batch
+		g.Go(func() error {
+			results[i], errs[i] = e.executeBatch(ctx, batch, dsInfo, batchCli, batchURL)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	// a query gets the responses of all the batches of its resources, or the error of one of them
+	queries := []*types.AzureMonitorQuery{}
+	queryErrs := map[*types.AzureMonitorQuery]error{}
+	queryValues := map[*types.AzureMonitorQuery]map[string]types.AzureMonitorResponse{}
+	for i, batch := range batches {
+		for _, query := range batch.queries {
+			if _, ok := queryValues[query]; !ok {
+				queries = append(queries, query)
+				queryValues[query] = map[string]types.AzureMonitorResponse{}
+			}
+			if errs[i] != nil {
+				queryErrs[query] = errs[i]
+				continue
+			}
+			for resourceID, value := range results[i] {
+				queryValues[query][resourceID] = value
+			}
+		}
+	}
+
+	responses := map[string]backend.DataResponse{}
+	subscriptions := map[string]string{}
+	for _, query := range queries {
+		if err := queryErrs[query]; err != nil {
+			responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+
+		subscription, ok := subscriptions[query.Subscription]
+		if !ok {
+			var err error
+			subscription, err = e.retrieveSubscriptionDetails(cli, ctx, query.Subscription, dsInfo.Routes["Azure Monitor"].URL, dsInfo.DatasourceID, dsInfo.OrgID)
+			if err != nil {
+				responses[query.RefID] = backend.DataResponse{Error: err}
+				continue
+			}
+			subscriptions[query.Subscription] = subscription
+		}
+
+		frames, err := e.parseBatchResponses(queryValues[query], query, dsInfo.Routes["Azure Portal"].URL, subscription)
+		if err != nil {
+			responses[query.RefID] = backend.DataResponse{Error: err}
+			continue
+		}
+		responses[query.RefID] = backend.DataResponse{Frames: frames}
+	}
+
+	return responses
+}
+
+// executeBatch calls the metrics batch API and returns the response of each resource by its lower case ID
+func (e *AzureMonitorDatasource) executeBatch(ctx context.Context, batch *metricsBatch, dsInfo types.DatasourceInfo, cli *http.Client, batchURL string) (map[string]types.AzureMonitorResponse, error) {
+	req, err := e.createRequest(ctx, strings.Replace(batchURL, "{region}", batch.region, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string][]string{"resourceids": batch.resourceIDs})
+	if err != nil {
+		return nil, err
+	}
+	req.Method = http.MethodPost
+	req.URL.Path = path.Join(req.URL.Path, "/subscriptions", batch.subscription, "metrics:getBatch")
+	req.URL.RawQuery = batch.params.Encode()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	_, span := tracing.DefaultTracer().Start(ctx, "azuremonitor batch query", trace.WithAttributes(
+		attribute.String("subscription", batch.subscription),
+		attribute.String("region", batch.region),
+		attribute.Int("resources", len(batch.resourceIDs)),
+		attribute.Int64("datasource_id", dsInfo.DatasourceID),
+		attribute.Int64("org_id", dsInfo.OrgID),
+	),
+	)
+	defer span.End()
+
+	res, err := cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			e.Logger.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("request failed, status: %s, error: %s", res.Status, string(resBody))
+	}
+
+	var data types.AzureMonitorBatchResponse
+	err = json.Unmarshal(resBody, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]types.AzureMonitorResponse{}
+	for _, value := range data.Values {
+		values[strings.ToLower(value.ResourceID)] = value.AzureMonitorResponse
+	}
+	return values, nil
+}
+
+// parseBatchResponses parses the responses of the resources of a query in the order of their IDs
+func (e *AzureMonitorDatasource) parseBatchResponses(values map[string]types.AzureMonitorResponse, query *types.AzureMonitorQuery, azurePortalUrl string, subscription string) (data.Frames, error) {
+	frames := data.Frames{}
+	for _, resourceID := range sortedResourceIDs(query) {
+		amr, ok := values[strings.ToLower(resourceID)]
+		if !ok {
+			continue
+		}
+		for _, metric := range amr.Value {
+			if metric.ErrorCode != "" && metric.ErrorCode != "Success" {
+				return nil, fmt.Errorf("failed to query the metric %s of %s: %s %s", metric.Name.Value, resourceID, metric.ErrorCode, metric.ErrorMessage)
+			}
+		}
+
+		// the subscription level queries return the resource of each time series as a dimension
+		if len(query.Resources) > 1 {
+			amr = withResourceIDDimension(amr, resourceID)
+		}
+		resourceFrames, err := e.parseResponse(amr, query, azurePortalUrl, subscription)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, resourceFrames...)
+	}
+	return frames, nil
+}
+
+// withResourceIDDimension returns a copy of the response which has the resource ID as a dimension of the time series
+func withResourceIDDimension(amr types.AzureMonitorResponse, resourceID string) types.AzureMonitorResponse {
+	if len(amr.Value) == 0 {
+		return amr
+	}
+
+	dimension := types.AzureMonitorMetadataValue{Value: resourceID}
+	dimension.Name.Value = "Microsoft.ResourceId"
+	dimension.Name.LocalizedValue = "Microsoft.ResourceId"
+
+	amr.Value = slices.Clone(amr.Value)
+	amr.Value[0].Timeseries = slices.Clone(amr.Value[0].Timeseries)
+	for i, series := range amr.Value[0].Timeseries {
+		amr.Value[0].Timeseries[i].Metadatavalues = append(slices.Clip(series.Metadatavalues), dimension)
+	}
+	return amr
+}
+
+func sortedResourceIDs(query *types.AzureMonitorQuery) []string {
+	resourceIDs := make([]string, 0, len(query.Resources))
+	for resourceID := range query.Resources {
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	sort.Strings(resourceIDs)
+	return resourceIDs
+}
+
+func hasAnyResource(query *types.AzureMonitorQuery, resourceIDs []string) bool {
+	for resourceID := range query.Resources {
+		for _, id := range resourceIDs {
+			if strings.EqualFold(resourceID, id) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getResourceSubscription returns the subscription of a resource ID like /subscriptions/{id}/resourceGroups/...
+func getResourceSubscription(resourceID string) string {
+	parts := strings.Split(strings.Trim(resourceID, "/"), "/")
+	if len(parts) < 2 || !strings.EqualFold(parts[0], "subscriptions") {
+		return ""
+	}
+	return parts[1]
+}
@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/experimental/featuretoggles"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/kinds/dataquery"
+	"github.com/grafana/grafana/pkg/tsdb/azuremonitor/types"
+)
+
+func TestAzureMonitorBatchQueries(t *testing.T) {
+	// Ignore gosec warning G304 since it's a test
+	// nolint:gosec
+	batchResponse, err := os.ReadFile(filepath.Join("../testdata", "azuremonitor/11-azure-monitor-batch-response.json"))
+	require.NoError(t, err)
+	// nolint:gosec
+	metricsResponse, err := os.ReadFile(filepath.Join("../testdata", "azuremonitor/1-azure-monitor-response-avg.json"))
+	require.NoError(t, err)
+
+	mu := sync.Mutex{}
+	batchRequests := []*http.Request{}
+	batchBodies := []string{}
+	metricsRequests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/metrics:getBatch"):
+			body, _ := io.ReadAll(r.Body)
+			batchRequests = append(batchRequests, r)
+			batchBodies = append(batchBodies, string(body))
+			_, _ = w.Write(batchResponse)
+		case strings.HasSuffix(strings.ToLower(r.URL.Path), "/providers/microsoft.insights/metrics"):
+			metricsRequests++
+			_, _ = w.Write(metricsResponse)
+		default:
+			_, _ = w.Write([]byte(`{"displayName": "test subscription"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	dsInfo := types.DatasourceInfo{
+		Routes: map[string]types.AzRoute{
+			"Azure Monitor": {URL: srv.URL},
+			"Azure Portal":  {URL: "http://portal"},
+		},
+		Services: map[string]types.DatasourceService{
+			metricsBatchRoute: {URL: srv.URL, HTTPClient: srv.Client()},
+		},
+	}
+
+	from := time.Date(2019, 2, 8, 10, 13, 0, 0, time.UTC)
+	query := func(refID string, region string, resourceNames ...string) backend.DataQuery {
+		resources := []map[string]string{}
+		for _, name := range resourceNames {
+			resources = append(resources, map[string]string{"resourceGroup": "grafanastaging", "resourceName": name})
+		}
+		model, err := json.Marshal(map[string]any{
+			"subscription": "12345678-aaaa-bbbb-cccc-123456789abc",
+			"azureMonitor": map[string]any{
+				"resources":       resources,
+				"metricNamespace": "Microsoft.Compute/virtualMachines",
+				"metricName":      "Percentage CPU",
+				"aggregation":     "Average",
+				"timeGrain":       "PT1M",
+				"region":          region,
+			},
+		})
+		require.NoError(t, err)
+		return backend.DataQuery{
+			RefID:     refID,
+			TimeRange: backend.TimeRange{From: from, To: from.Add(2 * time.Minute)},
+			JSON:      model,
+		}
+	}
+	queries := []backend.DataQuery{
+		query("A", "westeurope", "grafana"),
+		query("B", "westeurope", "grafana", "grafana2"),
+		query("C", "", "grafana"),
+	}
+
+	ctx := backend.WithGrafanaConfig(context.Background(), backend.NewGrafanaCfg(map[string]string{
+		featuretoggles.EnabledFeatures: FlagAzureMonitorMetricsBatchQueries,
+	}))
+	datasource := &AzureMonitorDatasource{Logger: log.DefaultLogger}
+	res, err := datasource.ExecuteTimeSeriesQuery(ctx, queries, dsInfo, srv.Client(), srv.URL)
+	require.NoError(t, err)
+
+	t.Run("queries the resources of the same metrics in a single batch", func(t *testing.T) {
+		require.Len(t, batchRequests, 1)
+		require.Equal(t, "/subscriptions/12345678-aaaa-bbbb-cccc-123456789abc/metrics:getBatch", batchRequests[0].URL.Path)
+		params := batchRequests[0].URL.Query()
+		require.Equal(t, AzureMonitorMetricsBatchAPIVersion, params.Get("api-version"))
+		require.Equal(t, "2019-02-08T10:13:00Z", params.Get("starttime"))
+		require.Equal(t, "2019-02-08T10:15:00Z", params.Get("endtime"))
+		require.Equal(t, "Percentage CPU", params.Get("metricnames"))
+		require.Equal(t, "Microsoft.Compute/virtualMachines", params.Get("metricnamespace"))
+		require.JSONEq(t, `{"resourceids": [
+			"/subscriptions/12345678-aaaa-bbbb-cccc-123456789abc/resourceGroups/grafanastaging/providers/Microsoft.Compute/virtualMachines/grafana",
+			"/subscriptions/12345678-aaaa-bbbb-cccc-123456789abc/resourceGroups/grafanastaging/providers/Microsoft.Compute/virtualMachines/grafana2"
+		]}`, batchBodies[0])
+	})
+
+	t.Run("splits the batch responses back to the queries", func(t *testing.T) {
+		require.NoError(t, res.Responses["A"].Error)
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, "A", frames[0].RefID)
+		require.Equal(t, 2, frames[0].Rows())
+		require.Equal(t, 2.0875, *frames[0].Fields[1].At(0).(*float64))
+		require.Empty(t, frames[0].Fields[1].Labels)
+
+		require.NoError(t, res.Responses["B"].Error)
+		frames = res.Responses["B"].Frames
+		require.Len(t, frames, 2)
+		require.Equal(t, data.Labels{"resourceName": "grafana"}, frames[0].Fields[1].Labels)
+		require.Equal(t, data.Labels{"resourceName": "grafana2"}, frames[1].Fields[1].Labels)
+		require.Equal(t, 5.25, *frames[1].Fields[1].At(0).(*float64))
+	})
+
+	t.Run("runs the queries without a region one by one", func(t *testing.T) {
+		require.Equal(t, 1, metricsRequests)
+		require.NoError(t, res.Responses["C"].Error)
+		require.Len(t, res.Responses["C"].Frames, 1)
+	})
+}
+
+func TestGroupBatchQueries(t *testing.T) {
+	from := time.Date(2019, 2, 8, 10, 13, 0, 0, time.UTC)
+	newQuery := func(refID string, region string, metricName string, resourceCount int) *types.AzureMonitorQuery {
+		resources := map[string]dataquery.AzureMonitorResource{}
+		for i := 0; i < resourceCount; i++ {
+			resources[fmt.Sprintf("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm%03d", i)] = dataquery.AzureMonitorResource{}
+		}
+		query := &types.AzureMonitorQuery{
+			RefID:     refID,
+			Params:    map[string][]string{"metricnames": {metricName}, "aggregation": {"Average"}},
+			TimeRange: backend.TimeRange{From: from, To: from.Add(time.Hour)},
+			Resources: resources,
+		}
+		if region != "" {
+			query.Params.Set("region", region)
+		}
+		return query
+	}
+
+	batches, remaining := groupBatchQueries([]*types.AzureMonitorQuery{
+		newQuery("A", "westeurope", "Percentage CPU", 120),
+		newQuery("B", "westeurope", "Percentage CPU", 10),
+		newQuery("C", "westeurope", "Network In", 10),
+		newQuery("D", "", "Percentage CPU", 10),
+	})
+
+	require.Len(t, remaining, 1)
+	require.Equal(t, "D", remaining[0].RefID)
+
+	require.Len(t, batches, 4)
+	for i, expected := range []struct {
+		resources int
+		refIDs    []string
+	}{
+		{resources: 50, refIDs: []string{"A", "B"}},
+		{resources: 50, refIDs: []string{"A"}},
+		{resources: 20, refIDs: []string{"A"}},
+		{resources: 10, refIDs: []string{"C"}},
+	} {
+		require.Equal(t, "sub", batches[i].subscription)
+		require.Equal(t, "westeurope", batches[i].region)
+		require.Len(t, batches[i].resourceIDs, expected.resources)
+		refIDs := []string{}
+		for _, query := range batches[i].queries {
+			refIDs = append(refIDs, query.RefID)
+		}
+		require.Equal(t, expected.refIDs, refIDs)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"strings"
 
 	"github.com/grafana/grafana-azure-sdk-go/azcredentials"
 	"github.com/grafana/grafana-azure-sdk-go/azsettings"
@@ -19,8 +20,18 @@ const (
 	azureResourceGraph = "Azure Resource Graph"
 	azureTraces        = "Azure Traces"
 	azurePortal        = "Azure Portal"
+
+	azureMonitorMetricsBatch = "Azure Monitor Metrics Batch"
 )
 
+// metricsBatchAudiences are the endpoints of the Azure Monitor metrics batch API, which aren't part of the
+// Azure cloud settings. The requests go to the endpoint of the region of the resources.
+var metricsBatchAudiences = map[string]string{
+	azsettings.AzurePublic:       "https://metrics.monitor.azure.com",
+	azsettings.AzureChina:        "https://metrics.monitor.azure.cn",
+	azsettings.AzureUSGovernment: "https://metrics.monitor.azure.us",
+}
+
 func getAzureMonitorRoutes(settings *azsettings.AzureSettings, credentials azcredentials.AzureCredentials, jsonData json.RawMessage) (map[string]types.AzRoute, error) {
 	azureCloud, err := azcredentials.GetAzureCloud(settings, credentials)
 	if err != nil {
@@ -85,6 +96,18 @@ func getAzureMonitorRoutes(settings *azsettings.AzureSettings, credentials azcre
 		azurePortal:        portalRoute,
 	}
 
+	if metricsBatchUrl, ok := metricsBatchAudiences[azureCloud]; ok {
+		metricsBatchScopes, err := audienceToScopes(metricsBatchUrl)
+		if err != nil {
+			return nil, err
+		}
+		routes[azureMonitorMetricsBatch] = types.AzRoute{
+			URL:     strings.Replace(metricsBatchUrl, "://", "://{region}.", 1),
+			Scopes:  metricsBatchScopes,
+			Headers: map[string]string{"x-ms-app": "Grafana"},
+		}
+	}
+
 	return routes, nil
 }
 
@@ -76,6 +76,8 @@ type AzureMonitorQuery struct {
 	Dimensions   []dataquery.AzureMetricDimension
 	Resources    map[string]dataquery.AzureMonitorResource
 	Subscription string
+	// DimensionFilter is the part of the filter on the dimensions, without the resources
+	DimensionFilter string
 }
 
 // AzureMonitorResponse is the json response from the Azure Monitor API
@@ -92,14 +94,8 @@ type AzureMonitorResponse struct {
 		} `json:"name"`
 		Unit       string `json:"unit"`
 		Timeseries []struct {
-			Metadatavalues []struct {
-				Name struct {
-					Value          string `json:"value"`
-					LocalizedValue string `json:"localizedValue"`
-				} `json:"name"`
-				Value string `json:"value"`
-			} `json:"metadatavalues"`
-			Data []struct {
+			Metadatavalues []AzureMonitorMetadataValue `json:"metadatavalues"`
+			Data           []struct {
 				TimeStamp time.Time `json:"timeStamp"`
 				Average   *float64  `json:"average,omitempty"`
 				Total     *float64  `json:"total,omitempty"`
@@ -108,11 +104,35 @@ type AzureMonitorResponse struct {
 				Minimum   *float64  `json:"minimum,omitempty"`
 			} `json:"data"`
 		} `json:"timeseries"`
+		ErrorCode    string `json:"errorCode,omitempty"`
+		ErrorMessage string `json:"errorMessage,omitempty"`
 	} `json:"value"`
 	Namespace      string `json:"namespace"`
 	Resourceregion string `json:"resourceregion"`
 }
 
+// AzureMonitorMetadataValue is the value of a dimension of an Azure Monitor time series
+type AzureMonitorMetadataValue struct {
+	Name struct {
+		Value          string `json:"value"`
+		LocalizedValue string `json:"localizedValue"`
+	} `json:"name"`
+	Value string `json:"value"`
+}
+
+// AzureMonitorBatchResponse is the json response from the Azure Monitor metrics batch API
+type AzureMonitorBatchResponse struct {
+	Values []AzureMonitorBatchValue `json:"values"`
+}
+
+// AzureMonitorBatchValue holds the metrics of one of the resources of a metrics batch request
+type AzureMonitorBatchValue struct {
+	AzureMonitorResponse
+	StartTime  string `json:"starttime"`
+	EndTime    string `json:"endtime"`
+	ResourceID string `json:"resourceid"`
+}
+
 // AzureResponseTable is the table format for Azure responses
 type AzureResponseTable struct {
 	Name    string `json:"name"`
@@ -0,0 +1,96 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/models/resources"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/utils"
+	"github.com/patrickmn/go-cache"
+)
+
+// cachedMetricsClient caches the results of ListMetrics, since metric and dimension discovery is requested on every
+// dashboard load and ListMetrics is throttled by AWS
+type cachedMetricsClient struct {
+	models.MetricsClientProvider
+	cache  *cache.Cache
+	region string
+}
+
+// NewCachedMetricsClient returns a client that caches the metrics listed by the client in the region. Caching is
+// disabled when the cache is nil.
+func NewCachedMetricsClient(client models.MetricsClientProvider, c *cache.Cache, region string) models.MetricsClientProvider {
+	if c == nil {
+		return client
+	}
+	return &cachedMetricsClient{MetricsClientProvider: client, cache: c, region: region}
+}
+
+func (c *cachedMetricsClient) ListMetricsWithPageLimit(ctx context.Context, params *cloudwatch.ListMetricsInput) ([]resources.MetricResponse, error) {
+	return getOrFetch(c.cache, utils.ListMetricsLabel, c.region, params, func() ([]resources.MetricResponse, error) {
+		return c.MetricsClientProvider.ListMetricsWithPageLimit(ctx, params)
+	})
+}
+
+// cachedOAMClient caches the sinks and the links of the monitoring account, which are used to discover the
+// accounts whose metrics and logs can be queried through the monitoring account
+type cachedOAMClient struct {
+	models.OAMAPIProvider
+	cache  *cache.Cache
+	region string
+}
+
+// NewCachedOAMClient returns a client that caches the sinks and attached links listed by the client in the region.
+// Caching is disabled when the cache is nil.
+func NewCachedOAMClient(client models.OAMAPIProvider, c *cache.Cache, region string) models.OAMAPIProvider {
+	if c == nil {
+		return client
+	}
+	return &cachedOAMClient{OAMAPIProvider: client, cache: c, region: region}
+}
+
+func (c *cachedOAMClient) ListSinksWithContext(ctx context.Context, in *oam.ListSinksInput, opts ...request.Option) (*oam.ListSinksOutput, error) {
+	return getOrFetch(c.cache, utils.ListSinksLabel, c.region, in, func() (*oam.ListSinksOutput, error) {
+		return c.OAMAPIProvider.ListSinksWithContext(ctx, in, opts...)
+	})
+}
+
+func (c *cachedOAMClient) ListAttachedLinksWithContext(ctx context.Context, in *oam.ListAttachedLinksInput, opts ...request.Option) (*oam.ListAttachedLinksOutput, error) {
+	return getOrFetch(c.cache, utils.ListAttachedLinksLabel, c.region, in, func() (*oam.ListAttachedLinksOutput, error) {
+		return c.OAMAPIProvider.ListAttachedLinksWithContext(ctx, in, opts...)
+	})
+}
+
+func getOrFetch[T any](c *cache.Cache, kind string, region string, input any, fetch func() (T, error)) (T, error) {
+	key, err := CacheKey(kind, region, input)
+	if err != nil {
+		var empty T
+		return empty, err
+	}
+	if cached, found := c.Get(key); found {
+		utils.CacheRequestsTotalCounter.WithLabelValues(kind, utils.CacheHitLabel).Inc()
+		return cached.(T), nil
+	}
+	utils.CacheRequestsTotalCounter.WithLabelValues(kind, utils.CacheMissLabel).Inc()
+
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+	c.SetDefault(key, value)
+	return value, nil
+}
+
+// CacheKey returns the key of the response to the input of an AWS API call in the region
+func CacheKey(kind string, region string, input any) (string, error) {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return "", fmt.Errorf("failed to build the cache key: %w", err)
+	}
+	return fmt.Sprintf("%s-%s-%s", kind, region, b), nil
+}
@@ -0,0 +1,82 @@
+package clients
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/oam"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedMetricsClient(t *testing.T) {
+	ctx := context.Background()
+	metrics := []*cloudwatch.Metric{{MetricName: aws.String("CPUUtilization"), Namespace: aws.String("AWS/EC2")}}
+
+	t.Run("lists the metrics once per input and region", func(t *testing.T) {
+		api := &mocks.MetricsAPI{Metrics: metrics}
+		api.On("ListMetricsPagesWithContext").Return(nil).Times(3)
+		c := cache.New(time.Minute, time.Minute)
+
+		client := NewCachedMetricsClient(NewMetricsClient(api, 100), c, "us-east-1")
+		for i := 0; i < 2; i++ {
+			response, err := client.ListMetricsWithPageLimit(ctx, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")})
+			require.NoError(t, err)
+			require.Len(t, response, 1)
+			assert.Equal(t, "CPUUtilization", *response[0].MetricName)
+		}
+
+		_, err := client.ListMetricsWithPageLimit(ctx, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2"), OwningAccount: aws.String("123456789012")})
+		require.NoError(t, err)
+		_, err = NewCachedMetricsClient(NewMetricsClient(api, 100), c, "eu-west-1").ListMetricsWithPageLimit(ctx, &cloudwatch.ListMetricsInput{Namespace: aws.String("AWS/EC2")})
+		require.NoError(t, err)
+
+		api.AssertExpectations(t)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		api := &mocks.MetricsAPI{}
+		api.On("ListMetricsPagesWithContext").Return(assert.AnError).Twice()
+		client := NewCachedMetricsClient(NewMetricsClient(api, 100), cache.New(time.Minute, time.Minute), "us-east-1")
+
+		for i := 0; i < 2; i++ {
+			_, err := client.ListMetricsWithPageLimit(ctx, &cloudwatch.ListMetricsInput{})
+			require.ErrorIs(t, err, assert.AnError)
+		}
+		api.AssertExpectations(t)
+	})
+
+	t.Run("caching is disabled without a cache", func(t *testing.T) {
+		metricsClient := NewMetricsClient(&mocks.MetricsAPI{}, 100)
+		assert.Equal(t, metricsClient, NewCachedMetricsClient(metricsClient, nil, "us-east-1"))
+	})
+}
+
+func TestCachedOAMClient(t *testing.T) {
+	ctx := context.Background()
+	api := &mocks.FakeOAMClient{}
+	api.On("ListSinksWithContext", &oam.ListSinksInput{}).Return(&oam.ListSinksOutput{
+		Items: []*oam.ListSinksItem{{Arn: aws.String("arn:aws:oam:us-east-1:123456789012:sink/sink-id"), Name: aws.String("sink")}},
+	}, nil).Once()
+	linksInput := &oam.ListAttachedLinksInput{SinkIdentifier: aws.String("arn:aws:oam:us-east-1:123456789012:sink/sink-id")}
+	api.On("ListAttachedLinksWithContext", linksInput).Return(&oam.ListAttachedLinksOutput{
+		Items: []*oam.ListAttachedLinksItem{{Label: aws.String("linked"), LinkArn: aws.String("arn:aws:oam:us-east-1:210987654321:link/link-id")}},
+	}, nil).Once()
+
+	client := NewCachedOAMClient(api, cache.New(time.Minute, time.Minute), "us-east-1")
+	for i := 0; i < 2; i++ {
+		sinks, err := client.ListSinksWithContext(ctx, &oam.ListSinksInput{})
+		require.NoError(t, err)
+		require.Len(t, sinks.Items, 1)
+
+		links, err := client.ListAttachedLinksWithContext(ctx, linksInput)
+		require.NoError(t, err)
+		require.Len(t, links.Items, 1)
+	}
+	api.AssertExpectations(t)
+}
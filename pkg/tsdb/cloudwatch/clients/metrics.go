@@ -30,7 +30,7 @@ func (l *metricsClient) ListMetricsWithPageLimit(ctx context.Context, params *cl
 		if err == nil {
 			for idx, metric := range metrics {
 				metric := resources.MetricResponse{Metric: metric.(*cloudwatch.Metric)}
-				if len(page.OwningAccounts) >= idx && params.IncludeLinkedAccounts != nil && *params.IncludeLinkedAccounts {
+				if idx < len(page.OwningAccounts) && params.IncludeLinkedAccounts != nil && *params.IncludeLinkedAccounts {
 					metric.AccountId = page.OwningAccounts[idx]
 				}
 				cloudWatchMetrics = append(cloudWatchMetrics, metric)
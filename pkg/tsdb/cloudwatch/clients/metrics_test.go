@@ -75,6 +75,16 @@ func TestMetricsClient(t *testing.T) {
 		require.NoError(t, err)
 		assert.Nil(t, response[0].AccountId)
 	})
+
+	t.Run("Should not fail in case fewer owning accounts than metrics are returned", func(t *testing.T) {
+		fakeApi := &mocks.FakeMetricsAPI{Metrics: []*cloudwatch.Metric{{MetricName: aws.String("Test_MetricName1")}}}
+		client := NewMetricsClient(fakeApi, 100)
+
+		response, err := client.ListMetricsWithPageLimit(ctx, &cloudwatch.ListMetricsInput{IncludeLinkedAccounts: aws.Bool(true)})
+		require.NoError(t, err)
+		require.Len(t, response, 1)
+		assert.Nil(t, response[0].AccountId)
+	})
 }
 
 func stringPtr(s string) *string { return &s }
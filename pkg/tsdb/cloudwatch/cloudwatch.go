@@ -31,6 +31,11 @@ import (
 
 const (
 	tagValueCacheExpiration = time.Hour * 24
+	// discoveryCacheExpiration is the expiration of the cached metrics, dimensions and linked accounts
+	discoveryCacheExpiration = time.Minute * 5
+	// maxMetricDataCacheExpiration caps the expiration of the cached GetMetricData responses, which otherwise expire
+	// after the period of the queries
+	maxMetricDataCacheExpiration = time.Minute * 5
 
 	// headerFromExpression is used by datasources to identify expression queries
 	headerFromExpression = "X-Grafana-From-Expr"
@@ -49,7 +54,11 @@ type DataSource struct {
 	HTTPClient    *http.Client
 	sessions      SessionCache
 	tagValueCache *cache.Cache
-	ProxyOpts     *proxy.Options
+	// discoveryCache holds the responses of ListMetrics and of the OAM APIs
+	discoveryCache *cache.Cache
+	// metricDataCache holds the GetMetricData responses, keyed on the period aligned time range
+	metricDataCache *cache.Cache
+	ProxyOpts       *proxy.Options
 }
 
 const (
@@ -111,10 +120,12 @@ func NewInstanceSettings(httpClientProvider *httpclient.Provider) datasource.Ins
 		}
 
 		return DataSource{
-			Settings:      instanceSettings,
-			HTTPClient:    httpClient,
-			tagValueCache: cache.New(tagValueCacheExpiration, tagValueCacheExpiration*5),
-			sessions:      awsds.NewSessionCache(),
+			Settings:        instanceSettings,
+			HTTPClient:      httpClient,
+			tagValueCache:   cache.New(tagValueCacheExpiration, tagValueCacheExpiration*5),
+			discoveryCache:  cache.New(discoveryCacheExpiration, discoveryCacheExpiration*5),
+			metricDataCache: cache.New(maxMetricDataCacheExpiration, maxMetricDataCacheExpiration*5),
+			sessions:        awsds.NewSessionCache(),
 			// this is used to build a custom dialer when secure socks proxy is enabled
 			ProxyOpts: opts.ProxyOptions,
 		}, nil
@@ -164,9 +175,12 @@ func (e *cloudWatchExecutor) getRequestContext(ctx context.Context, pluginCtx ba
 		return models.RequestContext{}, err
 	}
 
+	// metric and account discovery is cached, since it's requested on every load of a dashboard or query editor
+	metricsClient := clients.NewMetricsClient(NewMetricsAPI(sess), instance.Settings.GrafanaSettings.ListMetricsPageLimit)
+
 	return models.RequestContext{
-		OAMAPIProvider:        NewOAMAPI(sess),
-		MetricsClientProvider: clients.NewMetricsClient(NewMetricsAPI(sess), instance.Settings.GrafanaSettings.ListMetricsPageLimit),
+		OAMAPIProvider:        clients.NewCachedOAMClient(NewOAMAPI(sess), instance.discoveryCache, r),
+		MetricsClientProvider: clients.NewCachedMetricsClient(metricsClient, instance.discoveryCache, r),
 		LogsAPIProvider:       NewLogsAPI(sess),
 		EC2APIProvider:        ec2Client,
 		Settings:              instance.Settings,
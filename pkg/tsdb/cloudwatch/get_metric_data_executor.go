@@ -2,11 +2,15 @@ package cloudwatch
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/clients"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/utils"
+	"github.com/patrickmn/go-cache"
 )
 
 func (e *cloudWatchExecutor) executeRequest(ctx context.Context, client cloudwatchiface.CloudWatchAPI,
@@ -33,3 +37,47 @@ func (e *cloudWatchExecutor) executeRequest(ctx context.Context, client cloudwat
 
 	return mdo, nil
 }
+
+// executeCachedRequest executes the request unless it was executed for the same period aligned time range before.
+// The responses expire after the period, so that the data of the period in progress is refreshed.
+func (e *cloudWatchExecutor) executeCachedRequest(ctx context.Context, client cloudwatchiface.CloudWatchAPI, metricDataCache *cache.Cache,
+	region string, period time.Duration, metricDataInput *cloudwatch.GetMetricDataInput) ([]*cloudwatch.GetMetricDataOutput, error) {
+	if metricDataCache == nil || period <= 0 {
+		return e.executeRequest(ctx, client, metricDataInput)
+	}
+
+	alignedInput := *metricDataInput
+	alignedInput.StartTime = aws.Time(metricDataInput.StartTime.Truncate(period))
+	alignedInput.EndTime = aws.Time(metricDataInput.EndTime.Truncate(period))
+	key, err := clients.CacheKey(utils.GetMetricDataLabel, region, alignedInput)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached, found := metricDataCache.Get(key); found {
+		utils.CacheRequestsTotalCounter.WithLabelValues(utils.GetMetricDataLabel, utils.CacheHitLabel).Inc()
+		return copyMetricDataOutputs(cached.([]*cloudwatch.GetMetricDataOutput)), nil
+	}
+	utils.CacheRequestsTotalCounter.WithLabelValues(utils.GetMetricDataLabel, utils.CacheMissLabel).Inc()
+
+	mdo, err := e.executeRequest(ctx, client, metricDataInput)
+	if err != nil {
+		return mdo, err
+	}
+
+	expiration := period
+	if expiration > maxMetricDataCacheExpiration {
+		expiration = maxMetricDataCacheExpiration
+	}
+	// the parsing of the responses modifies them, so the cache holds a copy
+	metricDataCache.Set(key, copyMetricDataOutputs(mdo), expiration)
+	return mdo, nil
+}
+
+func copyMetricDataOutputs(outputs []*cloudwatch.GetMetricDataOutput) []*cloudwatch.GetMetricDataOutput {
+	copied := make([]*cloudwatch.GetMetricDataOutput, 0, len(outputs))
+	for _, output := range outputs {
+		copied = append(copied, awsutil.CopyOf(output).(*cloudwatch.GetMetricDataOutput))
+	}
+	return copied
+}
@@ -3,10 +3,12 @@ package cloudwatch
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/grafana/grafana/pkg/tsdb/cloudwatch/mocks"
+	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -32,3 +34,53 @@ func TestGetMetricDataExecutorTest(t *testing.T) {
 	assert.Equal(t, 23.5, *res[0].MetricDataResults[0].Values[1])
 	assert.Equal(t, 100.0, *res[1].MetricDataResults[0].Values[0])
 }
+
+func TestGetMetricDataExecutor_executeCachedRequest(t *testing.T) {
+	executor := &cloudWatchExecutor{}
+	start := time.Date(2024, time.January, 1, 12, 0, 30, 0, time.UTC)
+	newInput := func(offset time.Duration) *cloudwatch.GetMetricDataInput {
+		return &cloudwatch.GetMetricDataInput{
+			StartTime:         aws.Time(start.Add(offset)),
+			EndTime:           aws.Time(start.Add(time.Hour + offset)),
+			MetricDataQueries: []*cloudwatch.MetricDataQuery{{Id: aws.String("a")}},
+		}
+	}
+
+	t.Run("executes the request once per period", func(t *testing.T) {
+		mockMetricClient := &mocks.MetricsAPI{}
+		mockMetricClient.On("GetMetricDataWithContext", mock.Anything, mock.Anything, mock.Anything).Return(
+			&cloudwatch.GetMetricDataOutput{
+				MetricDataResults: []*cloudwatch.MetricDataResult{{Id: aws.String("a"), Values: []*float64{aws.Float64(1)}}},
+			}, nil)
+		metricDataCache := cache.New(time.Minute, time.Minute)
+
+		res, err := executor.executeCachedRequest(context.Background(), mockMetricClient, metricDataCache, "us-east-1", time.Minute, newInput(0))
+		require.NoError(t, err)
+		// the responses are modified when they are parsed
+		res[0].MetricDataResults[0].Values = append(res[0].MetricDataResults[0].Values, aws.Float64(2))
+
+		res, err = executor.executeCachedRequest(context.Background(), mockMetricClient, metricDataCache, "us-east-1", time.Minute, newInput(20*time.Second))
+		require.NoError(t, err)
+		require.Len(t, res[0].MetricDataResults[0].Values, 1)
+		mockMetricClient.AssertNumberOfCalls(t, "GetMetricDataWithContext", 1)
+
+		_, err = executor.executeCachedRequest(context.Background(), mockMetricClient, metricDataCache, "us-east-1", time.Minute, newInput(time.Minute))
+		require.NoError(t, err)
+		_, err = executor.executeCachedRequest(context.Background(), mockMetricClient, nil, "us-east-1", time.Minute, newInput(time.Minute))
+		require.NoError(t, err)
+		mockMetricClient.AssertNumberOfCalls(t, "GetMetricDataWithContext", 3)
+	})
+
+	t.Run("does not cache errors", func(t *testing.T) {
+		mockMetricClient := &mocks.MetricsAPI{}
+		mockMetricClient.On("GetMetricDataWithContext", mock.Anything, mock.Anything, mock.Anything).Return(
+			&cloudwatch.GetMetricDataOutput{}, assert.AnError)
+		metricDataCache := cache.New(time.Minute, time.Minute)
+
+		for i := 0; i < 2; i++ {
+			_, err := executor.executeCachedRequest(context.Background(), mockMetricClient, metricDataCache, "us-east-1", time.Minute, newInput(0))
+			require.ErrorIs(t, err, assert.AnError)
+		}
+		mockMetricClient.AssertNumberOfCalls(t, "GetMetricDataWithContext", 2)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
@@ -55,6 +56,12 @@ func (e *cloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, req *ba
 		requestQueriesByRegion[query.Region] = append(requestQueriesByRegion[query.Region], query)
 	}
 
+	// alert and expression queries are always evaluated on the latest data
+	metricDataCache := instance.metricDataCache
+	if _, fromAlert := req.Headers[headerFromAlert]; fromAlert || req.GetHTTPHeader(headerFromExpression) != "" {
+		metricDataCache = nil
+	}
+
 	resultChan := make(chan *responseWrapper, len(req.Queries))
 	eg, ectx := errgroup.WithContext(ctx)
 	for r, regionQueries := range requestQueriesByRegion {
@@ -91,7 +98,7 @@ func (e *cloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, req *ba
 					return err
 				}
 
-				mdo, err := e.executeRequest(ectx, client, metricDataInput)
+				mdo, err := e.executeCachedRequest(ectx, client, metricDataCache, region, getMinPeriod(requestQueries), metricDataInput)
 				if err != nil {
 					return err
 				}
@@ -135,6 +142,17 @@ func (e *cloudWatchExecutor) executeTimeSeriesQuery(ctx context.Context, req *ba
 	return resp, nil
 }
 
+// getMinPeriod returns the shortest period of the queries, which is the period the cached responses are aligned on
+func getMinPeriod(queries []*models.CloudWatchQuery) time.Duration {
+	minPeriod := 0
+	for _, query := range queries {
+		if query.Period > 0 && (minPeriod == 0 || query.Period < minPeriod) {
+			minPeriod = query.Period
+		}
+	}
+	return time.Duration(minPeriod) * time.Second
+}
+
 func getQueryRefIdFromErrorString(err string, queries []*models.CloudWatchQuery) string {
 	// error can be in format "Error in expression 'test': Invalid syntax"
 	// so we can find the query id or ref id between the quotations
@@ -8,8 +8,15 @@ import (
 const (
 	// Labels for the metric counter query types
 
-	ListMetricsLabel   = "list_metrics"
-	GetMetricDataLabel = "get_metric_data"
+	ListMetricsLabel       = "list_metrics"
+	GetMetricDataLabel     = "get_metric_data"
+	ListSinksLabel         = "list_sinks"
+	ListAttachedLinksLabel = "list_attached_links"
+
+	// Labels for the cache request results
+
+	CacheHitLabel  = "hit"
+	CacheMissLabel = "miss"
 )
 
 var QueriesTotalCounter = promauto.NewCounterVec(
@@ -20,3 +27,12 @@ var QueriesTotalCounter = promauto.NewCounterVec(
 	},
 	[]string{"query_type"},
 )
+
+var CacheRequestsTotalCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "grafana_plugin",
+		Name:      "aws_cloudwatch_cache_requests_total",
+		Help:      "Counter for the AWS responses looked up in the cache, by query type and result",
+	},
+	[]string{"query_type", "result"},
+)
@@ -51,6 +51,7 @@ type ConfiguredFields struct {
 type Client interface {
 	GetConfiguredFields() ConfiguredFields
 	ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearchResponse, error)
+	ExecuteSQL(r *SQLRequest) (*SQLResponse, error)
 	MultiSearch() *MultiSearchRequestBuilder
 }
 
@@ -133,20 +134,7 @@ func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte,
 }
 
 func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body []byte) (*http.Response, error) {
-	c.logger.Debug("Sending request to Elasticsearch", "url", c.ds.URL)
-	u, err := url.Parse(c.ds.URL)
-	if err != nil {
-		return nil, err
-	}
-	u.Path = path.Join(u.Path, uriPath)
-	u.RawQuery = uriQuery
-
-	var req *http.Request
-	if method == http.MethodPost {
-		req, err = http.NewRequestWithContext(c.ctx, http.MethodPost, u.String(), bytes.NewBuffer(body))
-	} else {
-		req, err = http.NewRequestWithContext(c.ctx, http.MethodGet, u.String(), nil)
-	}
+	req, err := c.newRequest(method, uriPath, uriQuery, body)
 	if err != nil {
 		return nil, err
 	}
@@ -161,6 +149,21 @@ func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body [
 	return resp, nil
 }
 
+func (c *baseClientImpl) newRequest(method, uriPath, uriQuery string, body []byte) (*http.Request, error) {
+	c.logger.Debug("Sending request to Elasticsearch", "url", c.ds.URL)
+	u, err := url.Parse(c.ds.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, uriPath)
+	u.RawQuery = uriQuery
+
+	if method == http.MethodPost {
+		return http.NewRequestWithContext(c.ctx, http.MethodPost, u.String(), bytes.NewBuffer(body))
+	}
+	return http.NewRequestWithContext(c.ctx, http.MethodGet, u.String(), nil)
+}
+
 func (c *baseClientImpl) ExecuteMultisearch(r *MultiSearchRequest) (*MultiSearchResponse, error) {
 	var err error
 	multiRequests := c.createMultiSearchRequests(r.Requests)
@@ -0,0 +1,291 @@
+package es
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// SQLLanguage is the Elasticsearch SQL language, queries are sent to the _sql endpoint.
+	SQLLanguage = "sql"
+	// ESQLLanguage is the Elasticsearch Query Language, queries are sent to the _query endpoint.
+	ESQLLanguage = "esql"
+)
+
+// SQLRequest represents a request of a tabular query language
+type SQLRequest struct {
+	Language string
+	Query    string
+	// FetchSize is the maximum number of rows returned.
+	FetchSize int
+	// Filter restricts the documents the query runs on, it is used to apply the time range.
+	Filter *Query
+	// TimeRange selects the indices of the configured index pattern the query runs on.
+	TimeRange backend.TimeRange
+}
+
+// SQLColumn represents a column of a tabular response
+type SQLColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// SQLResponse represents the tabular response of the _sql and _query endpoints
+type SQLResponse struct {
+	Columns []SQLColumn `json:"columns"`
+	// Rows are the rows of a SQL response, Values the rows of an ES|QL response.
+	Rows   [][]any `json:"rows"`
+	Values [][]any `json:"values"`
+	// Cursor is set if a SQL response has more rows than the fetch size.
+	Cursor string `json:"cursor"`
+	// Query is the query sent to Elasticsearch, with the indices of the data source in the FROM clause.
+	Query string `json:"-"`
+}
+
+// GetRows returns the rows of the response regardless of the language.
+func (r *SQLResponse) GetRows() [][]any {
+	if r.Values != nil {
+		return r.Values
+	}
+	return r.Rows
+}
+
+// ExecuteSQL runs a tabular query with the _sql or _query endpoint.
+func (c *baseClientImpl) ExecuteSQL(r *SQLRequest) (*SQLResponse, error) {
+	indices, err := c.indexPattern.GetIndices(r.TimeRange)
+	if err != nil {
+		return nil, err
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("the data source has no index configured")
+	}
+	query, err := restrictToIndex(r.Language, r.Query, strings.Join(indices, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	var uriPath, uriQuery string
+	body := map[string]any{
+		"query": query,
+	}
+	if r.Filter != nil {
+		body["filter"] = r.Filter
+	}
+	switch r.Language {
+	case SQLLanguage:
+		uriPath, uriQuery = "_sql", "format=json"
+		if r.FetchSize > 0 {
+			body["fetch_size"] = r.FetchSize
+		}
+		body["time_zone"] = "Z"
+	case ESQLLanguage:
+		uriPath = "_query"
+	default:
+		return nil, fmt.Errorf("unsupported query language %q", r.Language)
+	}
+
+	_, span := c.tracer.Start(c.ctx, "datasource.elasticsearch.queryData.executeSQL", trace.WithAttributes(
+		attribute.String("language", r.Language),
+		attribute.String("url", c.ds.URL),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	res, err := c.executeJSONRequest(uriPath, uriQuery, reqBody)
+	if err != nil {
+		c.logger.Error("Error received from Elasticsearch", "error", err, "language", r.Language, "duration", time.Since(start), "stage", StageDatabaseRequest)
+		return nil, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			c.logger.Warn("Failed to close response body", "error", err)
+		}
+	}()
+	c.logger.Info("Response received from Elasticsearch", "statusCode", res.StatusCode, "language", r.Language, "duration", time.Since(start), "stage", StageDatabaseRequest)
+
+	if res.StatusCode/100 != 2 {
+		err = readSQLError(res)
+		return nil, err
+	}
+
+	// the numbers are decoded as json.Number, so the long values keep their precision
+	var sr SQLResponse
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err = dec.Decode(&sr); err != nil {
+		c.logger.Error("Failed to decode response from Elasticsearch", "error", err)
+		return nil, err
+	}
+	sr.Query = query
+
+	if sr.Cursor != "" {
+		// the remaining rows are not read, the cursor is closed to free its resources
+		closeBody, _ := json.Marshal(map[string]string{"cursor": sr.Cursor})
+		if closeRes, err := c.executeJSONRequest("_sql/close", "", closeBody); err != nil {
+			c.logger.Warn("Failed to close SQL cursor", "error", err)
+		} else {
+			_ = closeRes.Body.Close()
+		}
+	}
+	return &sr, nil
+}
+
+func (c *baseClientImpl) executeJSONRequest(uriPath, uriQuery string, body []byte) (*http.Response, error) {
+	req, err := c.newRequest(http.MethodPost, uriPath, uriQuery, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	//nolint:bodyclose
+	return c.ds.HTTPClient.Do(req)
+}
+
+// readSQLError returns the reason of an error response of the _sql and _query endpoints.
+func readSQLError(res *http.Response) error {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	var e struct {
+		Error struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &e); err != nil || e.Error.Reason == "" {
+		return fmt.Errorf("request failed, status: %s, body: %s", res.Status, string(body))
+	}
+	return fmt.Errorf("%s: %s", e.Error.Type, e.Error.Reason)
+}
+
+// restrictToIndex replaces the sources of the FROM clauses of a query with the indices of the data source, so the
+// queries only read the indices the data source is configured with.
+func restrictToIndex(language, query, index string) (string, error) {
+	switch language {
+	case SQLLanguage:
+		return restrictSQLToIndex(query, index)
+	case ESQLLanguage:
+		return restrictESQLToIndex(query, index)
+	default:
+		return "", fmt.Errorf("unsupported query language %q", language)
+	}
+}
+
+// restrictSQLToIndex replaces the table of every FROM clause of a SELECT statement, the sub-selects are kept. The
+// string literals are skipped, a column named from has to be quoted in Elasticsearch SQL.
+func restrictSQLToIndex(query, index string) (string, error) {
+	if !strings.EqualFold(firstWord(strings.TrimLeft(query, " \t\r\n(")), "SELECT") {
+		return "", fmt.Errorf("sql queries should be SELECT statements")
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			end := quotedEnd(query, i)
+			b.WriteString(query[i:end])
+			i = end
+		case isWordByte(c):
+			word := firstWord(query[i:])
+			b.WriteString(word)
+			i += len(word)
+			if !strings.EqualFold(word, "FROM") {
+				continue
+			}
+			j := skipSpace(query, i)
+			if j < len(query) && query[j] == '(' {
+				continue
+			}
+			b.WriteString(query[i:j])
+			b.WriteString(`"` + index + `"`)
+			i = sourceEnd(query, j, ",;()")
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// restrictESQLToIndex replaces the sources of the FROM command, which has to be the source command of the query.
+func restrictESQLToIndex(query, index string) (string, error) {
+	start := skipSpace(query, 0)
+	if !strings.EqualFold(firstWord(query[start:]), "FROM") {
+		return "", fmt.Errorf("esql queries should start with a FROM command")
+	}
+
+	i := skipSpace(query, start+len("FROM"))
+	sources := i
+	for {
+		i = skipSpace(query, sourceEnd(query, i, ",|"))
+		if i >= len(query) || query[i] != ',' {
+			break
+		}
+		i = skipSpace(query, i+1)
+	}
+	if i == len(query) {
+		return query[:sources] + index, nil
+	}
+	return query[:sources] + index + " " + query[i:], nil
+}
+
+// sourceEnd returns the end of the index name starting at i, which is quoted or ends at a space or a separator.
+func sourceEnd(query string, i int, separators string) int {
+	if i < len(query) && (query[i] == '"' || query[i] == '`') {
+		return quotedEnd(query, i)
+	}
+	for i < len(query) && !unicode.IsSpace(rune(query[i])) && !strings.ContainsRune(separators, rune(query[i])) {
+		i++
+	}
+	return i
+}
+
+// quotedEnd returns the position after the closing quote of the quoted text starting at i.
+func quotedEnd(query string, i int) int {
+	end := strings.IndexByte(query[i+1:], query[i])
+	if end < 0 {
+		return len(query)
+	}
+	return i + end + 2
+}
+
+func firstWord(s string) string {
+	i := 0
+	for i < len(s) && isWordByte(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '@' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func skipSpace(s string, i int) int {
+	for i < len(s) && unicode.IsSpace(rune(s[i])) {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,105 @@
+package es
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestClient_ExecuteSQL(t *testing.T) {
+	var paths []string
+	var bodies []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?"+r.URL.RawQuery)
+		buf, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(buf, &body))
+		bodies = append(bodies, body)
+
+		switch r.URL.Path {
+		case "/_sql":
+			_, _ = rw.Write([]byte(`{"columns": [{"name": "host", "type": "keyword"}], "rows": [["a"], ["b"]], "cursor": "c1"}`))
+		case "/_query":
+			if strings.Contains(body["query"].(string), "broken") {
+				rw.WriteHeader(http.StatusBadRequest)
+				_, _ = rw.Write([]byte(`{"error": {"type": "verification_exception", "reason": "Unknown index [broken]"}}`))
+				return
+			}
+			_, _ = rw.Write([]byte(`{"columns": [{"name": "count", "type": "long"}], "values": [[3]]}`))
+		default:
+			_, _ = rw.Write([]byte(`{"succeeded": true}`))
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	ds := DatasourceInfo{URL: ts.URL, HTTPClient: ts.Client(), Database: "logs"}
+	c, err := NewClient(context.Background(), &ds, log.New("test", "test"), tracing.InitializeTracerForTest())
+	require.NoError(t, err)
+
+	t.Run("sql closes the cursor", func(t *testing.T) {
+		res, err := c.ExecuteSQL(&SQLRequest{Language: SQLLanguage, Query: "SELECT host FROM logs", FetchSize: 2})
+		require.NoError(t, err)
+		require.Equal(t, [][]any{{"a"}, {"b"}}, res.GetRows())
+		require.Equal(t, []string{"/_sql?format=json", "/_sql/close?"}, paths[len(paths)-2:])
+		require.Equal(t, float64(2), bodies[len(bodies)-2]["fetch_size"])
+		require.Equal(t, "c1", bodies[len(bodies)-1]["cursor"])
+		require.Equal(t, `SELECT host FROM "logs"`, res.Query)
+	})
+
+	t.Run("esql", func(t *testing.T) {
+		res, err := c.ExecuteSQL(&SQLRequest{Language: ESQLLanguage, Query: "FROM logs | STATS count = COUNT(*)"})
+		require.NoError(t, err)
+		require.Equal(t, []SQLColumn{{Name: "count", Type: "long"}}, res.Columns)
+		require.Equal(t, [][]any{{json.Number("3")}}, res.GetRows())
+		require.Equal(t, "/_query?", paths[len(paths)-1])
+		require.Equal(t, "FROM logs | STATS count = COUNT(*)", bodies[len(bodies)-1]["query"])
+	})
+
+	t.Run("returns the reason of errors", func(t *testing.T) {
+		_, err := c.ExecuteSQL(&SQLRequest{Language: ESQLLanguage, Query: "FROM logs | WHERE broken"})
+		require.EqualError(t, err, "verification_exception: Unknown index [broken]")
+
+		_, err = c.ExecuteSQL(&SQLRequest{Language: "ppl", Query: "source=logs"})
+		require.Error(t, err)
+	})
+}
+
+func TestRestrictToIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		query    string
+		expected string
+		err      string
+	}{
+		{name: "sql table", language: SQLLanguage, query: "SELECT host FROM other WHERE a = 1", expected: `SELECT host FROM "logs-*" WHERE a = 1`},
+		{name: "sql quoted table", language: SQLLanguage, query: `select * from "other-*" limit 1`, expected: `select * from "logs-*" limit 1`},
+		{name: "sql sub-select", language: SQLLanguage, query: "SELECT c FROM (SELECT COUNT(*) c FROM other)", expected: `SELECT c FROM (SELECT COUNT(*) c FROM "logs-*")`},
+		{name: "sql string literal", language: SQLLanguage, query: "SELECT host FROM other WHERE msg = 'from other'", expected: `SELECT host FROM "logs-*" WHERE msg = 'from other'`},
+		{name: "sql other statements", language: SQLLanguage, query: "SHOW TABLES", err: "SELECT"},
+		{name: "esql sources", language: ESQLLanguage, query: "FROM a, b METADATA _id | LIMIT 1", expected: "FROM logs-* METADATA _id | LIMIT 1"},
+		{name: "esql single source", language: ESQLLanguage, query: "from other", expected: "from logs-*"},
+		{name: "esql row", language: ESQLLanguage, query: "ROW a = 1", err: "FROM"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := restrictToIndex(tt.language, tt.query, "logs-*")
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, query)
+		})
+	}
+}
@@ -51,6 +51,20 @@ func (e *elasticsearchDataQuery) execute() (*backend.QueryDataResponse, error) {
 		return errorsource.AddPluginErrorToResponse(e.dataQueries[0].RefID, response, err), nil
 	}
 
+	// The sql and esql queries are sent one by one, the other queries in a single multisearch request.
+	searchQueries := make([]*Query, 0, len(queries))
+	for _, q := range queries {
+		if isSQLQuery(q) {
+			response.Responses[q.RefID] = e.executeSQLQuery(q)
+			continue
+		}
+		searchQueries = append(searchQueries, q)
+	}
+	if len(searchQueries) == 0 {
+		return response, nil
+	}
+	queries = searchQueries
+
 	ms := e.client.MultiSearch()
 
 	for _, q := range queries {
@@ -77,7 +91,14 @@ func (e *elasticsearchDataQuery) execute() (*backend.QueryDataResponse, error) {
 		return errorsource.AddErrorToResponse(e.dataQueries[0].RefID, response, err), nil
 	}
 
-	return parseResponse(e.ctx, res.Responses, queries, e.client.GetConfiguredFields(), e.logger, e.tracer)
+	result, err := parseResponse(e.ctx, res.Responses, queries, e.client.GetConfiguredFields(), e.logger, e.tracer)
+	if err != nil {
+		return result, err
+	}
+	for refID, r := range response.Responses {
+		result.Responses[refID] = r
+	}
+	return result, nil
 }
 
 func (e *elasticsearchDataQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilder, from, to int64) error {
@@ -1806,6 +1806,9 @@ type fakeClient struct {
 	multiSearchError    error
 	builder             *es.MultiSearchRequestBuilder
 	multisearchRequests []*es.MultiSearchRequest
+	sqlRequests         []*es.SQLRequest
+	sqlResponse         *es.SQLResponse
+	sqlError            error
 }
 
 func newFakeClient() *fakeClient {
@@ -1831,6 +1834,11 @@ func (c *fakeClient) ExecuteMultisearch(r *es.MultiSearchRequest) (*es.MultiSear
 	return c.multiSearchResponse, c.multiSearchError
 }
 
+func (c *fakeClient) ExecuteSQL(r *es.SQLRequest) (*es.SQLResponse, error) {
+	c.sqlRequests = append(c.sqlRequests, r)
+	return c.sqlResponse, c.sqlError
+}
+
 func (c *fakeClient) MultiSearch() *es.MultiSearchRequestBuilder {
 	c.builder = es.NewMultiSearchRequestBuilder()
 	return c.builder
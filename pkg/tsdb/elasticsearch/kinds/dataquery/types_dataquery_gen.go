@@ -73,6 +73,18 @@ const (
 	PipelineMetricAggregationTypeSerialDiff    PipelineMetricAggregationType = "serial_diff"
 )
 
+// Defines values for QueryFormat.
+const (
+	QueryFormatTable      QueryFormat = "table"
+	QueryFormatTimeSeries QueryFormat = "time_series"
+)
+
+// Defines values for QueryLanguage.
+const (
+	QueryLanguageEsql QueryLanguage = "esql"
+	QueryLanguageSql  QueryLanguage = "sql"
+)
+
 // Defines values for TermsOrder.
 const (
 	TermsOrderAsc  TermsOrder = "asc"
@@ -228,6 +240,9 @@ type ElasticsearchDataQuery struct {
 	// List of bucket aggregations
 	BucketAggs []any `json:"bucketAggs,omitempty"`
 
+	// Format of the results of the sql and esql queries
+	Format *QueryFormat `json:"format,omitempty"`
+
 	// List of metric aggregations
 	Metrics []any `json:"metrics,omitempty"`
 
@@ -495,6 +510,12 @@ type PipelineVariable struct {
 	PipelineAgg string `json:"pipelineAgg"`
 }
 
+// QueryFormat defines model for QueryFormat.
+type QueryFormat string
+
+// QueryLanguage The query type of the queries sent as text to the SQL and ES|QL endpoints, it is empty for the Lucene queries
+type QueryLanguage string
+
 // Rate defines model for Rate.
 type Rate struct {
 	MetricAggregationWithField
@@ -9,10 +9,14 @@ import (
 
 // Query represents the time series query model of the datasource
 type Query struct {
-	RawQuery      string       `json:"query"`
-	BucketAggs    []*BucketAgg `json:"bucketAggs"`
-	Metrics       []*MetricAgg `json:"metrics"`
-	Alias         string       `json:"alias"`
+	RawQuery   string       `json:"query"`
+	BucketAggs []*BucketAgg `json:"bucketAggs"`
+	Metrics    []*MetricAgg `json:"metrics"`
+	Alias      string       `json:"alias"`
+	// QueryType is sql or esql for the queries sent as raw text, it is empty for the Lucene queries.
+	QueryType string `json:"queryType"`
+	// Format is table or time_series, it is used by the sql and esql queries.
+	Format        string `json:"format"`
 	Interval      time.Duration
 	IntervalMs    int64
 	RefID         string
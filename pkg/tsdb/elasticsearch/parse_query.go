@@ -30,6 +30,8 @@ func parseQuery(tsdbQuery []backend.DataQuery, logger log.Logger) ([]*Query, err
 			return nil, err
 		}
 		alias := model.Get("alias").MustString("")
+		queryType := model.Get("queryType").MustString("")
+		format := model.Get("format").MustString("")
 		intervalMs := model.Get("intervalMs").MustInt64(0)
 		interval := q.Interval
 
@@ -38,6 +40,8 @@ func parseQuery(tsdbQuery []backend.DataQuery, logger log.Logger) ([]*Query, err
 			BucketAggs:    bucketAggs,
 			Metrics:       metrics,
 			Alias:         alias,
+			QueryType:     queryType,
+			Format:        format,
 			Interval:      interval,
 			IntervalMs:    intervalMs,
 			RefID:         q.RefID,
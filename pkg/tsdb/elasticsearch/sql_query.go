@@ -0,0 +1,232 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/experimental/errorsource"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+const (
+	timeSeriesFormat = "time_series"
+	// defaultSQLFetchSize is the maximum number of rows of a SQL query.
+	defaultSQLFetchSize = 10000
+)
+
+func isSQLQuery(query *Query) bool {
+	return query.QueryType == es.SQLLanguage || query.QueryType == es.ESQLLanguage
+}
+
+// executeSQLQuery runs a sql or esql query, the time range is applied by filtering on the configured time field.
+func (e *elasticsearchDataQuery) executeSQLQuery(q *Query) backend.DataResponse {
+	if q.RawQuery == "" {
+		return errorsource.Response(errorsource.PluginError(fmt.Errorf("query should not be empty"), false))
+	}
+
+	timeField := e.client.GetConfiguredFields().TimeField
+	from := q.TimeRange.From.UnixNano() / int64(time.Millisecond)
+	to := q.TimeRange.To.UnixNano() / int64(time.Millisecond)
+	qb := es.NewQueryBuilder()
+	qb.Bool().Filter().AddDateRangeFilter(timeField, to, from, es.DateFormatEpochMS)
+	filter, err := qb.Build()
+	if err != nil {
+		return errorsource.Response(errorsource.PluginError(err, false))
+	}
+
+	res, err := e.client.ExecuteSQL(&es.SQLRequest{
+		Language:  q.QueryType,
+		Query:     q.RawQuery,
+		FetchSize: defaultSQLFetchSize,
+		Filter:    filter,
+		TimeRange: q.TimeRange,
+	})
+	if err != nil {
+		return errorsource.Response(errorsource.DownstreamError(err, false))
+	}
+
+	frame, err := sqlResponseToFrame(res, q)
+	if err != nil {
+		return errorsource.Response(errorsource.PluginError(err, false))
+	}
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
+
+// sqlResponseToFrame converts a tabular response to a frame. The date columns become time fields, so the frame can
+// be converted to a wide time series for the time_series format.
+func sqlResponseToFrame(res *es.SQLResponse, q *Query) (*data.Frame, error) {
+	rows := res.GetRows()
+	frame := data.NewFrame(q.RefID)
+	for i, column := range res.Columns {
+		field, err := sqlColumnToField(column, rows, i)
+		if err != nil {
+			return nil, err
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+
+	executedQuery := res.Query
+	if executedQuery == "" {
+		executedQuery = q.RawQuery
+	}
+	frame.Meta = &data.FrameMeta{
+		ExecutedQueryString:    executedQuery,
+		PreferredVisualization: data.VisTypeTable,
+	}
+	if res.Cursor != "" {
+		frame.AppendNotices(data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("The query returned more than %d rows, only the first %d rows are shown", defaultSQLFetchSize, len(rows)),
+		})
+	}
+
+	if q.Format != timeSeriesFormat {
+		return frame, nil
+	}
+	return sqlFrameToTimeSeries(frame)
+}
+
+// sqlFrameToTimeSeries converts a long frame to a wide time series, the string fields become labels.
+func sqlFrameToTimeSeries(frame *data.Frame) (*data.Frame, error) {
+	timeIndex := -1
+	for i, field := range frame.Fields {
+		if field.Type() == data.FieldTypeNullableTime {
+			timeIndex = i
+			break
+		}
+	}
+	if timeIndex < 0 {
+		return nil, fmt.Errorf("time series format requires a date column in the results")
+	}
+
+	// the conversion requires non null times in ascending order
+	timeField := frame.Fields[timeIndex]
+	var rows []int
+	for i := 0; i < timeField.Len(); i++ {
+		if timeField.At(i).(*time.Time) != nil {
+			rows = append(rows, i)
+		}
+	}
+	sort.SliceStable(rows, func(a, b int) bool {
+		return timeField.At(rows[a]).(*time.Time).Before(*timeField.At(rows[b]).(*time.Time))
+	})
+
+	long := data.NewFrame(frame.Name)
+	long.Meta = frame.Meta
+	for i, field := range frame.Fields {
+		var f *data.Field
+		if i == timeIndex {
+			f = data.NewFieldFromFieldType(data.FieldTypeTime, len(rows))
+			for j, row := range rows {
+				f.Set(j, *field.At(row).(*time.Time))
+			}
+		} else {
+			f = data.NewFieldFromFieldType(field.Type(), len(rows))
+			for j, row := range rows {
+				f.Set(j, field.At(row))
+			}
+		}
+		f.Name = field.Name
+		long.Fields = append(long.Fields, f)
+	}
+
+	wide, err := data.LongToWide(long, nil)
+	if err != nil {
+		return nil, err
+	}
+	wide.Meta = frame.Meta
+	wide.Meta.PreferredVisualization = data.VisTypeGraph
+	return wide, nil
+}
+
+func sqlColumnToField(column es.SQLColumn, rows [][]any, index int) (*data.Field, error) {
+	var field *data.Field
+	switch column.Type {
+	case "date", "datetime", "date_nanos":
+		field = data.NewFieldFromFieldType(data.FieldTypeNullableTime, len(rows))
+	case "long", "integer", "short", "byte", "unsigned_long", "counter_long", "counter_integer":
+		field = data.NewFieldFromFieldType(data.FieldTypeNullableInt64, len(rows))
+	case "double", "float", "half_float", "scaled_float", "counter_double":
+		field = data.NewFieldFromFieldType(data.FieldTypeNullableFloat64, len(rows))
+	case "boolean":
+		field = data.NewFieldFromFieldType(data.FieldTypeNullableBool, len(rows))
+	default:
+		field = data.NewFieldFromFieldType(data.FieldTypeNullableString, len(rows))
+	}
+	field.Name = column.Name
+
+	for i, row := range rows {
+		if index >= len(row) || row[index] == nil {
+			continue
+		}
+		value, err := sqlValue(field.Type(), row[index])
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of column %s: %w", column.Name, err)
+		}
+		field.Set(i, value)
+	}
+	return field, nil
+}
+
+func sqlValue(fieldType data.FieldType, value any) (any, error) {
+	switch fieldType {
+	case data.FieldTypeNullableTime:
+		switch v := value.(type) {
+		case string:
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, err
+			}
+			return &t, nil
+		case json.Number:
+			// epoch_millis dates are returned as numbers
+			ms, err := v.Int64()
+			if err != nil {
+				return nil, err
+			}
+			t := time.UnixMilli(ms).UTC()
+			return &t, nil
+		}
+	case data.FieldTypeNullableInt64:
+		if v, ok := value.(json.Number); ok {
+			i, err := v.Int64()
+			if err != nil {
+				return nil, err
+			}
+			return &i, nil
+		}
+	case data.FieldTypeNullableFloat64:
+		if v, ok := value.(json.Number); ok {
+			f, err := v.Float64()
+			if err != nil {
+				return nil, err
+			}
+			return &f, nil
+		}
+	case data.FieldTypeNullableBool:
+		if v, ok := value.(bool); ok {
+			return &v, nil
+		}
+	default:
+		switch v := value.(type) {
+		case string:
+			return &v, nil
+		case json.Number:
+			s := v.String()
+			return &s, nil
+		}
+		// objects, arrays and the values of unknown types are shown as JSON
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		s := string(b)
+		return &s, nil
+	}
+	return nil, fmt.Errorf("unexpected value %v", value)
+}
@@ -0,0 +1,83 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+)
+
+func TestSQLQuery(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	t.Run("runs sql queries next to the search queries", func(t *testing.T) {
+		c := newFakeClient()
+		c.sqlResponse = &es.SQLResponse{
+			Columns: []es.SQLColumn{{Name: "@timestamp", Type: "datetime"}, {Name: "host", Type: "keyword"}, {Name: "bytes", Type: "long"}},
+			Rows: [][]any{
+				{"2024-01-01T00:01:00.000Z", "web", json.Number("10")},
+				{"2024-01-01T00:00:00.000Z", "db", nil},
+			},
+		}
+		res, err := executeElasticsearchDataQuery(c, `{"queryType": "sql", "query": "SELECT \"@timestamp\", host, bytes FROM logs"}`, from, to)
+		require.NoError(t, err)
+		require.Empty(t, c.multisearchRequests)
+
+		require.Len(t, c.sqlRequests, 1)
+		req := c.sqlRequests[0]
+		require.Equal(t, es.SQLLanguage, req.Language)
+		rangeFilter := req.Filter.Bool.Filters[0].(*es.RangeFilter)
+		require.Equal(t, "@timestamp", rangeFilter.Key)
+		require.Equal(t, from.UnixMilli(), rangeFilter.Gte)
+		require.Equal(t, to.UnixMilli(), rangeFilter.Lte)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, data.FieldTypeNullableTime, frames[0].Fields[0].Type())
+		require.Equal(t, time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC), *frames[0].Fields[0].At(0).(*time.Time))
+		require.Equal(t, int64(10), *frames[0].Fields[2].At(0).(*int64))
+		require.Nil(t, frames[0].Fields[2].At(1))
+	})
+
+	t.Run("converts esql results to time series", func(t *testing.T) {
+		res := &es.SQLResponse{
+			Columns: []es.SQLColumn{{Name: "bucket", Type: "date"}, {Name: "host", Type: "keyword"}, {Name: "avg", Type: "double"}},
+			Values: [][]any{
+				{"2024-01-01T00:01:00.000Z", "web", json.Number("2.0")},
+				{"2024-01-01T00:00:00.000Z", "web", json.Number("1.0")},
+				{"2024-01-01T00:00:00.000Z", "db", json.Number("3.0")},
+			},
+		}
+		frame, err := sqlResponseToFrame(res, &Query{RefID: "A", QueryType: es.ESQLLanguage, Format: timeSeriesFormat})
+		require.NoError(t, err)
+
+		require.Equal(t, data.FieldTypeTime, frame.Fields[0].Type())
+		require.Equal(t, 2, frame.Fields[0].Len())
+		require.Len(t, frame.Fields, 3)
+		require.Equal(t, data.Labels{"host": "db"}, frame.Fields[1].Labels)
+		require.Equal(t, data.Labels{"host": "web"}, frame.Fields[2].Labels)
+		require.Equal(t, 2.0, *frame.Fields[2].At(1).(*float64))
+	})
+
+	t.Run("keeps the precision of long values", func(t *testing.T) {
+		res := &es.SQLResponse{
+			Columns: []es.SQLColumn{{Name: "id", Type: "long"}, {Name: "ts", Type: "date"}},
+			Rows:    [][]any{{json.Number("9007199254740993"), json.Number("1704067200000")}},
+		}
+		frame, err := sqlResponseToFrame(res, &Query{RefID: "A"})
+		require.NoError(t, err)
+		require.Equal(t, int64(9007199254740993), *frame.Fields[0].At(0).(*int64))
+		require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *frame.Fields[1].At(0).(*time.Time))
+	})
+
+	t.Run("time series requires a date column", func(t *testing.T) {
+		res := &es.SQLResponse{Columns: []es.SQLColumn{{Name: "count", Type: "long"}}, Rows: [][]any{{json.Number("1")}}}
+		_, err := sqlResponseToFrame(res, &Query{Format: timeSeriesFormat})
+		require.Error(t, err)
+	})
+}
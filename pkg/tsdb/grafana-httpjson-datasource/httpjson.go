@@ -0,0 +1,159 @@
+package httpjson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+)
+
+var logger = backend.NewLoggerWith("logger", "tsdb.httpjson")
+
+var (
+	_ backend.QueryDataHandler   = (*Service)(nil)
+	_ backend.CheckHealthHandler = (*Service)(nil)
+)
+
+// Service queries the JSON APIs of HTTP servers and extracts the fields of the frames from the responses.
+type Service struct {
+	im     instancemgmt.InstanceManager
+	logger log.Logger
+}
+
+type datasourceInfo struct {
+	HTTPClient      *http.Client
+	URL             string
+	HealthCheckPath string
+	// cache holds the response bodies by request, it is nil when the responses aren't cached
+	cache *cache.Cache
+}
+
+func ProvideService(httpClientProvider httpclient.Provider) *Service {
+	return &Service{
+		im:     datasource.NewInstanceManager(newInstanceSettings(httpClientProvider)),
+		logger: logger,
+	}
+}
+
+func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(ctx context.Context, settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		model := settingsModel{}
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &model); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
+		opts, err := settings.HTTPClientOptions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		client, err := httpClientProvider.New(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		info := &datasourceInfo{
+			HTTPClient:      client,
+			URL:             settings.URL,
+			HealthCheckPath: model.HealthCheckPath,
+		}
+		if model.CacheTTL != "" {
+			ttl, err := time.ParseDuration(model.CacheTTL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cache TTL %q: %w", model.CacheTTL, err)
+			}
+			if ttl > 0 {
+				info.cache = cache.New(ttl, 2*ttl)
+			}
+		}
+		return info, nil
+	}
+}
+
+func (s *Service) getDSInfo(ctx context.Context, pluginCtx backend.PluginContext) (*datasourceInfo, error) {
+	i, err := s.im.Get(ctx, pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := i.(*datasourceInfo)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast datasource info")
+	}
+	return instance, nil
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	result := backend.NewQueryDataResponse()
+	for _, query := range req.Queries {
+		result.Responses[query.RefID] = s.executeQuery(ctx, dsInfo, query, req.GetHTTPHeaders())
+	}
+	return result, nil
+}
+
+func (s *Service) executeQuery(ctx context.Context, dsInfo *datasourceInfo, query backend.DataQuery, forwarded http.Header) backend.DataResponse {
+	model := queryModel{}
+	if err := json.Unmarshal(query.JSON, &model); err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query: %v", err))
+	}
+	if err := validateQuery(&model); err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+
+	frame, err := s.queryPages(ctx, dsInfo, model, query, forwarded)
+	if err != nil {
+		s.logger.FromContext(ctx).Debug("Query failed", "refId", query.RefID, "error", err)
+		return backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+	}
+	frame.RefID = query.RefID
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
+
+// CheckHealth requests the health check path and expects a successful status code.
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := resolveURL(dsInfo.URL, dsInfo.HealthCheckPath)
+	if err != nil {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: err.Error()}, nil
+	}
+	healthReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := dsInfo.HTTPClient.Do(healthReq)
+	if err != nil {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: fmt.Sprintf("request failed: %v", err)}, nil
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			s.logger.Warn("Failed to close response body", "error", err)
+		}
+	}()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode/100 != 2 {
+		return &backend.CheckHealthResult{Status: backend.HealthStatusError, Message: fmt.Sprintf("request failed, status: %s", res.Status)}, nil
+	}
+	return &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "Data source is working"}, nil
+}
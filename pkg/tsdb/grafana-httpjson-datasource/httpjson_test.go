@@ -0,0 +1,311 @@
+package httpjson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+)
+
+type recordedRequest struct {
+	method string
+	url    string
+	header http.Header
+	body   string
+}
+
+type testServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+func newTestServer(t *testing.T, handler func(r *http.Request) (int, string)) *testServer {
+	t.Helper()
+	ts := &testServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts.mu.Lock()
+		ts.requests = append(ts.requests, recordedRequest{method: r.Method, url: r.URL.String(), header: r.Header, body: string(body)})
+		ts.mu.Unlock()
+		status, rsp := handler(r)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(rsp))
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *testServer) query(t *testing.T, jsonData string, model string) (backend.DataResponse, *Service) {
+	t.Helper()
+	s := ProvideService(httpclient.NewProvider())
+	return ts.queryWith(t, s, jsonData, model), s
+}
+
+func (ts *testServer) queryWith(t *testing.T, s *Service, jsonData string, model string) backend.DataResponse {
+	t.Helper()
+	return ts.queryWithHeaders(t, s, jsonData, model, nil)
+}
+
+func (ts *testServer) queryWithHeaders(t *testing.T, s *Service, jsonData string, model string, headers map[string]string) backend.DataResponse {
+	t.Helper()
+	req := &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, URL: ts.URL + "/api", JSONData: json.RawMessage(jsonData)},
+		},
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: time.UnixMilli(1000), To: time.UnixMilli(61000)},
+				Interval:  15 * time.Second,
+				JSON:      json.RawMessage(model),
+			},
+		},
+	}
+	for key, value := range headers {
+		req.SetHTTPHeader(key, value)
+	}
+	rsp, err := s.QueryData(context.Background(), req)
+	require.NoError(t, err)
+	return rsp.Responses["A"]
+}
+
+func TestQueryData(t *testing.T) {
+	t.Run("extracts the fields with JSONPath", func(t *testing.T) {
+		ts := newTestServer(t, func(r *http.Request) (int, string) {
+			return http.StatusOK, `{"items": [
+				{"ts": "2024-01-01T00:00:00Z", "name": "a", "value": 1.5, "up": true, "tags": ["x"]},
+				{"ts": "2024-01-01T00:01:00Z", "name": "b", "value": null, "up": false, "tags": []}
+			]}`
+		})
+		res, _ := ts.query(t, `{}`, `{
+			"method": "POST",
+			"path": "/metrics?team=a",
+			"params": [{"key": "from", "value": "${__from:date:seconds}"}, {"key": "step", "value": "$__interval_ms"}],
+			"headers": [{"key": "X-Tenant", "value": "t1"}],
+			"body": "{\"from\": $__from, \"to\": ${__to}}",
+			"fields": [
+				{"name": "time", "path": "$.items[*].ts", "type": "time"},
+				{"name": "name", "path": "$.items[*].name"},
+				{"path": "$.items[*].value"},
+				{"name": "up", "path": "$.items[*].up"},
+				{"name": "tags", "path": "$.items[*].tags"}
+			]
+		}`)
+		require.NoError(t, res.Error)
+
+		require.Len(t, ts.requests, 1)
+		req := ts.requests[0]
+		require.Equal(t, http.MethodPost, req.method)
+		require.Equal(t, "/api/metrics?from=1&step=15000&team=a", req.url)
+		require.Equal(t, "t1", req.header.Get("X-Tenant"))
+		require.Equal(t, `{"from": 1000, "to": 61000}`, req.body)
+
+		frame := res.Frames[0]
+		require.Equal(t, "A", frame.RefID)
+		require.Equal(t, "POST "+ts.URL+"/api/metrics?from=1&step=15000&team=a", frame.Meta.ExecutedQueryString)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC), frame.Fields[0].At(1))
+		require.Equal(t, "b", *frame.Fields[1].At(1).(*string))
+		require.Equal(t, "field3", frame.Fields[2].Name)
+		require.Equal(t, 1.5, *frame.Fields[2].At(0).(*float64))
+		require.Nil(t, frame.Fields[2].At(1))
+		require.Equal(t, false, *frame.Fields[3].At(1).(*bool))
+		require.Equal(t, json.RawMessage(`["x"]`), frame.Fields[4].At(0))
+	})
+
+	t.Run("extracts the fields with JMESPath", func(t *testing.T) {
+		ts := newTestServer(t, func(r *http.Request) (int, string) {
+			return http.StatusOK, `{"data": {"series": [{"t": 1000, "v": "2"}, {"t": 2000, "v": "3"}]}}`
+		})
+		res, _ := ts.query(t, `{}`, `{
+			"language": "jmespath",
+			"fields": [
+				{"name": "time", "path": "data.series[].t", "type": "time"},
+				{"name": "value", "path": "data.series[].v", "type": "number"}
+			]
+		}`)
+		require.NoError(t, res.Error)
+		frame := res.Frames[0]
+		require.Equal(t, "GET", ts.requests[0].method)
+		require.Equal(t, time.UnixMilli(2000).UTC(), frame.Fields[0].At(1))
+		require.Equal(t, 3.0, *frame.Fields[1].At(1).(*float64))
+	})
+
+	t.Run("requests the pages until the last one", func(t *testing.T) {
+		ts := newTestServer(t, func(r *http.Request) (int, string) {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page == 3 {
+				return http.StatusOK, `[{"id": 5}]`
+			}
+			return http.StatusOK, fmt.Sprintf(`[{"id": %d}, {"id": %d}]`, page*2-1, page*2)
+		})
+		res, _ := ts.query(t, `{}`, `{
+			"fields": [{"name": "id", "path": "$[*].id"}],
+			"pagination": {"mode": "page", "param": "page", "sizeParam": "limit", "size": 2}
+		}`)
+		require.NoError(t, res.Error)
+		require.Len(t, ts.requests, 3)
+		require.Equal(t, "/api?limit=2&page=1", ts.requests[0].url)
+		require.Equal(t, 5, res.Frames[0].Rows())
+		require.Equal(t, 5.0, *res.Frames[0].Fields[0].At(4).(*float64))
+	})
+
+	t.Run("follows the cursors of the pages", func(t *testing.T) {
+		ts := newTestServer(t, func(r *http.Request) (int, string) {
+			switch r.URL.Query().Get("after") {
+			case "":
+				return http.StatusOK, `{"values": [1, 2], "next": "c1"}`
+			case "c1":
+				return http.StatusOK, `{"values": [3], "next": null}`
+			}
+			return http.StatusBadRequest, "unexpected cursor"
+		})
+		res, _ := ts.query(t, `{}`, `{
+			"fields": [{"name": "value", "path": "$.values"}],
+			"pagination": {"mode": "cursor", "param": "after", "cursorPath": "$.next"}
+		}`)
+		require.NoError(t, res.Error)
+		require.Len(t, ts.requests, 2)
+		require.Equal(t, 3, res.Frames[0].Rows())
+	})
+
+	t.Run("stops at the maximum number of pages", func(t *testing.T) {
+		ts := newTestServer(t, func(r *http.Request) (int, string) {
+			return http.StatusOK, `[{"id": 1}]`
+		})
+		res, _ := ts.query(t, `{}`, `{
+			"fields": [{"path": "$[*].id"}],
+			"pagination": {"mode": "offset", "param": "offset", "size": 1, "maxPages": 3}
+		}`)
+		require.NoError(t, res.Error)
+		require.Len(t, ts.requests, 3)
+		require.Equal(t, "/api?offset=2", ts.requests[2].url)
+	})
+
+	t.Run("caches the responses", func(t *testing.T) {
+		ts := newTestServer(t, func(r *http.Request) (int, string) {
+			return http.StatusOK, `[1, 2]`
+		})
+		model := `{"fields": [{"path": "$"}]}`
+		res, s := ts.query(t, `{"cacheTTL": "1m"}`, model)
+		require.NoError(t, res.Error)
+		res = ts.queryWith(t, s, `{"cacheTTL": "1m"}`, model)
+		require.NoError(t, res.Error)
+		require.Equal(t, 2, res.Frames[0].Rows())
+		require.Len(t, ts.requests, 1)
+
+		res = ts.queryWith(t, s, `{"cacheTTL": "1m"}`, `{"fields": [{"path": "$"}], "params": [{"key": "a", "value": "b"}]}`)
+		require.NoError(t, res.Error)
+		require.Len(t, ts.requests, 2)
+	})
+
+	t.Run("sends the forwarded headers and caches the responses per user", func(t *testing.T) {
+		ts := newTestServer(t, func(r *http.Request) (int, string) {
+			return http.StatusOK, `[1, 2]`
+		})
+		s := ProvideService(httpclient.NewProvider())
+		model := `{"fields": [{"path": "$"}]}`
+		for _, token := range []string{"Bearer a", "Bearer a", "Bearer b"} {
+			res := ts.queryWithHeaders(t, s, `{"cacheTTL": "1m"}`, model, map[string]string{"Authorization": token, "X-Id-Token": token})
+			require.NoError(t, res.Error)
+		}
+		require.Len(t, ts.requests, 2)
+		require.Equal(t, "Bearer a", ts.requests[0].header.Get("Authorization"))
+		require.Equal(t, "Bearer a", ts.requests[0].header.Get("X-Id-Token"))
+		require.Equal(t, "Bearer b", ts.requests[1].header.Get("Authorization"))
+	})
+
+	t.Run("returns the errors of the queries", func(t *testing.T) {
+		ts := newTestServer(t, func(r *http.Request) (int, string) {
+			if r.URL.Path == "/api/missing" {
+				return http.StatusNotFound, "not found"
+			}
+			return http.StatusOK, `{"a": [1, 2], "b": [1]}`
+		})
+		for model, msg := range map[string]string{
+			`{"method": "DELETE", "fields": [{"path": "$"}]}`: "unsupported method DELETE",
+			`{"fields": []}`: "the query needs at least one field",
+			`{"path": "http://other/api", "fields": [{"path": "$"}]}`:                     "the path must be relative to the URL of the data source",
+			`{"path": "/missing", "fields": [{"path": "$"}]}`:                             "request failed, status: 404 Not Found, body: not found",
+			`{"fields": [{"path": "$.a"}, {"path": "$.b"}]}`:                              "field field2 has 1 values, the previous fields have 2",
+			`{"fields": [{"name": "t", "path": "$.a", "type": "boolean"}]}`:               "field t: cannot convert 2 to boolean",
+			`{"fields": [{"path": "$"}], "pagination": {"mode": "page"}}`:                 "the pagination needs the query parameter",
+			`{"language": "jmespath", "fields": [{"path": "a[?"}]}`:                       "field field1: invalid JMESPath expression \"a[?\": SyntaxError: Incomplete expression",
+			`{"fields": [{"path": "$"}], "pagination": {"mode": "cursor", "param": "c"}}`: "the cursor pagination needs the path of the cursor",
+		} {
+			res, _ := ts.query(t, `{}`, model)
+			require.EqualError(t, res.Error, msg, model)
+		}
+	})
+}
+
+func TestResolveURL(t *testing.T) {
+	for rel, expected := range map[string]string{
+		"":                 "http://host/api?key=1",
+		"/series":          "http://host/api/series?key=1",
+		"series/?match=up": "http://host/api/series/?key=1&match=up",
+		"../../other":      "http://host/other?key=1",
+	} {
+		u, err := resolveURL("http://host/api?key=1", rel)
+		require.NoError(t, err)
+		require.Equal(t, expected, u.String(), rel)
+	}
+
+	for _, rel := range []string{"http://other/api", "//other/api", "https://user@host/api"} {
+		_, err := resolveURL("http://host/api", rel)
+		require.ErrorIs(t, err, errAbsolutePath, rel)
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	ts := newTestServer(t, func(r *http.Request) (int, string) {
+		if r.URL.Path == "/api/health" {
+			return http.StatusOK, "ok"
+		}
+		return http.StatusNotFound, ""
+	})
+
+	check := func(jsonData string) *backend.CheckHealthResult {
+		s := ProvideService(httpclient.NewProvider())
+		res, err := s.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{URL: ts.URL + "/api", JSONData: json.RawMessage(jsonData)},
+			},
+		})
+		require.NoError(t, err)
+		return res
+	}
+
+	require.Equal(t, backend.HealthStatusOk, check(`{"healthCheckPath": "/health"}`).Status)
+	res := check(`{}`)
+	require.Equal(t, backend.HealthStatusError, res.Status)
+	require.Equal(t, "request failed, status: 404 Not Found", res.Message)
+}
+
+func TestNewField(t *testing.T) {
+	field, err := newField("v", "", []any{nil, map[string]any{"a": 1.0}})
+	require.NoError(t, err)
+	require.Equal(t, data.FieldTypeJSON, field.Type())
+	require.Equal(t, json.RawMessage("null"), field.At(0))
+
+	field, err = newField("v", fieldTypeString, []any{1.5, true, "a"})
+	require.NoError(t, err)
+	require.Equal(t, "1.5", *field.At(0).(*string))
+	require.Equal(t, "true", *field.At(1).(*string))
+
+	_, err = newField("t", fieldTypeTime, []any{"yesterday"})
+	require.EqualError(t, err, "field t: cannot convert yesterday to time")
+}
@@ -0,0 +1,280 @@
+package httpjson
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/jmespath/go-jmespath"
+	"github.com/spyzhov/ajson"
+)
+
+// queryPages requests the pages of a query and returns the values of the fields of all the pages in a frame.
+func (s *Service) queryPages(ctx context.Context, dsInfo *datasourceInfo, model queryModel, query backend.DataQuery, forwarded http.Header) (*data.Frame, error) {
+	p := model.Pagination
+	if p == nil || p.Mode == "" {
+		p = &paginationModel{MaxPages: 1}
+	}
+
+	values := make([][]any, len(model.Fields))
+	executed := ""
+	cursor := ""
+	for page := int64(0); page < p.MaxPages; page++ {
+		pageParams := url.Values{}
+		switch p.Mode {
+		case paginationPage:
+			pageParams.Set(p.Param, strconv.FormatInt(page+1, 10))
+		case paginationOffset:
+			pageParams.Set(p.Param, strconv.FormatInt(page*p.Size, 10))
+		case paginationCursor:
+			if page > 0 {
+				pageParams.Set(p.Param, cursor)
+			}
+		}
+		if p.SizeParam != "" && p.Size > 0 {
+			pageParams.Set(p.SizeParam, strconv.FormatInt(p.Size, 10))
+		}
+
+		req, err := newRequest(ctx, dsInfo, model, query, pageParams)
+		if err != nil {
+			return nil, err
+		}
+		if executed == "" {
+			executed = fmt.Sprintf("%s %s", req.Method, req.URL.String())
+		}
+		body, err := s.doRequest(dsInfo, req, interpolate(model.Body, query), forwarded)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := parseDocument(model.Language, body)
+		if err != nil {
+			return nil, err
+		}
+		rows := -1
+		for i, field := range model.Fields {
+			fieldValues, err := doc.search(field.Path)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", fieldName(field, i), err)
+			}
+			if rows >= 0 && len(fieldValues) != rows {
+				return nil, fmt.Errorf("field %s has %d values, the previous fields have %d", fieldName(field, i), len(fieldValues), rows)
+			}
+			rows = len(fieldValues)
+			values[i] = append(values[i], fieldValues...)
+		}
+
+		if p.Mode == paginationCursor {
+			cursors, err := doc.search(p.CursorPath)
+			if err != nil {
+				return nil, fmt.Errorf("cursor: %w", err)
+			}
+			if len(cursors) == 0 || cursors[0] == nil || cursors[0] == "" {
+				break
+			}
+			cursor = fmt.Sprint(cursors[0])
+		} else if rows == 0 || (p.Size > 0 && int64(rows) < p.Size) {
+			break
+		}
+	}
+
+	frame := data.NewFrame("")
+	for i, field := range model.Fields {
+		f, err := newField(fieldName(field, i), field.Type, values[i])
+		if err != nil {
+			return nil, err
+		}
+		frame.Fields = append(frame.Fields, f)
+	}
+	frame.Meta = &data.FrameMeta{ExecutedQueryString: executed}
+	return frame, nil
+}
+
+func fieldName(field fieldModel, i int) string {
+	if field.Name != "" {
+		return field.Name
+	}
+	return fmt.Sprintf("field%d", i+1)
+}
+
+// document is a parsed response, which is searched with the expressions of the query language.
+type document struct {
+	language string
+	jsonPath *ajson.Node
+	jmesPath any
+}
+
+func parseDocument(language string, body []byte) (*document, error) {
+	doc := &document{language: language}
+	var err error
+	if language == languageJMESPath {
+		err = json.Unmarshal(body, &doc.jmesPath)
+	} else {
+		doc.jsonPath, err = ajson.Unmarshal(body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the response: %w", err)
+	}
+	return doc, nil
+}
+
+// search returns the values matching an expression. A single array is returned as its values.
+func (d *document) search(expression string) ([]any, error) {
+	if d.language == languageJMESPath {
+		result, err := jmespath.Search(expression, d.jmesPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JMESPath expression %q: %w", expression, err)
+		}
+		if values, ok := result.([]any); ok {
+			return values, nil
+		}
+		if result == nil {
+			return nil, nil
+		}
+		return []any{result}, nil
+	}
+
+	nodes, err := d.jsonPath.JSONPath(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath expression %q: %w", expression, err)
+	}
+	if len(nodes) == 1 && nodes[0].IsArray() {
+		nodes = nodes[0].MustArray()
+	}
+	values := make([]any, len(nodes))
+	for i, node := range nodes {
+		if values[i], err = node.Unpack(); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// newField converts the values to the type of the field, or to the type of its first value when the type isn't set.
+func newField(name string, fieldType string, values []any) (*data.Field, error) {
+	if fieldType == "" {
+		fieldType = detectFieldType(values)
+	}
+
+	var err error
+	field := data.NewFieldFromFieldType(fieldTypes[fieldType], len(values))
+	field.Name = name
+	for i, v := range values {
+		if v == nil {
+			switch fieldType {
+			case fieldTypeTime:
+				return nil, fmt.Errorf("field %s has a null time", name)
+			case "":
+				field.Set(i, json.RawMessage("null"))
+			}
+			continue
+		}
+		switch fieldType {
+		case fieldTypeNumber:
+			var n float64
+			if n, err = toNumber(v); err == nil {
+				field.Set(i, &n)
+			}
+		case fieldTypeBoolean:
+			b, ok := v.(bool)
+			if !ok {
+				b, err = strconv.ParseBool(fmt.Sprint(v))
+			}
+			if err == nil {
+				field.Set(i, &b)
+			}
+		case fieldTypeTime:
+			var t time.Time
+			if t, err = toTime(v); err == nil {
+				field.Set(i, t)
+			}
+		case fieldTypeString:
+			s := toString(v)
+			field.Set(i, &s)
+		default:
+			var b []byte
+			if b, err = json.Marshal(v); err == nil {
+				field.Set(i, json.RawMessage(b))
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("field %s: cannot convert %v to %s", name, v, fieldType)
+		}
+	}
+	return field, nil
+}
+
+var fieldTypes = map[string]data.FieldType{
+	fieldTypeString:  data.FieldTypeNullableString,
+	fieldTypeNumber:  data.FieldTypeNullableFloat64,
+	fieldTypeBoolean: data.FieldTypeNullableBool,
+	fieldTypeTime:    data.FieldTypeTime,
+	"":               data.FieldTypeJSON,
+}
+
+// detectFieldType returns the type of the first value which isn't null, the objects and arrays are kept as JSON.
+func detectFieldType(values []any) string {
+	for _, v := range values {
+		switch v.(type) {
+		case nil:
+			continue
+		case float64:
+			return fieldTypeNumber
+		case bool:
+			return fieldTypeBoolean
+		case string:
+			return fieldTypeString
+		default:
+			return ""
+		}
+	}
+	return fieldTypeString
+}
+
+func toNumber(v any) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("not a number")
+}
+
+// toTime converts the numbers from epoch milliseconds and the strings from RFC 3339.
+func toTime(v any) (time.Time, error) {
+	switch t := v.(type) {
+	case float64:
+		return time.UnixMilli(int64(t)).UTC(), nil
+	case string:
+		if ms, err := strconv.ParseInt(t, 10, 64); err == nil {
+			return time.UnixMilli(ms).UTC(), nil
+		}
+		return time.Parse(time.RFC3339Nano, t)
+	}
+	return time.Time{}, fmt.Errorf("not a time")
+}
+
+func toString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(s)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,240 @@
+package httpjson
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const (
+	defaultMaxPages = 10
+	maxPagesLimit   = 100
+
+	// maxErrorBodyLength is the number of characters of the body of a failed response in the error
+	maxErrorBodyLength = 512
+)
+
+var errAbsolutePath = errors.New("the path must be relative to the URL of the data source")
+
+// validateQuery checks the query and sets the defaults.
+func validateQuery(model *queryModel) error {
+	model.Method = strings.ToUpper(model.Method)
+	switch model.Method {
+	case "":
+		model.Method = http.MethodGet
+	case http.MethodGet, http.MethodPost:
+	default:
+		return fmt.Errorf("unsupported method %s", model.Method)
+	}
+
+	switch model.Language {
+	case "":
+		model.Language = languageJSONPath
+	case languageJSONPath, languageJMESPath:
+	default:
+		return fmt.Errorf("unsupported language %s", model.Language)
+	}
+
+	if len(model.Fields) == 0 {
+		return errors.New("the query needs at least one field")
+	}
+	for i, field := range model.Fields {
+		if field.Path == "" {
+			return fmt.Errorf("field %d has no path", i)
+		}
+		switch field.Type {
+		case "", fieldTypeString, fieldTypeNumber, fieldTypeBoolean, fieldTypeTime:
+		default:
+			return fmt.Errorf("field %d has the unsupported type %s", i, field.Type)
+		}
+	}
+
+	p := model.Pagination
+	if p == nil || p.Mode == "" {
+		return nil
+	}
+	switch p.Mode {
+	case paginationPage, paginationOffset:
+		if p.Mode == paginationOffset && p.Size <= 0 {
+			return errors.New("the offset pagination needs the page size")
+		}
+	case paginationCursor:
+		if p.CursorPath == "" {
+			return errors.New("the cursor pagination needs the path of the cursor")
+		}
+	default:
+		return fmt.Errorf("unsupported pagination %s", p.Mode)
+	}
+	if p.Param == "" {
+		return errors.New("the pagination needs the query parameter")
+	}
+	if p.MaxPages <= 0 {
+		p.MaxPages = defaultMaxPages
+	}
+	if p.MaxPages > maxPagesLimit {
+		return fmt.Errorf("the pagination is limited to %d pages", maxPagesLimit)
+	}
+	return nil
+}
+
+// resolveURL appends the path and the query parameters of a relative URL to the URL of the data source, which can't
+// be replaced by the queries.
+func resolveURL(baseURL string, rel string) (*url.URL, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data source URL: %w", err)
+	}
+	r, err := url.Parse(rel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	if r.Scheme != "" || r.Host != "" || r.User != nil {
+		return nil, errAbsolutePath
+	}
+
+	if r.Path != "" {
+		u.Path = path.Join("/", u.Path, r.Path)
+		if strings.HasSuffix(r.Path, "/") {
+			u.Path += "/"
+		}
+	}
+	params := u.Query()
+	for key, values := range r.Query() {
+		for _, value := range values {
+			params.Add(key, value)
+		}
+	}
+	u.RawQuery = params.Encode()
+	return u, nil
+}
+
+// interpolate replaces the global time variables, which the dashboards replace too, so that the queries of the
+// alert rules get the same requests.
+func interpolate(s string, query backend.DataQuery) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	from := query.TimeRange.From
+	to := query.TimeRange.To
+	return strings.NewReplacer(
+		"${__from:date:iso}", from.UTC().Format(time.RFC3339),
+		"${__to:date:iso}", to.UTC().Format(time.RFC3339),
+		"${__from:date:seconds}", strconv.FormatInt(from.Unix(), 10),
+		"${__to:date:seconds}", strconv.FormatInt(to.Unix(), 10),
+		"${__from}", strconv.FormatInt(from.UnixMilli(), 10),
+		"${__to}", strconv.FormatInt(to.UnixMilli(), 10),
+		"$__interval_ms", strconv.FormatInt(query.Interval.Milliseconds(), 10),
+		"$__from", strconv.FormatInt(from.UnixMilli(), 10),
+		"$__to", strconv.FormatInt(to.UnixMilli(), 10),
+	).Replace(s)
+}
+
+// newRequest creates the request of a query, with the extra query parameters of a page.
+func newRequest(ctx context.Context, dsInfo *datasourceInfo, model queryModel, query backend.DataQuery, pageParams url.Values) (*http.Request, error) {
+	u, err := resolveURL(dsInfo.URL, interpolate(model.Path, query))
+	if err != nil {
+		return nil, err
+	}
+	params := u.Query()
+	for _, param := range model.Params {
+		if param.Key != "" {
+			params.Add(param.Key, interpolate(param.Value, query))
+		}
+	}
+	for key, values := range pageParams {
+		params[key] = values
+	}
+	u.RawQuery = params.Encode()
+
+	var body io.Reader
+	if model.Method != http.MethodGet && model.Body != "" {
+		body = strings.NewReader(interpolate(model.Body, query))
+	}
+	req, err := http.NewRequestWithContext(ctx, model.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for _, header := range model.Headers {
+		if header.Key != "" {
+			req.Header.Set(header.Key, interpolate(header.Value, query))
+		}
+	}
+	return req, nil
+}
+
+// doRequest sends the request with the forwarded headers and returns the body of the response, from the cache when the
+// data source caches the responses. The forwarded headers are part of the cache key since the identity of the users
+// can be forwarded to the server.
+func (s *Service) doRequest(dsInfo *datasourceInfo, req *http.Request, body string, forwarded http.Header) ([]byte, error) {
+	for name, values := range forwarded {
+		req.Header[name] = values
+	}
+
+	key := ""
+	if dsInfo.cache != nil {
+		key = cacheKey(req, body)
+		if cached, ok := dsInfo.cache.Get(key); ok {
+			return cached.([]byte), nil
+		}
+	}
+
+	res, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			s.logger.Warn("Failed to close response body", "error", err)
+		}
+	}()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response: %w", err)
+	}
+	if res.StatusCode/100 != 2 {
+		msg := string(b)
+		if len(msg) > maxErrorBodyLength {
+			msg = msg[:maxErrorBodyLength] + "..."
+		}
+		return nil, fmt.Errorf("request failed, status: %s, body: %s", res.Status, msg)
+	}
+
+	if dsInfo.cache != nil {
+		dsInfo.cache.SetDefault(key, b)
+	}
+	return b, nil
+}
+
+// cacheKey identifies a request by its method, URL, headers and body.
+func cacheKey(req *http.Request, body string) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s %s\n", req.Method, req.URL.String())
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(h, "%s: %s\n", name, strings.Join(req.Header[name], ","))
+	}
+	_, _ = fmt.Fprint(h, "\n")
+	_, _ = fmt.Fprint(h, body)
+	return hex.EncodeToString(h.Sum(nil))
+}
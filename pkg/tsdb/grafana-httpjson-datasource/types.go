@@ -0,0 +1,61 @@
+package httpjson
+
+const (
+	languageJSONPath = "jsonpath"
+	languageJMESPath = "jmespath"
+
+	paginationPage   = "page"
+	paginationOffset = "offset"
+	paginationCursor = "cursor"
+
+	fieldTypeString  = "string"
+	fieldTypeNumber  = "number"
+	fieldTypeBoolean = "boolean"
+	fieldTypeTime    = "time"
+)
+
+// settingsModel is the JSON data of the data source settings, next to the HTTP client options.
+type settingsModel struct {
+	// CacheTTL is how long the responses are cached, like 30s. The responses aren't cached when it is empty.
+	CacheTTL string `json:"cacheTTL"`
+	// HealthCheckPath is requested, relative to the URL, to check the health of the data source.
+	HealthCheckPath string `json:"healthCheckPath"`
+}
+
+type queryModel struct {
+	Method     string           `json:"method"`
+	Path       string           `json:"path"`
+	Params     []keyValue       `json:"params"`
+	Headers    []keyValue       `json:"headers"`
+	Body       string           `json:"body"`
+	Language   string           `json:"language"`
+	Fields     []fieldModel     `json:"fields"`
+	Pagination *paginationModel `json:"pagination"`
+}
+
+type keyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// fieldModel extracts the values of a field from the response with a JSONPath or JMESPath expression.
+type fieldModel struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Type is string, number, boolean or time. The type of the first value is used when it is empty.
+	Type string `json:"type"`
+}
+
+type paginationModel struct {
+	// Mode is page, offset or cursor, the pages aren't requested when it is empty.
+	Mode string `json:"mode"`
+	// Param is the query parameter of the page number, the offset or the cursor.
+	Param string `json:"param"`
+	// SizeParam is the optional query parameter of the page size.
+	SizeParam string `json:"sizeParam"`
+	// Size is the number of rows of a page, the last page has less rows.
+	Size int64 `json:"size"`
+	// CursorPath is the expression selecting the cursor of the next page in the response.
+	CursorPath string `json:"cursorPath"`
+	MaxPages   int64  `json:"maxPages"`
+}
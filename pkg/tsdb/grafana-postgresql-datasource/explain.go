@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	RelationName string     `json:"Relation Name"`
+	Schema       string     `json:"Schema"`
+	PlanRows     float64    `json:"Plan Rows"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// postgresQueryPlanner explains queries with the JSON output of EXPLAIN.
+type postgresQueryPlanner struct{}
+
+func (postgresQueryPlanner) Explain(ctx context.Context, conn *sql.Conn, query string) (*sqleng.QueryPlan, error) {
+	var plan string
+	if err := conn.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON, VERBOSE) "+query).Scan(&plan); err != nil {
+		return nil, err
+	}
+	result, err := parsePostgresPlan([]byte(plan))
+	if err != nil {
+		return nil, err
+	}
+
+	// The rows of a sequential scan in the plan are the rows left after its filter, the rows it reads are estimated
+	// with the statistics of the table.
+	for i, scan := range result.FullScans {
+		var tuples float64
+		err := conn.QueryRowContext(ctx, "SELECT reltuples FROM pg_class WHERE oid = to_regclass($1)", scan.Table).Scan(&tuples)
+		if err != nil {
+			continue
+		}
+		if tuples > scan.Rows {
+			result.FullScans[i].Rows = tuples
+		}
+	}
+	return result, nil
+}
+
+// parsePostgresPlan reports the sequential scans of the plan as full scans.
+func parsePostgresPlan(plan []byte) (*sqleng.QueryPlan, error) {
+	var plans []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &plans); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+
+	result := &sqleng.QueryPlan{Plan: string(plan)}
+	var walk func(node planNode)
+	walk = func(node planNode) {
+		if node.NodeType == "Seq Scan" {
+			table := pgx.Identifier{node.RelationName}
+			if node.Schema != "" {
+				table = pgx.Identifier{node.Schema, node.RelationName}
+			}
+			result.FullScans = append(result.FullScans, sqleng.FullScan{Table: table.Sanitize(), Rows: node.PlanRows})
+		}
+		for _, child := range node.Plans {
+			walk(child)
+		}
+	}
+	for _, p := range plans {
+		walk(p.Plan)
+	}
+	return result, nil
+}
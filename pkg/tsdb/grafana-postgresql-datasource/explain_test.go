@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+func TestParsePostgresPlan(t *testing.T) {
+	plan, err := parsePostgresPlan([]byte(`[{"Plan": {
+		"Node Type": "Hash Join", "Plan Rows": 500,
+		"Plans": [
+			{"Node Type": "Seq Scan", "Relation Name": "metrics", "Schema": "public", "Plan Rows": 120000},
+			{"Node Type": "Hash", "Plan Rows": 10, "Plans": [
+				{"Node Type": "Index Scan", "Relation Name": "hosts", "Schema": "public", "Plan Rows": 10}
+			]}
+		]
+	}}]`))
+	require.NoError(t, err)
+	require.Equal(t, []sqleng.FullScan{{Table: `"public"."metrics"`, Rows: 120000}}, plan.FullScans)
+
+	_, err = parsePostgresPlan([]byte(`Seq Scan on metrics`))
+	require.Error(t, err)
+}
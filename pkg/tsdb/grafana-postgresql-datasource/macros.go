@@ -84,7 +84,7 @@ func (m *postgresMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *
 			return "", fmt.Errorf("missing time column argument for macro %v", name)
 		}
 
-		return fmt.Sprintf("%s BETWEEN '%s' AND '%s'", args[0], timeRange.From.UTC().Format(time.RFC3339Nano), timeRange.To.UTC().Format(time.RFC3339Nano)), nil
+		return m.TimeFilter(args[0], timeRange), nil
 	case "__timeFrom":
 		return fmt.Sprintf("'%s'", timeRange.From.UTC().Format(time.RFC3339Nano)), nil
 	case "__timeTo":
@@ -155,6 +155,25 @@ func (m *postgresMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *
 		}
 		return "", err
 	default:
+		if sql, ok, err := sqleng.EvaluateSharedMacro(m, timeRange, query, name, args); ok {
+			return sql, err
+		}
 		return "", fmt.Errorf("unknown macro %q", name)
 	}
 }
+
+func (m *postgresMacroEngine) EpochSeconds(column string) string {
+	return fmt.Sprintf("extract(epoch from %s)", column)
+}
+
+func (m *postgresMacroEngine) TimeFilter(column string, timeRange backend.TimeRange) string {
+	return fmt.Sprintf("%s BETWEEN '%s' AND '%s'", column, timeRange.From.UTC().Format(time.RFC3339Nano), timeRange.To.UTC().Format(time.RFC3339Nano))
+}
+
+func (m *postgresMacroEngine) TimeAlias() string {
+	return `AS "time"`
+}
+
+func (m *postgresMacroEngine) SelectTop(n int, columns string, rest string) string {
+	return fmt.Sprintf("SELECT %s %s LIMIT %d", columns, rest, n)
+}
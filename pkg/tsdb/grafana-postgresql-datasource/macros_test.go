@@ -144,6 +144,36 @@ func TestMacroEngine(t *testing.T) {
 			require.Equal(t, "SELECT floor((time_column+time_adjustment)/300)*300", sql)
 			require.Equal(t, sql2, sql+" AS \"time\"")
 		})
+
+		t.Run("interpolate __timeGroupTz function", func(t *testing.T) {
+			sql, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroupTz(time_column,'1d','Asia/Kolkata')")
+			require.Nil(t, err)
+			sql2, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroupTzAlias(time_column,'1d','Asia/Kolkata')")
+			require.Nil(t, err)
+			sql3, err := engine.Interpolate(query, timeRange, "GROUP BY $__timeGroupTz(time_column,'5m','UTC')")
+			require.Nil(t, err)
+
+			require.Equal(t, "GROUP BY (FLOOR((extract(epoch from time_column)+19800)/86400)*86400-19800)", sql)
+			require.Equal(t, sql+" AS \"time\"", sql2)
+			require.Equal(t, "GROUP BY FLOOR(extract(epoch from time_column)/300)*300", sql3)
+		})
+
+		t.Run("interpolate __rate function", func(t *testing.T) {
+			sql, err := engine.Interpolate(query, timeRange, "SELECT $__rate(bytes, time_column, host)")
+			require.Nil(t, err)
+
+			window := "PARTITION BY host ORDER BY time_column"
+			require.Equal(t, "SELECT CASE WHEN bytes >= LAG(bytes) OVER ("+window+") THEN 1.0*(bytes-LAG(bytes) OVER ("+window+"))"+
+				"/NULLIF(extract(epoch from time_column)-LAG(extract(epoch from time_column)) OVER ("+window+"), 0) END", sql)
+		})
+
+		t.Run("interpolate __topN function", func(t *testing.T) {
+			query := &backend.DataQuery{JSON: []byte("{}"), Interval: time.Minute}
+			sql, err := engine.Interpolate(query, timeRange, "WHERE $__topN(3, metrics, time_column, host, value)")
+			require.Nil(t, err)
+
+			require.Equal(t, fmt.Sprintf("WHERE host IN (SELECT metric FROM (SELECT metric FROM (SELECT host AS metric, AVG(value) AS value FROM metrics WHERE time_column BETWEEN '%s' AND '%s' GROUP BY host, FLOOR(extract(epoch from time_column)/60)) buckets GROUP BY metric ORDER BY MAX(value) DESC LIMIT 3) topn)", from.Format(time.RFC3339Nano), to.Format(time.RFC3339Nano)), sql)
+		})
 	})
 
 	t.Run("Given a time range between 1960-02-01 07:00 and 1965-02-03 08:00", func(t *testing.T) {
@@ -52,6 +52,9 @@ type Point struct {
 type ProfileResponse struct {
 	Flamebearer *Flamebearer
 	Units       string
+	// IsDiff is set when the flamebearer compares a baseline and a comparison profile, in which case each bar of its
+	// levels holds the values of both profiles
+	IsDiff bool
 }
 
 type SeriesResponse struct {
@@ -210,6 +213,59 @@ func (c *PyroscopeClient) GetSpanProfile(ctx context.Context, profileTypeID, lab
 	return profileQuery(ctx, err, span, resp.Msg.Flamegraph, profileTypeID)
 }
 
+func (c *PyroscopeClient) GetDiffProfile(ctx context.Context, profileTypeID, baselineLabelSelector string, baselineStart, baselineEnd int64,
+	comparisonLabelSelector string, comparisonStart, comparisonEnd int64, maxNodes *int64) (*ProfileResponse, error) {
+	ctx, span := tracing.DefaultTracer().Start(ctx, "datasource.pyroscope.GetDiffProfile", trace.WithAttributes(attribute.String("profileTypeID", profileTypeID), attribute.String("baselineLabelSelector", baselineLabelSelector), attribute.String("comparisonLabelSelector", comparisonLabelSelector)))
+	defer span.End()
+	req := connect.NewRequest(&querierv1.DiffRequest{
+		Left: &querierv1.SelectMergeStacktracesRequest{
+			ProfileTypeID: profileTypeID,
+			LabelSelector: baselineLabelSelector,
+			Start:         baselineStart,
+			End:           baselineEnd,
+			MaxNodes:      maxNodes,
+		},
+		Right: &querierv1.SelectMergeStacktracesRequest{
+			ProfileTypeID: profileTypeID,
+			LabelSelector: comparisonLabelSelector,
+			Start:         comparisonStart,
+			End:           comparisonEnd,
+			MaxNodes:      maxNodes,
+		},
+	})
+
+	resp, err := c.connectClient.Diff(ctx, req)
+	if err != nil {
+		logger.Error("Received error from client", "error", err, "function", logEntrypoint())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	if resp.Msg.Flamegraph == nil {
+		// Not an error, can happen when querying data out of range.
+		return nil, nil
+	}
+
+	levels := make([]*Level, len(resp.Msg.Flamegraph.Levels))
+	for i, level := range resp.Msg.Flamegraph.Levels {
+		levels[i] = &Level{
+			Values: level.Values,
+		}
+	}
+
+	return &ProfileResponse{
+		Flamebearer: &Flamebearer{
+			Names:   resp.Msg.Flamegraph.Names,
+			Levels:  levels,
+			Total:   resp.Msg.Flamegraph.Total,
+			MaxSelf: resp.Msg.Flamegraph.MaxSelf,
+		},
+		Units:  getUnits(profileTypeID),
+		IsDiff: true,
+	}, nil
+}
+
 func profileQuery(ctx context.Context, err error, span trace.Span, flamegraph *querierv1.FlameGraph, profileTypeID string) (*ProfileResponse, error) {
 	levels := make([]*Level, len(flamegraph.Levels))
 	for i, level := range flamegraph.Levels {
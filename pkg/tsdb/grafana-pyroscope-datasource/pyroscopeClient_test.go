@@ -52,6 +52,32 @@ func Test_PyroscopeClient(t *testing.T) {
 		require.Equal(t, series, resp)
 	})
 
+	t.Run("GetDiffProfile", func(t *testing.T) {
+		resp, err := client.GetDiffProfile(context.Background(), "memory:alloc_objects:count:space:bytes", "{app=\"a\"}", 0, 100, "{app=\"b\"}", 200, 300, nil)
+		require.Nil(t, err)
+
+		req := connectClient.Req.(*connect.Request[querierv1.DiffRequest])
+		require.Equal(t, "{app=\"a\"}", req.Msg.Left.LabelSelector)
+		require.Equal(t, int64(0), req.Msg.Left.Start)
+		require.Equal(t, "{app=\"b\"}", req.Msg.Right.LabelSelector)
+		require.Equal(t, int64(300), req.Msg.Right.End)
+
+		profile := &ProfileResponse{
+			Flamebearer: &Flamebearer{
+				Names: []string{"foo", "bar"},
+				Levels: []*Level{
+					{Values: []int64{0, 10, 0, 0, 12, 0, 0}},
+					{Values: []int64{0, 10, 10, 0, 12, 12, 1}},
+				},
+				Total:   22,
+				MaxSelf: 12,
+			},
+			Units:  "short",
+			IsDiff: true,
+		}
+		require.Equal(t, profile, resp)
+	})
+
 	t.Run("GetProfile with empty response", func(t *testing.T) {
 		connectClient.SendEmptyProfileResponse = true
 		maxNodes := int64(-1)
@@ -79,8 +105,20 @@ func (f *FakePyroscopeConnectClient) LabelNames(ctx context.Context, c *connect.
 }
 
 func (f *FakePyroscopeConnectClient) Diff(ctx context.Context, c *connect.Request[querierv1.DiffRequest]) (*connect.Response[querierv1.DiffResponse], error) {
-	//TODO implement me
-	panic("implement me")
+	f.Req = c
+	return &connect.Response[querierv1.DiffResponse]{
+		Msg: &querierv1.DiffResponse{
+			Flamegraph: &querierv1.FlameGraphDiff{
+				Names: []string{"foo", "bar"},
+				Levels: []*querierv1.Level{
+					{Values: []int64{0, 10, 0, 0, 12, 0, 0}},
+					{Values: []int64{0, 10, 10, 0, 12, 12, 1}},
+				},
+				Total:   22,
+				MaxSelf: 12,
+			},
+		},
+	}, nil
 }
 
 func (f *FakePyroscopeConnectClient) ProfileTypes(ctx context.Context, c *connect.Request[querierv1.ProfileTypesRequest]) (*connect.Response[querierv1.ProfileTypesResponse], error) {
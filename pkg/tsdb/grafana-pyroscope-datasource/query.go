@@ -34,6 +34,7 @@ const (
 	queryTypeProfile = string(dataquery.PyroscopeQueryTypeProfile)
 	queryTypeMetrics = string(dataquery.PyroscopeQueryTypeMetrics)
 	queryTypeBoth    = string(dataquery.PyroscopeQueryTypeBoth)
+	queryTypeDiff    = string(dataquery.PyroscopeQueryTypeDiff)
 )
 
 // query processes single Pyroscope query transforming the response to data.Frame packaged in DataResponse
@@ -148,6 +149,21 @@ func (d *PyroscopeDatasource) query(ctx context.Context, pCtx backend.PluginCont
 		})
 	}
 
+	if query.QueryType == queryTypeDiff {
+		g.Go(func() error {
+			frame, err := d.diffQuery(gCtx, qm, query)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return err
+			}
+			responseMutex.Lock()
+			response.Frames = append(response.Frames, frame)
+			responseMutex.Unlock()
+			return nil
+		})
+	}
+
 	if err := g.Wait(); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -157,10 +173,47 @@ func (d *PyroscopeDatasource) query(ctx context.Context, pCtx backend.PluginCont
 	return response
 }
 
+// diffQuery compares the profile of the query with a baseline profile, which is selected by another label selector or
+// by shifting the time range of the query back, and returns a diff flamegraph frame.
+func (d *PyroscopeDatasource) diffQuery(ctx context.Context, qm queryModel, query backend.DataQuery) (*data.Frame, error) {
+	baselineLabelSelector := qm.LabelSelector
+	if qm.BaselineLabelSelector != nil && *qm.BaselineLabelSelector != "" {
+		baselineLabelSelector = *qm.BaselineLabelSelector
+	}
+	baselineFrom, baselineTo := query.TimeRange.From, query.TimeRange.To
+	if qm.BaselineTimeShift != nil && *qm.BaselineTimeShift != "" {
+		shift, err := gtime.ParseDuration(*qm.BaselineTimeShift)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing baseline time shift: %v", err)
+		}
+		baselineFrom, baselineTo = baselineFrom.Add(-shift), baselineTo.Add(-shift)
+	}
+	if baselineLabelSelector == qm.LabelSelector && baselineFrom.Equal(query.TimeRange.From) {
+		return nil, fmt.Errorf("the baseline needs a different label selector or a time shift")
+	}
+
+	logger.Debug("Calling GetDiffProfile", "queryModel", qm, "function", logEntrypoint())
+	prof, err := d.client.GetDiffProfile(ctx, qm.ProfileTypeId, baselineLabelSelector, baselineFrom.UnixMilli(), baselineTo.UnixMilli(),
+		qm.LabelSelector, query.TimeRange.From.UnixMilli(), query.TimeRange.To.UnixMilli(), qm.MaxNodes)
+	if err != nil {
+		logger.Error("Error GetDiffProfile()", "err", err, "function", logEntrypoint())
+		return nil, err
+	}
+	if prof == nil {
+		// We still send empty data frame to give feedback that query really run, just didn't return any data.
+		return treeToNestedSetDiffDataFrame(nil, ""), nil
+	}
+	return responseToDataFrames(prof), nil
+}
+
 // responseToDataFrames turns Pyroscope response to data.Frame. We encode the data into a nested set format where we have
 // [level, value, label] columns and by ordering the items in a depth first traversal order we can recreate the whole
 // tree back.
 func responseToDataFrames(resp *ProfileResponse) *data.Frame {
+	if resp.IsDiff {
+		tree := diffLevelsToTree(resp.Flamebearer.Levels, resp.Flamebearer.Names)
+		return treeToNestedSetDiffDataFrame(tree, resp.Units)
+	}
 	tree := levelsToTree(resp.Flamebearer.Levels, resp.Flamebearer.Names)
 	return treeToNestedSetDataFrame(tree, resp.Units)
 }
@@ -180,29 +233,74 @@ const NAME_OFFSET = 3
 // ITEM_OFFSET Next bar. Each bar of the profile is represented by 4 number in a flat array.
 const ITEM_OFFSET = 4
 
+// In a diff profile, each bar is represented by the offset, value and self value of the baseline (left) profile,
+// followed by the ones of the comparison (right) profile and the index into the names array.
+const (
+	DIFF_RIGHT_START_OFFSET = 3
+	DIFF_RIGHT_VALUE_OFFSET = 4
+	DIFF_RIGHT_SELF_OFFSET  = 5
+	DIFF_NAME_OFFSET        = 6
+	DIFF_ITEM_OFFSET        = 7
+)
+
 type ProfileTree struct {
 	Start int64
 	Value int64
 	Self  int64
-	Level int
-	Name  string
-	Nodes []*ProfileTree
+	// ValueRight and SelfRight are the values of the comparison profile of a diff profile, in which case Value and Self
+	// are the values of the baseline profile
+	ValueRight int64
+	SelfRight  int64
+	Level      int
+	Name       string
+	Nodes      []*ProfileTree
+}
+
+// width is the width of the bar, which in a diff profile covers both profiles
+func (pt *ProfileTree) width() int64 {
+	return pt.Value + pt.ValueRight
+}
+
+// levelItemReader reads the bar at the index of a level, returning its offset relative to the end of the previous bar
+// and the node of the bar
+type levelItemReader func(values []int64, index int, names []string) (int64, *ProfileTree)
+
+func readItem(values []int64, index int, names []string) (int64, *ProfileTree) {
+	return values[index+START_OFFSET], &ProfileTree{
+		Value: values[index+VALUE_OFFSET],
+		Self:  values[index+SELF_OFFSET],
+		Name:  names[values[index+NAME_OFFSET]],
+	}
+}
+
+func readDiffItem(values []int64, index int, names []string) (int64, *ProfileTree) {
+	return values[index+START_OFFSET] + values[index+DIFF_RIGHT_START_OFFSET], &ProfileTree{
+		Value:      values[index+VALUE_OFFSET],
+		Self:       values[index+SELF_OFFSET],
+		ValueRight: values[index+DIFF_RIGHT_VALUE_OFFSET],
+		SelfRight:  values[index+DIFF_RIGHT_SELF_OFFSET],
+		Name:       names[values[index+DIFF_NAME_OFFSET]],
+	}
 }
 
 // levelsToTree converts flamebearer format into a tree. This is needed to then convert it into nested set format
 // dataframe. This should be temporary, and ideally we should get some sort of tree struct directly from Pyroscope API.
 func levelsToTree(levels []*Level, names []string) *ProfileTree {
+	return readLevels(levels, names, ITEM_OFFSET, readItem)
+}
+
+// diffLevelsToTree converts the flamebearer format of a diff profile into a tree.
+func diffLevelsToTree(levels []*Level, names []string) *ProfileTree {
+	return readLevels(levels, names, DIFF_ITEM_OFFSET, readDiffItem)
+}
+
+func readLevels(levels []*Level, names []string, itemSize int, read levelItemReader) *ProfileTree {
 	if len(levels) == 0 {
 		return nil
 	}
 
-	tree := &ProfileTree{
-		Start: 0,
-		Value: levels[0].Values[VALUE_OFFSET],
-		Self:  levels[0].Values[SELF_OFFSET],
-		Level: 0,
-		Name:  names[levels[0].Values[0]],
-	}
+	_, tree := read(levels[0].Values, 0, names)
+	tree.Name = names[levels[0].Values[0]]
 
 	parentsStack := []*ProfileTree{tree}
 	currentLevel := 1
@@ -232,26 +330,20 @@ func levelsToTree(levels []*Level, names []string) *ProfileTree {
 				break
 			}
 
-			itemStart := levels[currentLevel].Values[itemIndex+START_OFFSET] + offset
-			itemValue := levels[currentLevel].Values[itemIndex+VALUE_OFFSET]
-			selfValue := levels[currentLevel].Values[itemIndex+SELF_OFFSET]
-			itemEnd := itemStart + itemValue
-			parentEnd := currentParent.Start + currentParent.Value
+			itemOffset, treeItem := read(levels[currentLevel].Values, itemIndex, names)
+			itemStart := itemOffset + offset
+			itemEnd := itemStart + treeItem.width()
+			parentEnd := currentParent.Start + currentParent.width()
 
 			if itemStart >= currentParent.Start && itemEnd <= parentEnd {
 				// We have an item that is in the bounds of current parent item, so it should be its child
-				treeItem := &ProfileTree{
-					Start: itemStart,
-					Value: itemValue,
-					Self:  selfValue,
-					Level: currentLevel,
-					Name:  names[levels[currentLevel].Values[itemIndex+NAME_OFFSET]],
-				}
+				treeItem.Start = itemStart
+				treeItem.Level = currentLevel
 				// Add to parent
 				currentParent.Nodes = append(currentParent.Nodes, treeItem)
 				// Add this item as parent for the next level
 				nextParentsStack = append(nextParentsStack, treeItem)
-				itemIndex += ITEM_OFFSET
+				itemIndex += itemSize
 
 				// Update offset for next item. This is changing relative offset to absolute one.
 				offset = itemEnd
@@ -361,6 +453,27 @@ func treeToNestedSetDataFrame(tree *ProfileTree, unit string) *data.Frame {
 	return frame
 }
 
+// treeToNestedSetDiffDataFrame adds the valueRight and selfRight fields of the comparison profile to the nested set
+// frame, with which the flame graph shows the difference between the baseline and the comparison profiles.
+func treeToNestedSetDiffDataFrame(tree *ProfileTree, unit string) *data.Frame {
+	frame := treeToNestedSetDataFrame(tree, unit)
+
+	valueRightField := data.NewField("valueRight", nil, []int64{})
+	selfRightField := data.NewField("selfRight", nil, []int64{})
+	valueRightField.Config = &data.FieldConfig{Unit: unit}
+	selfRightField.Config = &data.FieldConfig{Unit: unit}
+
+	if tree != nil {
+		walkTree(tree, func(tree *ProfileTree) {
+			valueRightField.Append(tree.ValueRight)
+			selfRightField.Append(tree.SelfRight)
+		})
+	}
+
+	frame.Fields = append(frame.Fields, valueRightField, selfRightField)
+	return frame
+}
+
 type EnumField struct {
 	field     *data.Field
 	valuesMap map[string]data.EnumItemIndex
@@ -91,6 +91,31 @@ func Test_query(t *testing.T) {
 		require.True(t, ok)
 		require.Equal(t, []string{"app", "instance"}, groupBy)
 	})
+
+	t.Run("query diff", func(t *testing.T) {
+		dataQuery := makeDataQuery()
+		dataQuery.QueryType = queryTypeDiff
+		dataQuery.JSON = []byte(`{"profileTypeId":"memory:alloc_objects:count:space:bytes","labelSelector":"{app=\"baz\"}","baselineLabelSelector":"{app=\"bar\"}","baselineTimeShift":"5s"}`)
+		resp := ds.query(context.Background(), pCtx, *dataQuery)
+		require.Nil(t, resp.Error)
+		require.Equal(t, 1, len(resp.Frames))
+		require.Equal(t, []any{"memory:alloc_objects:count:space:bytes", `{app="bar"}`, int64(5000), int64(15000), `{app="baz"}`, int64(10000), int64(20000)}, client.Args)
+
+		frame := resp.Frames[0]
+		require.Equal(t, []int64{0, 1, 1}, fieldValues[int64](frame.Fields[0]))
+		require.Equal(t, []int64{10, 9, 0}, fieldValues[int64](frame.Fields[1]))
+		field, _ := frame.FieldByName("valueRight")
+		require.Equal(t, []int64{12, 8, 4}, fieldValues[int64](field))
+		field, _ = frame.FieldByName("selfRight")
+		require.Equal(t, []int64{0, 0, 4}, fieldValues[int64](field))
+	})
+
+	t.Run("query diff requires a different baseline", func(t *testing.T) {
+		dataQuery := makeDataQuery()
+		dataQuery.QueryType = queryTypeDiff
+		resp := ds.query(context.Background(), pCtx, *dataQuery)
+		require.EqualError(t, resp.Error, "the baseline needs a different label selector or a time shift")
+	})
 }
 
 func makeDataQuery() *backend.DataQuery {
@@ -187,6 +212,26 @@ func Test_levelsToTree(t *testing.T) {
 	})
 }
 
+func Test_diffLevelsToTree(t *testing.T) {
+	levels := []*Level{
+		{Values: []int64{0, 100, 0, 0, 50, 0, 0}},
+		{Values: []int64{0, 40, 0, 0, 30, 0, 1, 0, 0, 0, 10, 20, 20, 2}},
+		{Values: []int64{0, 15, 15, 0, 10, 10, 3}},
+	}
+
+	tree := diffLevelsToTree(levels, []string{"root", "func1", "func2", "func1:func3"})
+	require.Equal(t, &ProfileTree{
+		Start: 0, Value: 100, ValueRight: 50, Level: 0, Name: "root", Nodes: []*ProfileTree{
+			{
+				Start: 0, Value: 40, ValueRight: 30, Level: 1, Name: "func1", Nodes: []*ProfileTree{
+					{Start: 0, Value: 15, Self: 15, ValueRight: 10, SelfRight: 10, Level: 2, Name: "func1:func3"},
+				},
+			},
+			{Start: 80, ValueRight: 20, SelfRight: 20, Level: 1, Name: "func2"},
+		},
+	}, tree)
+}
+
 func Test_treeToNestedDataFrame(t *testing.T) {
 	t.Run("sample profile tree", func(t *testing.T) {
 		tree := &ProfileTree{
@@ -275,6 +320,23 @@ type FakeClient struct {
 	Args []any
 }
 
+func (f *FakeClient) GetDiffProfile(ctx context.Context, profileTypeID, baselineLabelSelector string, baselineStart, baselineEnd int64,
+	comparisonLabelSelector string, comparisonStart, comparisonEnd int64, maxNodes *int64) (*ProfileResponse, error) {
+	f.Args = []any{profileTypeID, baselineLabelSelector, baselineStart, baselineEnd, comparisonLabelSelector, comparisonStart, comparisonEnd}
+	return &ProfileResponse{
+		Flamebearer: &Flamebearer{
+			Names: []string{"foo", "bar", "baz"},
+			Levels: []*Level{
+				{Values: []int64{0, 10, 0, 0, 12, 0, 0}},
+				{Values: []int64{0, 9, 1, 0, 8, 0, 1, 0, 0, 0, 0, 4, 4, 2}},
+			},
+			Total: 22,
+		},
+		Units:  "count",
+		IsDiff: true,
+	}, nil
+}
+
 func (f *FakeClient) ProfileTypes(ctx context.Context, start int64, end int64) ([]*ProfileType, error) {
 	return []*ProfileType{
 		{
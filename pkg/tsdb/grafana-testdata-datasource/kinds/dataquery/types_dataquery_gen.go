@@ -55,6 +55,7 @@ const (
 	TestDataQueryTypeRandomWalkTable              TestDataQueryType = "random_walk_table"
 	TestDataQueryTypeRandomWalkWithError          TestDataQueryType = "random_walk_with_error"
 	TestDataQueryTypeRawFrame                     TestDataQueryType = "raw_frame"
+	TestDataQueryTypeReplay                       TestDataQueryType = "replay"
 	TestDataQueryTypeServerError500               TestDataQueryType = "server_error_500"
 	TestDataQueryTypeSimulation                   TestDataQueryType = "simulation"
 	TestDataQueryTypeSlowQuery                    TestDataQueryType = "slow_query"
@@ -164,23 +165,26 @@ type TestDataDataQuery struct {
 	CsvWave     []CSVWave `json:"csvWave,omitempty"`
 
 	// Drop percentage (the chance we will lose a point 0-100)
-	DropPercent     *float64           `json:"dropPercent,omitempty"`
-	ErrorType       *ErrorType         `json:"errorType,omitempty"`
-	FlamegraphDiff  *bool              `json:"flamegraphDiff,omitempty"`
-	Labels          *string            `json:"labels,omitempty"`
-	LevelColumn     *bool              `json:"levelColumn,omitempty"`
-	Lines           *int64             `json:"lines,omitempty"`
-	Nodes           *NodesQuery        `json:"nodes,omitempty"`
-	Points          [][]any            `json:"points,omitempty"`
-	PulseWave       *PulseWaveQuery    `json:"pulseWave,omitempty"`
-	RawFrameContent *string            `json:"rawFrameContent,omitempty"`
-	ScenarioId      *TestDataQueryType `json:"scenarioId,omitempty"`
-	SeriesCount     *int32             `json:"seriesCount,omitempty"`
-	Sim             *SimulationQuery   `json:"sim,omitempty"`
-	SpanCount       *int32             `json:"spanCount,omitempty"`
-	Stream          *StreamingQuery    `json:"stream,omitempty"`
-	StringInput     *string            `json:"stringInput,omitempty"`
-	Usa             *USAQuery          `json:"usa,omitempty"`
+	DropPercent     *float64        `json:"dropPercent,omitempty"`
+	ErrorType       *ErrorType      `json:"errorType,omitempty"`
+	FlamegraphDiff  *bool           `json:"flamegraphDiff,omitempty"`
+	Labels          *string         `json:"labels,omitempty"`
+	LevelColumn     *bool           `json:"levelColumn,omitempty"`
+	Lines           *int64          `json:"lines,omitempty"`
+	Nodes           *NodesQuery     `json:"nodes,omitempty"`
+	Points          [][]any         `json:"points,omitempty"`
+	PulseWave       *PulseWaveQuery `json:"pulseWave,omitempty"`
+	RawFrameContent *string         `json:"rawFrameContent,omitempty"`
+
+	// A recorded query response to replay, in the format of the query API with the recorded time range
+	Recording   *string            `json:"recording,omitempty"`
+	ScenarioId  *TestDataQueryType `json:"scenarioId,omitempty"`
+	SeriesCount *int32             `json:"seriesCount,omitempty"`
+	Sim         *SimulationQuery   `json:"sim,omitempty"`
+	SpanCount   *int32             `json:"spanCount,omitempty"`
+	Stream      *StreamingQuery    `json:"stream,omitempty"`
+	StringInput *string            `json:"stringInput,omitempty"`
+	Usa         *USAQuery          `json:"usa,omitempty"`
 }
 
 // ErrorType defines model for TestDataDataQuery.ErrorType.
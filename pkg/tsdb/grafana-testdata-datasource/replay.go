@@ -0,0 +1,88 @@
+package testdatasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// recording is a query response recorded by the query API with the time range of the request.
+type recording struct {
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Results json.RawMessage `json:"results"`
+}
+
+func (s *Service) handleReplayScenario(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+
+	for _, q := range req.Queries {
+		model, err := GetJSONModel(q.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse query json: %v", err)
+		}
+		if len(model.Recording) == 0 {
+			continue
+		}
+		resp.Responses[q.RefID] = replayRecording(q, model.Recording)
+	}
+
+	return resp, nil
+}
+
+// replayRecording returns the recorded response of the query, the response of the same refId or the only response
+// of the recording. The times of the frames are shifted by the difference between the end of the recorded time range
+// and the end of the time range of the query.
+func replayRecording(q backend.DataQuery, content string) backend.DataResponse {
+	var rec recording
+	if err := json.Unmarshal([]byte(content), &rec); err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("invalid recording: %v", err))
+	}
+
+	var recorded backend.QueryDataResponse
+	if err := json.Unmarshal([]byte(`{"results":`+string(rec.Results)+`}`), &recorded); err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("invalid recorded results: %v", err))
+	}
+
+	res, ok := recorded.Responses[q.RefID]
+	if !ok {
+		if len(recorded.Responses) != 1 {
+			return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("the recording has no response for refId %s", q.RefID))
+		}
+		for _, r := range recorded.Responses {
+			res = r
+		}
+	}
+
+	offset := time.Duration(0)
+	if !rec.To.IsZero() {
+		offset = q.TimeRange.To.Sub(rec.To)
+	}
+	for _, frame := range res.Frames {
+		frame.RefID = q.RefID
+		for _, field := range frame.Fields {
+			shiftTimes(field, offset)
+		}
+	}
+	return res
+}
+
+func shiftTimes(field *data.Field, offset time.Duration) {
+	for i := 0; i < field.Len(); i++ {
+		switch v := field.At(i).(type) {
+		case time.Time:
+			field.Set(i, v.Add(offset))
+		case *time.Time:
+			if v != nil {
+				t := v.Add(offset)
+				field.Set(i, &t)
+			}
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package testdatasource
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayScenario(t *testing.T) {
+	s := &Service{}
+	recordedTo := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	recorded := backend.NewQueryDataResponse()
+	recorded.Responses["A"] = backend.DataResponse{Frames: data.Frames{
+		data.NewFrame("cpu",
+			data.NewField("time", nil, []time.Time{recordedTo.Add(-time.Minute), recordedTo}),
+			data.NewField("value", data.Labels{"host": "a"}, []float64{1, 2}),
+		),
+	}}
+	recorded.Responses["B"] = backend.ErrDataResponse(backend.StatusBadRequest, "bad query")
+	results, err := json.Marshal(recorded)
+	require.NoError(t, err)
+	rec := `{"from": "2024-01-01T11:00:00Z", "to": "2024-01-01T12:00:00Z", ` + string(results)[1:]
+
+	to := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	query := func(refID string, model map[string]any) backend.DataQuery {
+		b, err := json.Marshal(model)
+		require.NoError(t, err)
+		return backend.DataQuery{RefID: refID, TimeRange: backend.TimeRange{From: to.Add(-time.Hour), To: to}, JSON: b}
+	}
+
+	resp, err := s.handleReplayScenario(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			query("A", map[string]any{"recording": rec}),
+			query("B", map[string]any{"recording": rec}),
+			query("C", map[string]any{"recording": rec}),
+			query("D", map[string]any{"recording": "{"}),
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("shifts the frames to the time range of the query", func(t *testing.T) {
+		res := resp.Responses["A"]
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+		frame := res.Frames[0]
+		require.Equal(t, "A", frame.RefID)
+		require.Equal(t, to.Add(-time.Minute), frame.Fields[0].At(0))
+		require.Equal(t, to, frame.Fields[0].At(1))
+		require.Equal(t, 2.0, frame.Fields[1].At(1))
+		require.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+	})
+
+	t.Run("replays the recorded errors", func(t *testing.T) {
+		require.Equal(t, backend.StatusBadRequest, resp.Responses["B"].Status)
+		require.EqualError(t, resp.Responses["B"].Error, "bad query")
+	})
+
+	t.Run("fails without a response of the refId", func(t *testing.T) {
+		require.EqualError(t, resp.Responses["C"].Error, "the recording has no response for refId C")
+		require.ErrorContains(t, resp.Responses["D"].Error, "invalid recording")
+	})
+}
@@ -47,6 +47,7 @@ const (
 	csvFileQueryType                  queryType = "csv_file"
 	csvContentQueryType               queryType = "csv_content"
 	traceType                         queryType = "trace"
+	replayQuery                       queryType = "replay"
 )
 
 type queryType string
@@ -227,6 +228,13 @@ Timestamps will line up evenly on timeStepSeconds (For example, 60 seconds means
 		Name: "Trace",
 	})
 
+	s.registerScenario(&Scenario{
+		ID:          string(replayQuery),
+		Name:        "Replay recorded response",
+		handler:     s.handleReplayScenario,
+		Description: "Replays a response recorded with the record option of the query API, shifted to the current time range",
+	})
+
 	s.queryMux.HandleFunc("", s.handleFallbackScenario)
 }
 
@@ -272,6 +280,7 @@ type JSONModel struct {
 	CSVContent  string  `json:"csvContent"`
 	CSVFileName string  `json:"csvFileName"`
 	DropPercent float64 `json:"dropPercent"`
+	Recording   string  `json:"recording"`
 }
 
 type pulseWave struct {
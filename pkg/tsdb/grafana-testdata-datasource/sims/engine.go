@@ -64,6 +64,7 @@ func NewSimulationEngine() (*SimulationEngine, error) {
 	// Initialize each type
 	initializers := []simulationInitializer{
 		newFlightSimInfo,
+		newScriptSimInfo,
 		newSinewaveInfo,
 		newTankSimInfo,
 	}
@@ -0,0 +1,421 @@
+package sims
+
+import (
+	"fmt"
+	"go/token"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// maxScriptSeries limits the label cardinality of a scripted simulation
+	maxScriptSeries = 10000
+	// maxScriptSteps limits the ticks evaluated for a single value, larger gaps use longer ticks
+	maxScriptSteps = 1000
+)
+
+// scriptSim evaluates a simulation described by a scriptConfig. The state of every series is updated on each
+// tick of the simulation, so the values of a query start with the initial values at the beginning of its range.
+type scriptSim struct {
+	key simulationKey
+	cfg scriptConfig
+
+	variables []compiledVariable
+	anomalies []compiledAnomaly
+	series    []*scriptSeries
+
+	started bool
+	tick    int64 // the tick of the current state
+
+	mutex sync.Mutex
+}
+
+var (
+	_ Simulation = (*scriptSim)(nil)
+)
+
+type scriptConfig struct {
+	Seed      int64            `json:"seed"`
+	Variables []scriptVariable `json:"variables"`
+	Labels    []scriptLabel    `json:"labels,omitempty"`
+	Anomalies []scriptAnomaly  `json:"anomalies,omitempty"`
+}
+
+type scriptVariable struct {
+	Name    string   `json:"name"`
+	Initial float64  `json:"initial,omitempty"`
+	Update  string   `json:"update,omitempty"` // formula evaluated on every tick
+	Noise   float64  `json:"noise,omitempty"`  // random noise added to the output
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+	Hidden  bool     `json:"hidden,omitempty"` // state only, not included in the output
+}
+
+// scriptLabel adds a series for each of its values, the series are the combinations of the values of all labels.
+type scriptLabel struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values,omitempty"`
+	Count  int      `json:"count,omitempty"` // number of generated values when no values are set
+}
+
+type scriptAnomaly struct {
+	Variable string            `json:"variable"`
+	Start    *time.Time        `json:"start,omitempty"`  // first occurrence, the epoch when not set
+	Every    float64           `json:"every,omitempty"`  // seconds between occurrences, a single occurrence when not set
+	Duration float64           `json:"duration"`         // seconds
+	Value    string            `json:"value"`            // formula replacing the output value
+	Labels   map[string]string `json:"labels,omitempty"` // only the matching series, all series when not set
+}
+
+type compiledVariable struct {
+	scriptVariable
+	update scriptExpr
+}
+
+type compiledAnomaly struct {
+	scriptAnomaly
+	variable int
+	value    scriptExpr
+}
+
+type scriptSeries struct {
+	labels data.Labels
+	keys   []string // the value key of each variable
+	vars   []float64
+	rng    *rand.Rand
+}
+
+func (s *scriptSim) GetState() simulationState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return simulationState{
+		Key:    s.key,
+		Config: s.cfg,
+	}
+}
+
+func (s *scriptSim) SetConfig(vals map[string]any) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cfg := s.cfg
+	err := updateConfigObjectFromJSON(&cfg, vals)
+	if err != nil {
+		return err
+	}
+	return s.compile(cfg)
+}
+
+// compile validates the config and resets the simulation.
+func (s *scriptSim) compile(cfg scriptConfig) error {
+	if len(cfg.Variables) == 0 {
+		return fmt.Errorf("missing variables")
+	}
+
+	names := make([]string, len(cfg.Variables))
+	for i, v := range cfg.Variables {
+		if !token.IsIdentifier(v.Name) || scriptReserved[v.Name] || isScriptConstant(v.Name) {
+			return fmt.Errorf("invalid variable name %q", v.Name)
+		}
+		for _, name := range names[:i] {
+			if name == v.Name {
+				return fmt.Errorf("duplicate variable %s", v.Name)
+			}
+		}
+		names[i] = v.Name
+	}
+
+	variables := make([]compiledVariable, len(cfg.Variables))
+	for i, v := range cfg.Variables {
+		variables[i] = compiledVariable{scriptVariable: v}
+		if v.Update == "" {
+			continue
+		}
+		update, err := compileScriptExpr(v.Update, names, false)
+		if err != nil {
+			return fmt.Errorf("variable %s: %w", v.Name, err)
+		}
+		variables[i].update = update
+	}
+
+	anomalies := make([]compiledAnomaly, len(cfg.Anomalies))
+	for i, a := range cfg.Anomalies {
+		idx := -1
+		for j, name := range names {
+			if name == a.Variable {
+				idx = j
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("anomaly %d: unknown variable %q", i, a.Variable)
+		}
+		if a.Duration <= 0 {
+			return fmt.Errorf("anomaly %d: duration must be positive", i)
+		}
+		if a.Every < 0 || (a.Every > 0 && a.Every < a.Duration) {
+			return fmt.Errorf("anomaly %d: every must not be shorter than the duration", i)
+		}
+		if a.Every == 0 && a.Start == nil {
+			return fmt.Errorf("anomaly %d: missing start or every", i)
+		}
+		value, err := compileScriptExpr(a.Value, names, true)
+		if err != nil {
+			return fmt.Errorf("anomaly %d: %w", i, err)
+		}
+		anomalies[i] = compiledAnomaly{scriptAnomaly: a, variable: idx, value: value}
+	}
+
+	labels, err := scriptLabelSets(cfg.Labels)
+	if err != nil {
+		return err
+	}
+	series := make([]*scriptSeries, len(labels))
+	for i, l := range labels {
+		keys := make([]string, len(names))
+		for j, name := range names {
+			keys[j] = valueKey(name, l)
+		}
+		series[i] = &scriptSeries{
+			labels: l,
+			keys:   keys,
+			vars:   make([]float64, len(names)),
+		}
+	}
+
+	s.cfg = cfg
+	s.variables = variables
+	s.anomalies = anomalies
+	s.series = series
+	s.started = false
+	return nil
+}
+
+// scriptLabelSets returns the labels of every series.
+func scriptLabelSets(labels []scriptLabel) ([]data.Labels, error) {
+	sets := []data.Labels{nil}
+	for _, label := range labels {
+		if label.Name == "" {
+			return nil, fmt.Errorf("missing label name")
+		}
+		values := label.Values
+		if len(values) == 0 {
+			if label.Count <= 0 {
+				return nil, fmt.Errorf("label %s: missing values or count", label.Name)
+			}
+			values = make([]string, label.Count)
+			for i := range values {
+				values[i] = fmt.Sprintf("%s-%d", label.Name, i+1)
+			}
+		}
+		if len(sets)*len(values) > maxScriptSeries {
+			return nil, fmt.Errorf("too many series, the limit is %d", maxScriptSeries)
+		}
+
+		next := make([]data.Labels, 0, len(sets)*len(values))
+		for _, set := range sets {
+			for _, v := range values {
+				l := set.Copy()
+				if l == nil {
+					l = data.Labels{}
+				}
+				l[label.Name] = v
+				next = append(next, l)
+			}
+		}
+		sets = next
+	}
+	return sets, nil
+}
+
+func (s *scriptSim) NewFrame(size int) *data.Frame {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	frame := data.NewFrame("")
+	frame.Fields = append(frame.Fields, data.NewField(data.TimeSeriesTimeFieldName, nil, make([]time.Time, size)))
+	for _, series := range s.series {
+		for _, v := range s.variables {
+			if v.Hidden {
+				continue
+			}
+			frame.Fields = append(frame.Fields, data.NewField(v.Name, series.labels, make([]float64, size)))
+		}
+	}
+	return frame
+}
+
+func (s *scriptSim) GetValues(t time.Time) map[string]any {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	hz := s.key.TickHZ
+	tick := int64(math.Floor(float64(t.UnixNano()) / float64(time.Second) * hz))
+	if !s.started || tick < s.tick {
+		s.reset(tick)
+	} else if tick > s.tick {
+		s.advance(tick)
+	}
+
+	env := &scriptEnv{t: float64(t.UnixNano()) / float64(time.Second)}
+	values := make(map[string]any, len(s.series)*len(s.variables)+1)
+	values[data.TimeSeriesTimeFieldName] = t
+	for i, series := range s.series {
+		env.vars = series.vars
+		env.series = float64(i)
+		for j, v := range s.variables {
+			if v.Hidden {
+				continue
+			}
+			value := series.vars[j]
+			if v.Noise > 0 {
+				value += (noiseAt(s.cfg.Seed, tick, i, j)*2.0 - 1.0) * v.Noise
+			}
+			for _, a := range s.anomalies {
+				if a.variable == j && a.active(t) && series.labels.Contains(a.Labels) {
+					env.value = value
+					env.rng = anomalyRand(s.cfg.Seed, tick, i, j)
+					value = a.value(env)
+				}
+			}
+			values[series.keys[j]] = value
+		}
+	}
+	return values
+}
+
+// reset sets the initial values of every series.
+func (s *scriptSim) reset(tick int64) {
+	for i, series := range s.series {
+		for j, v := range s.variables {
+			series.vars[j] = v.Initial
+		}
+		series.rng = rand.New(rand.NewSource(s.cfg.Seed + tick*int64(len(s.series)) + int64(i)))
+	}
+	s.started = true
+	s.tick = tick
+}
+
+// advance evaluates the updates of the ticks since the current state, gaps of more than maxScriptSteps ticks are
+// evaluated with longer ticks.
+func (s *scriptSim) advance(tick int64) {
+	steps := tick - s.tick
+	if steps > maxScriptSteps {
+		steps = maxScriptSteps
+	}
+	dt := float64(tick-s.tick) / s.key.TickHZ / float64(steps)
+	start := float64(s.tick) / s.key.TickHZ
+
+	env := &scriptEnv{dt: dt}
+	for step := int64(1); step <= steps; step++ {
+		env.t = start + float64(step)*dt
+		for i, series := range s.series {
+			env.vars = series.vars
+			env.series = float64(i)
+			env.rng = series.rng
+			for j, v := range s.variables {
+				if v.update == nil {
+					continue
+				}
+				value := v.update(env)
+				if v.Min != nil && value < *v.Min {
+					value = *v.Min
+				}
+				if v.Max != nil && value > *v.Max {
+					value = *v.Max
+				}
+				series.vars[j] = value
+			}
+		}
+	}
+	s.tick = tick
+}
+
+func (a *compiledAnomaly) active(t time.Time) bool {
+	start := time.Unix(0, 0)
+	if a.Start != nil {
+		start = *a.Start
+	}
+	since := t.Sub(start).Seconds()
+	if since < 0 {
+		return false
+	}
+	if a.Every > 0 {
+		since = math.Mod(since, a.Every)
+	}
+	return since < a.Duration
+}
+
+// noiseAt returns a random number in [0,1) that only depends on its arguments, so the noise of a value does not
+// depend on how often the simulation is queried.
+func noiseAt(seed int64, tick int64, series int, variable int) float64 {
+	// splitmix64
+	x := uint64(seed) ^ uint64(tick)*0x9e3779b97f4a7c15 ^ uint64(series)<<20 ^ uint64(variable)
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11) / (1 << 53)
+}
+
+// anomalyRand returns the random source of the anomalies of a variable at a tick. Like the noise, the values of
+// the anomalies do not depend on the queries, so they do not use the random source of the updates.
+func anomalyRand(seed int64, tick int64, series int, variable int) *rand.Rand {
+	return rand.New(rand.NewSource(int64(noiseAt(seed, tick, series, variable) * (1 << 53))))
+}
+
+func (s *scriptSim) Close() error {
+	return nil
+}
+
+func newScriptSimInfo() simulationInfo {
+	min := 0.0
+	max := 100.0
+	defaultConfig := scriptConfig{
+		Variables: []scriptVariable{
+			{Name: "cpu", Initial: 50, Update: "cpu + normal() * 2", Min: &min, Max: &max},
+			{Name: "requests", Initial: 100, Update: "100 + 50 * sin(2 * pi * t / 300)", Noise: 5},
+		},
+		Labels: []scriptLabel{
+			{Name: "host", Count: 3},
+		},
+		Anomalies: []scriptAnomaly{
+			{Variable: "cpu", Every: 600, Duration: 30, Value: "100"},
+		},
+	}
+
+	df := data.NewFrame("")
+	df.Fields = append(df.Fields, data.NewField("seed", nil, []int64{defaultConfig.Seed}))
+
+	return simulationInfo{
+		Type:         "script",
+		Name:         "Scripted",
+		Description:  "Simulation described by variables, formulas, noise, anomalies and labels",
+		ConfigFields: df,
+		OnlyForward:  false,
+		create: func(state simulationState) (Simulation, error) {
+			s := &scriptSim{
+				key: state.Key,
+			}
+			vals, err := asStringMap(state.Config)
+			if err != nil {
+				return nil, err
+			}
+			cfg := defaultConfig
+			if len(vals) > 0 {
+				cfg = scriptConfig{}
+				if err := updateConfigObjectFromJSON(&cfg, vals); err != nil {
+					return nil, err
+				}
+			}
+			if err := s.compile(cfg); err != nil {
+				return nil, err
+			}
+			return s, nil
+		},
+	}
+}
@@ -0,0 +1,257 @@
+package sims
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// scriptEnv holds the values a formula is evaluated with.
+type scriptEnv struct {
+	vars   []float64 // current values of the variables
+	value  float64   // output value of the variable an anomaly is applied to
+	t      float64   // seconds since the epoch
+	dt     float64   // seconds since the previous tick
+	series float64   // index of the series
+	rng    *rand.Rand
+}
+
+type scriptExpr func(env *scriptEnv) float64
+
+// scriptConstants can be used in any formula.
+var scriptConstants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+func isScriptConstant(name string) bool {
+	_, ok := scriptConstants[name]
+	return ok
+}
+
+type scriptFunc struct {
+	args int // -1 for one or more arguments
+	eval func(env *scriptEnv, args []float64) float64
+}
+
+func unaryFunc(f func(float64) float64) scriptFunc {
+	return scriptFunc{args: 1, eval: func(_ *scriptEnv, args []float64) float64 { return f(args[0]) }}
+}
+
+var scriptFuncs = map[string]scriptFunc{
+	"abs":   unaryFunc(math.Abs),
+	"ceil":  unaryFunc(math.Ceil),
+	"floor": unaryFunc(math.Floor),
+	"round": unaryFunc(math.Round),
+	"sqrt":  unaryFunc(math.Sqrt),
+	"exp":   unaryFunc(math.Exp),
+	"log":   unaryFunc(math.Log),
+	"sin":   unaryFunc(math.Sin),
+	"cos":   unaryFunc(math.Cos),
+	"tan":   unaryFunc(math.Tan),
+	"pow": {args: 2, eval: func(_ *scriptEnv, args []float64) float64 {
+		return math.Pow(args[0], args[1])
+	}},
+	"min": {args: -1, eval: func(_ *scriptEnv, args []float64) float64 {
+		v := args[0]
+		for _, a := range args[1:] {
+			v = math.Min(v, a)
+		}
+		return v
+	}},
+	"max": {args: -1, eval: func(_ *scriptEnv, args []float64) float64 {
+		v := args[0]
+		for _, a := range args[1:] {
+			v = math.Max(v, a)
+		}
+		return v
+	}},
+	"clamp": {args: 3, eval: func(_ *scriptEnv, args []float64) float64 {
+		return math.Max(args[1], math.Min(args[2], args[0]))
+	}},
+	"when": {args: 3, eval: func(_ *scriptEnv, args []float64) float64 {
+		if args[0] != 0 {
+			return args[1]
+		}
+		return args[2]
+	}},
+	// random returns a uniform random number in [0,1)
+	"random": {args: 0, eval: func(env *scriptEnv, _ []float64) float64 {
+		return env.rng.Float64()
+	}},
+	// normal returns a normally distributed random number with a mean of 0 and a standard deviation of 1
+	"normal": {args: 0, eval: func(env *scriptEnv, _ []float64) float64 {
+		return env.rng.NormFloat64()
+	}},
+}
+
+// scriptReserved are the names that are not available for variables.
+var scriptReserved = map[string]bool{
+	"t":      true,
+	"dt":     true,
+	"series": true,
+	"value":  true,
+}
+
+// compileScriptExpr compiles a formula that uses the variables of names. The value of the variable an anomaly is
+// applied to is only available when withValue is set.
+func compileScriptExpr(src string, names []string, withValue bool) (scriptExpr, error) {
+	node, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid formula %q: %w", src, err)
+	}
+	c := &scriptCompiler{vars: make(map[string]int, len(names)), withValue: withValue}
+	for i, name := range names {
+		c.vars[name] = i
+	}
+	expr, err := c.compile(node)
+	if err != nil {
+		return nil, fmt.Errorf("invalid formula %q: %w", src, err)
+	}
+	return expr, nil
+}
+
+type scriptCompiler struct {
+	vars      map[string]int
+	withValue bool
+}
+
+func (c *scriptCompiler) compile(node ast.Expr) (scriptExpr, error) {
+	switch n := node.(type) {
+	case *ast.ParenExpr:
+		return c.compile(n.X)
+	case *ast.BasicLit:
+		if n.Kind != token.INT && n.Kind != token.FLOAT {
+			return nil, fmt.Errorf("unsupported literal %s", n.Value)
+		}
+		v, err := strconv.ParseFloat(n.Value, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(*scriptEnv) float64 { return v }, nil
+	case *ast.Ident:
+		return c.ident(n.Name)
+	case *ast.UnaryExpr:
+		x, err := c.compile(n.X)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case token.ADD:
+			return x, nil
+		case token.SUB:
+			return func(env *scriptEnv) float64 { return -x(env) }, nil
+		case token.NOT:
+			return func(env *scriptEnv) float64 { return boolValue(x(env) == 0) }, nil
+		}
+		return nil, fmt.Errorf("unsupported operator %s", n.Op)
+	case *ast.BinaryExpr:
+		return c.binary(n)
+	case *ast.CallExpr:
+		return c.call(n)
+	}
+	return nil, fmt.Errorf("unsupported expression at position %d", node.Pos())
+}
+
+func (c *scriptCompiler) ident(name string) (scriptExpr, error) {
+	if idx, ok := c.vars[name]; ok {
+		return func(env *scriptEnv) float64 { return env.vars[idx] }, nil
+	}
+	if v, ok := scriptConstants[name]; ok {
+		return func(*scriptEnv) float64 { return v }, nil
+	}
+	switch name {
+	case "t":
+		return func(env *scriptEnv) float64 { return env.t }, nil
+	case "dt":
+		return func(env *scriptEnv) float64 { return env.dt }, nil
+	case "series":
+		return func(env *scriptEnv) float64 { return env.series }, nil
+	case "value":
+		if c.withValue {
+			return func(env *scriptEnv) float64 { return env.value }, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown variable %s", name)
+}
+
+func (c *scriptCompiler) binary(n *ast.BinaryExpr) (scriptExpr, error) {
+	x, err := c.compile(n.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := c.compile(n.Y)
+	if err != nil {
+		return nil, err
+	}
+	switch n.Op {
+	case token.ADD:
+		return func(env *scriptEnv) float64 { return x(env) + y(env) }, nil
+	case token.SUB:
+		return func(env *scriptEnv) float64 { return x(env) - y(env) }, nil
+	case token.MUL:
+		return func(env *scriptEnv) float64 { return x(env) * y(env) }, nil
+	case token.QUO:
+		return func(env *scriptEnv) float64 { return x(env) / y(env) }, nil
+	case token.REM:
+		return func(env *scriptEnv) float64 { return math.Mod(x(env), y(env)) }, nil
+	case token.EQL:
+		return func(env *scriptEnv) float64 { return boolValue(x(env) == y(env)) }, nil
+	case token.NEQ:
+		return func(env *scriptEnv) float64 { return boolValue(x(env) != y(env)) }, nil
+	case token.LSS:
+		return func(env *scriptEnv) float64 { return boolValue(x(env) < y(env)) }, nil
+	case token.LEQ:
+		return func(env *scriptEnv) float64 { return boolValue(x(env) <= y(env)) }, nil
+	case token.GTR:
+		return func(env *scriptEnv) float64 { return boolValue(x(env) > y(env)) }, nil
+	case token.GEQ:
+		return func(env *scriptEnv) float64 { return boolValue(x(env) >= y(env)) }, nil
+	case token.LAND:
+		return func(env *scriptEnv) float64 { return boolValue(x(env) != 0 && y(env) != 0) }, nil
+	case token.LOR:
+		return func(env *scriptEnv) float64 { return boolValue(x(env) != 0 || y(env) != 0) }, nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", n.Op)
+}
+
+func (c *scriptCompiler) call(n *ast.CallExpr) (scriptExpr, error) {
+	name, ok := n.Fun.(*ast.Ident)
+	if !ok {
+		return nil, fmt.Errorf("unsupported function call at position %d", n.Pos())
+	}
+	fn, ok := scriptFuncs[name.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name.Name)
+	}
+	if (fn.args < 0 && len(n.Args) == 0) || (fn.args >= 0 && len(n.Args) != fn.args) {
+		return nil, fmt.Errorf("wrong number of arguments for %s", name.Name)
+	}
+
+	args := make([]scriptExpr, len(n.Args))
+	for i, arg := range n.Args {
+		expr, err := c.compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = expr
+	}
+	return func(env *scriptEnv) float64 {
+		vals := make([]float64, len(args))
+		for i, arg := range args {
+			vals[i] = arg(env)
+		}
+		return fn.eval(env, vals)
+	}, nil
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,260 @@
+package sims
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptExpr(t *testing.T) {
+	env := &scriptEnv{vars: []float64{2, 10}, value: 7, t: 60, dt: 0.5, series: 3, rng: rand.New(rand.NewSource(1))}
+
+	tests := map[string]float64{
+		"1 + 2 * 3":          7,
+		"(1 + 2) * 3":        9,
+		"-a + b / 4":         0.5,
+		"b % 3":              1,
+		"a < b && b > 5":     1,
+		"!(a == 2) || a > 3": 0,
+		"when(a > 1, b, 0)":  10,
+		"max(a, b, 4)":       10,
+		"min(a, b, 4)":       2,
+		"clamp(b, 0, 5)":     5,
+		"pow(a, 3)":          8,
+		"t / 60 + dt":        1.5,
+		"series":             3,
+		"value * 2":          14,
+		"floor(sin(pi / 2))": 1,
+	}
+	for src, expected := range tests {
+		expr, err := compileScriptExpr(src, []string{"a", "b"}, true)
+		require.NoError(t, err, src)
+		require.InDelta(t, expected, expr(env), 1e-9, src)
+	}
+
+	for src, msg := range map[string]string{
+		"a +":         "invalid formula \"a +\": 1:4: expected operand, found 'EOF'",
+		"c":           "invalid formula \"c\": unknown variable c",
+		"value":       "invalid formula \"value\": unknown variable value",
+		"foo(a)":      "invalid formula \"foo(a)\": unknown function foo",
+		"pow(a)":      "invalid formula \"pow(a)\": wrong number of arguments for pow",
+		"a & b":       "invalid formula \"a & b\": unsupported operator &",
+		"\"a\" + b":   "invalid formula \"\\\"a\\\" + b\": unsupported literal \"a\"",
+		"math.Sin(a)": "invalid formula \"math.Sin(a)\": unsupported function call at position 1",
+	} {
+		_, err := compileScriptExpr(src, []string{"a", "b"}, false)
+		require.EqualError(t, err, msg, src)
+	}
+}
+
+func TestScriptSimulation(t *testing.T) {
+	start := time.Date(2020, time.January, 10, 23, 0, 0, 0, time.UTC)
+
+	newSim := func(t *testing.T, cfg map[string]any) *scriptSim {
+		t.Helper()
+		sim, err := newScriptSimInfo().create(simulationState{
+			Key:    simulationKey{Type: "script", TickHZ: 1},
+			Config: cfg,
+		})
+		require.NoError(t, err)
+		return sim.(*scriptSim)
+	}
+
+	t.Run("updates the state on every tick", func(t *testing.T) {
+		sim := newSim(t, map[string]any{
+			"variables": []map[string]any{
+				{"name": "count", "update": "count + 1"},
+				{"name": "double", "update": "count * 2"},
+				{"name": "level", "initial": 5, "update": "level - dt * 2", "min": 0},
+			},
+		})
+
+		v := sim.GetValues(start)
+		require.Equal(t, map[string]any{"Time": start, "count": 0.0, "double": 0.0, "level": 5.0}, v)
+
+		v = sim.GetValues(start.Add(2 * time.Second))
+		require.Equal(t, 2.0, v["count"])
+		require.Equal(t, 4.0, v["double"]) // sees the value of the same tick
+		require.Equal(t, 1.0, v["level"])
+
+		v = sim.GetValues(start.Add(10 * time.Second))
+		require.Equal(t, 10.0, v["count"])
+		require.Equal(t, 0.0, v["level"])
+
+		// going back restarts the simulation
+		v = sim.GetValues(start.Add(time.Second))
+		require.Equal(t, 0.0, v["count"])
+	})
+
+	t.Run("long gaps are evaluated with longer ticks", func(t *testing.T) {
+		sim := newSim(t, map[string]any{
+			"variables": []map[string]any{
+				{"name": "steps", "update": "steps + 1"},
+				{"name": "elapsed", "update": "elapsed + dt"},
+			},
+		})
+		sim.GetValues(start)
+		v := sim.GetValues(start.Add(time.Hour))
+		require.Equal(t, float64(maxScriptSteps), v["steps"])
+		require.InDelta(t, 3600.0, v["elapsed"], 1e-6)
+	})
+
+	t.Run("adds a series for each combination of labels", func(t *testing.T) {
+		sim := newSim(t, map[string]any{
+			"variables": []map[string]any{
+				{"name": "id", "update": "series"},
+				{"name": "hidden", "hidden": true},
+			},
+			"labels": []map[string]any{
+				{"name": "region", "values": []string{"eu", "us"}},
+				{"name": "host", "count": 3},
+			},
+		})
+
+		frame := sim.NewFrame(0)
+		require.Len(t, frame.Fields, 7)
+		require.Equal(t, data.Labels{"region": "eu", "host": "host-1"}, frame.Fields[1].Labels)
+		require.Equal(t, data.Labels{"region": "us", "host": "host-3"}, frame.Fields[6].Labels)
+
+		sim.GetValues(start)
+		appendFrameRow(frame, sim.GetValues(start.Add(time.Second)))
+		require.Equal(t, 1, frame.Rows())
+		for i, field := range frame.Fields[1:] {
+			require.Equal(t, "id", field.Name)
+			require.Equal(t, float64(i), field.At(0))
+		}
+	})
+
+	t.Run("adds noise that does not depend on the queries", func(t *testing.T) {
+		cfg := map[string]any{
+			"seed":      42,
+			"variables": []map[string]any{{"name": "v", "initial": 10, "noise": 1}},
+		}
+		a := newSim(t, cfg)
+		b := newSim(t, cfg)
+
+		b.GetValues(start.Add(-time.Minute))
+		for i := 0; i < 10; i++ {
+			ts := start.Add(time.Duration(i) * time.Second)
+			va := a.GetValues(ts)["v"].(float64)
+			require.Equal(t, va, b.GetValues(ts)["v"])
+			require.InDelta(t, 10.0, va, 1)
+			require.NotEqual(t, 10.0, va)
+		}
+	})
+
+	t.Run("injects scheduled anomalies", func(t *testing.T) {
+		sim := newSim(t, map[string]any{
+			"variables": []map[string]any{{"name": "v", "initial": 1}},
+			"labels":    []map[string]any{{"name": "host", "values": []string{"a", "b"}}},
+			"anomalies": []map[string]any{
+				{"variable": "v", "every": 60, "duration": 10, "value": "value * 100", "labels": map[string]string{"host": "b"}},
+				{"variable": "v", "start": start.Add(30 * time.Second), "duration": 5, "value": "-1"},
+			},
+		})
+
+		key := func(host string) string {
+			return valueKey("v", data.Labels{"host": host})
+		}
+		for offset, expected := range map[time.Duration][]float64{
+			0:                {1, 100},
+			9 * time.Second:  {1, 100},
+			10 * time.Second: {1, 1},
+			30 * time.Second: {-1, -1},
+			35 * time.Second: {1, 1},
+			61 * time.Second: {1, 100},
+			90 * time.Second: {1, 1},
+		} {
+			v := sim.GetValues(start.Add(offset))
+			require.Equal(t, expected, []float64{v[key("a")].(float64), v[key("b")].(float64)}, offset)
+		}
+	})
+
+	t.Run("anomalies can use random values", func(t *testing.T) {
+		cfg := map[string]any{
+			"seed":      42,
+			"variables": []map[string]any{{"name": "v", "initial": 1}},
+			"anomalies": []map[string]any{{"variable": "v", "every": 60, "duration": 10, "value": "value + 10 + random() + normal()"}},
+		}
+		a := newSim(t, cfg)
+		b := newSim(t, cfg)
+
+		b.GetValues(start.Add(-time.Minute))
+		for i := 0; i < 10; i++ {
+			ts := start.Add(time.Duration(i) * time.Second)
+			va := a.GetValues(ts)["v"].(float64)
+			require.Equal(t, va, a.GetValues(ts)["v"])
+			require.Equal(t, va, b.GetValues(ts)["v"])
+			require.Greater(t, va, 5.0)
+		}
+	})
+
+	t.Run("validates the config", func(t *testing.T) {
+		for msg, cfg := range map[string]map[string]any{
+			"missing variables":                                     {"labels": []map[string]any{{"name": "host", "count": 2}}},
+			"invalid variable name \"t\"":                           {"variables": []map[string]any{{"name": "t"}}},
+			"duplicate variable v":                                  {"variables": []map[string]any{{"name": "v"}, {"name": "v"}}},
+			"variable v: invalid formula \"w\": unknown variable w": {"variables": []map[string]any{{"name": "v", "update": "w"}}},
+			"label host: missing values or count":                   {"variables": []map[string]any{{"name": "v"}}, "labels": []map[string]any{{"name": "host"}}},
+			"too many series, the limit is 10000":                   {"variables": []map[string]any{{"name": "v"}}, "labels": []map[string]any{{"name": "a", "count": 200}, {"name": "b", "count": 100}}},
+			"anomaly 0: unknown variable \"w\"":                     {"variables": []map[string]any{{"name": "v"}}, "anomalies": []map[string]any{{"variable": "w", "every": 10, "duration": 1, "value": "0"}}},
+			"anomaly 0: missing start or every":                     {"variables": []map[string]any{{"name": "v"}}, "anomalies": []map[string]any{{"variable": "v", "duration": 1, "value": "0"}}},
+		} {
+			_, err := newScriptSimInfo().create(simulationState{
+				Key:    simulationKey{Type: "script", TickHZ: 1},
+				Config: cfg,
+			})
+			require.EqualError(t, err, msg)
+		}
+
+		sim := newSim(t, nil)
+		require.Error(t, sim.SetConfig(map[string]any{"variables": []map[string]any{{"name": "v", "update": "v +"}}}))
+		require.Len(t, sim.GetState().Config.(scriptConfig).Variables, 2) // the default config is kept
+	})
+}
+
+func TestScriptQuery(t *testing.T) {
+	s, err := NewSimulationEngine()
+	require.NoError(t, err)
+
+	sb, err := json.Marshal(map[string]any{
+		"sim": map[string]any{
+			"key": simulationKey{Type: "script", TickHZ: 1, UID: "query"},
+			"config": map[string]any{
+				"variables": []map[string]any{{"name": "count", "update": "count + 1"}},
+				"labels":    []map[string]any{{"name": "host", "count": 2}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	start := time.Date(2020, time.January, 10, 23, 0, 0, 0, time.UTC)
+	rsp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID:         "A",
+				TimeRange:     backend.TimeRange{From: start, To: start.Add(10 * time.Second)},
+				Interval:      2 * time.Second,
+				MaxDataPoints: 10,
+				JSON:          sb,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	frames := rsp.Responses["A"].Frames
+	require.Len(t, frames, 1)
+	require.Len(t, frames[0].Fields, 3)
+	require.Equal(t, 5, frames[0].Rows())
+	for _, field := range frames[0].Fields[1:] {
+		require.Equal(t, []float64{0, 2, 4, 6, 8}, []float64{
+			field.At(0).(float64), field.At(1).(float64), field.At(2).(float64), field.At(3).(float64), field.At(4).(float64),
+		})
+	}
+}
@@ -47,9 +47,17 @@ func asStringMap(input any) (map[string]any, error) {
 	return v, err
 }
 
+// valueKey is the key of the value of a field, the name of fields without labels.
+func valueKey(name string, labels data.Labels) string {
+	if len(labels) == 0 {
+		return name
+	}
+	return name + "{" + labels.String() + "}"
+}
+
 func setFrameRow(frame *data.Frame, idx int, values map[string]any) {
 	for _, field := range frame.Fields {
-		v, ok := values[field.Name]
+		v, ok := values[valueKey(field.Name, field.Labels)]
 		if ok {
 			field.Set(idx, v)
 		}
@@ -58,7 +66,7 @@ func setFrameRow(frame *data.Frame, idx int, values map[string]any) {
 
 func appendFrameRow(frame *data.Frame, values map[string]any) {
 	for _, field := range frame.Fields {
-		v, ok := values[field.Name]
+		v, ok := values[valueKey(field.Name, field.Labels)]
 		if ok {
 			field.Append(v)
 		} else {
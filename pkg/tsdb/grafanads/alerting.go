@@ -0,0 +1,159 @@
+package grafanads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+// AlertingService gives access to the alert instances and the state history of unified alerting.
+type AlertingService interface {
+	// GetAlertStates returns the current state of the alert instances the user can read.
+	GetAlertStates(ctx context.Context, user identity.Requester) ([]*state.State, error)
+	// QueryStateHistory returns the state history of the alert rules matching the query.
+	QueryStateHistory(ctx context.Context, query ngmodels.HistoryQuery) (*data.Frame, error)
+}
+
+func (s *Service) doAlertStatesQuery(ctx context.Context, query backend.DataQuery) backend.DataResponse {
+	m, errResponse := s.parseAlertingQuery(ctx, query, ac.EvalPermission(ac.ActionAlertingInstanceRead))
+	if errResponse != nil {
+		return *errResponse
+	}
+	user, _ := appcontext.User(ctx)
+
+	all, err := s.alerting.GetAlertStates(ctx, user)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get the alert states: %v", err))
+	}
+	var states []*state.State
+	for _, st := range all {
+		if m.RuleUID != "" && st.AlertRuleUID != m.RuleUID {
+			continue
+		}
+		if m.State != "" && !strings.EqualFold(st.State.String(), m.State) {
+			continue
+		}
+		if !st.Labels.Contains(m.Labels) {
+			continue
+		}
+		states = append(states, st)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].AlertRuleUID != states[j].AlertRuleUID {
+			return states[i].AlertRuleUID < states[j].AlertRuleUID
+		}
+		return states[i].CacheID < states[j].CacheID
+	})
+
+	return backend.DataResponse{Frames: data.Frames{alertStatesToFrame(states)}}
+}
+
+func alertStatesToFrame(states []*state.State) *data.Frame {
+	ruleUIDs := make([]string, len(states))
+	labels := make([]json.RawMessage, len(states))
+	stateNames := make([]string, len(states))
+	reasons := make([]string, len(states))
+	startsAt := make([]time.Time, len(states))
+	lastEvaluations := make([]time.Time, len(states))
+	values := make([]json.RawMessage, len(states))
+
+	for i, st := range states {
+		ruleUIDs[i] = st.AlertRuleUID
+		labels[i] = marshalOrEmpty(st.Labels)
+		stateNames[i] = st.State.String()
+		reasons[i] = st.StateReason
+		startsAt[i] = st.StartsAt
+		lastEvaluations[i] = st.LastEvaluationTime
+		values[i] = marshalOrEmpty(st.Values)
+	}
+
+	return data.NewFrame("alertStates",
+		data.NewField("ruleUID", nil, ruleUIDs),
+		data.NewField("labels", nil, labels),
+		data.NewField("state", nil, stateNames),
+		data.NewField("reason", nil, reasons),
+		data.NewField("startsAt", nil, startsAt),
+		data.NewField("lastEvaluation", nil, lastEvaluations),
+		data.NewField("values", nil, values),
+	)
+}
+
+func (s *Service) doStateHistoryQuery(ctx context.Context, req *backend.QueryDataRequest, query backend.DataQuery) backend.DataResponse {
+	m, errResponse := s.parseAlertingQuery(ctx, query, ac.EvalPermission(ac.ActionAlertingRuleRead))
+	if errResponse != nil {
+		return *errResponse
+	}
+	user, _ := appcontext.User(ctx)
+
+	frame, err := s.alerting.QueryStateHistory(ctx, ngmodels.HistoryQuery{
+		RuleUID:      m.RuleUID,
+		OrgID:        req.PluginContext.OrgID,
+		DashboardUID: m.DashboardUID,
+		Labels:       m.Labels,
+		From:         query.TimeRange.From,
+		To:           query.TimeRange.To,
+		Limit:        m.Limit,
+		SignedInUser: user,
+	})
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to query the state history: %v", err))
+	}
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
+
+// parseAlertingQuery checks that alerting is available and that the signed in user is allowed to read it.
+func (s *Service) parseAlertingQuery(ctx context.Context, query backend.DataQuery, evaluator ac.Evaluator) (*alertingQueryModel, *backend.DataResponse) {
+	if s.alerting == nil {
+		rsp := backend.ErrDataResponse(backend.StatusBadRequest, "unified alerting is disabled")
+		return nil, &rsp
+	}
+	if rsp := s.authorize(ctx, evaluator); rsp != nil {
+		return nil, rsp
+	}
+
+	m := &alertingQueryModel{}
+	if err := json.Unmarshal(query.JSON, m); err != nil {
+		rsp := backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query: %v", err))
+		return nil, &rsp
+	}
+	return m, nil
+}
+
+// authorize returns an error response unless the signed in user has the permissions of the evaluator.
+func (s *Service) authorize(ctx context.Context, evaluator ac.Evaluator) *backend.DataResponse {
+	user, err := appcontext.User(ctx)
+	if err != nil {
+		rsp := backend.ErrDataResponse(backend.StatusUnauthorized, "the query requires a signed in user")
+		return &rsp
+	}
+	ok, err := s.ac.Evaluate(ctx, user, evaluator)
+	if err != nil {
+		rsp := backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to evaluate permissions: %v", err))
+		return &rsp
+	}
+	if !ok {
+		rsp := backend.ErrDataResponse(backend.StatusForbidden, fmt.Sprintf("missing permissions: %s", evaluator.String()))
+		return &rsp
+	}
+	return nil
+}
+
+func marshalOrEmpty(v any) json.RawMessage {
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return json.RawMessage("{}")
+	}
+	return b
+}
@@ -0,0 +1,109 @@
+package grafanads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/services/annotations"
+)
+
+const (
+	annotationTypeDashboard = "dashboard"
+	annotationTypeTags      = "tags"
+
+	defaultAnnotationsLimit = 100
+)
+
+func (s *Service) doAnnotationsQuery(ctx context.Context, req *backend.QueryDataRequest, query backend.DataQuery) backend.DataResponse {
+	user, err := appcontext.User(ctx)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusUnauthorized, "annotations require a signed in user")
+	}
+
+	m := annotationsQueryModel{}
+	if err := json.Unmarshal(query.JSON, &m); err != nil {
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("failed to parse query: %v", err))
+	}
+
+	q := &annotations.ItemQuery{
+		OrgID:        req.PluginContext.OrgID,
+		From:         query.TimeRange.From.UnixMilli(),
+		To:           query.TimeRange.To.UnixMilli(),
+		MatchAny:     m.MatchAny,
+		Limit:        m.Limit,
+		SignedInUser: user,
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultAnnotationsLimit
+	}
+	switch m.Type {
+	case annotationTypeDashboard:
+		if m.DashboardUID == "" {
+			return backend.ErrDataResponse(backend.StatusBadRequest, "missing dashboard UID")
+		}
+		q.DashboardUID = m.DashboardUID
+	case annotationTypeTags, "":
+		q.DashboardUID = m.DashboardUID
+		q.Tags = m.Tags
+	default:
+		return backend.ErrDataResponse(backend.StatusBadRequest, fmt.Sprintf("unknown annotation type: %s", m.Type))
+	}
+
+	items, err := s.annotationsRepo.Find(ctx, q)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to find annotations: %v", err))
+	}
+	return backend.DataResponse{Frames: data.Frames{annotationsToFrame(items)}}
+}
+
+func annotationsToFrame(items []*annotations.ItemDTO) *data.Frame {
+	ids := make([]int64, len(items))
+	times := make([]time.Time, len(items))
+	timeEnds := make([]time.Time, len(items))
+	texts := make([]string, len(items))
+	tags := make([]json.RawMessage, len(items))
+	dashboardUIDs := make([]string, len(items))
+	panelIDs := make([]int64, len(items))
+	alertIDs := make([]int64, len(items))
+	newStates := make([]string, len(items))
+	logins := make([]string, len(items))
+
+	for i, item := range items {
+		ids[i] = item.ID
+		times[i] = time.UnixMilli(item.Time).UTC()
+		timeEnds[i] = time.UnixMilli(item.TimeEnd).UTC()
+		texts[i] = item.Text
+		tags[i] = json.RawMessage("[]")
+		if len(item.Tags) > 0 {
+			if b, err := json.Marshal(item.Tags); err == nil {
+				tags[i] = b
+			}
+		}
+		if item.DashboardUID != nil {
+			dashboardUIDs[i] = *item.DashboardUID
+		}
+		panelIDs[i] = item.PanelID
+		alertIDs[i] = item.AlertID
+		newStates[i] = item.NewState
+		logins[i] = item.Login
+	}
+
+	return data.NewFrame("annotations",
+		data.NewField("id", nil, ids),
+		data.NewField("time", nil, times),
+		data.NewField("timeEnd", nil, timeEnds),
+		data.NewField("text", nil, texts),
+		data.NewField("tags", nil, tags),
+		data.NewField("dashboardUID", nil, dashboardUIDs),
+		data.NewField("panelId", nil, panelIDs),
+		data.NewField("alertId", nil, alertIDs),
+		data.NewField("newState", nil, newStates),
+		data.NewField("login", nil, logins),
+	)
+}
@@ -14,8 +14,11 @@ import (
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/searchV2"
+	"github.com/grafana/grafana/pkg/services/stats"
 	"github.com/grafana/grafana/pkg/services/store"
 	testdatasource "github.com/grafana/grafana/pkg/tsdb/grafana-testdata-datasource"
 )
@@ -51,15 +54,21 @@ var (
 	)
 )
 
-func ProvideService(search searchV2.SearchService, store store.StorageService) *Service {
-	return newService(search, store)
+func ProvideService(search searchV2.SearchService, store store.StorageService, annotationsRepo annotations.Repository,
+	statsService stats.Service, ac accesscontrol.AccessControl, alerting AlertingService) *Service {
+	return newService(search, store, annotationsRepo, statsService, ac, alerting)
 }
 
-func newService(search searchV2.SearchService, store store.StorageService) *Service {
+func newService(search searchV2.SearchService, store store.StorageService, annotationsRepo annotations.Repository,
+	statsService stats.Service, ac accesscontrol.AccessControl, alerting AlertingService) *Service {
 	s := &Service{
-		search: search,
-		store:  store,
-		log:    log.New("grafanads"),
+		search:          search,
+		store:           store,
+		annotationsRepo: annotationsRepo,
+		stats:           statsService,
+		ac:              ac,
+		alerting:        alerting,
+		log:             log.New("grafanads"),
 	}
 
 	return s
@@ -67,9 +76,13 @@ func newService(search searchV2.SearchService, store store.StorageService) *Serv
 
 // Service exists regardless of user settings
 type Service struct {
-	search searchV2.SearchService
-	store  store.StorageService
-	log    log.Logger
+	search          searchV2.SearchService
+	store           store.StorageService
+	annotationsRepo annotations.Repository
+	stats           stats.Service
+	ac              accesscontrol.AccessControl
+	alerting        AlertingService
+	log             log.Logger
 }
 
 func DataSourceModel(orgId int64) *datasources.DataSource {
@@ -97,6 +110,14 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 			response.Responses[q.RefID] = s.doReadQuery(ctx, q)
 		case queryTypeSearch:
 			response.Responses[q.RefID] = s.doSearchQuery(ctx, req, q)
+		case queryTypeAnnotations:
+			response.Responses[q.RefID] = s.doAnnotationsQuery(ctx, req, q)
+		case queryTypeAlertStates:
+			response.Responses[q.RefID] = s.doAlertStatesQuery(ctx, q)
+		case queryTypeStateHistory:
+			response.Responses[q.RefID] = s.doStateHistoryQuery(ctx, req, q)
+		case queryTypeUsage:
+			response.Responses[q.RefID] = s.doUsageQuery(ctx, req)
 		default:
 			response.Responses[q.RefID] = backend.DataResponse{
 				Error: fmt.Errorf("unknown query type"),
@@ -0,0 +1,173 @@
+package grafanads
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/auth/identity"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/stats"
+	"github.com/grafana/grafana/pkg/services/stats/statstest"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+type fakeAlerting struct {
+	states       []*state.State
+	user         identity.Requester
+	historyQuery ngmodels.HistoryQuery
+}
+
+func (f *fakeAlerting) GetAlertStates(ctx context.Context, user identity.Requester) ([]*state.State, error) {
+	f.user = user
+	return f.states, nil
+}
+
+func (f *fakeAlerting) QueryStateHistory(ctx context.Context, query ngmodels.HistoryQuery) (*data.Frame, error) {
+	f.historyQuery = query
+	return data.NewFrame("history"), nil
+}
+
+func queryRequest(queryType string, model string) *backend.QueryDataRequest {
+	return &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{OrgID: 1},
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				QueryType: queryType,
+				TimeRange: backend.TimeRange{From: time.UnixMilli(1000), To: time.UnixMilli(2000)},
+				JSON:      json.RawMessage(model),
+			},
+		},
+	}
+}
+
+func TestAnnotationsQuery(t *testing.T) {
+	ctx := appcontext.WithUser(context.Background(), &user.SignedInUser{OrgID: 1})
+	dashboardUID := "dash"
+
+	t.Run("finds the annotations with the tags", func(t *testing.T) {
+		repo := annotations.NewFakeAnnotationsRepo(t)
+		repo.On("Find", mock.Anything, mock.MatchedBy(func(q *annotations.ItemQuery) bool {
+			return q.OrgID == 1 && q.From == 1000 && q.To == 2000 && q.Limit == 100 && q.MatchAny &&
+				len(q.Tags) == 2 && q.DashboardUID == "" && q.SignedInUser != nil
+		})).Return([]*annotations.ItemDTO{
+			{ID: 3, Time: 1500, TimeEnd: 1600, Text: "deploy", Tags: []string{"a", "b"}, DashboardUID: &dashboardUID, PanelID: 2},
+		}, nil)
+		s := newService(nil, nil, repo, nil, nil, nil)
+
+		rsp, err := s.QueryData(ctx, queryRequest(queryTypeAnnotations, `{"type": "tags", "tags": ["a", "b"], "matchAny": true}`))
+		require.NoError(t, err)
+		res := rsp.Responses["A"]
+		require.NoError(t, res.Error)
+		require.Len(t, res.Frames, 1)
+		frame := res.Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, "deploy", frame.Fields[3].At(0))
+		require.Equal(t, json.RawMessage(`["a","b"]`), frame.Fields[4].At(0))
+		require.Equal(t, "dash", frame.Fields[5].At(0))
+		require.Equal(t, time.UnixMilli(1500).UTC(), frame.Fields[1].At(0))
+	})
+
+	t.Run("requires the dashboard of dashboard annotations", func(t *testing.T) {
+		s := newService(nil, nil, annotations.NewFakeAnnotationsRepo(t), nil, nil, nil)
+		rsp, err := s.QueryData(ctx, queryRequest(queryTypeAnnotations, `{"type": "dashboard"}`))
+		require.NoError(t, err)
+		require.EqualError(t, rsp.Responses["A"].Error, "missing dashboard UID")
+	})
+
+	t.Run("requires a signed in user", func(t *testing.T) {
+		s := newService(nil, nil, annotations.NewFakeAnnotationsRepo(t), nil, nil, nil)
+		rsp, err := s.QueryData(context.Background(), queryRequest(queryTypeAnnotations, `{}`))
+		require.NoError(t, err)
+		require.Equal(t, backend.StatusUnauthorized, rsp.Responses["A"].Status)
+	})
+}
+
+func TestAlertingQueries(t *testing.T) {
+	ctx := appcontext.WithUser(context.Background(), &user.SignedInUser{OrgID: 1})
+	alerting := &fakeAlerting{
+		states: []*state.State{
+			{AlertRuleUID: "b", CacheID: "1", State: eval.Normal, Labels: data.Labels{"team": "a"}},
+			{AlertRuleUID: "a", CacheID: "2", State: eval.Alerting, Labels: data.Labels{"team": "a"}, Values: map[string]float64{"B": 1}},
+			{AlertRuleUID: "a", CacheID: "1", State: eval.Alerting, Labels: data.Labels{"team": "b"}},
+		},
+	}
+
+	t.Run("returns the filtered alert states", func(t *testing.T) {
+		s := newService(nil, nil, nil, nil, actest.FakeAccessControl{ExpectedEvaluate: true}, alerting)
+
+		rsp, err := s.QueryData(ctx, queryRequest(queryTypeAlertStates, `{"state": "alerting"}`))
+		require.NoError(t, err)
+		frame := rsp.Responses["A"].Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, int64(1), alerting.user.GetOrgID())
+		require.Equal(t, json.RawMessage(`{"team":"b"}`), frame.Fields[1].At(0))
+		require.Equal(t, json.RawMessage(`{"B":1}`), frame.Fields[6].At(1))
+
+		rsp, err = s.QueryData(ctx, queryRequest(queryTypeAlertStates, `{"labels": {"team": "a"}}`))
+		require.NoError(t, err)
+		frame = rsp.Responses["A"].Frames[0]
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, "a", frame.Fields[0].At(0))
+		require.Equal(t, "Normal", frame.Fields[2].At(1))
+	})
+
+	t.Run("queries the state history", func(t *testing.T) {
+		s := newService(nil, nil, nil, nil, actest.FakeAccessControl{ExpectedEvaluate: true}, alerting)
+
+		rsp, err := s.QueryData(ctx, queryRequest(queryTypeStateHistory, `{"ruleUID": "a", "labels": {"team": "a"}, "limit": 10}`))
+		require.NoError(t, err)
+		require.NoError(t, rsp.Responses["A"].Error)
+		require.Equal(t, "a", alerting.historyQuery.RuleUID)
+		require.Equal(t, int64(1), alerting.historyQuery.OrgID)
+		require.Equal(t, 10, alerting.historyQuery.Limit)
+		require.Equal(t, map[string]string{"team": "a"}, alerting.historyQuery.Labels)
+		require.Equal(t, time.UnixMilli(1000), alerting.historyQuery.From)
+	})
+
+	t.Run("requires permissions and alerting", func(t *testing.T) {
+		s := newService(nil, nil, nil, nil, actest.FakeAccessControl{ExpectedEvaluate: false}, alerting)
+		rsp, err := s.QueryData(ctx, queryRequest(queryTypeAlertStates, `{}`))
+		require.NoError(t, err)
+		require.Equal(t, backend.StatusForbidden, rsp.Responses["A"].Status)
+
+		s = newService(nil, nil, nil, nil, actest.FakeAccessControl{ExpectedEvaluate: true}, nil)
+		rsp, err = s.QueryData(ctx, queryRequest(queryTypeStateHistory, `{}`))
+		require.NoError(t, err)
+		require.EqualError(t, rsp.Responses["A"].Error, "unified alerting is disabled")
+	})
+}
+
+func TestUsageQuery(t *testing.T) {
+	ctx := appcontext.WithUser(context.Background(), &user.SignedInUser{OrgID: 1})
+	statsService := statstest.NewFakeService()
+	statsService.ExpectedOrgStats = &stats.OrgStats{Dashboards: 10, Users: 3}
+
+	s := newService(nil, nil, nil, statsService, actest.FakeAccessControl{ExpectedEvaluate: true}, nil)
+	rsp, err := s.QueryData(ctx, queryRequest(queryTypeUsage, `{}`))
+	require.NoError(t, err)
+	frame := rsp.Responses["A"].Frames[0]
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, "activeUsers", frame.Fields[0].Name)
+	field, _ := frame.FieldByName("dashboards")
+	require.Equal(t, int64(10), field.At(0))
+	field, _ = frame.FieldByName("users")
+	require.Equal(t, int64(3), field.At(0))
+
+	s = newService(nil, nil, nil, statsService, actest.FakeAccessControl{ExpectedEvaluate: false}, nil)
+	rsp, err = s.QueryData(ctx, queryRequest(queryTypeUsage, `{}`))
+	require.NoError(t, err)
+	require.Equal(t, backend.StatusForbidden, rsp.Responses["A"].Status)
+}
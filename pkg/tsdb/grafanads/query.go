@@ -14,6 +14,18 @@ const (
 	// currently only .csv files are supported,
 	// other file types will eventually be supported (parquet, etc)
 	queryTypeRead = "read"
+
+	// queryTypeAnnotations returns the annotations of the time range
+	queryTypeAnnotations = "annotations"
+
+	// queryTypeAlertStates returns the current state of the alert instances
+	queryTypeAlertStates = "alertStates"
+
+	// queryTypeStateHistory returns the state history of the alert rules in the time range
+	queryTypeStateHistory = "stateHistory"
+
+	// queryTypeUsage returns the usage statistics of the org
+	queryTypeUsage = "usage"
 )
 
 type listQueryModel struct {
@@ -22,3 +34,19 @@ type listQueryModel struct {
 type readQueryModel struct {
 	Path string `json:"path"`
 }
+
+type annotationsQueryModel struct {
+	Type         string   `json:"type"` // dashboard or tags
+	DashboardUID string   `json:"dashboardUID"`
+	Tags         []string `json:"tags"`
+	MatchAny     bool     `json:"matchAny"`
+	Limit        int64    `json:"limit"`
+}
+
+type alertingQueryModel struct {
+	RuleUID      string            `json:"ruleUID"`
+	DashboardUID string            `json:"dashboardUID"` // state history only
+	Labels       map[string]string `json:"labels"`
+	State        string            `json:"state"` // alert states only
+	Limit        int               `json:"limit"` // state history only
+}
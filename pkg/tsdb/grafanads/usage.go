@@ -0,0 +1,49 @@
+package grafanads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/stats"
+)
+
+// doUsageQuery returns the statistics of the org of the request as a frame with a single row. Like the org API, it
+// only requires to be able to read the org.
+func (s *Service) doUsageQuery(ctx context.Context, req *backend.QueryDataRequest) backend.DataResponse {
+	if rsp := s.authorize(ctx, ac.EvalPermission(ac.ActionOrgsRead)); rsp != nil {
+		return *rsp
+	}
+
+	orgStats, err := s.stats.GetOrgStats(ctx, &stats.GetOrgStatsQuery{OrgID: req.PluginContext.OrgID})
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, fmt.Sprintf("failed to get the usage statistics: %v", err))
+	}
+
+	// use the JSON names of the statistics
+	b, err := json.Marshal(orgStats)
+	if err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, err.Error())
+	}
+	values := map[string]int64{}
+	if err := json.Unmarshal(b, &values); err != nil {
+		return backend.ErrDataResponse(backend.StatusInternal, err.Error())
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	frame := data.NewFrame("usage")
+	for _, name := range names {
+		frame.Fields = append(frame.Fields, data.NewField(name, nil, []int64{values[name]}))
+	}
+	return backend.DataResponse{Frames: data.Frames{frame}}
+}
@@ -0,0 +1,227 @@
+package graphite
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// maxConcurrentFetches limits the metric paths fetched from the render API at the same time.
+const maxConcurrentFetches = 4
+
+// defaultMaxDataPoints is used for queries without max data points, like the render requests of the targets.
+const defaultMaxDataPoints = 500
+
+// fetchKey identifies the raw series of a metric path, queries in a request may use different time ranges.
+type fetchKey struct {
+	path  string
+	from  string
+	until string
+}
+
+type fetchResult struct {
+	list []*series
+	err  error
+}
+
+type evaluatedQuery struct {
+	query backend.DataQuery
+	ex    *expr
+}
+
+// evaluateQueries evaluates the functions of the query targets in Grafana. Only the metric paths of the targets are
+// sent to Graphite, so the queries work with stores that do not implement the functions. Targets with functions
+// Grafana does not evaluate are sent to Graphite as they are.
+func (s *Service) evaluateQueries(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	result := backend.NewQueryDataResponse()
+	var evaluated []evaluatedQuery
+	var rendered []backend.DataQuery
+	for _, query := range req.Queries {
+		model, err := simplejson.NewJson(query.JSON)
+		if err != nil {
+			return nil, err
+		}
+		target := getTarget(model)
+		if target == "" {
+			logger.Debug("Graphite", "empty query target", model)
+			continue
+		}
+
+		ex, err := parseTarget(target)
+		if err != nil {
+			result.Responses[query.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
+		}
+		if name := unsupportedFunction(ex); name != "" {
+			logger.Debug("Sending target to Graphite, the function is not evaluated in Grafana", "refId", query.RefID, "function", name)
+			rendered = append(rendered, query)
+			continue
+		}
+		evaluated = append(evaluated, evaluatedQuery{query: query, ex: ex})
+	}
+
+	fetched := s.fetchPaths(ctx, logger, dsInfo, evaluated)
+	for _, q := range evaluated {
+		frames, err := evaluateTarget(q, fetched)
+		if err != nil {
+			result.Responses[q.query.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
+		}
+		result.Responses[q.query.RefID] = backend.DataResponse{Frames: frames}
+	}
+
+	if len(rendered) > 0 {
+		res, err := s.renderTargets(ctx, logger, dsInfo, &backend.QueryDataRequest{
+			PluginContext: req.PluginContext,
+			Headers:       req.Headers,
+			Queries:       rendered,
+		})
+		for _, query := range rendered {
+			if err != nil {
+				result.Responses[query.RefID] = backend.ErrDataResponse(backend.StatusBadGateway, err.Error())
+				continue
+			}
+			if resp, ok := res.Responses[query.RefID]; ok {
+				result.Responses[query.RefID] = resp
+			}
+		}
+	}
+	return result, nil
+}
+
+// fetchPaths fetches the metric paths of the queries once each, with at most maxConcurrentFetches requests at a time.
+func (s *Service) fetchPaths(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, queries []evaluatedQuery) map[fetchKey]fetchResult {
+	var mu sync.Mutex
+	fetched := map[fetchKey]fetchResult{}
+	var g errgroup.Group
+	g.SetLimit(maxConcurrentFetches)
+	for _, q := range queries {
+		for _, path := range metricPaths(q.ex) {
+			key := newFetchKey(q.query, path)
+			mu.Lock()
+			_, ok := fetched[key]
+			if !ok {
+				fetched[key] = fetchResult{}
+			}
+			mu.Unlock()
+			if ok {
+				continue
+			}
+			g.Go(func() error {
+				list, err := s.fetchSeries(ctx, logger, dsInfo, key)
+				mu.Lock()
+				fetched[key] = fetchResult{list: list, err: err}
+				mu.Unlock()
+				return nil
+			})
+		}
+	}
+	_ = g.Wait()
+	return fetched
+}
+
+func newFetchKey(query backend.DataQuery, path string) fetchKey {
+	from, until := epochMStoGraphiteTime(query.TimeRange)
+	return fetchKey{path: path, from: from, until: until}
+}
+
+func evaluateTarget(q evaluatedQuery, fetched map[fetchKey]fetchResult) (data.Frames, error) {
+	evaluator := &functionEvaluator{
+		fetch: func(path string) ([]*series, error) {
+			res := fetched[newFetchKey(q.query, path)]
+			if res.err != nil {
+				return nil, res.err
+			}
+			// the functions change the series in place, so every query gets its own copies
+			list := make([]*series, 0, len(res.list))
+			for _, sr := range res.list {
+				c := *sr
+				c.times = append([]time.Time(nil), sr.times...)
+				c.values = append([]*float64(nil), sr.values...)
+				list = append(list, &c)
+			}
+			return list, nil
+		},
+	}
+	list, err := evaluator.eval(q.ex)
+	if err != nil {
+		return nil, err
+	}
+
+	// the functions are applied to the raw series, only their results are consolidated to the max data points
+	maxDataPoints := q.query.MaxDataPoints
+	if maxDataPoints <= 0 {
+		maxDataPoints = defaultMaxDataPoints
+	}
+	frames := make(data.Frames, 0, len(list))
+	for _, series := range list {
+		series.consolidate(int(maxDataPoints))
+		frames = append(frames, newSeriesFrame(q.query.RefID, series.name, series.tags, series.times, series.values))
+	}
+	return frames, nil
+}
+
+// fetchSeries fetches the raw series of a metric path from the render API. The max data points are not sent, so
+// Graphite does not consolidate the series before the functions are applied.
+func (s *Service) fetchSeries(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, key fetchKey) ([]*series, error) {
+	formData := url.Values{
+		"from":   []string{key.from},
+		"until":  []string{key.until},
+		"format": []string{"json"},
+		"target": []string{key.path},
+	}
+	graphiteReq, err := s.createRequest(ctx, logger, dsInfo, formData)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := s.tracer.Start(ctx, "graphite query")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("target", key.path),
+		attribute.String("from", key.from),
+		attribute.String("until", key.until),
+		attribute.Int64("datasource_id", dsInfo.Id),
+	)
+	s.tracer.Inject(ctx, graphiteReq.Header, span)
+
+	res, err := dsInfo.HTTPClient.Do(graphiteReq)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("graphite.response.code", res.StatusCode))
+
+	responseData, err := s.parseResponse(logger, res)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	list := make([]*series, 0, len(responseData))
+	for _, r := range responseData {
+		sr := &series{name: r.Target, pathExpression: key.path, tags: convertTags(r.Tags)}
+		for _, dataPoint := range r.DataPoints {
+			timestamp, value, err := parseDataTimePoint(dataPoint)
+			if err != nil {
+				return nil, err
+			}
+			sr.times = append(sr.times, timestamp)
+			sr.values = append(sr.values, value)
+		}
+		list = append(list, sr)
+	}
+	return list, nil
+}
@@ -0,0 +1,497 @@
+package graphite
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// series is a series of the function pipeline, the points are sorted by time.
+type series struct {
+	name string
+	// pathExpression is the expression the series was selected with, it names the result of aggregations.
+	pathExpression string
+	tags           map[string]string
+	times          []time.Time
+	values         []*float64
+}
+
+// fetchFunc fetches the raw series of a metric path.
+type fetchFunc func(path string) ([]*series, error)
+
+// functionEvaluator evaluates a core subset of the Graphite functions in Grafana, so alerts and expressions work
+// with stores that only support fetching raw series.
+type functionEvaluator struct {
+	fetch fetchFunc
+}
+
+// evaluatedFunctions are the functions call evaluates, targets with other functions are sent to Graphite.
+var evaluatedFunctions = map[string]bool{
+	"aliasByNode":   true,
+	"sumSeries":     true,
+	"sum":           true,
+	"scale":         true,
+	"movingAverage": true,
+	"perSecond":     true,
+	"groupByNode":   true,
+}
+
+// unsupportedFunction returns the first function of the expression Grafana cannot evaluate, or an empty string.
+func unsupportedFunction(ex *expr) string {
+	if ex.typ != exprCall {
+		return ""
+	}
+	if !evaluatedFunctions[ex.name] {
+		return ex.name
+	}
+	for _, arg := range ex.args {
+		if name := unsupportedFunction(arg); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// metricPaths returns the metric paths the expression fetches.
+func metricPaths(ex *expr) []string {
+	switch ex.typ {
+	case exprPath:
+		return []string{ex.name}
+	case exprCall:
+		var paths []string
+		for _, arg := range ex.args {
+			paths = append(paths, metricPaths(arg)...)
+		}
+		return paths
+	default:
+		return nil
+	}
+}
+
+func (e *functionEvaluator) eval(ex *expr) ([]*series, error) {
+	switch ex.typ {
+	case exprPath:
+		return e.fetch(ex.name)
+	case exprCall:
+		return e.call(ex)
+	default:
+		return nil, fmt.Errorf("expected a series list, got a constant")
+	}
+}
+
+func (e *functionEvaluator) call(ex *expr) ([]*series, error) {
+	switch ex.name {
+	case "aliasByNode":
+		return e.aliasByNode(ex)
+	case "sumSeries", "sum":
+		return e.sumSeries(ex)
+	case "scale":
+		return e.scale(ex)
+	case "movingAverage":
+		return e.movingAverage(ex)
+	case "perSecond":
+		return e.perSecond(ex)
+	case "groupByNode":
+		return e.groupByNode(ex)
+	default:
+		return nil, fmt.Errorf("function %s is not supported when Grafana evaluates the functions", ex.name)
+	}
+}
+
+func (e *functionEvaluator) seriesArg(ex *expr, i int) ([]*series, error) {
+	if len(ex.args) <= i {
+		return nil, fmt.Errorf("%s: missing series list argument", ex.name)
+	}
+	return e.eval(ex.args[i])
+}
+
+func numberArg(ex *expr, i int) (float64, error) {
+	if len(ex.args) <= i || ex.args[i].typ != exprNumber {
+		return 0, fmt.Errorf("%s: argument %d should be a number", ex.name, i+1)
+	}
+	return ex.args[i].number, nil
+}
+
+func (e *functionEvaluator) aliasByNode(ex *expr) ([]*series, error) {
+	list, err := e.seriesArg(ex, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(ex.args) < 2 {
+		return nil, fmt.Errorf("%s: missing node argument", ex.name)
+	}
+	var nodes []int
+	for i := 1; i < len(ex.args); i++ {
+		n, err := numberArg(ex, i)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, int(n))
+	}
+	for _, s := range list {
+		parts := strings.Split(metricPath(s.name), ".")
+		var name []string
+		for _, n := range nodes {
+			if part, ok := node(parts, n); ok {
+				name = append(name, part)
+			}
+		}
+		s.name = strings.Join(name, ".")
+	}
+	return list, nil
+}
+
+func (e *functionEvaluator) sumSeries(ex *expr) ([]*series, error) {
+	var list []*series
+	for i := range ex.args {
+		l, err := e.seriesArg(ex, i)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, l...)
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	name := fmt.Sprintf("sumSeries(%s)", pathExpressions(list))
+	return []*series{aggregate(name, list, "sum")}, nil
+}
+
+func (e *functionEvaluator) scale(ex *expr) ([]*series, error) {
+	list, err := e.seriesArg(ex, 0)
+	if err != nil {
+		return nil, err
+	}
+	factor, err := numberArg(ex, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range list {
+		for i, v := range s.values {
+			if v != nil {
+				s.values[i] = pointer(*v * factor)
+			}
+		}
+		s.rename(fmt.Sprintf("scale(%s,%s)", s.name, formatNumber(factor)))
+	}
+	return list, nil
+}
+
+// movingAverage averages the non-null values of the window ending at every point. The window is filled with the
+// points of the time range, so the first points average fewer values.
+func (e *functionEvaluator) movingAverage(ex *expr) ([]*series, error) {
+	list, err := e.seriesArg(ex, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(ex.args) < 2 {
+		return nil, fmt.Errorf("%s: missing window size argument", ex.name)
+	}
+	var points int
+	var window time.Duration
+	var windowArg string
+	switch arg := ex.args[1]; arg.typ {
+	case exprNumber:
+		points = int(arg.number)
+		windowArg = formatNumber(arg.number)
+	case exprString:
+		if window, err = parseInterval(arg.str); err != nil {
+			return nil, fmt.Errorf("%s: %w", ex.name, err)
+		}
+		windowArg = fmt.Sprintf("'%s'", arg.str)
+	default:
+		return nil, fmt.Errorf("%s: window size should be a number of points or an interval", ex.name)
+	}
+
+	for _, s := range list {
+		size := points
+		if window > 0 {
+			size = int(window / s.step())
+		}
+		if size < 1 {
+			size = 1
+		}
+		averages := make([]*float64, len(s.values))
+		sum, count := 0.0, 0
+		for i, v := range s.values {
+			if v != nil {
+				sum += *v
+				count++
+			}
+			if j := i - size; j >= 0 && s.values[j] != nil {
+				sum -= *s.values[j]
+				count--
+			}
+			if count > 0 {
+				averages[i] = pointer(sum / float64(count))
+			}
+		}
+		s.values = averages
+		s.rename(fmt.Sprintf("movingAverage(%s,%s)", s.name, windowArg))
+	}
+	return list, nil
+}
+
+// perSecond returns the per-second rate of counters between the non-null values, it is null after a counter reset
+// unless the counter wrapped at the optional max value.
+func (e *functionEvaluator) perSecond(ex *expr) ([]*series, error) {
+	list, err := e.seriesArg(ex, 0)
+	if err != nil {
+		return nil, err
+	}
+	maxValue := math.NaN()
+	if len(ex.args) > 1 && ex.args[1].typ == exprNumber {
+		maxValue = ex.args[1].number
+	}
+
+	for _, s := range list {
+		rates := make([]*float64, len(s.values))
+		prev := -1
+		for i, v := range s.values {
+			if v == nil {
+				continue
+			}
+			if prev >= 0 {
+				delta := *v - *s.values[prev]
+				if delta < 0 && !math.IsNaN(maxValue) && maxValue >= *s.values[prev] {
+					delta = maxValue - *s.values[prev] + *v + 1
+				}
+				if seconds := s.times[i].Sub(s.times[prev]).Seconds(); delta >= 0 && seconds > 0 {
+					rates[i] = pointer(delta / seconds)
+				}
+			}
+			prev = i
+		}
+		s.values = rates
+		s.rename(fmt.Sprintf("perSecond(%s)", s.name))
+	}
+	return list, nil
+}
+
+func (e *functionEvaluator) groupByNode(ex *expr) ([]*series, error) {
+	list, err := e.seriesArg(ex, 0)
+	if err != nil {
+		return nil, err
+	}
+	n, err := numberArg(ex, 1)
+	if err != nil {
+		return nil, err
+	}
+	callback := "average"
+	if len(ex.args) > 2 {
+		if ex.args[2].typ != exprString {
+			return nil, fmt.Errorf("%s: callback should be a string", ex.name)
+		}
+		callback = ex.args[2].str
+	}
+	if _, ok := aggregations[callback]; !ok {
+		return nil, fmt.Errorf("%s: callback %s is not supported", ex.name, callback)
+	}
+
+	groups := map[string][]*series{}
+	var keys []string
+	for _, s := range list {
+		key, _ := node(strings.Split(metricPath(s.name), "."), int(n))
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], s)
+	}
+	result := make([]*series, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, aggregate(key, groups[key], callback))
+	}
+	return result, nil
+}
+
+// aggregations are the aggregation functions of sumSeries and groupByNode, they are called with the non-null values
+// at a time.
+var aggregations = map[string]func(values []float64) float64{
+	"sum":     sum,
+	"average": average,
+	"avg":     average,
+	"min": func(values []float64) float64 {
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	},
+	"max": func(values []float64) float64 {
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	},
+	"count": func(values []float64) float64 {
+		return float64(len(values))
+	},
+}
+
+func sum(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+func average(values []float64) float64 {
+	return sum(values) / float64(len(values))
+}
+
+// aggregate aggregates the values of the series at every time any of the series has a point.
+func aggregate(name string, list []*series, callback string) *series {
+	fn := aggregations[callback]
+	byTime := map[int64][]float64{}
+	for _, s := range list {
+		for i, t := range s.times {
+			values, ok := byTime[t.Unix()]
+			if !ok {
+				byTime[t.Unix()] = nil
+			}
+			if v := s.values[i]; v != nil {
+				byTime[t.Unix()] = append(values, *v)
+			}
+		}
+	}
+	times := make([]int64, 0, len(byTime))
+	for t := range byTime {
+		times = append(times, t)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	result := &series{name: name, pathExpression: name}
+	for _, t := range times {
+		result.times = append(result.times, time.Unix(t, 0).UTC())
+		if values := byTime[t]; len(values) > 0 {
+			result.values = append(result.values, pointer(fn(values)))
+		} else {
+			result.values = append(result.values, nil)
+		}
+	}
+	return result
+}
+
+func (s *series) rename(name string) {
+	s.name = name
+	s.pathExpression = name
+}
+
+// consolidate reduces the series to at most maxDataPoints points by averaging the non-null values of consecutive
+// points, like Graphite consolidates the series it renders. A consolidated point has the time of its first point.
+func (s *series) consolidate(maxDataPoints int) {
+	if maxDataPoints <= 0 || len(s.values) <= maxDataPoints {
+		return
+	}
+	perPoint := (len(s.values) + maxDataPoints - 1) / maxDataPoints
+	times := make([]time.Time, 0, maxDataPoints)
+	values := make([]*float64, 0, maxDataPoints)
+	for i := 0; i < len(s.values); i += perPoint {
+		var nonNull []float64
+		for _, v := range s.values[i:min(i+perPoint, len(s.values))] {
+			if v != nil {
+				nonNull = append(nonNull, *v)
+			}
+		}
+		times = append(times, s.times[i])
+		if len(nonNull) > 0 {
+			values = append(values, pointer(average(nonNull)))
+		} else {
+			values = append(values, nil)
+		}
+	}
+	s.times = times
+	s.values = values
+}
+
+// step returns the interval between the points of the series, one minute if it has less than two points.
+func (s *series) step() time.Duration {
+	if len(s.times) < 2 {
+		return time.Minute
+	}
+	return s.times[1].Sub(s.times[0])
+}
+
+// pathExpressions returns the distinct path expressions of the series, joined by commas.
+func pathExpressions(list []*series) string {
+	seen := map[string]bool{}
+	var exprs []string
+	for _, s := range list {
+		if !seen[s.pathExpression] {
+			seen[s.pathExpression] = true
+			exprs = append(exprs, s.pathExpression)
+		}
+	}
+	sort.Strings(exprs)
+	return strings.Join(exprs, ",")
+}
+
+var metricPathRegExp = regexp.MustCompile(`(?:.*\()?([-\w*.:#{}\[\]]+)`)
+
+// metricPath returns the metric path of a series name that may be wrapped in functions, e.g. servers.web.cpu for
+// scale(servers.web.cpu,2).
+func metricPath(name string) string {
+	if m := metricPathRegExp.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	return name
+}
+
+// node returns the node n of a path, negative nodes are counted from the end.
+func node(parts []string, n int) (string, bool) {
+	if n < 0 {
+		n += len(parts)
+	}
+	if n < 0 || n >= len(parts) {
+		return "", false
+	}
+	return parts[n], true
+}
+
+var intervalRegExp = regexp.MustCompile(`^(-?\d+)([a-z]+)$`)
+
+// parseInterval parses a Graphite interval, e.g. 5min or 1h.
+func parseInterval(s string) (time.Duration, error) {
+	m := intervalRegExp.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return 0, fmt.Errorf("invalid interval %q", s)
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q", s)
+	}
+	var unit time.Duration
+	switch {
+	case m[2] == "s" || strings.HasPrefix(m[2], "sec"):
+		unit = time.Second
+	case m[2] == "m" || strings.HasPrefix(m[2], "min"):
+		unit = time.Minute
+	case m[2] == "h" || strings.HasPrefix(m[2], "hour"):
+		unit = time.Hour
+	case m[2] == "d" || strings.HasPrefix(m[2], "day"):
+		unit = 24 * time.Hour
+	case m[2] == "w" || strings.HasPrefix(m[2], "week"):
+		unit = 7 * 24 * time.Hour
+	case strings.HasPrefix(m[2], "mon"):
+		unit = 30 * 24 * time.Hour
+	case m[2] == "y" || strings.HasPrefix(m[2], "year"):
+		unit = 365 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("invalid interval unit %q", m[2])
+	}
+	return time.Duration(n) * unit, nil
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func pointer(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,263 @@
+package graphite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestParseTarget(t *testing.T) {
+	t.Run("parses nested functions", func(t *testing.T) {
+		ex, err := parseTarget(`aliasByNode(movingAverage(servers.{web,db}.cpu, '5min'), 1, -1)`)
+		require.NoError(t, err)
+		require.Equal(t, &expr{typ: exprCall, name: "aliasByNode", args: []*expr{
+			{typ: exprCall, name: "movingAverage", args: []*expr{
+				{typ: exprPath, name: "servers.{web,db}.cpu"},
+				{typ: exprString, str: "5min"},
+			}},
+			{typ: exprNumber, number: 1},
+			{typ: exprNumber, number: -1},
+		}}, ex)
+	})
+
+	t.Run("parses named arguments and booleans", func(t *testing.T) {
+		ex, err := parseTarget(`perSecond(servers.*.requests, maxValue=4294967295)`)
+		require.NoError(t, err)
+		require.Equal(t, float64(4294967295), ex.args[1].number)
+
+		ex, err = parseTarget(`foo(true, "bar")`)
+		require.NoError(t, err)
+		require.Equal(t, []*expr{{typ: exprBool, bool: true}, {typ: exprString, str: "bar"}}, ex.args)
+	})
+
+	t.Run("returns errors for invalid targets", func(t *testing.T) {
+		for _, target := range []string{`sumSeries(a.b`, `scale(a.b, 'x)`, `sumSeries(a.b))`, `sumSeries(a.b c)`, ``} {
+			_, err := parseTarget(target)
+			require.Error(t, err, target)
+		}
+	})
+}
+
+// fakeStore returns the series of a metric path like Graphite returns them for the render API.
+type fakeStore map[string][]*series
+
+func (s fakeStore) fetch(path string) ([]*series, error) {
+	list, ok := s[path]
+	if !ok {
+		return nil, fmt.Errorf("unknown path %s", path)
+	}
+	// the functions modify the series, so every fetch returns copies
+	copies := make([]*series, 0, len(list))
+	for _, sr := range list {
+		c := *sr
+		c.pathExpression = path
+		c.values = append([]*float64(nil), sr.values...)
+		copies = append(copies, &c)
+	}
+	return copies, nil
+}
+
+func testSeries(name string, values ...any) *series {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &series{name: name}
+	for i, v := range values {
+		s.times = append(s.times, start.Add(time.Duration(i)*time.Minute))
+		if v == nil {
+			s.values = append(s.values, nil)
+		} else {
+			s.values = append(s.values, pointer(float64(v.(int))))
+		}
+	}
+	return s
+}
+
+func evaluate(t *testing.T, store fakeStore, target string) ([]string, [][]*float64) {
+	t.Helper()
+	ex, err := parseTarget(target)
+	require.NoError(t, err)
+	list, err := (&functionEvaluator{fetch: store.fetch}).eval(ex)
+	require.NoError(t, err)
+	var names []string
+	var values [][]*float64
+	for _, s := range list {
+		names = append(names, s.name)
+		values = append(values, s.values)
+	}
+	return names, values
+}
+
+func points(values ...any) []*float64 {
+	return testSeries("", values...).values
+}
+
+func TestFunctionEvaluator(t *testing.T) {
+	store := fakeStore{
+		"servers.*.requests": {
+			testSeries("servers.web.requests", 0, 60, 180, 60),
+			testSeries("servers.db.requests", 10, nil, 130, 250),
+		},
+		"dc.*.*.cpu": {
+			testSeries("dc.eu.web.cpu", 1, 2, 3),
+			testSeries("dc.eu.db.cpu", 3, 4, 5),
+			testSeries("dc.us.web.cpu", 10, nil, 30),
+		},
+	}
+
+	t.Run("aliasByNode", func(t *testing.T) {
+		names, _ := evaluate(t, store, `aliasByNode(scale(dc.*.*.cpu, 2), 1, -2)`)
+		require.Equal(t, []string{"eu.web", "eu.db", "us.web"}, names)
+	})
+
+	t.Run("sumSeries", func(t *testing.T) {
+		names, values := evaluate(t, store, `sumSeries(servers.*.requests)`)
+		require.Equal(t, []string{"sumSeries(servers.*.requests)"}, names)
+		require.Equal(t, [][]*float64{points(10, 60, 310, 310)}, values)
+	})
+
+	t.Run("scale", func(t *testing.T) {
+		names, values := evaluate(t, store, `scale(servers.*.requests, 0.5)`)
+		require.Equal(t, []string{"scale(servers.web.requests,0.5)", "scale(servers.db.requests,0.5)"}, names)
+		require.Equal(t, points(0, 30, 90, 30), values[0])
+		require.Equal(t, points(5, nil, 65, 125), values[1])
+	})
+
+	t.Run("movingAverage", func(t *testing.T) {
+		names, values := evaluate(t, store, `movingAverage(servers.*.requests, 2)`)
+		require.Equal(t, "movingAverage(servers.web.requests,2)", names[0])
+		require.Equal(t, points(0, 30, 120, 120), values[0])
+		require.Equal(t, points(10, 10, 130, 190), values[1])
+
+		_, byInterval := evaluate(t, store, `movingAverage(servers.*.requests, '2min')`)
+		require.Equal(t, values, byInterval)
+	})
+
+	t.Run("perSecond", func(t *testing.T) {
+		names, values := evaluate(t, store, `perSecond(servers.*.requests)`)
+		require.Equal(t, "perSecond(servers.web.requests)", names[0])
+		require.Equal(t, points(nil, 1, 2, nil), values[0])
+		require.Equal(t, points(nil, nil, 1, 2), values[1])
+
+		store["servers.web.requests"] = store["servers.*.requests"][:1]
+		_, wrapped := evaluate(t, store, `perSecond(servers.web.requests, 239)`)
+		require.Equal(t, points(nil, 1, 2, 2), wrapped[0])
+	})
+
+	t.Run("groupByNode", func(t *testing.T) {
+		names, values := evaluate(t, store, `groupByNode(dc.*.*.cpu, 1, 'sum')`)
+		require.Equal(t, []string{"eu", "us"}, names)
+		require.Equal(t, [][]*float64{points(4, 6, 8), points(10, nil, 30)}, values)
+
+		_, averages := evaluate(t, store, `groupByNode(dc.*.*.cpu, 2)`)
+		require.Equal(t, [][]*float64{{pointer(5.5), pointer(2), pointer(16.5)}, points(3, 4, 5)}, averages)
+	})
+
+	t.Run("unsupported functions", func(t *testing.T) {
+		ex, err := parseTarget(`highestMax(servers.*.requests, 1)`)
+		require.NoError(t, err)
+		_, err = (&functionEvaluator{fetch: store.fetch}).eval(ex)
+		require.ErrorContains(t, err, "highestMax is not supported")
+	})
+}
+
+func TestServerSideFunctions(t *testing.T) {
+	var mu sync.Mutex
+	var targets, maxDataPoints []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		mu.Lock()
+		targets = append(targets, form["target"]...)
+		maxDataPoints = append(maxDataPoints, form.Get("maxDataPoints"))
+		mu.Unlock()
+		if strings.HasPrefix(form.Get("target"), "aliasSub(") {
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"target": "servers.db.requests B", "datapoints": [][]any{{3, 1700000000}, {nil, 1700000060}}},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]map[string]any{
+			{"target": "servers.web.requests", "datapoints": [][]any{{1, 1700000000}, {2, 1700000060}}},
+			{"target": "servers.db.requests", "datapoints": [][]any{{3, 1700000000}, {nil, 1700000060}}},
+		})
+	}))
+	defer srv.Close()
+
+	service := &Service{tracer: tracing.InitializeTracerForTest()}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL, ServerSideFunctions: true}
+	res, err := service.evaluateQueries(context.Background(), logger, dsInfo, &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{RefID: "A", MaxDataPoints: 1000, JSON: []byte(`{"target": "aliasByNode(sumSeries(servers.*.requests), 2)"}`)},
+			{RefID: "B", JSON: []byte(`{"target": "highestMax(servers.*.requests, 1)"}`)},
+			{RefID: "C", MaxDataPoints: 1000, JSON: []byte(`{"target": "scale(servers.*.requests, 2)"}`)},
+		},
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"servers.*.requests", `aliasSub(highestMax(servers.*.requests, 1),"(^.*$)","\1 B")`}, targets)
+	// the raw series are fetched without max data points, only the targets rendered by Graphite are consolidated
+	require.ElementsMatch(t, []string{"", "500"}, maxDataPoints)
+
+	frames := res.Responses["A"].Frames
+	require.Len(t, frames, 1)
+	require.Equal(t, "A", frames[0].Name)
+	require.Equal(t, "requests", frames[0].Fields[1].Config.DisplayNameFromDS)
+	require.Equal(t, []*float64{pointer(4), pointer(2)}, fieldValues(frames[0].Fields[1]))
+
+	frames = res.Responses["B"].Frames
+	require.NoError(t, res.Responses["B"].Error)
+	require.Len(t, frames, 1)
+	require.Equal(t, "B", frames[0].Name)
+
+	frames = res.Responses["C"].Frames
+	require.Len(t, frames, 2)
+	require.Equal(t, []*float64{pointer(2), pointer(4)}, fieldValues(frames[0].Fields[1]))
+}
+
+func TestConsolidate(t *testing.T) {
+	start := time.Unix(1700000000, 0).UTC()
+	s := &series{}
+	for i, v := range []*float64{pointer(1), pointer(3), nil, pointer(4), nil, nil, pointer(7)} {
+		s.times = append(s.times, start.Add(time.Duration(i)*time.Minute))
+		s.values = append(s.values, v)
+	}
+
+	s.consolidate(3)
+	require.Equal(t, []time.Time{start, start.Add(3 * time.Minute), start.Add(6 * time.Minute)}, s.times)
+	require.Equal(t, []*float64{pointer(2), pointer(4), pointer(7)}, s.values)
+
+	s.consolidate(3)
+	require.Len(t, s.values, 3)
+}
+
+func TestUnsupportedFunction(t *testing.T) {
+	ex, err := parseTarget("aliasByNode(sumSeries(servers.*.requests), 2)")
+	require.NoError(t, err)
+	require.Empty(t, unsupportedFunction(ex))
+	require.Equal(t, []string{"servers.*.requests"}, metricPaths(ex))
+
+	ex, err = parseTarget("sumSeries(highestMax(servers.*.requests, 1), servers.db.errors)")
+	require.NoError(t, err)
+	require.Equal(t, "highestMax", unsupportedFunction(ex))
+	require.Equal(t, []string{"servers.*.requests", "servers.db.errors"}, metricPaths(ex))
+}
+
+func fieldValues(field *data.Field) []*float64 {
+	values := make([]*float64, field.Len())
+	for i := range values {
+		values[i] = field.At(i).(*float64)
+	}
+	return values
+}
@@ -52,6 +52,12 @@ type datasourceInfo struct {
 	HTTPClient *http.Client
 	URL        string
 	Id         int64
+	// ServerSideFunctions evaluates the functions of the targets in Grafana and only fetches raw series from Graphite.
+	ServerSideFunctions bool
+}
+
+type jsonData struct {
+	ServerSideFunctions bool `json:"serverSideFunctions"`
 }
 
 func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
@@ -66,10 +72,18 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		var jd jsonData
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jd); err != nil {
+				return nil, fmt.Errorf("error reading settings: %w", err)
+			}
+		}
+
 		model := datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
-			Id:         settings.ID,
+			HTTPClient:          client,
+			URL:                 settings.URL,
+			Id:                  settings.ID,
+			ServerSideFunctions: jd.ServerSideFunctions,
 		}
 
 		return model, nil
@@ -98,6 +112,14 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		return nil, err
 	}
 
+	if dsInfo.ServerSideFunctions {
+		return s.evaluateQueries(ctx, logger, dsInfo, req)
+	}
+	return s.renderTargets(ctx, logger, dsInfo, req)
+}
+
+// renderTargets sends the targets of the queries to the render API, which evaluates their functions.
+func (s *Service) renderTargets(ctx context.Context, logger log.Logger, dsInfo *datasourceInfo, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	// take the first query in the request list, since all query should share the same timerange
 	q := req.Queries[0]
 
@@ -208,12 +230,7 @@ func (s *Service) processQueries(logger log.Logger, queries []backend.DataQuery)
 			return nil, nil, nil, err
 		}
 		logger.Debug("Graphite", "query", model)
-		currTarget := ""
-		if fullTarget, err := model.Get(TargetFullModelField).String(); err == nil {
-			currTarget = fullTarget
-		} else {
-			currTarget = model.Get(TargetModelField).MustString()
-		}
+		currTarget := getTarget(model)
 		if currTarget == "" {
 			logger.Debug("Graphite", "empty query target", model)
 			emptyQueries = append(emptyQueries, fmt.Sprintf("Query: %v has no target", model))
@@ -236,6 +253,14 @@ func (s *Service) processQueries(logger log.Logger, queries []backend.DataQuery)
 	return targets, emptyQueries, origRefIds, nil
 }
 
+// getTarget returns the target of a query model, the full target has the nested queries of the target replaced.
+func getTarget(model *simplejson.Json) string {
+	if fullTarget, err := model.Get(TargetFullModelField).String(); err == nil {
+		return fullTarget
+	}
+	return model.Get(TargetModelField).MustString()
+}
+
 func (s *Service) parseResponse(logger log.Logger, res *http.Response) ([]TargetResponseDTO, error) {
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
@@ -294,19 +319,7 @@ func (s *Service) toDataFrames(logger log.Logger, response *http.Response, origR
 			values = append(values, value)
 		}
 
-		tags := make(map[string]string)
-		for name, value := range series.Tags {
-			switch value := value.(type) {
-			case string:
-				tags[name] = value
-			case float64:
-				tags[name] = strconv.FormatFloat(value, 'f', -1, 64)
-			}
-		}
-
-		frames = append(frames, data.NewFrame(refId,
-			data.NewField("time", nil, timeVector),
-			data.NewField("value", tags, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: target})))
+		frames = append(frames, newSeriesFrame(refId, target, convertTags(series.Tags), timeVector, values))
 
 		if setting.Env == setting.Dev {
 			logger.Debug("Graphite response", "target", series.Target, "datapoints", len(series.DataPoints))
@@ -315,6 +328,25 @@ func (s *Service) toDataFrames(logger log.Logger, response *http.Response, origR
 	return frames, nil
 }
 
+func convertTags(seriesTags map[string]any) map[string]string {
+	tags := make(map[string]string)
+	for name, value := range seriesTags {
+		switch value := value.(type) {
+		case string:
+			tags[name] = value
+		case float64:
+			tags[name] = strconv.FormatFloat(value, 'f', -1, 64)
+		}
+	}
+	return tags
+}
+
+func newSeriesFrame(refId string, target string, tags map[string]string, timeVector []time.Time, values []*float64) *data.Frame {
+	return data.NewFrame(refId,
+		data.NewField("time", nil, timeVector),
+		data.NewField("value", tags, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: target}))
+}
+
 func (s *Service) createRequest(ctx context.Context, l log.Logger, dsInfo *datasourceInfo, data url.Values) (*http.Request, error) {
 	u, err := url.Parse(dsInfo.URL)
 	if err != nil {
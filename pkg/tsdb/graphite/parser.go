@@ -0,0 +1,147 @@
+package graphite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type exprType int
+
+const (
+	exprPath exprType = iota
+	exprCall
+	exprNumber
+	exprString
+	exprBool
+)
+
+// expr is a node of a parsed Graphite target.
+type expr struct {
+	typ exprType
+	// name is the metric path of a path or the function name of a call.
+	name   string
+	args   []*expr
+	number float64
+	str    string
+	bool   bool
+}
+
+// parseTarget parses a Graphite target, e.g. aliasByNode(sumSeries(servers.*.cpu), 1).
+func parseTarget(target string) (*expr, error) {
+	p := &targetParser{input: target}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d of target", p.input[p.pos:], p.pos)
+	}
+	return e, nil
+}
+
+type targetParser struct {
+	input string
+	pos   int
+}
+
+func (p *targetParser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *targetParser) parseExpr() (*expr, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return nil, fmt.Errorf("unexpected end of target")
+	}
+	if c := p.input[p.pos]; c == '\'' || c == '"' {
+		return p.parseString(c)
+	}
+
+	token := p.readToken()
+	if token == "" {
+		return nil, fmt.Errorf("unexpected %q at position %d of target", p.input[p.pos:], p.pos)
+	}
+	if p.pos < len(p.input) && p.input[p.pos] == '(' {
+		return p.parseCall(token)
+	}
+	switch token {
+	case "true", "false":
+		return &expr{typ: exprBool, bool: token == "true"}, nil
+	}
+	if n, err := strconv.ParseFloat(token, 64); err == nil {
+		return &expr{typ: exprNumber, number: n}, nil
+	}
+	return &expr{typ: exprPath, name: token}, nil
+}
+
+func (p *targetParser) parseString(quote byte) (*expr, error) {
+	end := strings.IndexByte(p.input[p.pos+1:], quote)
+	if end < 0 {
+		return nil, fmt.Errorf("missing closing quote of string at position %d of target", p.pos)
+	}
+	s := p.input[p.pos+1 : p.pos+1+end]
+	p.pos += end + 2
+	return &expr{typ: exprString, str: s}, nil
+}
+
+func (p *targetParser) parseCall(name string) (*expr, error) {
+	// skip the opening parenthesis
+	p.pos++
+	e := &expr{typ: exprCall, name: name}
+	p.skipSpaces()
+	if p.pos < len(p.input) && p.input[p.pos] == ')' {
+		p.pos++
+		return e, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		// named arguments are passed by position
+		if arg.typ == exprPath && p.pos < len(p.input) && p.input[p.pos] == '=' {
+			p.pos++
+			if arg, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		e.args = append(e.args, arg)
+
+		p.skipSpaces()
+		if p.pos >= len(p.input) {
+			return nil, fmt.Errorf("missing closing parenthesis of %s", name)
+		}
+		switch p.input[p.pos] {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return e, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q in arguments of %s", p.input[p.pos], name)
+		}
+	}
+}
+
+// readToken reads a function name, a number or a metric path. The commas in the braces of a path, e.g.
+// servers.{web,db}.cpu, are part of the path.
+func (p *targetParser) readToken() string {
+	start := p.pos
+	depth := 0
+	for ; p.pos < len(p.input); p.pos++ {
+		switch c := p.input[p.pos]; {
+		case c == '{' || c == '[':
+			depth++
+		case (c == '}' || c == ']') && depth > 0:
+			depth--
+		case depth > 0:
+		case c == ',' || c == '(' || c == ')' || c == ' ' || c == '=':
+			return p.input[start:p.pos]
+		}
+	}
+	return p.input[start:p.pos]
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"runtime/debug"
+	"sort"
 	"time"
 
 	"github.com/apache/arrow/go/v15/arrow"
@@ -52,14 +53,17 @@ func newQueryDataResponse(reader recordReader, query sqlutil.Query, headers meta
 
 	switch query.Format {
 	case sqlutil.FormatOptionTimeSeries:
-		if _, idx := frame.FieldByName("time"); idx == -1 {
+		timeIndices := frame.TypeIndices(data.FieldTypeTime, data.FieldTypeNullableTime)
+		if len(timeIndices) == 0 {
 			resp.Error = fmt.Errorf("no time column found")
 			return resp
 		}
 
 		if frame.TimeSeriesSchema().Type == data.TimeSeriesTypeLong {
+			// the rows are not ordered by time unless the query orders them
+			frame = sortByTime(frame, timeIndices[0])
 			var err error
-			frame, err = data.LongToWide(frame, nil)
+			frame, err = data.LongToWide(frame, query.FillMissing)
 			if err != nil {
 				resp.Error = err
 				return resp
@@ -78,6 +82,36 @@ func newQueryDataResponse(reader recordReader, query sqlutil.Query, headers meta
 	return resp
 }
 
+// sortByTime returns the rows of the frame in ascending order of the time field, the rows without time are dropped.
+func sortByTime(frame *data.Frame, timeIndex int) *data.Frame {
+	timeField := frame.Fields[timeIndex]
+	rows := make([]int, 0, timeField.Len())
+	for i := 0; i < timeField.Len(); i++ {
+		if _, ok := timeField.ConcreteAt(i); ok {
+			rows = append(rows, i)
+		}
+	}
+	at := func(i int) time.Time {
+		t, _ := timeField.ConcreteAt(rows[i])
+		return t.(time.Time)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return at(i).Before(at(j)) })
+
+	sorted := data.NewFrame(frame.Name)
+	sorted.Meta = frame.Meta
+	for _, field := range frame.Fields {
+		f := data.NewFieldFromFieldType(field.Type(), len(rows))
+		f.Name = field.Name
+		f.Labels = field.Labels
+		f.Config = field.Config
+		for j, row := range rows {
+			f.Set(j, field.At(row))
+		}
+		sorted.Fields = append(sorted.Fields, f)
+	}
+	return sorted
+}
+
 // frameForRecords creates a [data.Frame] from a stream of [arrow.Record]s.
 func frameForRecords(reader recordReader) (*data.Frame, error) {
 	var (
@@ -265,6 +265,44 @@ func TestNewQueryDataResponse_WideTable(t *testing.T) {
 	assert.Equal(t, []int64{1, 0, 0}, extractFieldValues[int64](t, frame.Fields[3]))
 }
 
+func TestNewQueryDataResponse_UnsortedLongTable(t *testing.T) {
+	alloc := memory.DefaultAllocator
+	schema := arrow.NewSchema(
+		[]arrow.Field{
+			{Name: "bucket", Type: &arrow.TimestampType{}},
+			{Name: "host", Type: &arrow.StringType{}},
+			{Name: "value", Type: arrow.PrimitiveTypes.Int64},
+		},
+		nil,
+	)
+
+	times, _, err := array.FromJSON(
+		alloc,
+		&arrow.TimestampType{},
+		strings.NewReader(`["2023-01-01T00:00:01Z", "2023-01-01T00:00:00Z", "2023-01-01T00:00:01Z", "2023-01-01T00:00:00Z"]`),
+	)
+	assert.NoError(t, err)
+	strs, _, err := array.FromJSON(alloc, &arrow.StringType{}, strings.NewReader(`["a", "a", "b", "b"]`))
+	assert.NoError(t, err)
+	i64s, _, err := array.FromJSON(alloc, arrow.PrimitiveTypes.Int64, strings.NewReader(`[2, 1, 4, 3]`))
+	assert.NoError(t, err)
+
+	record := array.NewRecord(schema, []arrow.Array{times, strs, i64s}, -1)
+	reader, err := array.NewRecordReader(schema, []arrow.Record{record})
+	assert.NoError(t, err)
+
+	resp := newQueryDataResponse(errReader{RecordReader: reader}, sqlutil.Query{}, metadata.MD{})
+	assert.NoError(t, resp.Error)
+	assert.Len(t, resp.Frames, 1)
+
+	frame := resp.Frames[0]
+	assert.Equal(t, "bucket", frame.Fields[0].Name)
+	assert.Equal(t, data.Labels{"host": "a"}, frame.Fields[1].Labels)
+	assert.Equal(t, []int64{1, 2}, extractFieldValues[int64](t, frame.Fields[1]))
+	assert.Equal(t, data.Labels{"host": "b"}, frame.Fields[2].Labels)
+	assert.Equal(t, []int64{3, 4}, extractFieldValues[int64](t, frame.Fields[2]))
+}
+
 func extractFieldValues[T any](t *testing.T, field *data.Field) []T {
 	t.Helper()
 
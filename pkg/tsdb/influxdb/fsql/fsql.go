@@ -6,10 +6,12 @@ import (
 	"net/url"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
 	"google.golang.org/grpc/metadata"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
 )
 
 var (
@@ -71,12 +73,29 @@ func Query(ctx context.Context, dsInfo *models.DatasourceInfo, req backend.Query
 			logger.Error(fmt.Sprintf("Failed to extract headers: %s", err))
 		}
 
-		tRes.Responses[q.RefID] = newQueryDataResponse(reader, *qm.Query, headers)
+		resp := newQueryDataResponse(reader, *qm.Query, headers)
+		if resp.Error == nil && qm.FillMissing != nil && qm.Format == sqlutil.FormatOptionTimeSeries {
+			resp = fillMissing(resp, qm)
+		}
+		tRes.Responses[q.RefID] = resp
 	}
 
 	return tRes, nil
 }
 
+// fillMissing resamples the time series at the interval of the $__timeGroup macro and fills the missing values.
+func fillMissing(resp backend.DataResponse, qm *queryModel) backend.DataResponse {
+	for i, frame := range resp.Frames {
+		resampled, err := sqleng.ResampleWideFrame(frame, qm.FillMissing, qm.TimeRange, qm.FillInterval)
+		if err != nil {
+			resp.Error = fmt.Errorf("failed to fill missing values: %w", err)
+			return resp
+		}
+		resp.Frames[i] = resampled
+	}
+	return resp
+}
+
 type runner struct {
 	client *client
 }
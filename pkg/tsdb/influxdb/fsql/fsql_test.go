@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/apache/arrow/go/v15/arrow/flight"
@@ -96,6 +98,61 @@ func (suite *FSQLTestSuite) TestIntegration_QueryData() {
 	})
 }
 
+type recordingSender struct {
+	res *backend.CallResourceResponse
+}
+
+func (s *recordingSender) Send(res *backend.CallResourceResponse) error {
+	s.res = res
+	return nil
+}
+
+func (suite *FSQLTestSuite) TestIntegration_CallResource() {
+	// SQLite has no information schema, it is attached as a database with the tables of the schema
+	suite.db.SetMaxOpenConns(1)
+	_, err := suite.db.Exec(`
+	ATTACH DATABASE ':memory:' AS information_schema;
+	CREATE TABLE information_schema.tables (table_schema varchar(100), table_name varchar(100));
+	CREATE TABLE information_schema.columns (table_name varchar(100), column_name varchar(100), data_type varchar(100), ordinal_position int);
+	INSERT INTO information_schema.tables VALUES ('iox', 'intTable'), ('iox', 'foreignTable'), ('system', 'queries');
+	INSERT INTO information_schema.columns VALUES ('intTable', 'value', 'Int64', 2), ('intTable', 'keyName', 'Dictionary(Int32, Utf8)', 1);
+	`)
+	require.NoError(suite.T(), err)
+
+	dsInfo := &models.DatasourceInfo{URL: "http://localhost:12345", InsecureGrpc: true}
+	call := func(path string) (int, string) {
+		sender := &recordingSender{}
+		err := CallResource(context.Background(), dsInfo, &backend.CallResourceRequest{
+			Method: http.MethodGet,
+			Path:   strings.Split(path, "?")[0],
+			URL:    path,
+		}, sender)
+		require.NoError(suite.T(), err)
+		return sender.res.Status, string(sender.res.Body)
+	}
+
+	suite.Run("tables", func() {
+		status, body := call("tables")
+		require.Equal(suite.T(), http.StatusOK, status)
+		require.JSONEq(suite.T(), `["foreignTable", "intTable"]`, body)
+	})
+
+	suite.Run("columns", func() {
+		status, body := call("columns?table=intTable")
+		require.Equal(suite.T(), http.StatusOK, status)
+		require.JSONEq(suite.T(), `[{"name": "keyName", "type": "Dictionary(Int32, Utf8)"}, {"name": "value", "type": "Int64"}]`, body)
+	})
+
+	suite.Run("tag values", func() {
+		status, body := call("tagValues?table=intTable&tag=keyName&limit=2")
+		require.Equal(suite.T(), http.StatusOK, status)
+		require.JSONEq(suite.T(), `["negative one", "one"]`, body)
+
+		status, _ = call("tagValues?table=intTable")
+		require.Equal(suite.T(), http.StatusBadRequest, status)
+	})
+}
+
 func mustQueryJSON(t *testing.T, refID, sql string) []byte {
 	t.Helper()
 
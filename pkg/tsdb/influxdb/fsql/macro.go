@@ -2,26 +2,122 @@ package fsql
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
 )
 
-var macros = sqlutil.Macros{
-	"dateBin":        macroDateBin(""),
-	"dateBinAlias":   macroDateBin("_binned"),
-	"interval":       macroInterval,
-	"timeGroup":      macroTimeGroup,
-	"timeGroupAlias": macroTimeGroupAlias,
+// fillOptions are set by the time group macros with a fill mode argument, the time series are resampled at the
+// interval of the macro and the missing values are filled with the fill mode.
+type fillOptions struct {
+	missing  *data.FillMissing
+	interval time.Duration
+}
 
-	// The behaviors of timeFrom and timeTo as defined in the SDK are different
-	// from all other Grafana SQL plugins. Instead we'll take the implementations,
-	// rename them and define timeFrom and timeTo ourselves.
-	"timeTo":   macroTo,
-	"timeFrom": macroFrom,
+// newMacros returns the macros of a query, fill is set by the macros with a fill mode argument.
+func newMacros(fill *fillOptions) sqlutil.Macros {
+	return sqlutil.Macros{
+		"dateBin":         macroDateBin(""),
+		"dateBinAlias":    macroDateBin("_binned"),
+		"interval":        macroInterval,
+		"interval_ms":     macroIntervalMs,
+		"timeGroup":       macroTimeGroup(fill, ""),
+		"timeGroupAlias":  macroTimeGroup(fill, " AS time"),
+		"unixEpochFilter": macroUnixEpochFilter,
+		"unixEpochFrom":   macroUnixEpochFrom,
+		"unixEpochTo":     macroUnixEpochTo,
+
+		// The behaviors of timeFrom and timeTo as defined in the SDK are different
+		// from all other Grafana SQL plugins. Instead we'll take the implementations,
+		// rename them and define timeFrom and timeTo ourselves.
+		"timeTo":   macroTo,
+		"timeFrom": macroFrom,
+	}
 }
 
-func macroTimeGroup(query *sqlutil.Query, args []string) (string, error) {
+// macroTimeGroup groups the time column in buckets of an interval like the SQL data sources, e.g.
+// $__timeGroup(time, 5m, NULL). The groups by date parts of earlier versions, e.g. $__timeGroup(time, hour), are
+// still supported.
+func macroTimeGroup(fill *fillOptions, alias string) sqlutil.MacroFunc {
+	return func(query *sqlutil.Query, args []string) (string, error) {
+		if len(args) == 2 && isDatePart(args[1]) {
+			if alias != "" {
+				return macroDatePartGroupAlias(query, args)
+			}
+			return macroDatePartGroup(query, args)
+		}
+		if len(args) < 2 || len(args) > 3 {
+			return "", fmt.Errorf("%w: expected 2 or 3 arguments, received %d", sqlutil.ErrorBadArgumentCount, len(args))
+		}
+
+		interval, err := parseGroupInterval(query, args[1])
+		if err != nil {
+			return "", err
+		}
+		if len(args) == 3 {
+			missing, err := parseFillMode(args[2])
+			if err != nil {
+				return "", err
+			}
+			fill.missing = missing
+			fill.interval = interval
+		}
+		return fmt.Sprintf("date_bin(interval '%d second', %s, timestamp '1970-01-01T00:00:00Z')%s", int64(interval.Seconds()), args[0], alias), nil
+	}
+}
+
+// expandedIntervalRegex matches the expansion of $__interval, the macros are expanded in no particular order so the
+// interval argument of the time group macros can already be expanded.
+var expandedIntervalRegex = regexp.MustCompile(`^interval '(\d+) second'$`)
+
+// parseGroupInterval parses the interval argument of the time group macros, a duration or $__interval.
+func parseGroupInterval(query *sqlutil.Query, arg string) (time.Duration, error) {
+	if arg == "$__interval" {
+		return query.Interval, nil
+	}
+	if m := expandedIntervalRegex.FindStringSubmatch(arg); m != nil {
+		seconds, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing interval %v", arg)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	interval, err := gtime.ParseInterval(strings.Trim(arg, `'"`))
+	if err != nil {
+		return 0, fmt.Errorf("error parsing interval %v", arg)
+	}
+	return interval, nil
+}
+
+func isDatePart(arg string) bool {
+	switch arg {
+	case "minute", "hour", "day", "month", "year":
+		return true
+	}
+	return false
+}
+
+// parseFillMode parses the fill mode argument of the time group macros: NULL, previous or a value.
+func parseFillMode(arg string) (*data.FillMissing, error) {
+	switch arg {
+	case "NULL":
+		return &data.FillMissing{Mode: data.FillModeNull}, nil
+	case "previous":
+		return &data.FillMissing{Mode: data.FillModePrevious}, nil
+	}
+	value, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing fill value %v", arg)
+	}
+	return &data.FillMissing{Mode: data.FillModeValue, Value: value}, nil
+}
+
+func macroDatePartGroup(query *sqlutil.Query, args []string) (string, error) {
 	if len(args) != 2 {
 		return "", fmt.Errorf("%w: expected 1 argument, received %d", sqlutil.ErrorBadArgumentCount, len(args))
 	}
@@ -49,7 +145,7 @@ func macroTimeGroup(query *sqlutil.Query, args []string) (string, error) {
 	return res, nil
 }
 
-func macroTimeGroupAlias(query *sqlutil.Query, args []string) (string, error) {
+func macroDatePartGroupAlias(query *sqlutil.Query, args []string) (string, error) {
 	if len(args) != 2 {
 		return "", fmt.Errorf("%w: expected 1 argument, received %d", sqlutil.ErrorBadArgumentCount, len(args))
 	}
@@ -81,6 +177,25 @@ func macroInterval(query *sqlutil.Query, _ []string) (string, error) {
 	return fmt.Sprintf("interval '%d second'", int64(query.Interval.Seconds())), nil
 }
 
+func macroIntervalMs(query *sqlutil.Query, _ []string) (string, error) {
+	return strconv.FormatInt(query.Interval.Milliseconds(), 10), nil
+}
+
+func macroUnixEpochFilter(query *sqlutil.Query, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("%w: expected 1 argument, received %d", sqlutil.ErrorBadArgumentCount, len(args))
+	}
+	return fmt.Sprintf("%s >= %d AND %s <= %d", args[0], query.TimeRange.From.Unix(), args[0], query.TimeRange.To.Unix()), nil
+}
+
+func macroUnixEpochFrom(query *sqlutil.Query, _ []string) (string, error) {
+	return strconv.FormatInt(query.TimeRange.From.Unix(), 10), nil
+}
+
+func macroUnixEpochTo(query *sqlutil.Query, _ []string) (string, error) {
+	return strconv.FormatInt(query.TimeRange.To.Unix(), 10), nil
+}
+
 // https://docs.influxdata.com/influxdb/cloud-serverless/query-data/sql/cast-types/?t=CAST%28%29#cast-to-a-timestamp-type
 func macroFrom(query *sqlutil.Query, _ []string) (string, error) {
 	return fmt.Sprintf("cast('%s' as timestamp)", query.TimeRange.From.Format(time.RFC3339)), nil
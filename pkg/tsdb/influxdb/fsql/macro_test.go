@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
 	"github.com/stretchr/testify/require"
 )
@@ -44,6 +45,26 @@ func TestMacros(t *testing.T) {
 			in:  `select * from x where $__timeFilter(time)`,
 			out: `select * from x where time >= '2023-01-01T00:00:00Z' AND time <= '2023-01-01T00:10:00Z'`,
 		},
+		{
+			in:  `select $__timeGroup(time, 5m), avg(value) from x`,
+			out: `select date_bin(interval '300 second', time, timestamp '1970-01-01T00:00:00Z'), avg(value) from x`,
+		},
+		{
+			in:  `select $__timeGroup(time, $__interval), avg(value) from x`,
+			out: `select date_bin(interval '10 second', time, timestamp '1970-01-01T00:00:00Z'), avg(value) from x`,
+		},
+		{
+			in:  `select $__timeGroupAlias(time, '1h')`,
+			out: `select date_bin(interval '3600 second', time, timestamp '1970-01-01T00:00:00Z') AS time`,
+		},
+		{
+			in:  `select $__timeGroup(time, day)`,
+			out: `select datepart('day', time),datepart('month', time),datepart('year', time)`,
+		},
+		{
+			in:  `select * from x where $__unixEpochFilter(ts) and $__interval_ms > 0`,
+			out: `select * from x where ts >= 1672531200 AND ts <= 1672531800 and 10000 > 0`,
+		},
 		{
 			in:  `select * from x where time >= $__timeFrom`,
 			out: `select * from x where time >= cast('2023-01-01T00:00:00Z' as timestamp)`,
@@ -55,9 +76,40 @@ func TestMacros(t *testing.T) {
 	}
 	for _, c := range cs {
 		t.Run(c.in, func(t *testing.T) {
-			sql, err := sqlutil.Interpolate(query.WithSQL(c.in), macros)
+			sql, err := sqlutil.Interpolate(query.WithSQL(c.in), newMacros(&fillOptions{}))
 			require.NoError(t, err)
 			require.Equal(t, c.out, sql)
 		})
 	}
+
+	t.Run("expands $__interval in the time group macros regardless of the order of the macros", func(t *testing.T) {
+		// the macros are a map, so the order of the expansion changes between runs
+		for i := 0; i < 50; i++ {
+			fill := &fillOptions{}
+			sql, err := sqlutil.Interpolate(query.WithSQL(`select $__timeGroupAlias(time, $__interval, 0)`), newMacros(fill))
+			require.NoError(t, err)
+			require.Equal(t, `select date_bin(interval '10 second', time, timestamp '1970-01-01T00:00:00Z') AS time`, sql)
+			require.Equal(t, 10*time.Second, fill.interval)
+		}
+	})
+}
+
+func TestTimeGroupFill(t *testing.T) {
+	from, _ := time.Parse(time.RFC3339, "2023-01-01T00:00:00Z")
+	query := sqlutil.Query{TimeRange: backend.TimeRange{From: from, To: from.Add(time.Hour)}}
+
+	for arg, want := range map[string]data.FillMissing{
+		"NULL":     {Mode: data.FillModeNull},
+		"previous": {Mode: data.FillModePrevious},
+		"1.5":      {Mode: data.FillModeValue, Value: 1.5},
+	} {
+		fill := &fillOptions{}
+		_, err := sqlutil.Interpolate(query.WithSQL("select $__timeGroup(time, 5m, "+arg+")"), newMacros(fill))
+		require.NoError(t, err)
+		require.Equal(t, want, *fill.missing)
+		require.Equal(t, 5*time.Minute, fill.interval)
+	}
+
+	_, err := sqlutil.Interpolate(query.WithSQL("select $__timeGroup(time, 5m, zero)"), newMacros(&fillOptions{}))
+	require.Error(t, err)
 }
@@ -11,6 +11,9 @@ import (
 
 type queryModel struct {
 	*sqlutil.Query
+	// FillInterval is the interval the time series are resampled at to fill the missing values, it is set if
+	// Query.FillMissing is set.
+	FillInterval time.Duration
 }
 
 // queryRequest is an inbound query request as part of a batch of queries sent
@@ -49,11 +52,13 @@ func getQueryModel(dataQuery backend.DataQuery) (*queryModel, error) {
 	}
 
 	// Process macros and execute the query.
-	sql, err := sqlutil.Interpolate(query, macros)
+	fill := &fillOptions{}
+	sql, err := sqlutil.Interpolate(query, newMacros(fill))
 	if err != nil {
 		return nil, fmt.Errorf("macro interpolation: %w", err)
 	}
 	query.RawSQL = sql
+	query.FillMissing = fill.missing
 
-	return &queryModel{query}, nil
+	return &queryModel{Query: query, FillInterval: fill.interval}, nil
 }
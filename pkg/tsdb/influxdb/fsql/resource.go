@@ -0,0 +1,154 @@
+package fsql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+const (
+	tablesResourcePath    = "tables"
+	columnsResourcePath   = "columns"
+	tagValuesResourcePath = "tagValues"
+
+	defaultTagValuesLimit = 1000
+)
+
+type column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// CallResource serves the schema of the database for the query editor: the tables, the columns of a table and the
+// distinct values of a tag column. The tables and columns are read from the information schema.
+func CallResource(ctx context.Context, dsInfo *models.DatasourceInfo, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req.Method != http.MethodGet {
+		return sendJSON(sender, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid url: %s", err)})
+	}
+	params := u.Query()
+
+	var query string
+	switch req.Path {
+	case tablesResourcePath:
+		query = "SELECT table_name FROM information_schema.tables WHERE table_schema NOT IN ('information_schema', 'system') ORDER BY table_name"
+	case columnsResourcePath:
+		table := params.Get("table")
+		if table == "" {
+			return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": "missing table"})
+		}
+		query = fmt.Sprintf("SELECT column_name, data_type FROM information_schema.columns WHERE table_name = %s ORDER BY ordinal_position", quoteLiteral(table))
+	case tagValuesResourcePath:
+		table, tag := params.Get("table"), params.Get("tag")
+		if table == "" || tag == "" {
+			return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": "missing table or tag"})
+		}
+		limit := defaultTagValuesLimit
+		if l := params.Get("limit"); l != "" {
+			if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+				return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid limit %q", l)})
+			}
+		}
+		query = fmt.Sprintf("SELECT DISTINCT %[1]s FROM %[2]s WHERE %[1]s IS NOT NULL ORDER BY %[1]s LIMIT %[3]d", quoteIdentifier(tag), quoteIdentifier(table), limit)
+	default:
+		return sendJSON(sender, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+
+	frame, err := runQuery(ctx, dsInfo, query)
+	if err != nil {
+		return sendJSON(sender, http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+
+	switch req.Path {
+	case columnsResourcePath:
+		columns := []column{}
+		for i := 0; i < frame.Rows(); i++ {
+			columns = append(columns, column{Name: stringAt(frame.Fields[0], i), Type: stringAt(frame.Fields[1], i)})
+		}
+		return sendJSON(sender, http.StatusOK, columns)
+	default:
+		values := []string{}
+		for i := 0; i < frame.Rows(); i++ {
+			values = append(values, stringAt(frame.Fields[0], i))
+		}
+		return sendJSON(sender, http.StatusOK, values)
+	}
+}
+
+// runQuery runs a query and returns its results in a single frame.
+func runQuery(ctx context.Context, dsInfo *models.DatasourceInfo, query string) (*data.Frame, error) {
+	logger := glog.FromContext(ctx)
+	r, err := runnerFromDataSource(dsInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := r.client.Close(); err != nil {
+			logger.Warn("Failed to close fsql client", "err", err)
+		}
+	}()
+
+	if r.client.md.Len() != 0 {
+		ctx = metadata.NewOutgoingContext(ctx, r.client.md)
+	}
+
+	info, err := r.client.Execute(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("flightsql: %w", err)
+	}
+	if len(info.Endpoint) != 1 {
+		return nil, fmt.Errorf("unsupported endpoint count in response: %d", len(info.Endpoint))
+	}
+	reader, err := r.client.DoGetWithHeaderExtraction(ctx, info.Endpoint[0].Ticket)
+	if err != nil {
+		return nil, fmt.Errorf("flightsql: %w", err)
+	}
+	defer reader.Release()
+
+	return frameForRecords(reader)
+}
+
+// stringAt returns the value of a field as a string, the values of the tag columns are not always strings.
+func stringAt(field *data.Field, i int) string {
+	v, ok := field.ConcreteAt(i)
+	if !ok {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+func sendJSON(sender backend.CallResourceResponseSender, status int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
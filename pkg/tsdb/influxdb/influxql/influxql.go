@@ -46,9 +46,12 @@ func Query(ctx context.Context, tracer trace.Tracer, dsInfo *models.DatasourceIn
 		responseLock := sync.Mutex{}
 		err = concurrency.ForEachJob(ctx, len(req.Queries), concurrentQueryCount, func(ctx context.Context, idx int) error {
 			reqQuery := req.Queries[idx]
-			query, err := models.QueryParse(reqQuery)
+			query, err := parseQuery(reqQuery)
 			if err != nil {
-				return err
+				responseLock.Lock()
+				defer responseLock.Unlock()
+				response.Responses[reqQuery.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+				return nil
 			}
 
 			rawQuery, err := query.Build(req)
@@ -85,9 +88,11 @@ func Query(ctx context.Context, tracer trace.Tracer, dsInfo *models.DatasourceIn
 		}
 	} else {
 		for _, reqQuery := range req.Queries {
-			query, err := models.QueryParse(reqQuery)
+			query, err := parseQuery(reqQuery)
 			if err != nil {
-				return &backend.QueryDataResponse{}, err
+				// an invalid query fails on its own, the other queries are still run
+				response.Responses[reqQuery.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+				continue
 			}
 
 			rawQuery, err := query.Build(req)
@@ -120,6 +125,19 @@ func Query(ctx context.Context, tracer trace.Tracer, dsInfo *models.DatasourceIn
 	return response, err
 }
 
+// parseQuery parses and validates the model of a query, the errors of the structured queries are
+// *models.ValidationError errors that name the invalid fields.
+func parseQuery(reqQuery backend.DataQuery) (*models.Query, error) {
+	query, err := models.QueryParse(reqQuery)
+	if err != nil {
+		return nil, err
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
 func createRequest(ctx context.Context, logger log.Logger, dsInfo *models.DatasourceInfo, queryStr string, retentionPolicy string) (*http.Request, error) {
 	u, err := url.Parse(dsInfo.URL)
 	if err != nil {
@@ -3,13 +3,18 @@ package influxql
 import (
 	"context"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
 )
 
@@ -60,3 +65,44 @@ func TestExecutor_createRequest(t *testing.T) {
 		require.EqualError(t, err, ErrInvalidHttpMode.Error())
 	})
 }
+
+func TestQuery(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("q"))
+		_, _ = w.Write([]byte(`{"results": [{"statement_id": 0}]}`))
+	}))
+	defer srv.Close()
+
+	datasource := &models.DatasourceInfo{
+		HTTPClient: srv.Client(),
+		URL:        srv.URL,
+		DbName:     "db",
+		HTTPMode:   "GET",
+	}
+	timeRange := backend.TimeRange{From: time.Unix(0, 0), To: time.Unix(3600, 0)}
+	req := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				TimeRange: timeRange,
+				JSON:      []byte(`{"select": [[{"type": "field", "params": ["value"]}, {"type": "mean", "params": []}]], "groupBy": [{"type": "time", "params": ["$__interval"]}]}`),
+			},
+			{
+				RefID:     "B",
+				TimeRange: timeRange,
+				Interval:  time.Minute,
+				JSON:      []byte(`{"measurement": "cpu", "select": [[{"type": "field", "params": ["value"]}, {"type": "mean", "params": []}]], "groupBy": [{"type": "time", "params": ["$__interval"]}, {"type": "fill", "params": ["none"]}]}`),
+			},
+		},
+	}
+
+	res, err := Query(context.Background(), nil, datasource, req, featuremgmt.WithFeatures())
+	require.NoError(t, err)
+
+	require.Equal(t, backend.StatusBadRequest, res.Responses["A"].Status)
+	require.EqualError(t, res.Responses["A"].Error, "invalid query: measurement: measurement is required")
+
+	require.NoError(t, res.Responses["B"].Error)
+	require.Equal(t, []string{`SELECT mean("value") FROM "cpu" WHERE time >= 0ms and time <= 3600000ms GROUP BY time(1m) fill(none)`}, queries)
+}
@@ -75,13 +75,13 @@ func parseSelects(model *simplejson.Json) ([]*Select, error) {
 	selectObjs := model.Get("select").MustArray()
 	result := make([]*Select, 0, len(selectObjs))
 
-	for _, selectObj := range selectObjs {
+	for i, selectObj := range selectObjs {
 		selectJson := simplejson.NewFromAny(selectObj)
 		var parts Select
 
-		for _, partObj := range selectJson.MustArray() {
+		for j, partObj := range selectJson.MustArray() {
 			part := simplejson.NewFromAny(partObj)
-			queryPart, err := parseQueryPart(part)
+			queryPart, err := parseQueryPart(part, fmt.Sprintf("select[%d][%d]", i, j))
 			if err != nil {
 				return nil, err
 			}
@@ -98,19 +98,19 @@ func parseSelects(model *simplejson.Json) ([]*Select, error) {
 func parseTags(model *simplejson.Json) ([]*Tag, error) {
 	tags := model.Get("tags").MustArray()
 	result := make([]*Tag, 0, len(tags))
-	for _, t := range tags {
+	for i, t := range tags {
 		tagJson := simplejson.NewFromAny(t)
 		tag := &Tag{}
 		var err error
 
 		tag.Key, err = tagJson.Get("key").String()
 		if err != nil {
-			return nil, err
+			return nil, newFieldError(fmt.Sprintf("tags[%d].key", i), "must be a string")
 		}
 
 		tag.Value, err = tagJson.Get("value").String()
 		if err != nil {
-			return nil, err
+			return nil, newFieldError(fmt.Sprintf("tags[%d].value", i), "must be a string")
 		}
 
 		operator, err := tagJson.Get("operator").String()
@@ -129,14 +129,15 @@ func parseTags(model *simplejson.Json) ([]*Tag, error) {
 	return result, nil
 }
 
-func parseQueryPart(model *simplejson.Json) (*QueryPart, error) {
+// parseQueryPart parses a part of a select or of the group by, path is the path of the part in the model.
+func parseQueryPart(model *simplejson.Json, path string) (*QueryPart, error) {
 	typ, err := model.Get("type").String()
 	if err != nil {
-		return nil, err
+		return nil, newFieldError(path+".type", "must be a string")
 	}
 
 	var params []string
-	for _, paramObj := range model.Get("params").MustArray() {
+	for i, paramObj := range model.Get("params").MustArray() {
 		param := simplejson.NewFromAny(paramObj)
 
 		stringParam, err := param.String()
@@ -151,12 +152,18 @@ func parseQueryPart(model *simplejson.Json) (*QueryPart, error) {
 			continue
 		}
 
-		return nil, err
+		floatParam, err := param.Float64()
+		if err == nil {
+			params = append(params, strconv.FormatFloat(floatParam, 'f', -1, 64))
+			continue
+		}
+
+		return nil, newFieldError(fmt.Sprintf("%s.params[%d]", path, i), "must be a string or a number")
 	}
 
 	qp, err := NewQueryPart(typ, params)
 	if err != nil {
-		return nil, err
+		return nil, newFieldError(path+".type", "unknown type %q", typ)
 	}
 
 	return qp, nil
@@ -165,9 +172,9 @@ func parseQueryPart(model *simplejson.Json) (*QueryPart, error) {
 func parseGroupBy(model *simplejson.Json) ([]*QueryPart, error) {
 	groupBy := model.Get("groupBy").MustArray()
 	result := make([]*QueryPart, 0, len(groupBy))
-	for _, groupObj := range groupBy {
+	for i, groupObj := range groupBy {
 		groupJson := simplejson.NewFromAny(groupObj)
-		queryPart, err := parseQueryPart(groupJson)
+		queryPart, err := parseQueryPart(groupJson, fmt.Sprintf("groupBy[%d]", i))
 		if err != nil {
 			return nil, err
 		}
@@ -9,92 +9,131 @@ import (
 
 var renders map[string]QueryDefinition
 
+// The categories of the query parts, they match the categories of the query editor.
+const (
+	categoryFields          = "Fields"
+	categoryAggregations    = "Aggregations"
+	categorySelectors       = "Selectors"
+	categoryTransformations = "Transformations"
+	categoryPredictors      = "Predictors"
+	categoryMath            = "Math"
+	categoryAliasing        = "Aliasing"
+	categoryGroupBy         = "GroupBy"
+)
+
 type DefinitionParameters struct {
 	Name string
 	Type string
+	// Optional parameters can be omitted, they are always the last parameters.
+	Optional bool
 }
 
 type QueryDefinition struct {
 	Renderer func(query *Query, queryContext *backend.QueryDataRequest, part *QueryPart, innerExpr string) string
 	Params   []DefinitionParameters
+	Category string
 }
 
 func init() {
 	renders = make(map[string]QueryDefinition)
 
-	renders["field"] = QueryDefinition{Renderer: fieldRenderer}
+	renders["field"] = QueryDefinition{
+		Renderer: fieldRenderer,
+		Params:   []DefinitionParameters{{Name: "field", Type: "string"}},
+		Category: categoryFields,
+	}
 
-	renders["spread"] = QueryDefinition{Renderer: functionRenderer}
-	renders["count"] = QueryDefinition{Renderer: functionRenderer}
-	renders["distinct"] = QueryDefinition{Renderer: functionRenderer}
-	renders["integral"] = QueryDefinition{Renderer: functionRenderer}
-	renders["mean"] = QueryDefinition{Renderer: functionRenderer}
-	renders["median"] = QueryDefinition{Renderer: functionRenderer}
-	renders["sum"] = QueryDefinition{Renderer: functionRenderer}
-	renders["mode"] = QueryDefinition{Renderer: functionRenderer}
-	renders["cumulative_sum"] = QueryDefinition{Renderer: functionRenderer}
-	renders["non_negative_difference"] = QueryDefinition{Renderer: functionRenderer}
+	renders["spread"] = QueryDefinition{Renderer: functionRenderer, Category: categoryTransformations}
+	renders["count"] = QueryDefinition{Renderer: functionRenderer, Category: categoryAggregations}
+	renders["distinct"] = QueryDefinition{Renderer: functionRenderer, Category: categoryAggregations}
+	renders["integral"] = QueryDefinition{Renderer: functionRenderer, Category: categoryAggregations}
+	renders["mean"] = QueryDefinition{Renderer: functionRenderer, Category: categoryAggregations}
+	renders["median"] = QueryDefinition{Renderer: functionRenderer, Category: categoryAggregations}
+	renders["sum"] = QueryDefinition{Renderer: functionRenderer, Category: categoryAggregations}
+	renders["mode"] = QueryDefinition{Renderer: functionRenderer, Category: categoryAggregations}
+	renders["cumulative_sum"] = QueryDefinition{Renderer: functionRenderer, Category: categoryTransformations}
+	renders["non_negative_difference"] = QueryDefinition{Renderer: functionRenderer, Category: categoryTransformations}
 
 	renders["holt_winters"] = QueryDefinition{
 		Renderer: functionRenderer,
 		Params:   []DefinitionParameters{{Name: "number", Type: "number"}, {Name: "season", Type: "number"}},
+		Category: categoryPredictors,
 	}
 	renders["holt_winters_with_fit"] = QueryDefinition{
 		Renderer: functionRenderer,
 		Params:   []DefinitionParameters{{Name: "number", Type: "number"}, {Name: "season", Type: "number"}},
+		Category: categoryPredictors,
 	}
 
 	renders["derivative"] = QueryDefinition{
 		Renderer: functionRenderer,
-		Params:   []DefinitionParameters{{Name: "duration", Type: "interval"}},
+		Params:   []DefinitionParameters{{Name: "duration", Type: "interval", Optional: true}},
+		Category: categoryTransformations,
 	}
 
 	renders["non_negative_derivative"] = QueryDefinition{
 		Renderer: functionRenderer,
-		Params:   []DefinitionParameters{{Name: "duration", Type: "interval"}},
+		Params:   []DefinitionParameters{{Name: "duration", Type: "interval", Optional: true}},
+		Category: categoryTransformations,
 	}
-	renders["difference"] = QueryDefinition{Renderer: functionRenderer}
+	renders["difference"] = QueryDefinition{Renderer: functionRenderer, Category: categoryTransformations}
 	renders["moving_average"] = QueryDefinition{
 		Renderer: functionRenderer,
 		Params:   []DefinitionParameters{{Name: "window", Type: "number"}},
+		Category: categoryTransformations,
 	}
-	renders["stddev"] = QueryDefinition{Renderer: functionRenderer}
+	renders["stddev"] = QueryDefinition{Renderer: functionRenderer, Category: categoryTransformations}
 	renders["time"] = QueryDefinition{
 		Renderer: functionRenderer,
-		Params:   []DefinitionParameters{{Name: "interval", Type: "time"}, {Name: "offset", Type: "time"}},
+		Params:   []DefinitionParameters{{Name: "interval", Type: "time"}, {Name: "offset", Type: "time", Optional: true}},
+		Category: categoryGroupBy,
 	}
 	renders["fill"] = QueryDefinition{
 		Renderer: functionRenderer,
 		Params:   []DefinitionParameters{{Name: "fill", Type: "string"}},
+		Category: categoryGroupBy,
 	}
 	renders["elapsed"] = QueryDefinition{
 		Renderer: functionRenderer,
-		Params:   []DefinitionParameters{{Name: "duration", Type: "interval"}},
+		Params:   []DefinitionParameters{{Name: "duration", Type: "interval", Optional: true}},
+		Category: categoryTransformations,
 	}
 	renders["bottom"] = QueryDefinition{
 		Renderer: functionRenderer,
 		Params:   []DefinitionParameters{{Name: "count", Type: "int"}},
+		Category: categorySelectors,
 	}
 
-	renders["first"] = QueryDefinition{Renderer: functionRenderer}
-	renders["last"] = QueryDefinition{Renderer: functionRenderer}
-	renders["max"] = QueryDefinition{Renderer: functionRenderer}
-	renders["min"] = QueryDefinition{Renderer: functionRenderer}
+	renders["first"] = QueryDefinition{Renderer: functionRenderer, Category: categorySelectors}
+	renders["last"] = QueryDefinition{Renderer: functionRenderer, Category: categorySelectors}
+	renders["max"] = QueryDefinition{Renderer: functionRenderer, Category: categorySelectors}
+	renders["min"] = QueryDefinition{Renderer: functionRenderer, Category: categorySelectors}
 	renders["percentile"] = QueryDefinition{
 		Renderer: functionRenderer,
-		Params:   []DefinitionParameters{{Name: "nth", Type: "int"}},
+		Params:   []DefinitionParameters{{Name: "nth", Type: "number"}},
+		Category: categorySelectors,
 	}
 	renders["top"] = QueryDefinition{
 		Renderer: functionRenderer,
 		Params:   []DefinitionParameters{{Name: "count", Type: "int"}},
+		Category: categorySelectors,
 	}
 	renders["tag"] = QueryDefinition{
 		Renderer: fieldRenderer,
 		Params:   []DefinitionParameters{{Name: "tag", Type: "string"}},
+		Category: categoryGroupBy,
 	}
 
-	renders["math"] = QueryDefinition{Renderer: suffixRenderer}
-	renders["alias"] = QueryDefinition{Renderer: aliasRenderer}
+	renders["math"] = QueryDefinition{
+		Renderer: suffixRenderer,
+		Params:   []DefinitionParameters{{Name: "expr", Type: "string"}},
+		Category: categoryMath,
+	}
+	renders["alias"] = QueryDefinition{
+		Renderer: aliasRenderer,
+		Params:   []DefinitionParameters{{Name: "name", Type: "string"}},
+		Category: categoryAliasing,
+	}
 }
 
 func fieldRenderer(query *Query, queryContext *backend.QueryDataRequest, part *QueryPart, innerExpr string) string {
@@ -0,0 +1,250 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// FieldError is an error of a field of the query model. Field is the path of the field in the JSON model, e.g.
+// select[0][1].params[0] for the first parameter of the second part of the first select.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError holds the errors of the invalid fields of a query model.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Error())
+	}
+	return "invalid query: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func newFieldError(field, format string, args ...any) *ValidationError {
+	e := &ValidationError{}
+	e.add(field, format, args...)
+	return e
+}
+
+var (
+	tagOperators   = []string{"", "=", "!=", "<>", "<", ">", "<=", ">=", "=~", "!~", "Is", "Is Not"}
+	tagConditions  = []string{"", "AND", "OR"}
+	fillOptions    = []string{"none", "null", "previous", "linear"}
+	orderByTimes   = []string{"", "ASC", "DESC"}
+	resultFormats  = []string{"", "time_series", "table", "logs"}
+	singleSelected = map[string]bool{categoryAggregations: true, categorySelectors: true}
+	// spread and stddev are listed with the transformations in the query editor, but they aggregate the points of
+	// the field like mean or sum do
+	aggregatingTransformations = map[string]bool{"spread": true, "stddev": true}
+)
+
+// Validate checks the query model the query is built from, it returns a *ValidationError with the errors of all
+// the invalid fields. Raw queries are sent as they are and are not validated.
+func (query *Query) Validate() error {
+	if query.UseRawQuery && query.RawQuery != "" {
+		return nil
+	}
+
+	e := &ValidationError{}
+	if query.Measurement == "" {
+		e.add("measurement", "measurement is required")
+	}
+	if len(query.Selects) == 0 {
+		e.add("select", "at least one field must be selected")
+	}
+
+	groupByTime := false
+	for i, part := range query.GroupBy {
+		if part.Type == "time" {
+			groupByTime = true
+		}
+		query.validateGroupBy(e, i, part)
+	}
+
+	for i, sel := range query.Selects {
+		validateSelect(e, i, *sel, groupByTime)
+	}
+
+	for i, tag := range query.Tags {
+		validateTag(e, i, tag)
+	}
+
+	if !slices.Contains(orderByTimes, query.OrderByTime) {
+		e.add("orderByTime", "must be ASC or DESC")
+	}
+	if !slices.Contains(resultFormats, query.ResultFormat) {
+		e.add("resultFormat", "unknown result format %q", query.ResultFormat)
+	}
+	if query.Limit != "" && !isPositiveInt(query.Limit) {
+		e.add("limit", "must be a positive integer")
+	}
+	if query.Slimit != "" && !isPositiveInt(query.Slimit) {
+		e.add("slimit", "must be a positive integer")
+	}
+	if query.Tz != "" {
+		if _, err := time.LoadLocation(query.Tz); err != nil {
+			e.add("tz", "unknown time zone %q", query.Tz)
+		}
+	}
+
+	if len(e.Errors) > 0 {
+		return e
+	}
+	return nil
+}
+
+func validateSelect(e *ValidationError, index int, sel Select, groupByTime bool) {
+	path := fmt.Sprintf("select[%d]", index)
+	if len(sel) == 0 || sel[0].Type != "field" {
+		e.add(path, "must start with a field")
+		return
+	}
+
+	aggregated := false
+	for i, part := range sel {
+		partPath := fmt.Sprintf("%s[%d]", path, i)
+		validateParams(e, partPath, &part)
+
+		switch category := part.Def.Category; {
+		case category == categoryFields && i > 0:
+			e.add(partPath, "a select can only have one field")
+		case category == categoryGroupBy:
+			e.add(partPath, "%s can only be used in GROUP BY", part.Type)
+		case singleSelected[category] || aggregatingTransformations[part.Type]:
+			if aggregated {
+				e.add(partPath, "a select can only have one aggregation or selector")
+			}
+			aggregated = true
+		case category == categoryAliasing && i != len(sel)-1:
+			e.add(partPath, "alias must be the last part of a select")
+		}
+	}
+
+	if groupByTime && !aggregated {
+		e.add(path, "GROUP BY time requires an aggregation or a selector")
+	}
+}
+
+func (query *Query) validateGroupBy(e *ValidationError, index int, part *QueryPart) {
+	path := fmt.Sprintf("groupBy[%d]", index)
+	validateParams(e, path, part)
+
+	switch part.Type {
+	case "time", "tag":
+		for _, p := range query.GroupBy[:index] {
+			if part.Type == "time" && p.Type == "time" {
+				e.add(path, "GROUP BY can only have one time interval")
+			}
+			if p.Type == "fill" {
+				e.add(path, "fill must be the last part of GROUP BY")
+			}
+		}
+	case "fill":
+		hasTime := false
+		for _, p := range query.GroupBy {
+			hasTime = hasTime || p.Type == "time"
+		}
+		if !hasTime {
+			e.add(path, "fill requires a GROUP BY time interval")
+		}
+		if len(part.Params) == 1 && !slices.Contains(fillOptions, part.Params[0]) && !isVariable(part.Params[0]) {
+			if _, err := strconv.ParseFloat(part.Params[0], 64); err != nil {
+				e.add(path+".params[0]", "must be none, null, previous, linear or a number")
+			}
+		}
+	default:
+		e.add(path, "%s cannot be used in GROUP BY", part.Type)
+	}
+}
+
+func validateTag(e *ValidationError, index int, tag *Tag) {
+	path := fmt.Sprintf("tags[%d]", index)
+	if tag.Key == "" {
+		e.add(path+".key", "key is required")
+	}
+	if !slices.Contains(tagOperators, tag.Operator) {
+		e.add(path+".operator", "unknown operator %q", tag.Operator)
+	}
+	if (tag.Operator == "=~" || tag.Operator == "!~") && !regexpOperatorPattern.MatchString(tag.Value) {
+		e.add(path+".value", "must be a regular expression between slashes with the %s operator", tag.Operator)
+	}
+	if index > 0 && !slices.Contains(tagConditions, tag.Condition) {
+		e.add(path+".condition", "must be AND or OR")
+	}
+}
+
+// validateParams checks the number and the types of the parameters of a part against its definition.
+func validateParams(e *ValidationError, path string, part *QueryPart) {
+	required := 0
+	for _, p := range part.Def.Params {
+		if !p.Optional {
+			required++
+		}
+	}
+	if len(part.Params) < required || len(part.Params) > len(part.Def.Params) {
+		if required == len(part.Def.Params) {
+			e.add(path+".params", "%s expects %d parameters, got %d", part.Type, required, len(part.Params))
+		} else {
+			e.add(path+".params", "%s expects %d to %d parameters, got %d", part.Type, required, len(part.Def.Params), len(part.Params))
+		}
+		return
+	}
+
+	for i, param := range part.Params {
+		paramPath := fmt.Sprintf("%s.params[%d]", path, i)
+		def := part.Def.Params[i]
+		// variables are replaced when the query is built
+		if isVariable(param) {
+			continue
+		}
+
+		switch def.Type {
+		case "string":
+			if param == "" {
+				e.add(paramPath, "%s is required", def.Name)
+			}
+		case "int":
+			if !isPositiveInt(param) {
+				e.add(paramPath, "%s must be a positive integer", def.Name)
+			}
+		case "number":
+			if _, err := strconv.ParseFloat(param, 64); err != nil {
+				e.add(paramPath, "%s must be a number", def.Name)
+			}
+		case "interval", "time":
+			if def.Type == "time" && param == "auto" {
+				continue
+			}
+			if _, err := gtime.ParseDuration(strings.TrimPrefix(param, "-")); err != nil {
+				e.add(paramPath, "%s must be a duration", def.Name)
+			}
+		}
+	}
+}
+
+func isVariable(param string) bool {
+	return strings.Contains(param, "$")
+}
+
+func isPositiveInt(s string) bool {
+	i, err := strconv.Atoi(s)
+	return err == nil && i > 0
+}
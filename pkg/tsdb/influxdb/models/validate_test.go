@@ -0,0 +1,184 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/require"
+)
+
+func parseAndValidate(t *testing.T, json string) error {
+	t.Helper()
+	query, err := QueryParse(backend.DataQuery{JSON: []byte(json)})
+	if err != nil {
+		return err
+	}
+	return query.Validate()
+}
+
+func fieldErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	res := map[string]string{}
+	for _, fe := range validationErr.Errors {
+		res[fe.Field] = fe.Message
+	}
+	return res
+}
+
+func TestQueryValidate(t *testing.T) {
+	t.Run("accepts the default query of the editor", func(t *testing.T) {
+		err := parseAndValidate(t, `{
+			"measurement": "cpu",
+			"policy": "default",
+			"resultFormat": "time_series",
+			"orderByTime": "ASC",
+			"tags": [
+				{"key": "host", "operator": "=~", "value": "/^server[12]$/"},
+				{"condition": "AND", "key": "cpu::tag", "operator": "=", "value": "cpu-total"}
+			],
+			"groupBy": [
+				{"type": "time", "params": ["$__interval"]},
+				{"type": "tag", "params": ["host"]},
+				{"type": "fill", "params": ["null"]}
+			],
+			"select": [
+				[{"type": "field", "params": ["usage_idle"]}, {"type": "mean", "params": []}, {"type": "derivative", "params": ["10s"]}, {"type": "math", "params": ["* 100"]}, {"type": "alias", "params": ["idle"]}],
+				[{"type": "field", "params": ["usage_user"]}, {"type": "percentile", "params": [99.5]}]
+			],
+			"limit": "10",
+			"tz": "Europe/Paris"
+		}`)
+		require.NoError(t, err)
+	})
+
+	t.Run("does not validate raw queries", func(t *testing.T) {
+		err := parseAndValidate(t, `{"rawQuery": true, "query": "SELECT mean(\"value\") FROM \"cpu\""}`)
+		require.NoError(t, err)
+	})
+
+	t.Run("maps the errors to the fields of the model", func(t *testing.T) {
+		err := parseAndValidate(t, `{
+			"tags": [
+				{"key": "host", "operator": "=~", "value": "server1"},
+				{"condition": "XOR", "key": "", "operator": "like", "value": "a"}
+			],
+			"groupBy": [
+				{"type": "time", "params": ["5 minutes"]},
+				{"type": "fill", "params": ["zero"]},
+				{"type": "mean", "params": []}
+			],
+			"select": [
+				[{"type": "field", "params": ["value"]}, {"type": "mean", "params": []}, {"type": "max", "params": []}],
+				[{"type": "mean", "params": []}],
+				[{"type": "field", "params": ["value"]}, {"type": "alias", "params": ["v"]}, {"type": "top", "params": [0]}],
+				[{"type": "field", "params": ["value"]}, {"type": "holt_winters", "params": [10]}]
+			],
+			"orderByTime": "up",
+			"limit": "-1",
+			"tz": "Mars/Olympus"
+		}`)
+		require.Equal(t, map[string]string{
+			"measurement":            "measurement is required",
+			"tags[0].value":          "must be a regular expression between slashes with the =~ operator",
+			"tags[1].key":            "key is required",
+			"tags[1].operator":       `unknown operator "like"`,
+			"tags[1].condition":      "must be AND or OR",
+			"groupBy[0].params[0]":   "interval must be a duration",
+			"groupBy[1].params[0]":   "must be none, null, previous, linear or a number",
+			"groupBy[2]":             "mean cannot be used in GROUP BY",
+			"select[0][2]":           "a select can only have one aggregation or selector",
+			"select[1]":              "must start with a field",
+			"select[2][1]":           "alias must be the last part of a select",
+			"select[2][2].params[0]": "count must be a positive integer",
+			"select[3][1].params":    "holt_winters expects 2 parameters, got 1",
+			"select[3]":              "GROUP BY time requires an aggregation or a selector",
+			"orderByTime":            "must be ASC or DESC",
+			"limit":                  "must be a positive integer",
+			"tz":                     `unknown time zone "Mars/Olympus"`,
+		}, fieldErrors(t, err))
+	})
+
+	t.Run("reports the path of the parts that cannot be parsed", func(t *testing.T) {
+		err := parseAndValidate(t, `{"measurement": "cpu", "select": [[{"type": "field", "params": ["value"]}, {"type": "avg", "params": []}]]}`)
+		require.Equal(t, map[string]string{"select[0][1].type": `unknown type "avg"`}, fieldErrors(t, err))
+
+		err = parseAndValidate(t, `{"measurement": "cpu", "groupBy": [{"type": "time", "params": [true]}]}`)
+		require.Equal(t, map[string]string{"groupBy[0].params[0]": "must be a string or a number"}, fieldErrors(t, err))
+
+		err = parseAndValidate(t, `{"measurement": "cpu", "tags": [{"key": "host", "value": 1}]}`)
+		require.Equal(t, map[string]string{"tags[0].value": "must be a string"}, fieldErrors(t, err))
+	})
+}
+
+func TestValidateSelect(t *testing.T) {
+	tests := []struct {
+		name        string
+		groupByTime bool
+		sel         string
+		errors      map[string]string
+	}{
+		{
+			name:        "aggregation",
+			groupByTime: true,
+			sel:         `[{"type": "field", "params": ["value"]}, {"type": "sum", "params": []}]`,
+		},
+		{
+			name:        "selector",
+			groupByTime: true,
+			sel:         `[{"type": "field", "params": ["value"]}, {"type": "last", "params": []}]`,
+		},
+		{
+			name:        "spread is an aggregation",
+			groupByTime: true,
+			sel:         `[{"type": "field", "params": ["value"]}, {"type": "spread", "params": []}]`,
+		},
+		{
+			name:        "stddev is an aggregation",
+			groupByTime: true,
+			sel:         `[{"type": "field", "params": ["value"]}, {"type": "stddev", "params": []}, {"type": "alias", "params": ["sd"]}]`,
+		},
+		{
+			name:        "transformation of an aggregation",
+			groupByTime: true,
+			sel:         `[{"type": "field", "params": ["value"]}, {"type": "stddev", "params": []}, {"type": "derivative", "params": ["10s"]}]`,
+		},
+		{
+			name: "transformation without GROUP BY time",
+			sel:  `[{"type": "field", "params": ["value"]}, {"type": "difference", "params": []}]`,
+		},
+		{
+			name:        "transformation without aggregation",
+			groupByTime: true,
+			sel:         `[{"type": "field", "params": ["value"]}, {"type": "difference", "params": []}]`,
+			errors:      map[string]string{"select[0]": "GROUP BY time requires an aggregation or a selector"},
+		},
+		{
+			name:        "spread and an aggregation",
+			groupByTime: true,
+			sel:         `[{"type": "field", "params": ["value"]}, {"type": "spread", "params": []}, {"type": "mean", "params": []}]`,
+			errors:      map[string]string{"select[0][2]": "a select can only have one aggregation or selector"},
+		},
+		{
+			name:   "stddev and a selector",
+			sel:    `[{"type": "field", "params": ["value"]}, {"type": "max", "params": []}, {"type": "stddev", "params": []}]`,
+			errors: map[string]string{"select[0][2]": "a select can only have one aggregation or selector"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groupBy := `[]`
+			if tt.groupByTime {
+				groupBy = `[{"type": "time", "params": ["$__interval"]}]`
+			}
+			err := parseAndValidate(t, `{"measurement": "cpu", "groupBy": `+groupBy+`, "select": [`+tt.sel+`]}`)
+			if tt.errors == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.Equal(t, tt.errors, fieldErrors(t, err))
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/grafana/dskit/concurrency"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
@@ -66,6 +67,10 @@ type datasourceInfo struct {
 	HTTPClient *http.Client
 	URL        string
 
+	// tailDialer and tailHeader connect to the tail websocket with the settings of the HTTP client.
+	tailDialer *websocket.Dialer
+	tailHeader http.Header
+
 	// open streams
 	streams   map[string]data.FrameJSONCache
 	streamsMu sync.RWMutex
@@ -100,9 +105,16 @@ func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.Inst
 			return nil, err
 		}
 
+		tailDialer, tailHeader, err := newTailDialer(opts)
+		if err != nil {
+			return nil, err
+		}
+
 		model := &datasourceInfo{
 			HTTPClient: client,
 			URL:        settings.URL,
+			tailDialer: tailDialer,
+			tailHeader: tailHeader,
 			streams:    make(map[string]data.FrameJSONCache),
 		}
 		return model, nil
@@ -4,13 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/url"
-	"os"
-	"os/signal"
 	"strings"
-	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
@@ -70,101 +65,45 @@ func (s *Service) RunStream(ctx context.Context, req *backend.RunStreamRequest,
 		return err
 	}
 	if query.Expr == "" {
-		return fmt.Errorf("missing expr in cuannel")
+		return fmt.Errorf("missing expr in channel")
 	}
 
-	logger := logger.FromContext(ctx)
-	count := int64(0)
-
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt)
-
-	params := url.Values{}
-	params.Add("query", query.Expr)
-
-	wsurl, _ := url.Parse(dsInfo.URL)
-
-	wsurl.Path = "/loki/api/v2alpha/tail"
-
-	if wsurl.Scheme == "https" {
-		wsurl.Scheme = "wss"
-	} else {
-		wsurl.Scheme = "ws"
+	var opts tailOptions
+	if err := json.Unmarshal(req.Data, &opts); err != nil {
+		return err
 	}
-	wsurl.RawQuery = params.Encode()
 
-	logger.Info("Connecting to websocket", "url", wsurl)
-	c, r, err := websocket.DefaultDialer.Dial(wsurl.String(), nil)
+	logger := logger.FromContext(ctx)
+	t, err := newTailer(dsInfo, query.Expr, opts, logger)
 	if err != nil {
-		logger.Error("Error connecting to websocket", "err", err)
-		return fmt.Errorf("error connecting to websocket")
+		return err
 	}
 
 	defer func() {
 		dsInfo.streamsMu.Lock()
 		delete(dsInfo.streams, req.Path)
 		dsInfo.streamsMu.Unlock()
-		if r != nil {
-			_ = r.Body.Close()
-		}
-		err = c.Close()
-		logger.Error("Closing loki websocket", "err", err)
 	}()
 
 	prev := data.FrameJSONCache{}
-
-	// Read all messages
-	done := make(chan struct{})
-	go func() {
-		defer close(done)
-		for {
-			_, message, err := c.ReadMessage()
-			if err != nil {
-				logger.Error("Websocket read:", "err", err)
-				return
-			}
-
-			frame := &data.Frame{}
-			err = json.Unmarshal(message, &frame)
-
-			if err == nil && frame != nil {
-				next, _ := data.FrameToJSONCache(frame)
-				if next.SameSchema(&prev) {
-					err = sender.SendBytes(next.Bytes(data.IncludeDataOnly))
-				} else {
-					err = sender.SendFrame(frame, data.IncludeAll)
-				}
-				prev = next
-
-				// Cache the initial data
-				dsInfo.streamsMu.Lock()
-				dsInfo.streams[req.Path] = prev
-				dsInfo.streamsMu.Unlock()
-			}
-
-			if err != nil {
-				logger.Error("Websocket write:", "err", err, "raw", message)
-				return
-			}
+	return t.run(ctx, func(frame *data.Frame) error {
+		next, err := data.FrameToJSONCache(frame)
+		if err != nil {
+			return err
 		}
-	}()
-
-	ticker := time.NewTicker(time.Second * 60) //.Step)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-done:
-			logger.Info("Socket done")
-			return nil
-		case <-ctx.Done():
-			logger.Info("Stop streaming (context canceled)")
-			return nil
-		case t := <-ticker.C:
-			count++
-			logger.Error("Loki websocket ping?", "time", t, "count", count)
+		if next.SameSchema(&prev) {
+			err = sender.SendBytes(next.Bytes(data.IncludeDataOnly))
+		} else {
+			err = sender.SendFrame(frame, data.IncludeAll)
 		}
-	}
+		prev = next
+
+		// Cache the initial data
+		dsInfo.streamsMu.Lock()
+		dsInfo.streams[req.Path] = prev
+		dsInfo.streamsMu.Unlock()
+		return err
+	})
 }
 
 func (s *Service) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
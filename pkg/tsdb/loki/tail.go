@@ -0,0 +1,338 @@
+package loki
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+const (
+	tailMinBackoff = time.Second
+	tailMaxBackoff = 30 * time.Second
+	tailPingPeriod = 30 * time.Second
+	// tailDedupWindow is how long the lines are remembered to drop the lines Loki sends again after a reconnection.
+	tailDedupWindow = 10 * time.Second
+)
+
+var errTailSend = errors.New("failed to send tailed lines")
+
+// tailOptions are the options of a live tail, they are read from the channel data. They apply to all the subscribers
+// of the channel, so the frontend includes them in the channel key with the expression.
+type tailOptions struct {
+	// LineFilter keeps the lines containing the text.
+	LineFilter string `json:"liveLineFilter,omitempty"`
+	// LineFilterRegex keeps the lines matching the regular expression.
+	LineFilterRegex string `json:"liveLineFilterRegex,omitempty"`
+	// MaxLinesPerSecond limits the lines sent to the subscribers, the lines over the limit are dropped.
+	MaxLinesPerSecond int `json:"liveMaxLinesPerSecond,omitempty"`
+}
+
+// tailResponse is a message of the Loki tail websocket.
+type tailResponse struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+	DroppedEntries []struct {
+		Labels    map[string]string `json:"labels"`
+		Timestamp string            `json:"timestamp"`
+	} `json:"dropped_entries"`
+}
+
+type tailEntry struct {
+	ts     int64
+	labels json.RawMessage
+	line   string
+	id     string
+}
+
+// tailer tails a query with the Loki tail websocket. It reconnects when the connection is lost, resuming from the
+// last received line, and drops the lines it already sent.
+type tailer struct {
+	url     *url.URL
+	dialer  *websocket.Dialer
+	header  http.Header
+	logger  log.Logger
+	filter  func(line string) bool
+	limiter *rate.Limiter
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	// last is the timestamp of the newest line in nanoseconds.
+	last int64
+	// seen are the ids of the recent lines with their timestamps.
+	seen map[string]int64
+}
+
+// newTailDialer returns the dialer and the headers of the tail websocket, with the TLS, basic authentication and
+// custom headers settings of the data source.
+func newTailDialer(opts sdkhttpclient.Options) (*websocket.Dialer, http.Header, error) {
+	tlsConfig, err := sdkhttpclient.GetTLSConfig(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+		TLSClientConfig:  tlsConfig,
+	}
+
+	header := http.Header{}
+	for name, values := range opts.Header {
+		header[name] = values
+	}
+	if opts.BasicAuth != nil && header.Get("Authorization") == "" {
+		credentials := opts.BasicAuth.User + ":" + opts.BasicAuth.Password
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+	return dialer, header, nil
+}
+
+func newTailer(dsInfo *datasourceInfo, expr string, opts tailOptions, logger log.Logger) (*tailer, error) {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "/loki/api/v1/tail")
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	u.RawQuery = url.Values{"query": {expr}}.Encode()
+
+	t := &tailer{
+		url:        u,
+		dialer:     dsInfo.tailDialer,
+		header:     dsInfo.tailHeader,
+		logger:     logger,
+		filter:     func(string) bool { return true },
+		minBackoff: tailMinBackoff,
+		maxBackoff: tailMaxBackoff,
+		seen:       map[string]int64{},
+	}
+	switch {
+	case opts.LineFilterRegex != "":
+		re, err := regexp.Compile(opts.LineFilterRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line filter regex: %w", err)
+		}
+		t.filter = re.MatchString
+	case opts.LineFilter != "":
+		t.filter = func(line string) bool { return strings.Contains(line, opts.LineFilter) }
+	}
+	if opts.MaxLinesPerSecond > 0 {
+		t.limiter = rate.NewLimiter(rate.Limit(opts.MaxLinesPerSecond), opts.MaxLinesPerSecond)
+	}
+	if t.dialer == nil {
+		t.dialer = websocket.DefaultDialer
+	}
+	return t, nil
+}
+
+// run tails until the context is canceled or sending fails.
+func (t *tailer) run(ctx context.Context, send func(*data.Frame) error) error {
+	backoff := t.minBackoff
+	for {
+		received, err := t.tail(ctx, send)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, errTailSend) {
+			return err
+		}
+		if received {
+			backoff = t.minBackoff
+		}
+		t.logger.Warn("Loki tail connection lost, reconnecting", "error", err, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > t.maxBackoff {
+			backoff = t.maxBackoff
+		}
+	}
+}
+
+// tail reads the messages of a single connection, it returns whether it received any message.
+func (t *tailer) tail(ctx context.Context, send func(*data.Frame) error) (bool, error) {
+	u := *t.url
+	if t.last > 0 {
+		query := u.Query()
+		query.Set("start", strconv.FormatInt(t.last, 10))
+		u.RawQuery = query.Encode()
+	}
+
+	t.logger.Debug("Connecting to Loki tail websocket", "url", u.String())
+	conn, res, err := t.dialer.DialContext(ctx, u.String(), t.header)
+	if res != nil {
+		_ = res.Body.Close()
+	}
+	if err != nil {
+		return false, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(tailPingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				_ = conn.Close()
+				return
+			case <-ctx.Done():
+				// unblocks the read
+				_ = conn.Close()
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(tailPingPeriod)); err != nil {
+					t.logger.Debug("Failed to ping Loki tail websocket", "error", err)
+				}
+			}
+		}
+	}()
+
+	received := false
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return received, err
+		}
+		received = true
+
+		var msg tailResponse
+		if err := json.Unmarshal(message, &msg); err != nil {
+			return received, fmt.Errorf("failed to parse tail message: %w", err)
+		}
+		frame, err := t.frame(msg)
+		if err != nil {
+			return received, err
+		}
+		if frame == nil {
+			continue
+		}
+		if err := send(frame); err != nil {
+			return received, fmt.Errorf("%w: %w", errTailSend, err)
+		}
+	}
+}
+
+// frame converts a tail message to a logs frame, dropping the lines already sent, the filtered lines and the lines
+// over the rate limit. It returns nil if there is nothing to send.
+func (t *tailer) frame(msg tailResponse) (*data.Frame, error) {
+	var entries []tailEntry
+	// Loki drops the lines when the tail connection does not keep up with the stream, the lines over the limit of
+	// the options are dropped here.
+	dropped := len(msg.DroppedEntries)
+	limited := 0
+	for _, stream := range msg.Streams {
+		labels, err := json.Marshal(stream.Stream)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range stream.Values {
+			ts, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp %q in tail message", value[0])
+			}
+			id, err := calculateCheckSum(value[0], value[1], labels)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := t.seen[id]; ok {
+				continue
+			}
+			t.seen[id] = ts
+			if ts > t.last {
+				t.last = ts
+			}
+
+			if !t.filter(value[1]) {
+				continue
+			}
+			if t.limiter != nil && !t.limiter.Allow() {
+				limited++
+				continue
+			}
+			entries = append(entries, tailEntry{ts: ts, labels: labels, line: value[1], id: id})
+		}
+	}
+	t.forget()
+
+	if len(entries) == 0 && dropped == 0 && limited == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].ts < entries[j].ts })
+
+	labelsField := data.NewFieldFromFieldType(data.FieldTypeJSON, len(entries))
+	timeField := data.NewFieldFromFieldType(data.FieldTypeTime, len(entries))
+	lineField := data.NewFieldFromFieldType(data.FieldTypeString, len(entries))
+	tsField := data.NewFieldFromFieldType(data.FieldTypeString, len(entries))
+	idField := data.NewFieldFromFieldType(data.FieldTypeString, len(entries))
+	labelsField.Name = "labels"
+	timeField.Name = "Time"
+	lineField.Name = "Line"
+	tsField.Name = "tsNs"
+	idField.Name = "id"
+	for i, e := range entries {
+		labelsField.Set(i, e.labels)
+		timeField.Set(i, time.Unix(0, e.ts).UTC())
+		lineField.Set(i, e.line)
+		tsField.Set(i, strconv.FormatInt(e.ts, 10))
+		idField.Set(i, e.id)
+	}
+
+	frame := data.NewFrame("", labelsField, timeField, lineField, tsField, idField)
+	frame.Meta = &data.FrameMeta{
+		Custom: map[string]string{
+			"frameType": "LabeledTimeValues",
+		},
+	}
+	if dropped > 0 {
+		frame.Meta.Notices = append(frame.Meta.Notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("Loki dropped %d lines, the live tail does not keep up with the stream", dropped),
+		})
+	}
+	if limited > 0 {
+		frame.Meta.Notices = append(frame.Meta.Notices, data.Notice{
+			Severity: data.NoticeSeverityWarning,
+			Text:     fmt.Sprintf("%d lines were dropped, the stream is faster than the limit of %d lines per second", limited, t.limiter.Burst()),
+		})
+	}
+	return frame, nil
+}
+
+// forget removes the lines older than the dedup window from the seen lines.
+func (t *tailer) forget() {
+	oldest := t.last - tailDedupWindow.Nanoseconds()
+	for id, ts := range t.seen {
+		if ts < oldest {
+			delete(t.seen, id)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func tailMessage(entries ...string) string {
+	msg := `{"streams": [{"stream": {"app": "grafana"}, "values": [`
+	for i, e := range entries {
+		if i > 0 {
+			msg += ","
+		}
+		msg += e
+	}
+	return msg + `]}]}`
+}
+
+func frameLines(frame *data.Frame) []string {
+	lines := make([]string, frame.Fields[2].Len())
+	for i := range lines {
+		lines[i] = frame.Fields[2].At(i).(string)
+	}
+	return lines
+}
+
+func TestTailer(t *testing.T) {
+	t.Run("reconnects and drops the lines sent again", func(t *testing.T) {
+		var mu sync.Mutex
+		var starts []string
+		connections := [][]string{
+			{tailMessage(`["1000", "first"]`, `["2000", "second"]`)},
+			{tailMessage(`["2000", "second"]`, `["3000", "third"]`)},
+		}
+		upgrader := websocket.Upgrader{}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/loki/api/v1/tail", r.URL.Path)
+			require.Equal(t, `{app="grafana"}`, r.URL.Query().Get("query"))
+
+			mu.Lock()
+			starts = append(starts, r.URL.Query().Get("start"))
+			n := len(starts)
+			mu.Unlock()
+
+			conn, err := upgrader.Upgrade(w, r, nil)
+			require.NoError(t, err)
+			defer func() { _ = conn.Close() }()
+			if n > len(connections) {
+				// keep the last connection open until the tailer stops
+				_, _, _ = conn.ReadMessage()
+				return
+			}
+			for _, msg := range connections[n-1] {
+				require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+			}
+		}))
+		defer srv.Close()
+
+		tailer, err := newTailer(&datasourceInfo{URL: srv.URL}, `{app="grafana"}`, tailOptions{}, logger)
+		require.NoError(t, err)
+		tailer.minBackoff = time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		var lines []string
+		err = tailer.run(ctx, func(frame *data.Frame) error {
+			lines = append(lines, frameLines(frame)...)
+			if len(lines) == 3 {
+				cancel()
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"first", "second", "third"}, lines)
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, []string{"", "2000"}, starts[:2])
+	})
+
+	t.Run("filters and limits the lines", func(t *testing.T) {
+		tailer, err := newTailer(&datasourceInfo{URL: "http://localhost:3100"}, `{app="grafana"}`, tailOptions{
+			LineFilterRegex:   "^level=(error|warn)",
+			MaxLinesPerSecond: 1,
+		}, logger)
+		require.NoError(t, err)
+		require.Equal(t, "ws", tailer.url.Scheme)
+
+		var msg tailResponse
+		require.NoError(t, json.Unmarshal([]byte(tailMessage(
+			`["1000", "level=error msg=a"]`,
+			`["2000", "level=info msg=b"]`,
+			`["3000", "level=warn msg=c"]`,
+		)), &msg))
+		frame, err := tailer.frame(msg)
+		require.NoError(t, err)
+		require.Equal(t, []string{"level=error msg=a"}, frameLines(frame))
+		require.Len(t, frame.Meta.Notices, 1)
+		require.Contains(t, frame.Meta.Notices[0].Text, "1 lines were dropped")
+		require.Equal(t, "1000", frame.Fields[3].At(0))
+	})
+
+	t.Run("tells the lines dropped by Loki from the lines over the limit", func(t *testing.T) {
+		tailer, err := newTailer(&datasourceInfo{URL: "http://localhost:3100"}, `{app="grafana"}`, tailOptions{MaxLinesPerSecond: 1}, logger)
+		require.NoError(t, err)
+
+		var msg tailResponse
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"streams": [{"stream": {"app": "grafana"}, "values": [["1000", "a"], ["2000", "b"]]}],
+			"dropped_entries": [{"labels": {"app": "grafana"}, "timestamp": "500"}]
+		}`), &msg))
+		frame, err := tailer.frame(msg)
+		require.NoError(t, err)
+		require.Len(t, frame.Meta.Notices, 2)
+		require.Equal(t, "Loki dropped 1 lines, the live tail does not keep up with the stream", frame.Meta.Notices[0].Text)
+		require.Equal(t, "1 lines were dropped, the stream is faster than the limit of 1 lines per second", frame.Meta.Notices[1].Text)
+	})
+
+	t.Run("connects with the settings of the data source", func(t *testing.T) {
+		headers := make(chan http.Header, 1)
+		upgrader := websocket.Upgrader{}
+		srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			headers <- r.Header.Clone()
+			conn, err := upgrader.Upgrade(w, r, nil)
+			require.NoError(t, err)
+			defer func() { _ = conn.Close() }()
+			_, _, _ = conn.ReadMessage()
+		}))
+		defer srv.Close()
+
+		dialer, header, err := newTailDialer(sdkhttpclient.Options{
+			BasicAuth: &sdkhttpclient.BasicAuthOptions{User: "user", Password: "pass"},
+			Header:    http.Header{"X-Scope-Orgid": {"tenant"}},
+			TLS:       &sdkhttpclient.TLSOptions{InsecureSkipVerify: true},
+		})
+		require.NoError(t, err)
+		tailer, err := newTailer(&datasourceInfo{URL: srv.URL, tailDialer: dialer, tailHeader: header}, `{app="grafana"}`, tailOptions{}, logger)
+		require.NoError(t, err)
+		require.Equal(t, "wss", tailer.url.Scheme)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var h http.Header
+		go func() {
+			h = <-headers
+			cancel()
+		}()
+		require.NoError(t, tailer.run(ctx, func(*data.Frame) error { return nil }))
+		require.Equal(t, "tenant", h.Get("X-Scope-Orgid"))
+		require.Equal(t, "Basic dXNlcjpwYXNz", h.Get("Authorization"))
+	})
+
+	t.Run("returns an error for an invalid regex", func(t *testing.T) {
+		_, err := newTailer(&datasourceInfo{URL: "http://localhost:3100"}, `{app="grafana"}`, tailOptions{LineFilterRegex: "("}, logger)
+		require.Error(t, err)
+	})
+}
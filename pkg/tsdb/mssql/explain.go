@@ -0,0 +1,93 @@
+package mssql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+// fullScanOperators are the physical operators of SQL Server that read all the rows of a table or index.
+var fullScanOperators = map[string]bool{
+	"Table Scan":           true,
+	"Clustered Index Scan": true,
+	"Index Scan":           true,
+}
+
+// mssqlQueryPlanner explains queries with SHOWPLAN_ALL, the queries are not executed while it is enabled.
+type mssqlQueryPlanner struct{}
+
+func (mssqlQueryPlanner) Explain(ctx context.Context, conn *sql.Conn, query string) (*sqleng.QueryPlan, error) {
+	// SHOWPLAN_ALL has to be set in its own batch and applies to the connection until it is disabled again.
+	if _, err := conn.ExecContext(ctx, "SET SHOWPLAN_ALL ON"); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// the connection is returned to the pool, so the option is disabled even if the context is canceled
+		if _, err := conn.ExecContext(context.Background(), "SET SHOWPLAN_ALL OFF"); err != nil {
+			// the queries would not be executed on a connection that still has the option enabled, so it is closed
+			// instead of being returned to the pool
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, values, err := sqleng.ReadRowsAsStrings(rows)
+	if err != nil {
+		return nil, err
+	}
+	return parseMSSQLPlan(columns, values), nil
+}
+
+// parseMSSQLPlan reports the scan operators as full scans. The rows a scan reads are estimated with the rows it
+// returns for every execution of the operator.
+func parseMSSQLPlan(columns []string, values [][]string) *sqleng.QueryPlan {
+	column := func(row []string, name string) string {
+		for i, c := range columns {
+			if c == name {
+				return row[i]
+			}
+		}
+		return ""
+	}
+
+	var plan []string
+	result := &sqleng.QueryPlan{}
+	for _, row := range values {
+		plan = append(plan, column(row, "StmtText"))
+		if !fullScanOperators[column(row, "PhysicalOp")] {
+			continue
+		}
+		rows, err := strconv.ParseFloat(column(row, "EstimateRows"), 64)
+		if err != nil {
+			continue
+		}
+		if executions, err := strconv.ParseFloat(column(row, "EstimateExecutions"), 64); err == nil && executions > 1 {
+			rows *= executions
+		}
+		result.FullScans = append(result.FullScans, sqleng.FullScan{Table: scanObject(column(row, "Argument")), Rows: rows})
+	}
+	result.Plan = strings.Join(plan, "\n")
+	return result
+}
+
+// scanObject returns the object of the argument of a scan operator, e.g. [db].[dbo].[table] for
+// OBJECT:([db].[dbo].[table]), WHERE:(...).
+func scanObject(argument string) string {
+	object, ok := strings.CutPrefix(argument, "OBJECT:(")
+	if !ok {
+		return argument
+	}
+	if i := strings.Index(object, ")"); i >= 0 {
+		return object[:i]
+	}
+	return object
+}
@@ -0,0 +1,26 @@
+package mssql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+func TestParseMSSQLPlan(t *testing.T) {
+	columns := []string{"StmtText", "PhysicalOp", "Argument", "EstimateRows", "EstimateExecutions"}
+	plan := parseMSSQLPlan(columns, [][]string{
+		{"SELECT * FROM metrics m JOIN hosts h ON h.id = m.host_id", "", "", "5000", ""},
+		{"  |--Nested Loops(Inner Join)", "Nested Loops", "", "5000", "1"},
+		{"       |--Table Scan(OBJECT:([db].[dbo].[metrics] AS [m]))", "Table Scan", "OBJECT:([db].[dbo].[metrics] AS [m])", "5000", "1"},
+		{"       |--Clustered Index Seek(OBJECT:([db].[dbo].[hosts].[PK_hosts]))", "Clustered Index Seek", "OBJECT:([db].[dbo].[hosts].[PK_hosts])", "1", "5000"},
+		{"       |--Index Scan(OBJECT:([db].[dbo].[tags].[IX_tags]))", "Index Scan", "OBJECT:([db].[dbo].[tags].[IX_tags]), WHERE:([t].[name]='x')", "10", "3"},
+	})
+
+	require.Equal(t, []sqleng.FullScan{
+		{Table: "[db].[dbo].[metrics] AS [m]", Rows: 5000},
+		{Table: "[db].[dbo].[tags].[IX_tags]", Rows: 30},
+	}, plan.FullScans)
+	require.Contains(t, plan.Plan, "Nested Loops(Inner Join)")
+}
@@ -65,7 +65,7 @@ func (m *msSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 			return "", fmt.Errorf("missing time column argument for macro %v", name)
 		}
 
-		return fmt.Sprintf("%s BETWEEN '%s' AND '%s'", args[0], timeRange.From.UTC().Format(time.RFC3339), timeRange.To.UTC().Format(time.RFC3339)), nil
+		return m.TimeFilter(args[0], timeRange), nil
 	case "__timeFrom":
 		return fmt.Sprintf("'%s'", timeRange.From.UTC().Format(time.RFC3339)), nil
 	case "__timeTo":
@@ -127,6 +127,25 @@ func (m *msSQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		}
 		return "", err
 	default:
+		if sql, ok, err := sqleng.EvaluateSharedMacro(m, timeRange, query, name, args); ok {
+			return sql, err
+		}
 		return "", fmt.Errorf("unknown macro %q", name)
 	}
 }
+
+func (m *msSQLMacroEngine) EpochSeconds(column string) string {
+	return fmt.Sprintf("DATEDIFF(second, '1970-01-01', %s)", column)
+}
+
+func (m *msSQLMacroEngine) TimeFilter(column string, timeRange backend.TimeRange) string {
+	return fmt.Sprintf("%s BETWEEN '%s' AND '%s'", column, timeRange.From.UTC().Format(time.RFC3339), timeRange.To.UTC().Format(time.RFC3339))
+}
+
+func (m *msSQLMacroEngine) TimeAlias() string {
+	return "AS [time]"
+}
+
+func (m *msSQLMacroEngine) SelectTop(n int, columns string, rest string) string {
+	return fmt.Sprintf("SELECT TOP %d %s %s", n, columns, rest)
+}
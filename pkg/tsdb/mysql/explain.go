@@ -0,0 +1,57 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+// mysqlQueryPlanner explains queries with the tabular output of EXPLAIN.
+type mysqlQueryPlanner struct{}
+
+func (mysqlQueryPlanner) Explain(ctx context.Context, conn *sql.Conn, query string) (*sqleng.QueryPlan, error) {
+	rows, err := conn.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, values, err := sqleng.ReadRowsAsStrings(rows)
+	if err != nil {
+		return nil, err
+	}
+	return parseMySQLPlan(columns, values), nil
+}
+
+// parseMySQLPlan reports the tables with the join type ALL as full scans, the rows column is the estimated number
+// of rows MySQL examines for the table.
+func parseMySQLPlan(columns []string, values [][]string) *sqleng.QueryPlan {
+	column := func(row []string, name string) string {
+		for i, c := range columns {
+			if strings.EqualFold(c, name) {
+				return row[i]
+			}
+		}
+		return ""
+	}
+
+	var plan strings.Builder
+	plan.WriteString(strings.Join(columns, "\t"))
+	result := &sqleng.QueryPlan{}
+	for _, row := range values {
+		plan.WriteString("\n" + strings.Join(row, "\t"))
+		if column(row, "type") != "ALL" {
+			continue
+		}
+		rows, err := strconv.ParseFloat(column(row, "rows"), 64)
+		if err != nil {
+			continue
+		}
+		result.FullScans = append(result.FullScans, sqleng.FullScan{Table: column(row, "table"), Rows: rows})
+	}
+	result.Plan = plan.String()
+	return result
+}
@@ -0,0 +1,20 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
+)
+
+func TestParseMySQLPlan(t *testing.T) {
+	columns := []string{"id", "select_type", "table", "partitions", "type", "possible_keys", "key", "key_len", "ref", "rows", "filtered", "Extra"}
+	plan := parseMySQLPlan(columns, [][]string{
+		{"1", "SIMPLE", "metrics", "", "ALL", "", "", "", "", "250000", "11.11", "Using where"},
+		{"1", "SIMPLE", "hosts", "", "eq_ref", "PRIMARY", "PRIMARY", "4", "db.metrics.host_id", "1", "100.00", ""},
+	})
+
+	require.Equal(t, []sqleng.FullScan{{Table: "metrics", Rows: 250000}}, plan.FullScans)
+	require.Contains(t, plan.Plan, "metrics\t\tALL")
+}
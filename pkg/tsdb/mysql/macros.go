@@ -72,10 +72,7 @@ func (m *mySQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		if len(args) == 0 {
 			return "", fmt.Errorf("missing time column argument for macro %v", name)
 		}
-		if timeRange.From.UTC().Unix() < 0 {
-			return fmt.Sprintf("%s BETWEEN DATE_ADD(FROM_UNIXTIME(0), INTERVAL %d SECOND) AND FROM_UNIXTIME(%d)", args[0], timeRange.From.UTC().Unix(), timeRange.To.UTC().Unix()), nil
-		}
-		return fmt.Sprintf("%s BETWEEN FROM_UNIXTIME(%d) AND FROM_UNIXTIME(%d)", args[0], timeRange.From.UTC().Unix(), timeRange.To.UTC().Unix()), nil
+		return m.TimeFilter(args[0], timeRange), nil
 	case "__timeFrom":
 		return fmt.Sprintf("FROM_UNIXTIME(%d)", timeRange.From.UTC().Unix()), nil
 	case "__timeTo":
@@ -137,6 +134,28 @@ func (m *mySQLMacroEngine) evaluateMacro(timeRange backend.TimeRange, query *bac
 		}
 		return "", err
 	default:
+		if sql, ok, err := sqleng.EvaluateSharedMacro(m, timeRange, query, name, args); ok {
+			return sql, err
+		}
 		return "", fmt.Errorf("unknown macro %v", name)
 	}
 }
+
+func (m *mySQLMacroEngine) EpochSeconds(column string) string {
+	return fmt.Sprintf("UNIX_TIMESTAMP(%s)", column)
+}
+
+func (m *mySQLMacroEngine) TimeFilter(column string, timeRange backend.TimeRange) string {
+	if timeRange.From.UTC().Unix() < 0 {
+		return fmt.Sprintf("%s BETWEEN DATE_ADD(FROM_UNIXTIME(0), INTERVAL %d SECOND) AND FROM_UNIXTIME(%d)", column, timeRange.From.UTC().Unix(), timeRange.To.UTC().Unix())
+	}
+	return fmt.Sprintf("%s BETWEEN FROM_UNIXTIME(%d) AND FROM_UNIXTIME(%d)", column, timeRange.From.UTC().Unix(), timeRange.To.UTC().Unix())
+}
+
+func (m *mySQLMacroEngine) TimeAlias() string {
+	return `AS "time"`
+}
+
+func (m *mySQLMacroEngine) SelectTop(n int, columns string, rest string) string {
+	return fmt.Sprintf("SELECT %s %s LIMIT %d", columns, rest, n)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -70,14 +71,24 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 
 	q := req.Queries[0]
 
-	myRefID := q.RefID
-
 	tsdbQuery.Start = q.TimeRange.From.UnixNano() / int64(time.Millisecond)
 	tsdbQuery.End = q.TimeRange.To.UnixNano() / int64(time.Millisecond)
+	tsdbQuery.ShowQuery = true
 
+	// All the queries are sent in a single request, the refIDs are in the order of the sub queries.
+	result := backend.NewQueryDataResponse()
+	refIDs := make([]string, 0, len(req.Queries))
 	for _, query := range req.Queries {
-		metric := s.buildMetric(query)
+		metric, err := s.buildMetric(query)
+		if err != nil {
+			result.Responses[query.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, err.Error())
+			continue
+		}
 		tsdbQuery.Queries = append(tsdbQuery.Queries, metric)
+		refIDs = append(refIDs, query.RefID)
+	}
+	if len(refIDs) == 0 {
+		return result, nil
 	}
 
 	// TODO: Don't use global variable
@@ -107,10 +118,13 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 		}
 	}()
 
-	result, err := s.parseResponse(logger, res, myRefID)
+	queryResult, err := s.parseResponse(logger, res, refIDs)
 	if err != nil {
 		return &backend.QueryDataResponse{}, err
 	}
+	for refID, r := range queryResult.Responses {
+		result.Responses[refID] = r
+	}
 
 	return result, nil
 }
@@ -138,7 +152,8 @@ func (s *Service) createRequest(ctx context.Context, logger log.Logger, dsInfo *
 	return req, nil
 }
 
-func (s *Service) parseResponse(logger log.Logger, res *http.Response, myRefID string) (*backend.QueryDataResponse, error) {
+// parseResponse converts the results of the sub queries to frames, refIDs are the refIDs of the sub queries.
+func (s *Service) parseResponse(logger log.Logger, res *http.Response, refIDs []string) (*backend.QueryDataResponse, error) {
 	resp := backend.NewQueryDataResponse()
 
 	body, err := io.ReadAll(res.Body)
@@ -163,43 +178,79 @@ func (s *Service) parseResponse(logger log.Logger, res *http.Response, myRefID s
 		return nil, err
 	}
 
-	frames := data.Frames{}
+	for _, refID := range refIDs {
+		resp.Responses[refID] = backend.DataResponse{Frames: data.Frames{}}
+	}
 	for _, val := range responseData {
-		timeVector := make([]time.Time, 0, len(val.DataPoints))
-		values := make([]float64, 0, len(val.DataPoints))
-		name := val.Metric
-		tags := val.Tags
-
+		type dataPoint struct {
+			timestamp int64
+			value     float64
+		}
+		dataPoints := make([]dataPoint, 0, len(val.DataPoints))
 		for timeString, value := range val.DataPoints {
 			timestamp, err := strconv.ParseInt(timeString, 10, 64)
 			if err != nil {
 				logger.Info("Failed to unmarshal opentsdb timestamp", "timestamp", timeString)
 				return nil, err
 			}
-			timeVector = append(timeVector, time.Unix(timestamp, 0).UTC())
-			values = append(values, value)
+			dataPoints = append(dataPoints, dataPoint{timestamp: timestamp, value: value})
+		}
+		sort.Slice(dataPoints, func(i, j int) bool { return dataPoints[i].timestamp < dataPoints[j].timestamp })
+
+		timeVector := make([]time.Time, 0, len(dataPoints))
+		values := make([]float64, 0, len(dataPoints))
+		for _, dp := range dataPoints {
+			timeVector = append(timeVector, time.Unix(dp.timestamp, 0).UTC())
+			values = append(values, dp.value)
 		}
-		frames = append(frames, data.NewFrame(name,
+
+		// OpenTSDB versions without showQuery support only return the results of the first query.
+		refID := refIDs[0]
+		if val.Query != nil && val.Query.Index >= 0 && val.Query.Index < len(refIDs) {
+			refID = refIDs[val.Query.Index]
+		}
+		result := resp.Responses[refID]
+		result.Frames = append(result.Frames, data.NewFrame(val.Metric,
 			data.NewField("time", nil, timeVector),
-			data.NewField("value", tags, values)))
+			data.NewField("value", val.Tags, values)))
+		resp.Responses[refID] = result
 	}
-	result := resp.Responses[myRefID]
-	result.Frames = frames
-	resp.Responses[myRefID] = result
 	return resp, nil
 }
 
-func (s *Service) buildMetric(query backend.DataQuery) map[string]any {
+// filterTypes are the tag value filters supported by OpenTSDB.
+var filterTypes = map[string]bool{
+	"literal_or":      true,
+	"iliteral_or":     true,
+	"not_literal_or":  true,
+	"not_iliteral_or": true,
+	"wildcard":        true,
+	"iwildcard":       true,
+	"regexp":          true,
+}
+
+// fillPolicies are the policies OpenTSDB fills missing downsampled values with.
+var fillPolicies = map[string]bool{
+	"none": true,
+	"nan":  true,
+	"null": true,
+	"zero": true,
+}
+
+func (s *Service) buildMetric(query backend.DataQuery) (map[string]any, error) {
 	metric := make(map[string]any)
 
 	model, err := simplejson.NewJson(query.JSON)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
 	// Setting metric and aggregator
 	metric["metric"] = model.Get("metric").MustString()
 	metric["aggregator"] = model.Get("aggregator").MustString()
+	if metric["metric"] == "" {
+		return nil, fmt.Errorf("query has no metric")
+	}
 
 	// Setting downsampling options
 	disableDownsampling := model.Get("disableDownsampling").MustBool()
@@ -208,9 +259,24 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]any {
 		if downsampleInterval == "" {
 			downsampleInterval = "1m" // default value for blank
 		}
-		downsample := downsampleInterval + "-" + model.Get("downsampleAggregator").MustString()
-		if model.Get("downsampleFillPolicy").MustString() != "none" {
-			metric["downsample"] = downsample + "-" + model.Get("downsampleFillPolicy").MustString()
+		if downsampleInterval == "all" {
+			// downsamples the whole time range to a single value
+			downsampleInterval = "0all"
+		}
+		downsampleAggregator := model.Get("downsampleAggregator").MustString("avg")
+		if downsampleAggregator == "" {
+			downsampleAggregator = "avg"
+		}
+		downsample := downsampleInterval + "-" + downsampleAggregator
+		fillPolicy := model.Get("downsampleFillPolicy").MustString("none")
+		if fillPolicy == "" {
+			fillPolicy = "none"
+		}
+		if !fillPolicies[fillPolicy] {
+			return nil, fmt.Errorf("unsupported downsample fill policy %q", fillPolicy)
+		}
+		if fillPolicy != "none" {
+			metric["downsample"] = downsample + "-" + fillPolicy
 		} else {
 			metric["downsample"] = downsample
 		}
@@ -248,10 +314,24 @@ func (s *Service) buildMetric(query backend.DataQuery) map[string]any {
 	// Setting filters
 	filters, filtersCheck := model.CheckGet("filters")
 	if filtersCheck && len(filters.MustArray()) > 0 {
+		for i := range filters.MustArray() {
+			filter := filters.GetIndex(i)
+			if filterType := filter.Get("type").MustString(); !filterTypes[filterType] {
+				return nil, fmt.Errorf("unsupported filter type %q", filterType)
+			}
+			if filter.Get("tagk").MustString() == "" {
+				return nil, fmt.Errorf("filter %d has no tag key", i+1)
+			}
+		}
 		metric["filters"] = filters.MustArray()
 	}
 
-	return metric
+	// Only return the series that have exactly the tags of the tags and filters
+	if model.Get("explicitTags").MustBool() {
+		metric["explicitTags"] = true
+	}
+
+	return metric, nil
 }
 
 func (s *Service) getDSInfo(ctx context.Context, pluginCtx backend.PluginContext) (*datasourceInfo, error) {
@@ -33,7 +33,7 @@ func TestOpenTsdbExecutor(t *testing.T) {
 	t.Run("Parse response should handle invalid JSON", func(t *testing.T) {
 		response := `{ invalid }`
 
-		result, err := service.parseResponse(logger, &http.Response{Body: io.NopCloser(strings.NewReader(response))}, "A")
+		result, err := service.parseResponse(logger, &http.Response{Body: io.NopCloser(strings.NewReader(response))}, []string{"A"})
 		require.Nil(t, result)
 		require.Error(t, err)
 	})
@@ -63,7 +63,7 @@ func TestOpenTsdbExecutor(t *testing.T) {
 
 		resp := http.Response{Body: io.NopCloser(strings.NewReader(response))}
 		resp.StatusCode = 200
-		result, err := service.parseResponse(logger, &resp, "A")
+		result, err := service.parseResponse(logger, &resp, []string{"A"})
 		require.NoError(t, err)
 
 		frame := result.Responses["A"]
@@ -100,7 +100,7 @@ func TestOpenTsdbExecutor(t *testing.T) {
 
 		resp := http.Response{Body: io.NopCloser(strings.NewReader(response))}
 		resp.StatusCode = 200
-		result, err := service.parseResponse(logger, &resp, myRefid)
+		result, err := service.parseResponse(logger, &resp, []string{myRefid})
 		require.NoError(t, err)
 
 		if diff := cmp.Diff(testFrame, result.Responses[myRefid].Frames[0], data.FrameTestCompareOptions()...); diff != "" {
@@ -122,7 +122,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			),
 		}
 
-		metric := service.buildMetric(query)
+		metric, err := service.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 3)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -144,7 +145,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			),
 		}
 
-		metric := service.buildMetric(query)
+		metric, err := service.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 2)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -165,7 +167,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			),
 		}
 
-		metric := service.buildMetric(query)
+		metric, err := service.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 3)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -191,7 +194,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			),
 		}
 
-		metric := service.buildMetric(query)
+		metric, err := service.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 3)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -222,7 +226,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			),
 		}
 
-		metric := service.buildMetric(query)
+		metric, err := service.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 5)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -257,7 +262,8 @@ func TestOpenTsdbExecutor(t *testing.T) {
 			),
 		}
 
-		metric := service.buildMetric(query)
+		metric, err := service.buildMetric(query)
+		require.NoError(t, err)
 
 		require.Len(t, metric, 5)
 		require.Equal(t, "cpu.average.percent", metric["metric"])
@@ -276,4 +282,63 @@ func TestOpenTsdbExecutor(t *testing.T) {
 		require.Equal(t, float64(45), metricRateOptions["counterMax"])
 		require.Equal(t, float64(60), metricRateOptions["resetValue"])
 	})
+
+	t.Run("Build metric with filters and fill policy", func(t *testing.T) {
+		query := backend.DataQuery{
+			JSON: []byte(`
+					{
+						"metric": "cpu.average.percent",
+						"aggregator": "sum",
+						"downsampleInterval": "5m",
+						"downsampleAggregator": "",
+						"downsampleFillPolicy": "zero",
+						"explicitTags": true,
+						"filters": [
+							{"type": "wildcard", "tagk": "host", "filter": "web-*", "groupBy": true},
+							{"type": "literal_or", "tagk": "env", "filter": "prod|staging", "groupBy": false}
+						]
+					}`,
+			),
+		}
+
+		metric, err := service.buildMetric(query)
+		require.NoError(t, err)
+
+		require.Len(t, metric, 5)
+		require.Equal(t, "5m-avg-zero", metric["downsample"])
+		require.True(t, metric["explicitTags"].(bool))
+		require.Len(t, metric["filters"], 2)
+	})
+
+	t.Run("Build metric with invalid options", func(t *testing.T) {
+		for name, model := range map[string]string{
+			"missing metric":      `{"aggregator": "avg"}`,
+			"unknown fill policy": `{"metric": "cpu", "downsampleFillPolicy": "previous"}`,
+			"unknown filter type": `{"metric": "cpu", "filters": [{"type": "range", "tagk": "host", "filter": "a"}]}`,
+			"missing filter tag":  `{"metric": "cpu", "filters": [{"type": "regexp", "filter": "web-.*"}]}`,
+		} {
+			_, err := service.buildMetric(backend.DataQuery{JSON: []byte(model)})
+			require.Error(t, err, name)
+		}
+	})
+
+	t.Run("Parse response should map results to their queries", func(t *testing.T) {
+		response := `
+		[
+			{"metric": "b", "dps": {"1405544160": 2.0, "1405544146": 1.0}, "query": {"index": 1}},
+			{"metric": "a", "dps": {"1405544146": 3.0}, "query": {"index": 0}}
+		]`
+
+		resp := http.Response{Body: io.NopCloser(strings.NewReader(response)), StatusCode: 200}
+		result, err := service.parseResponse(logger, &resp, []string{"A", "B", "C"})
+		require.NoError(t, err)
+
+		require.Len(t, result.Responses["A"].Frames, 1)
+		require.Equal(t, "a", result.Responses["A"].Frames[0].Name)
+		require.Len(t, result.Responses["B"].Frames, 1)
+		require.Equal(t, "b", result.Responses["B"].Frames[0].Name)
+		require.Equal(t, time.Date(2014, 7, 16, 20, 55, 46, 0, time.UTC), result.Responses["B"].Frames[0].Fields[0].At(0))
+		require.Equal(t, 1.0, result.Responses["B"].Frames[0].Fields[1].At(0))
+		require.Empty(t, result.Responses["C"].Frames)
+	})
 }
@@ -0,0 +1,173 @@
+package opentsdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+const (
+	suggestResourcePath = "suggest"
+	lookupResourcePath  = "lookup"
+
+	defaultSuggestMax = 25
+)
+
+var suggestTypes = map[string]bool{
+	"metrics": true,
+	"tagk":    true,
+	"tagv":    true,
+}
+
+// CallResource serves the resources used by the query editor and the template variables: suggest returns the metric
+// names, tag keys or tag values starting with a prefix, lookup returns the tag values of the series of a metric.
+func (s *Service) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req.Method != http.MethodGet {
+		return sendJSON(sender, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+
+	dsInfo, err := s.getDSInfo(ctx, req.PluginContext)
+	if err != nil {
+		return err
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid url: %s", err)})
+	}
+
+	var status int
+	var result any
+	switch req.Path {
+	case suggestResourcePath:
+		status, result = s.suggest(ctx, dsInfo, u.Query())
+	case lookupResourcePath:
+		status, result = s.lookup(ctx, dsInfo, u.Query())
+	default:
+		status, result = http.StatusNotFound, map[string]string{"error": "not found"}
+	}
+	return sendJSON(sender, status, result)
+}
+
+// suggest proxies /api/suggest, it returns the names as a JSON array.
+func (s *Service) suggest(ctx context.Context, dsInfo *datasourceInfo, query url.Values) (int, any) {
+	typ := query.Get("type")
+	if !suggestTypes[typ] {
+		return http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("unsupported suggest type %q", typ)}
+	}
+	limit := defaultSuggestMax
+	if m := query.Get("max"); m != "" {
+		n, err := strconv.Atoi(m)
+		if err != nil || n <= 0 {
+			return http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid max %q", m)}
+		}
+		limit = n
+	}
+
+	params := url.Values{}
+	params.Set("type", typ)
+	params.Set("q", query.Get("q"))
+	params.Set("max", strconv.Itoa(limit))
+
+	names := []string{}
+	if err := s.get(ctx, dsInfo, "api/suggest", params, &names); err != nil {
+		return http.StatusBadGateway, map[string]string{"error": err.Error()}
+	}
+	return http.StatusOK, names
+}
+
+// lookup proxies /api/search/lookup, it returns the distinct values of every tag key of the series matching the
+// metric, e.g. {"tags": {"host": ["a", "b"]}}. The metric can contain tags to narrow the series, e.g. cpu{env=prod}.
+func (s *Service) lookup(ctx context.Context, dsInfo *datasourceInfo, query url.Values) (int, any) {
+	metric := query.Get("m")
+	if metric == "" {
+		metric = query.Get("metric")
+	}
+	if metric == "" {
+		return http.StatusBadRequest, map[string]string{"error": "missing metric"}
+	}
+
+	params := url.Values{}
+	params.Set("m", metric)
+	if limit := query.Get("limit"); limit != "" {
+		params.Set("limit", limit)
+	}
+
+	var res OpenTsdbLookupResponse
+	if err := s.get(ctx, dsInfo, "api/search/lookup", params, &res); err != nil {
+		return http.StatusBadGateway, map[string]string{"error": err.Error()}
+	}
+
+	values := map[string]map[string]bool{}
+	for _, r := range res.Results {
+		for k, v := range r.Tags {
+			if values[k] == nil {
+				values[k] = map[string]bool{}
+			}
+			values[k][v] = true
+		}
+	}
+	tags := make(map[string][]string, len(values))
+	for k, set := range values {
+		list := make([]string, 0, len(set))
+		for v := range set {
+			list = append(list, v)
+		}
+		sort.Strings(list)
+		tags[k] = list
+	}
+	return http.StatusOK, map[string]any{"tags": tags}
+}
+
+// get sends a GET request to an endpoint of the OpenTSDB API and decodes its JSON response into v.
+func (s *Service) get(ctx context.Context, dsInfo *datasourceInfo, endpoint string, params url.Values, v any) error {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, endpoint)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	res, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			logger.Warn("failed to close response body", "error", err)
+		}
+	}()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("request failed, status: %s, body: %s", res.Status, string(body))
+	}
+	return json.Unmarshal(body, v)
+}
+
+func sendJSON(sender backend.CallResourceResponseSender, status int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
@@ -0,0 +1,60 @@
+package opentsdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResources(t *testing.T) {
+	var requests []*http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		switch r.URL.Path {
+		case "/api/suggest":
+			_, _ = w.Write([]byte(`["cpu.system", "cpu.user"]`))
+		case "/api/search/lookup":
+			_, _ = w.Write([]byte(`{"results": [
+				{"metric": "cpu", "tags": {"host": "web-2", "env": "prod"}},
+				{"metric": "cpu", "tags": {"host": "web-1", "env": "prod"}}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	service := &Service{}
+	dsInfo := &datasourceInfo{HTTPClient: srv.Client(), URL: srv.URL}
+
+	t.Run("suggest", func(t *testing.T) {
+		status, result := service.suggest(context.Background(), dsInfo, url.Values{"type": {"metrics"}, "q": {"cpu"}})
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, []string{"cpu.system", "cpu.user"}, result)
+
+		query := requests[len(requests)-1].URL.Query()
+		require.Equal(t, "cpu", query.Get("q"))
+		require.Equal(t, "25", query.Get("max"))
+
+		status, _ = service.suggest(context.Background(), dsInfo, url.Values{"type": {"series"}})
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		status, result := service.lookup(context.Background(), dsInfo, url.Values{"m": {"cpu{env=prod}"}})
+		require.Equal(t, http.StatusOK, status)
+
+		body, err := json.Marshal(result)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"tags": {"host": ["web-1", "web-2"], "env": ["prod"]}}`, string(body))
+		require.Equal(t, "cpu{env=prod}", requests[len(requests)-1].URL.Query().Get("m"))
+
+		status, _ = service.lookup(context.Background(), dsInfo, url.Values{})
+		require.Equal(t, http.StatusBadRequest, status)
+	})
+}
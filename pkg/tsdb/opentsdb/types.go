@@ -4,10 +4,23 @@ type OpenTsdbQuery struct {
 	Start   int64            `json:"start"`
 	End     int64            `json:"end"`
 	Queries []map[string]any `json:"queries"`
+	// ShowQuery returns the sub query of every result, its index maps the result to the query it belongs to.
+	ShowQuery bool `json:"showQuery,omitempty"`
 }
 
 type OpenTsdbResponse struct {
 	Metric     string             `json:"metric"`
 	Tags       map[string]string  `json:"tags"`
 	DataPoints map[string]float64 `json:"dps"`
+	Query      *OpenTsdbSubQuery  `json:"query,omitempty"`
+}
+
+type OpenTsdbSubQuery struct {
+	Index int `json:"index"`
+}
+
+type OpenTsdbLookupResponse struct {
+	Results []struct {
+		Tags map[string]string `json:"tags"`
+	} `json:"results"`
 }
@@ -2,13 +2,17 @@ package prometheus
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/grafana/grafana-azure-sdk-go/azsettings"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/promlib"
+	"github.com/grafana/grafana/pkg/promlib/querydata"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/prometheus/azureauth"
 )
 
@@ -16,11 +20,19 @@ type Service struct {
 	lib *promlib.Service
 }
 
-func ProvideService(httpClientProvider *sdkhttpclient.Provider) *Service {
+func ProvideService(httpClientProvider *sdkhttpclient.Provider, cfg *setting.Cfg, cacheStorage remotecache.CacheStorage) *Service {
 	plog := backend.NewLoggerWith("logger", "tsdb.prometheus")
 	plog.Debug("Initializing")
+	var opts []promlib.Option
+	switch {
+	case cfg.PrometheusResultsCacheStore == "remote_cache" && cacheStorage != nil:
+		// Results of range queries are shared between the Grafana instances using the remote cache.
+		opts = append(opts, promlib.WithResultsCacheStore(&resultsCacheStore{cacheStorage}))
+	case cfg.PrometheusResultsCacheMaxBytes > 0:
+		opts = append(opts, promlib.WithResultsCacheStore(querydata.NewMemoryCacheStore(cfg.PrometheusResultsCacheMaxBytes)))
+	}
 	return &Service{
-		lib: promlib.NewService(httpClientProvider, plog, extendClientOpts),
+		lib: promlib.NewService(httpClientProvider, plog, extendClientOpts, opts...),
 	}
 }
 
@@ -66,3 +78,16 @@ func extendClientOpts(ctx context.Context, settings backend.DataSourceInstanceSe
 
 	return nil
 }
+
+// resultsCacheStore adapts the remote cache to the store of the results cache of promlib.
+type resultsCacheStore struct {
+	remotecache.CacheStorage
+}
+
+func (s *resultsCacheStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.CacheStorage.Get(ctx, key)
+	if errors.Is(err, remotecache.ErrCacheItemNotFound) {
+		return nil, querydata.ErrCacheItemNotFound
+	}
+	return value, err
+}
@@ -3,11 +3,15 @@ package prometheus
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-azure-sdk-go/azsettings"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/promlib/querydata"
 )
 
 func TestExtendClientOpts(t *testing.T) {
@@ -52,3 +56,16 @@ func TestExtendClientOpts(t *testing.T) {
 		require.Equal(t, "aps", opts.SigV4.Service)
 	})
 }
+
+func TestResultsCacheStore(t *testing.T) {
+	ctx := context.Background()
+	store := &resultsCacheStore{remotecache.NewFakeCacheStorage()}
+
+	_, err := store.Get(ctx, "key")
+	require.ErrorIs(t, err, querydata.ErrCacheItemNotFound)
+
+	require.NoError(t, store.Set(ctx, "key", []byte("value"), time.Minute))
+	value, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), value)
+}
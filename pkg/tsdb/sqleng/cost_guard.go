@@ -0,0 +1,214 @@
+package sqleng
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+const (
+	// CostGuardWarn adds a notice to the results of queries that exceed the cost guard.
+	CostGuardWarn = "warn"
+	// CostGuardReject fails the queries that exceed the cost guard without executing them.
+	CostGuardReject = "reject"
+
+	// DefaultCostGuardMaxRows is the number of rows a full scan may read if the data source does not configure it.
+	DefaultCostGuardMaxRows = 1000000
+
+	explainResourcePath = "explain"
+)
+
+var ErrQueryCostExceeded = errors.New("query cost exceeded")
+
+// FullScan is a full scan of a table in a query plan.
+type FullScan struct {
+	Table string `json:"table"`
+	// Rows is the number of rows the database estimates the scan reads.
+	Rows float64 `json:"rows"`
+}
+
+// QueryPlan is the plan of a query estimated by the database.
+type QueryPlan struct {
+	// Plan is the plan in the format returned by the database.
+	Plan      string     `json:"plan"`
+	FullScans []FullScan `json:"fullScans"`
+}
+
+// QueryPlanner explains queries with the EXPLAIN statement of the SQL dialect.
+type QueryPlanner interface {
+	Explain(ctx context.Context, conn *sql.Conn, query string) (*QueryPlan, error)
+}
+
+// ReadRowsAsStrings reads the columns and the rows of a result as strings, NULL values are read as empty strings.
+func ReadRowsAsStrings(rows *sql.Rows) ([]string, [][]string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	var values [][]string
+	for rows.Next() {
+		row := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, err
+		}
+		value := make([]string, len(columns))
+		for i, v := range row {
+			value[i] = v.String
+		}
+		values = append(values, value)
+	}
+	return columns, values, rows.Err()
+}
+
+func (e *DataSourceHandler) costGuardEnabled() bool {
+	mode := e.dsInfo.JsonData.CostGuardMode
+	return e.queryPlanner != nil && (mode == CostGuardWarn || mode == CostGuardReject)
+}
+
+func (e *DataSourceHandler) costGuardMaxRows() float64 {
+	if e.dsInfo.JsonData.CostGuardMaxRows > 0 {
+		return float64(e.dsInfo.JsonData.CostGuardMaxRows)
+	}
+	return DefaultCostGuardMaxRows
+}
+
+func (e *DataSourceHandler) explain(ctx context.Context, query string) (*QueryPlan, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			e.log.Warn("Failed to close connection", "err", err)
+		}
+	}()
+	return e.queryPlanner.Explain(ctx, conn, query)
+}
+
+// exceedingScans returns the full scans of the plan that read more rows than the cost guard allows.
+func (e *DataSourceHandler) exceedingScans(plan *QueryPlan) []FullScan {
+	var exceeding []FullScan
+	for _, scan := range plan.FullScans {
+		if scan.Rows > e.costGuardMaxRows() {
+			exceeding = append(exceeding, scan)
+		}
+	}
+	return exceeding
+}
+
+// checkQueryCost explains the query before it is executed. It returns an error if the query exceeds the cost guard in
+// reject mode, and the notices to add to the results in warn mode. Queries that cannot be explained are executed.
+func (e *DataSourceHandler) checkQueryCost(ctx context.Context, query string) ([]data.Notice, error) {
+	if !e.costGuardEnabled() {
+		return nil, nil
+	}
+	logger := e.log.FromContext(ctx)
+
+	plan, err := e.explain(ctx, query)
+	if err != nil {
+		logger.Warn("Failed to explain query, skipping the cost guard", "err", err)
+		return nil, nil
+	}
+	exceeding := e.exceedingScans(plan)
+	if len(exceeding) == 0 {
+		return nil, nil
+	}
+
+	var notices []data.Notice
+	for _, scan := range exceeding {
+		text := fmt.Sprintf("full scan of %s is estimated to read %.0f rows, more than the limit of %.0f rows", scan.Table, scan.Rows, e.costGuardMaxRows())
+		if e.dsInfo.JsonData.CostGuardMode == CostGuardReject {
+			return nil, fmt.Errorf("%w: %s, add a time filter or a condition on an indexed column", ErrQueryCostExceeded, text)
+		}
+		notices = append(notices, data.Notice{Severity: data.NoticeSeverityWarning, Text: "Query " + text})
+	}
+	return notices, nil
+}
+
+type explainRequest struct {
+	RefID     string `json:"refId"`
+	RawSql    string `json:"rawSql"`
+	TimeRange struct {
+		From int64 `json:"from"`
+		To   int64 `json:"to"`
+	} `json:"timeRange"`
+	IntervalMs int64 `json:"intervalMs"`
+}
+
+type explainResponse struct {
+	SQL  string     `json:"sql"`
+	Plan *QueryPlan `json:"plan"`
+	// Exceeding are the full scans that exceed the cost guard, they are returned even if the cost guard is disabled.
+	Exceeding []FullScan `json:"exceeding"`
+	MaxRows   float64    `json:"maxRows"`
+}
+
+// CallResource serves the explain resource, it returns the interpolated SQL of a query with its plan.
+func (e *DataSourceHandler) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req.Path != explainResourcePath {
+		return sendJSON(sender, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+	if req.Method != http.MethodPost {
+		return sendJSON(sender, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+	if e.queryPlanner == nil {
+		return sendJSON(sender, http.StatusNotImplemented, map[string]string{"error": "the data source does not support explaining queries"})
+	}
+
+	var q explainRequest
+	if err := json.Unmarshal(req.Body, &q); err != nil {
+		return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid request: %s", err)})
+	}
+	if q.RawSql == "" {
+		return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": "rawSql should not be empty"})
+	}
+	interpolatedQuery, err := e.interpolate(backend.DataQuery{
+		RefID:    q.RefID,
+		JSON:     req.Body,
+		Interval: time.Duration(q.IntervalMs) * time.Millisecond,
+		TimeRange: backend.TimeRange{
+			From: time.UnixMilli(q.TimeRange.From),
+			To:   time.UnixMilli(q.TimeRange.To),
+		},
+	}, q.RawSql)
+	if err != nil {
+		return sendJSON(sender, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	plan, err := e.explain(ctx, interpolatedQuery)
+	if err != nil {
+		return sendJSON(sender, http.StatusBadRequest, map[string]any{
+			"sql":   interpolatedQuery,
+			"error": e.TransformQueryError(e.log.FromContext(ctx), err).Error(),
+		})
+	}
+	return sendJSON(sender, http.StatusOK, explainResponse{
+		SQL:       interpolatedQuery,
+		Plan:      plan,
+		Exceeding: e.exceedingScans(plan),
+		MaxRows:   e.costGuardMaxRows(),
+	})
+}
+
+func sendJSON(sender backend.CallResourceResponseSender, status int, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return sender.Send(&backend.CallResourceResponse{
+		Status:  status,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Body:    body,
+	})
+}
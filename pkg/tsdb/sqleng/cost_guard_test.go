@@ -0,0 +1,153 @@
+package sqleng
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQueryPlanner reports a full scan of the table t with the configured rows for every query.
+type fakeQueryPlanner struct {
+	rows    float64
+	queries []string
+}
+
+func (p *fakeQueryPlanner) Explain(_ context.Context, _ *sql.Conn, query string) (*QueryPlan, error) {
+	p.queries = append(p.queries, query)
+	return &QueryPlan{Plan: "SCAN t", FullScans: []FullScan{{Table: "t", Rows: p.rows}}}, nil
+}
+
+func newCostGuardTestHandler(t *testing.T, planner QueryPlanner, mode string) *DataSourceHandler {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	handler, err := NewQueryDataHandler("", db, DataPluginConfiguration{
+		DSInfo:       DataSourceInfo{JsonData: JsonData{CostGuardMode: mode, CostGuardMaxRows: 100}},
+		RowLimit:     1000,
+		QueryPlanner: planner,
+	}, &testQueryResultTransformer{}, passthroughMacroEngine{}, log.New())
+	require.NoError(t, err)
+	return handler
+}
+
+func TestCostGuard(t *testing.T) {
+	query := func() *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{Queries: []backend.DataQuery{{
+			RefID:     "A",
+			JSON:      json.RawMessage(`{"rawSql": "SELECT 1 AS x", "format": "table"}`),
+			TimeRange: backend.TimeRange{From: time.Now().Add(-time.Hour), To: time.Now()},
+		}}}
+	}
+
+	t.Run("rejects queries that exceed the max rows", func(t *testing.T) {
+		planner := &fakeQueryPlanner{rows: 1000}
+		res, err := newCostGuardTestHandler(t, planner, CostGuardReject).QueryData(context.Background(), query())
+		require.NoError(t, err)
+		require.ErrorIs(t, res.Responses["A"].Error, ErrQueryCostExceeded)
+		require.Equal(t, []string{"SELECT 1 AS x"}, planner.queries)
+	})
+
+	t.Run("warns about queries that exceed the max rows", func(t *testing.T) {
+		res, err := newCostGuardTestHandler(t, &fakeQueryPlanner{rows: 1000}, CostGuardWarn).QueryData(context.Background(), query())
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+		frame := res.Responses["A"].Frames[0]
+		require.Equal(t, 1, frame.Rows())
+		require.Len(t, frame.Meta.Notices, 1)
+		require.Contains(t, frame.Meta.Notices[0].Text, "full scan of t")
+	})
+
+	t.Run("executes queries below the max rows", func(t *testing.T) {
+		res, err := newCostGuardTestHandler(t, &fakeQueryPlanner{rows: 10}, CostGuardReject).QueryData(context.Background(), query())
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+		require.Empty(t, res.Responses["A"].Frames[0].Meta.Notices)
+	})
+
+	t.Run("does not explain queries when disabled", func(t *testing.T) {
+		planner := &fakeQueryPlanner{rows: 1000}
+		res, err := newCostGuardTestHandler(t, planner, "").QueryData(context.Background(), query())
+		require.NoError(t, err)
+		require.NoError(t, res.Responses["A"].Error)
+		require.Empty(t, planner.queries)
+	})
+}
+
+func TestCostGuard_RunStream(t *testing.T) {
+	run := func(handler *DataSourceHandler) (*recordingPacketSender, error) {
+		packets := &recordingPacketSender{}
+		err := handler.RunStream(context.Background(), &backend.RunStreamRequest{
+			Path: "query/A",
+			Data: json.RawMessage(`{"refId": "A", "rawSql": "SELECT 1 AS x", "format": "table"}`),
+		}, backend.NewStreamSender(packets))
+		return packets, err
+	}
+
+	t.Run("rejects queries that exceed the max rows", func(t *testing.T) {
+		packets, err := run(newCostGuardTestHandler(t, &fakeQueryPlanner{rows: 1000}, CostGuardReject))
+		require.ErrorIs(t, err, ErrQueryCostExceeded)
+		require.Empty(t, packets.frames)
+	})
+
+	t.Run("sends the notices with the schema", func(t *testing.T) {
+		packets, err := run(newCostGuardTestHandler(t, &fakeQueryPlanner{rows: 1000}, CostGuardWarn))
+		require.NoError(t, err)
+		require.Len(t, packets.frames, 1)
+		require.Equal(t, 1, packets.frames[0].Rows())
+		require.Len(t, packets.frames[0].Meta.Notices, 1)
+		require.Contains(t, packets.frames[0].Meta.Notices[0].Text, "full scan of t")
+	})
+}
+
+type resourceRecorder struct {
+	res *backend.CallResourceResponse
+}
+
+func (r *resourceRecorder) Send(res *backend.CallResourceResponse) error {
+	r.res = res
+	return nil
+}
+
+func TestExplainResource(t *testing.T) {
+	call := func(handler *DataSourceHandler, body string) *backend.CallResourceResponse {
+		recorder := &resourceRecorder{}
+		err := handler.CallResource(context.Background(), &backend.CallResourceRequest{
+			Path:   "explain",
+			Method: http.MethodPost,
+			Body:   []byte(body),
+		}, recorder)
+		require.NoError(t, err)
+		return recorder.res
+	}
+
+	t.Run("returns the interpolated query with its plan", func(t *testing.T) {
+		res := call(newCostGuardTestHandler(t, &fakeQueryPlanner{rows: 1000}, ""),
+			`{"rawSql": "SELECT $__interval_ms", "intervalMs": 60000, "timeRange": {"from": 0, "to": 3600000}}`)
+		require.Equal(t, http.StatusOK, res.Status)
+
+		var body explainResponse
+		require.NoError(t, json.Unmarshal(res.Body, &body))
+		require.Equal(t, "SELECT 60000", body.SQL)
+		require.Equal(t, "SCAN t", body.Plan.Plan)
+		require.Equal(t, []FullScan{{Table: "t", Rows: 1000}}, body.Exceeding)
+	})
+
+	t.Run("returns bad request for invalid queries", func(t *testing.T) {
+		res := call(newCostGuardTestHandler(t, &fakeQueryPlanner{}, ""), `{"rawSql": ""}`)
+		require.Equal(t, http.StatusBadRequest, res.Status)
+	})
+
+	t.Run("is not implemented without planner", func(t *testing.T) {
+		res := call(newCostGuardTestHandler(t, nil, ""), `{"rawSql": "SELECT 1"}`)
+		require.Equal(t, http.StatusNotImplemented, res.Status)
+	})
+}
@@ -0,0 +1,190 @@
+package sqleng
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+// conditionalAllMacro is expanded before the macros of the dialects, its condition can contain parentheses.
+const conditionalAllMacro = "$__conditionalAll("
+
+// allValue is the custom all value of the variables used with $__conditionalAll.
+const allValue = "$__all"
+
+// SQLDialect generates the dialect specific parts of the macros shared by the SQL data sources.
+type SQLDialect interface {
+	// EpochSeconds returns an expression converting a time column to seconds since the epoch.
+	EpochSeconds(column string) string
+	// TimeFilter returns the condition of the time range for a time column.
+	TimeFilter(column string, timeRange backend.TimeRange) string
+	// TimeAlias returns the alias of a time column, e.g. AS "time".
+	TimeAlias() string
+	// SelectTop returns a query selecting the first n rows, rest is the part of the query after the columns.
+	SelectTop(n int, columns string, rest string) string
+}
+
+// EvaluateSharedMacro evaluates the macros shared by the SQL data sources, the second return value is false if name
+// is not a shared macro.
+func EvaluateSharedMacro(dialect SQLDialect, timeRange backend.TimeRange, query *backend.DataQuery, name string, args []string) (string, bool, error) {
+	var sql string
+	var err error
+	switch name {
+	case "__timeGroupTz":
+		sql, err = timeGroupTz(dialect, timeRange, query, name, args)
+	case "__timeGroupTzAlias":
+		if sql, err = timeGroupTz(dialect, timeRange, query, name, args); err == nil {
+			sql += " " + dialect.TimeAlias()
+		}
+	case "__rate":
+		sql, err = rate(dialect, name, args)
+	case "__topN":
+		sql, err = topN(dialect, timeRange, query, name, args)
+	default:
+		return "", false, nil
+	}
+	return sql, true, err
+}
+
+// timeGroupTz groups the time column in buckets aligned to the timezone, e.g. daily buckets start at midnight in the
+// timezone instead of midnight UTC. The offset of the timezone at the start of the time range is used for the whole
+// range, so the buckets after a daylight saving time change are shifted by the difference.
+func timeGroupTz(dialect SQLDialect, timeRange backend.TimeRange, query *backend.DataQuery, name string, args []string) (string, error) {
+	if len(args) < 3 {
+		return "", fmt.Errorf("macro %v needs time column, interval, timezone and optional fill value", name)
+	}
+	interval, err := gtime.ParseInterval(strings.Trim(args[1], `'"`))
+	if err != nil {
+		return "", fmt.Errorf("error parsing interval %v", args[1])
+	}
+	loc, err := time.LoadLocation(strings.Trim(args[2], `'"`))
+	if err != nil {
+		return "", fmt.Errorf("error parsing timezone %v", args[2])
+	}
+	if len(args) == 4 {
+		if err := SetupFillmode(query, interval, args[3]); err != nil {
+			return "", err
+		}
+	}
+
+	_, offset := timeRange.From.In(loc).Zone()
+	seconds := interval.Seconds()
+	if offset == 0 {
+		return fmt.Sprintf("FLOOR(%s/%.0f)*%.0f", dialect.EpochSeconds(args[0]), seconds, seconds), nil
+	}
+	return fmt.Sprintf("(FLOOR((%s+%d)/%.0f)*%.0f-%d)", dialect.EpochSeconds(args[0]), offset, seconds, seconds, offset), nil
+}
+
+// rate returns the per-second rate of a counter column between consecutive rows ordered by the time column, it is
+// NULL for the first row and after a counter reset.
+func rate(dialect SQLDialect, name string, args []string) (string, error) {
+	if len(args) == 0 || args[0] == "" {
+		return "", fmt.Errorf("missing value column argument for macro %v", name)
+	}
+	if len(args) > 3 {
+		return "", fmt.Errorf("macro %v needs value column, optional time column and optional partition column", name)
+	}
+	value := args[0]
+	timeColumn := "time"
+	if len(args) > 1 {
+		timeColumn = args[1]
+	}
+	window := "ORDER BY " + timeColumn
+	if len(args) > 2 {
+		window = "PARTITION BY " + args[2] + " " + window
+	}
+
+	epoch := dialect.EpochSeconds(timeColumn)
+	return fmt.Sprintf("CASE WHEN %[1]s >= LAG(%[1]s) OVER (%[2]s) THEN 1.0*(%[1]s-LAG(%[1]s) OVER (%[2]s))/NULLIF(%[3]s-LAG(%[3]s) OVER (%[2]s), 0) END",
+		value, window, epoch), nil
+}
+
+// topN returns a condition selecting the n metrics with the highest values. The metrics are ranked by the highest
+// average they have in an interval of the query, so they are the top series of the graph at its resolution.
+func topN(dialect SQLDialect, timeRange backend.TimeRange, query *backend.DataQuery, name string, args []string) (string, error) {
+	if len(args) != 5 {
+		return "", fmt.Errorf("macro %v needs n, table, time column, metric column and value column", name)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return "", fmt.Errorf("error parsing n %v", args[0])
+	}
+	table, timeColumn, metric, value := args[1], args[2], args[3], args[4]
+
+	seconds := query.Interval.Seconds()
+	if seconds < 1 {
+		seconds = 1
+	}
+	bucket := fmt.Sprintf("FLOOR(%s/%.0f)", dialect.EpochSeconds(timeColumn), seconds)
+	buckets := fmt.Sprintf("SELECT %[1]s AS metric, AVG(%[2]s) AS value FROM %[3]s WHERE %[4]s GROUP BY %[1]s, %[5]s",
+		metric, value, table, dialect.TimeFilter(timeColumn, timeRange), bucket)
+	// The ranking is wrapped in a derived table, as MySQL does not support LIMIT in IN subqueries.
+	return fmt.Sprintf("%s IN (SELECT metric FROM (%s) topn)", metric, dialect.SelectTop(n, "metric",
+		fmt.Sprintf("FROM (%s) buckets GROUP BY metric ORDER BY MAX(value) DESC", buckets))), nil
+}
+
+// expandConditionalAll replaces $__conditionalAll(condition, value) with the condition, or with 1=1 if the value is
+// the all value, so the condition is skipped when all the values of a variable are selected. The variable has to use
+// $__all as custom all value.
+func expandConditionalAll(sql string) (string, error) {
+	var result strings.Builder
+	for {
+		start := strings.Index(sql, conditionalAllMacro)
+		if start < 0 {
+			result.WriteString(sql)
+			return result.String(), nil
+		}
+		result.WriteString(sql[:start])
+		sql = sql[start+len(conditionalAllMacro):]
+
+		args, end, err := splitMacroArgs(sql)
+		if err != nil {
+			return "", err
+		}
+		if len(args) < 2 {
+			return "", fmt.Errorf("macro __conditionalAll needs a condition and a value")
+		}
+		// the value of a multi-value variable is split in several arguments
+		if len(args) == 2 && strings.Trim(args[1], `'"`) == allValue {
+			result.WriteString("1=1")
+		} else {
+			result.WriteString(args[0])
+		}
+		sql = sql[end:]
+	}
+}
+
+// splitMacroArgs splits the arguments of a macro until the closing parenthesis. The commas in parentheses and quotes
+// do not separate arguments. It returns the position after the closing parenthesis.
+func splitMacroArgs(sql string) ([]string, int, error) {
+	var args []string
+	depth := 0
+	var quote rune
+	argStart := 0
+	for i, c := range sql {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == ')':
+			args = append(args, strings.TrimSpace(sql[argStart:i]))
+			return args, i + 1, nil
+		case c == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(sql[argStart:i]))
+			argStart = i + 1
+		}
+	}
+	return nil, 0, errors.New("missing closing parenthesis of macro")
+}
@@ -0,0 +1,34 @@
+package sqleng
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandConditionalAll(t *testing.T) {
+	t.Run("keeps the condition of selected values", func(t *testing.T) {
+		sql, err := expandConditionalAll("SELECT * FROM t WHERE $__conditionalAll(host IN ('a','b'), 'a','b') AND $__conditionalAll(dc = 'x', 'x')")
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM t WHERE host IN ('a','b') AND dc = 'x'", sql)
+	})
+
+	t.Run("replaces the condition of the all value", func(t *testing.T) {
+		sql, err := expandConditionalAll("SELECT * FROM t WHERE $__conditionalAll(host IN ($__all), $__all) AND $__conditionalAll(lower(dc) = '$__all', '$__all')")
+		require.NoError(t, err)
+		require.Equal(t, "SELECT * FROM t WHERE 1=1 AND 1=1", sql)
+	})
+
+	t.Run("ignores parentheses and commas in quotes", func(t *testing.T) {
+		sql, err := expandConditionalAll("WHERE $__conditionalAll(name IN ('a)', 'b,c'), 'a)','b,c')")
+		require.NoError(t, err)
+		require.Equal(t, "WHERE name IN ('a)', 'b,c')", sql)
+	})
+
+	t.Run("returns errors for invalid macros", func(t *testing.T) {
+		_, err := expandConditionalAll("WHERE $__conditionalAll(host IN ('a')")
+		require.Error(t, err)
+		_, err = expandConditionalAll("WHERE $__conditionalAll(host = 'a')")
+		require.Error(t, err)
+	})
+}
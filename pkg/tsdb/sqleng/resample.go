@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
@@ -62,6 +63,16 @@ func getRowFillValues(f *data.Frame, tsSchema data.TimeSeriesSchema, currentTime
 	return vals
 }
 
+// ResampleWideFrame resamples a time series frame at the interval and fills the missing values, it is used by the
+// data sources that support the fill mode of the $__timeGroup macro without using the SQL engine.
+func ResampleWideFrame(f *data.Frame, fillMissing *data.FillMissing, timeRange backend.TimeRange, interval time.Duration) (*data.Frame, error) {
+	return resample(f, dataQueryModel{
+		FillMissing: fillMissing,
+		TimeRange:   timeRange,
+		Interval:    interval,
+	})
+}
+
 // resample resample provided time-series data.Frame.
 // This is needed in the case of the selected query interval doesn't
 // match the intervals of the time-series field in the data.Frame and
@@ -59,6 +59,9 @@ type JsonData struct {
 	SecureDSProxyUsername   string `json:"secureSocksProxyUsername"`
 	AllowCleartextPasswords bool   `json:"allowCleartextPasswords"`
 	AuthenticationType      string `json:"authenticationType"`
+	StreamingMaxBytes       int64  `json:"streamingMaxBytes"`
+	CostGuardMode           string `json:"costGuardMode"`
+	CostGuardMaxRows        int64  `json:"costGuardMaxRows"`
 }
 
 type DataSourceInfo struct {
@@ -77,10 +80,13 @@ type DataPluginConfiguration struct {
 	TimeColumnNames   []string
 	MetricColumnTypes []string
 	RowLimit          int64
+	// QueryPlanner explains the queries for the cost guard and the explain resource, they are disabled if it is nil.
+	QueryPlanner QueryPlanner
 }
 
 type DataSourceHandler struct {
 	macroEngine            SQLMacroEngine
+	queryPlanner           QueryPlanner
 	queryResultTransformer SqlQueryResultTransformer
 	db                     *sql.DB
 	timeColumnNames        []string
@@ -119,6 +125,7 @@ func NewQueryDataHandler(userFacingDefaultError string, db *sql.DB, config DataP
 	queryDataHandler := DataSourceHandler{
 		queryResultTransformer: queryResultTransformer,
 		macroEngine:            macroEngine,
+		queryPlanner:           config.QueryPlanner,
 		timeColumnNames:        []string{"time"},
 		log:                    log,
 		dsInfo:                 config.DSInfo,
@@ -232,8 +239,6 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 		panic("Query model property rawSql should not be empty at this point")
 	}
 
-	timeRange := query.TimeRange
-
 	errAppendDebug := func(frameErr string, err error, query string) {
 		var emptyFrame data.Frame
 		emptyFrame.SetMeta(&data.FrameMeta{
@@ -244,16 +249,18 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 		ch <- queryResult
 	}
 
-	// global substitutions
-	interpolatedQuery := Interpolate(query, timeRange, e.dsInfo.JsonData.TimeInterval, queryJson.RawSql)
-
-	// data source specific substitutions
-	interpolatedQuery, err := e.macroEngine.Interpolate(&query, timeRange, interpolatedQuery)
+	interpolatedQuery, err := e.interpolate(query, queryJson.RawSql)
 	if err != nil {
 		errAppendDebug("interpolation failed", e.TransformQueryError(logger, err), interpolatedQuery)
 		return
 	}
 
+	costNotices, err := e.checkQueryCost(queryContext, interpolatedQuery)
+	if err != nil {
+		errAppendDebug("query rejected", err, interpolatedQuery)
+		return
+	}
+
 	rows, err := e.db.QueryContext(queryContext, interpolatedQuery)
 	if err != nil {
 		errAppendDebug("db query error", e.TransformQueryError(logger, err), interpolatedQuery)
@@ -284,6 +291,7 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 	}
 
 	frame.Meta.ExecutedQueryString = interpolatedQuery
+	frame.AppendNotices(costNotices...)
 
 	// If no rows were returned, clear any previously set `Fields` with a single empty `data.Field` slice.
 	// Then assign `queryResult.dataResponse.Frames` the current single frame with that single empty Field.
@@ -366,6 +374,16 @@ func (e *DataSourceHandler) executeQuery(query backend.DataQuery, wg *sync.WaitG
 	ch <- queryResult
 }
 
+// interpolate applies the global and the data source specific substitutions to the query.
+func (e *DataSourceHandler) interpolate(query backend.DataQuery, rawSQL string) (string, error) {
+	interpolatedQuery := Interpolate(query, query.TimeRange, e.dsInfo.JsonData.TimeInterval, rawSQL)
+	interpolatedQuery, err := expandConditionalAll(interpolatedQuery)
+	if err != nil {
+		return "", err
+	}
+	return e.macroEngine.Interpolate(&query, query.TimeRange, interpolatedQuery)
+}
+
 // Interpolate provides global macros/substitutions for all sql datasources.
 var Interpolate = func(query backend.DataQuery, timeRange backend.TimeRange, timeInterval string, sql string) string {
 	interval := query.Interval
@@ -0,0 +1,269 @@
+package sqleng
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+)
+
+const (
+	// streamPathPrefix is the prefix of the channel paths of streamed queries, the rest of the path is returned by
+	// streamKey.
+	streamPathPrefix = "query/"
+
+	defaultStreamBatchSize = 1000
+	maxStreamBatchSize     = 50000
+	// DefaultStreamMaxBytes is the number of bytes a streamed query may send if the data source does not configure it.
+	DefaultStreamMaxBytes = 64 * 1024 * 1024
+)
+
+// StreamQuery is the data of a streamed query channel. The query is the same as the one sent to QueryData,
+// but it is executed once per channel and its rows are sent in batches.
+type StreamQuery struct {
+	RefID     string `json:"refId"`
+	RawSql    string `json:"rawSql"`
+	Format    string `json:"format"`
+	TimeRange struct {
+		From int64 `json:"from"`
+		To   int64 `json:"to"`
+	} `json:"timeRange"`
+	IntervalMs int64 `json:"intervalMs"`
+	// BatchSize is the number of rows sent in a frame.
+	BatchSize int64 `json:"batchSize"`
+}
+
+func parseStreamQuery(raw json.RawMessage) (*StreamQuery, error) {
+	q := &StreamQuery{Format: string(dataQueryFormatTable)}
+	if err := json.Unmarshal(raw, q); err != nil {
+		return nil, fmt.Errorf("error unmarshal query json: %w", err)
+	}
+	if q.RawSql == "" {
+		return nil, errors.New("query model property rawSql should not be empty")
+	}
+	// Time series are converted from the complete result, they cannot be sent in batches.
+	if q.Format != string(dataQueryFormatTable) {
+		return nil, fmt.Errorf("only table queries can be streamed, got format %q", q.Format)
+	}
+	if q.BatchSize <= 0 {
+		q.BatchSize = defaultStreamBatchSize
+	}
+	if q.BatchSize > maxStreamBatchSize {
+		q.BatchSize = maxStreamBatchSize
+	}
+	return q, nil
+}
+
+func (q *StreamQuery) dataQuery(raw json.RawMessage) backend.DataQuery {
+	return backend.DataQuery{
+		RefID:    q.RefID,
+		JSON:     raw,
+		Interval: time.Duration(q.IntervalMs) * time.Millisecond,
+		TimeRange: backend.TimeRange{
+			From: time.UnixMilli(q.TimeRange.From),
+			To:   time.UnixMilli(q.TimeRange.To),
+		},
+	}
+}
+
+// streamKey identifies the channel of a streamed query. The query runs with the data of the first subscriber of its
+// channel, so the key is a hash of the organization, the user and every field the interpolated query is built from.
+// Users therefore never share a channel, and a channel only runs the query it was created for. The frontend builds
+// the same key, the fields are separated by new lines and the SQL is last, as it may contain new lines.
+func streamKey(pCtx backend.PluginContext, q *StreamQuery) string {
+	login := ""
+	if pCtx.User != nil {
+		login = pCtx.User.Login
+	}
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%d\n%s\n%s\n%s\n%d\n%d\n%d\n%s",
+		pCtx.OrgID, login, q.RefID, q.Format, q.TimeRange.From, q.TimeRange.To, q.IntervalMs, q.RawSql)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SubscribeStream allows subscribing to the channels of streamed queries, the query is sent as the data of the channel.
+// The path of the channel has to match the key of the query and the user.
+func (e *DataSourceHandler) SubscribeStream(_ context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	key, ok := strings.CutPrefix(req.Path, streamPathPrefix)
+	if !ok {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, nil
+	}
+	q, err := parseStreamQuery(req.Data)
+	if err != nil {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusNotFound}, err
+	}
+	if key != streamKey(req.PluginContext, q) {
+		return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusPermissionDenied}, nil
+	}
+	return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusOK}, nil
+}
+
+// PublishStream rejects publications, streamed queries are only sent from the data source.
+func (e *DataSourceHandler) PublishStream(_ context.Context, _ *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	return &backend.PublishStreamResponse{Status: backend.PublishStreamStatusPermissionDenied}, nil
+}
+
+// RunStream executes the query of the channel and sends its rows in frames of at most BatchSize rows. The first frame
+// contains the schema, the following frames only the data. The next batch is read from the database only after the
+// previous one was sent, so a slow subscriber slows down the query instead of filling the memory. The query is
+// canceled when the last subscriber leaves the channel, and stops after the row limit or the max bytes configured for
+// the data source are reached. Queries that exceed the cost guard are rejected or streamed with a notice, like in
+// QueryData.
+func (e *DataSourceHandler) RunStream(ctx context.Context, req *backend.RunStreamRequest, sender *backend.StreamSender) error {
+	logger := e.log.FromContext(ctx)
+	q, err := parseStreamQuery(req.Data)
+	if err != nil {
+		return err
+	}
+
+	interpolatedQuery, err := e.interpolate(q.dataQuery(req.Data), q.RawSql)
+	if err != nil {
+		return e.TransformQueryError(logger, err)
+	}
+
+	// The cost guard applies to streamed queries too, its notices are sent with the schema.
+	costNotices, err := e.checkQueryCost(ctx, interpolatedQuery)
+	if err != nil {
+		return err
+	}
+
+	rows, err := e.db.QueryContext(ctx, interpolatedQuery)
+	if err != nil {
+		return e.TransformQueryError(logger, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Warn("Failed to close rows", "err", err)
+		}
+	}()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	converters := sqlutil.ToConverters(e.queryResultTransformer.GetConverterList()...)
+	scanRow, err := sqlutil.MakeScanRow(columnTypes, columnNames, converters...)
+	if err != nil {
+		return err
+	}
+	qm := &dataQueryModel{timeIndex: -1, timeEndIndex: -1}
+	for i, col := range columnNames {
+		for _, tc := range e.timeColumnNames {
+			if col == tc {
+				qm.timeIndex = i
+			}
+		}
+		if col == "timeend" {
+			qm.timeEndIndex = i
+		}
+	}
+
+	maxBytes := e.dsInfo.JsonData.StreamingMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultStreamMaxBytes
+	}
+
+	var sentRows, sentBytes int64
+	schemaSent := false
+	// sendJSON sends a frame already marshalled to JSON that has the given number of rows.
+	sendJSON := func(b []byte, rows int) error {
+		if err := sender.SendBytes(b); err != nil {
+			return err
+		}
+		schemaSent = true
+		sentBytes += int64(len(b))
+		sentRows += int64(rows)
+		return nil
+	}
+	sendFrame := func(frame *data.Frame, include data.FrameInclude) error {
+		b, err := data.FrameToJSON(frame, include)
+		if err != nil {
+			return err
+		}
+		return sendJSON(b, frame.Rows())
+	}
+	// send sends a batch of rows, it returns false if the batch was not sent because of a limit.
+	send := func(frame *data.Frame) (bool, error) {
+		if err := convertSQLTimeColumnsToEpochMS(frame, qm); err != nil {
+			return false, err
+		}
+		frame.RefID = q.RefID
+		include := data.IncludeDataOnly
+		if !schemaSent {
+			frame.SetMeta(&data.FrameMeta{ExecutedQueryString: interpolatedQuery, PreferredVisualization: data.VisTypeTable})
+			frame.AppendNotices(costNotices...)
+			include = data.IncludeAll
+		}
+		b, err := data.FrameToJSON(frame, include)
+		if err != nil {
+			return false, err
+		}
+		if sentBytes+int64(len(b)) > maxBytes {
+			return false, sendFrame(e.limitFrame(frame, interpolatedQuery,
+				fmt.Sprintf("Results have been limited to %v rows because the streaming limit of %d bytes was reached", sentRows, maxBytes)), data.IncludeAll)
+		}
+		return true, sendJSON(b, frame.Rows())
+	}
+
+	frame := sqlutil.NewFrame(columnNames, scanRow.Converters...)
+	for {
+		for rows.Next() {
+			if e.rowLimit >= 0 && sentRows+int64(frame.Rows()) >= e.rowLimit {
+				if ok, err := send(frame); !ok || err != nil {
+					return err
+				}
+				return sendFrame(e.limitFrame(frame, interpolatedQuery,
+					fmt.Sprintf("Results have been limited to %v because the SQL row limit was reached", e.rowLimit)), data.IncludeAll)
+			}
+
+			r := scanRow.NewScannableRow()
+			if err := rows.Scan(r...); err != nil {
+				return err
+			}
+			if err := sqlutil.Append(frame, r, scanRow.Converters...); err != nil {
+				return err
+			}
+
+			if int64(frame.Rows()) >= q.BatchSize {
+				if ok, err := send(frame); !ok || err != nil {
+					return err
+				}
+				frame = sqlutil.NewFrame(columnNames, scanRow.Converters...)
+			}
+		}
+		if !rows.NextResultSet() {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return e.TransformQueryError(logger, err)
+	}
+
+	// Send the remaining rows, or the schema if the query returned no rows.
+	if frame.Rows() > 0 || !schemaSent {
+		_, err := send(frame)
+		return err
+	}
+	return nil
+}
+
+// limitFrame returns a frame without rows that has the schema of the streamed frames and a notice about the limit that
+// stopped the stream.
+func (e *DataSourceHandler) limitFrame(frame *data.Frame, interpolatedQuery string, notice string) *data.Frame {
+	limit := frame.EmptyCopy()
+	limit.SetMeta(&data.FrameMeta{ExecutedQueryString: interpolatedQuery, PreferredVisualization: data.VisTypeTable})
+	limit.AppendNotices(data.Notice{Severity: data.NoticeSeverityWarning, Text: notice})
+	return limit
+}
@@ -0,0 +1,159 @@
+package sqleng
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/log"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+type passthroughMacroEngine struct{}
+
+func (passthroughMacroEngine) Interpolate(_ *backend.DataQuery, _ backend.TimeRange, sql string) (string, error) {
+	return sql, nil
+}
+
+// recordingPacketSender decodes the sent frames, the frames that only contain data are decoded with the last sent schema.
+type recordingPacketSender struct {
+	schema json.RawMessage
+	frames []*data.Frame
+}
+
+func (s *recordingPacketSender) Send(packet *backend.StreamPacket) error {
+	var parts map[string]json.RawMessage
+	if err := json.Unmarshal(packet.Data, &parts); err != nil {
+		return err
+	}
+	if schema, ok := parts["schema"]; ok {
+		s.schema = schema
+	}
+	// the schema has to be decoded before the data
+	b := `{"schema":` + string(s.schema) + `,"data":` + string(parts["data"]) + `}`
+	frame := &data.Frame{}
+	if err := json.Unmarshal([]byte(b), frame); err != nil {
+		return err
+	}
+	s.frames = append(s.frames, frame)
+	return nil
+}
+
+func (s *recordingPacketSender) rows() int {
+	rows := 0
+	for _, f := range s.frames {
+		rows += f.Rows()
+	}
+	return rows
+}
+
+func newStreamTestHandler(t *testing.T, rowLimit int64, maxBytes int64) *DataSourceHandler {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	handler, err := NewQueryDataHandler("", db, DataPluginConfiguration{
+		DSInfo:   DataSourceInfo{JsonData: JsonData{StreamingMaxBytes: maxBytes}},
+		RowLimit: rowLimit,
+	}, &testQueryResultTransformer{}, passthroughMacroEngine{}, log.New())
+	require.NoError(t, err)
+	return handler
+}
+
+func runStream(t *testing.T, handler *DataSourceHandler, query string) (*recordingPacketSender, error) {
+	t.Helper()
+	packets := &recordingPacketSender{}
+	err := handler.RunStream(context.Background(), &backend.RunStreamRequest{
+		Path: "query/A",
+		Data: json.RawMessage(query),
+	}, backend.NewStreamSender(packets))
+	return packets, err
+}
+
+const streamTestSQL = `WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n WHERE x < 25) SELECT x, 'row' || x AS name FROM n`
+
+func TestRunStream(t *testing.T) {
+	query := func(batchSize int) string {
+		b, _ := json.Marshal(map[string]any{"refId": "A", "rawSql": streamTestSQL, "format": "table", "batchSize": batchSize})
+		return string(b)
+	}
+
+	t.Run("sends the rows in batches", func(t *testing.T) {
+		packets, err := runStream(t, newStreamTestHandler(t, 1000, 0), query(10))
+		require.NoError(t, err)
+		require.Len(t, packets.frames, 3)
+		require.Equal(t, []int{10, 10, 5}, []int{packets.frames[0].Rows(), packets.frames[1].Rows(), packets.frames[2].Rows()})
+
+		first := packets.frames[0]
+		require.Equal(t, "A", first.RefID)
+		require.Len(t, first.Fields, 2)
+		require.Equal(t, "name", first.Fields[1].Name)
+		require.Equal(t, streamTestSQL, first.Meta.ExecutedQueryString)
+	})
+
+	t.Run("stops at the row limit", func(t *testing.T) {
+		packets, err := runStream(t, newStreamTestHandler(t, 15, 0), query(10))
+		require.NoError(t, err)
+		require.Equal(t, 15, packets.rows())
+		last := packets.frames[len(packets.frames)-1]
+		require.Len(t, last.Meta.Notices, 1)
+		require.Contains(t, last.Meta.Notices[0].Text, "row limit")
+	})
+
+	t.Run("stops at the max bytes", func(t *testing.T) {
+		packets, err := runStream(t, newStreamTestHandler(t, 1000, 600), query(5))
+		require.NoError(t, err)
+		require.Less(t, packets.rows(), 25)
+		last := packets.frames[len(packets.frames)-1]
+		require.Len(t, last.Meta.Notices, 1)
+		require.Contains(t, last.Meta.Notices[0].Text, "600 bytes")
+	})
+
+	t.Run("sends the schema of empty results", func(t *testing.T) {
+		packets, err := runStream(t, newStreamTestHandler(t, 1000, 0), `{"refId": "A", "rawSql": "SELECT 1 AS x WHERE 1 = 0"}`)
+		require.NoError(t, err)
+		require.Len(t, packets.frames, 1)
+		require.Equal(t, 0, packets.frames[0].Rows())
+		require.Len(t, packets.frames[0].Fields, 1)
+	})
+
+	t.Run("rejects time series queries", func(t *testing.T) {
+		handler := newStreamTestHandler(t, 1000, 0)
+		_, err := runStream(t, handler, `{"refId": "A", "rawSql": "SELECT 1", "format": "time_series"}`)
+		require.Error(t, err)
+
+		res, err := handler.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{
+			Path: "query/A",
+			Data: json.RawMessage(`{"refId": "A", "rawSql": "SELECT 1", "format": "time_series"}`),
+		})
+		require.Error(t, err)
+		require.Equal(t, backend.SubscribeStreamStatusNotFound, res.Status)
+	})
+}
+
+func TestSubscribeStream(t *testing.T) {
+	handler := newStreamTestHandler(t, 1000, 0)
+	raw := json.RawMessage(`{"refId": "A", "rawSql": "SELECT 1\nFROM t", "format": "table", "timeRange": {"from": 1000, "to": 2000}, "intervalMs": 15000}`)
+	pCtx := backend.PluginContext{OrgID: 1, User: &backend.User{Login: "admin"}}
+	subscribe := func(pCtx backend.PluginContext, path string) backend.SubscribeStreamStatus {
+		t.Helper()
+		res, err := handler.SubscribeStream(context.Background(), &backend.SubscribeStreamRequest{PluginContext: pCtx, Path: path, Data: raw})
+		require.NoError(t, err)
+		return res.Status
+	}
+
+	// The key is the hex encoded SHA-256 of "1\nadmin\nA\ntable\n1000\n2000\n15000\nSELECT 1\nFROM t", which the frontend builds too.
+	path := "query/7a4232f273d29a3a92c615f08c43d850f4f7bc27de324a327a2c6a69964ffa7f"
+	require.Equal(t, backend.SubscribeStreamStatusOK, subscribe(pCtx, path))
+
+	// Other users and organizations cannot subscribe to the channel, and its path cannot be used for other queries.
+	require.Equal(t, backend.SubscribeStreamStatusPermissionDenied, subscribe(backend.PluginContext{OrgID: 1, User: &backend.User{Login: "viewer"}}, path))
+	require.Equal(t, backend.SubscribeStreamStatusPermissionDenied, subscribe(backend.PluginContext{OrgID: 2, User: &backend.User{Login: "admin"}}, path))
+	require.Equal(t, backend.SubscribeStreamStatusPermissionDenied, subscribe(pCtx, "query/A"))
+	require.Equal(t, backend.SubscribeStreamStatusNotFound, subscribe(pCtx, "other/"+path))
+}
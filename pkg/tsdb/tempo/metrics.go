@@ -0,0 +1,398 @@
+package tempo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+const (
+	MetricsPathPrefix = "metrics/"
+
+	// defaultMetricsPoints is the number of points of a series when neither the step nor the interval is set.
+	defaultMetricsPoints = 100
+	// metricsStreamChunks is the number of consecutive ranges a streamed metrics query is split into, the partial
+	// results are sent after each range.
+	metricsStreamChunks = 4
+)
+
+// metricsQueryRangeResponse is the response of the /api/metrics/query_range endpoint.
+type metricsQueryRangeResponse struct {
+	Series []*metricsSeries `json:"series"`
+}
+
+type metricsSeries struct {
+	Labels     []metricsLabel  `json:"labels"`
+	Samples    []metricsSample `json:"samples"`
+	PromLabels string          `json:"promLabels"`
+}
+
+type metricsLabel struct {
+	Key   string            `json:"key"`
+	Value metricsLabelValue `json:"value"`
+}
+
+// metricsLabelValue is an OTLP AnyValue, the 64-bit integers are encoded as strings.
+type metricsLabelValue struct {
+	StringValue *string      `json:"stringValue,omitempty"`
+	IntValue    *json.Number `json:"intValue,omitempty"`
+	DoubleValue *float64     `json:"doubleValue,omitempty"`
+	BoolValue   *bool        `json:"boolValue,omitempty"`
+}
+
+type metricsSample struct {
+	TimestampMs json.Number `json:"timestampMs"`
+	Value       float64     `json:"value"`
+}
+
+func (v metricsLabelValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.IntValue != nil:
+		return v.IntValue.String()
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'f', -1, 64)
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	}
+	return ""
+}
+
+func (s *metricsSeries) labels() data.Labels {
+	labels := data.Labels{}
+	for _, l := range s.Labels {
+		labels[l.Key] = l.Value.String()
+	}
+	return labels
+}
+
+// metricsQuery is a TraceQL metrics query with the time range and the step it is run with.
+type metricsQuery struct {
+	query string
+	start time.Time
+	end   time.Time
+	step  time.Duration
+}
+
+func newMetricsQuery(query backend.DataQuery) (*metricsQuery, error) {
+	model := &dataquery.TempoQuery{}
+	if err := json.Unmarshal(query.JSON, model); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Tempo query model: %w", err)
+	}
+	if model.Query == nil || *model.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	step := query.Interval
+	if model.Step != nil && *model.Step != "" {
+		var err error
+		if step, err = gtime.ParseDuration(*model.Step); err != nil {
+			return nil, fmt.Errorf("invalid step %q: %w", *model.Step, err)
+		}
+	}
+	if step <= 0 {
+		step = query.TimeRange.Duration() / defaultMetricsPoints
+	}
+	if step < time.Second {
+		step = time.Second
+	}
+
+	return &metricsQuery{
+		query: *model.Query,
+		start: query.TimeRange.From,
+		end:   query.TimeRange.To,
+		step:  step.Truncate(time.Second),
+	}, nil
+}
+
+// chunks splits the time range in consecutive ranges whose boundaries are multiples of the step, like the buckets of
+// Tempo, so a bucket is never split between two ranges.
+func (q *metricsQuery) chunks(n int) []*metricsQuery {
+	if !q.start.Before(q.end) {
+		return []*metricsQuery{q}
+	}
+	size := (q.end.Sub(q.start) / time.Duration(n)).Truncate(q.step)
+	if size < q.step {
+		size = q.step
+	}
+	// the buckets are aligned on the Unix epoch, time.Truncate would align them on the zero time
+	alignedStart := time.UnixMilli(q.start.UnixMilli() - q.start.UnixMilli()%q.step.Milliseconds())
+
+	var chunks []*metricsQuery
+	start := q.start
+	for boundary := alignedStart.Add(size); ; boundary = boundary.Add(size) {
+		end := boundary
+		if !end.Before(q.end) || len(chunks) == n-1 {
+			end = q.end
+		}
+		chunks = append(chunks, &metricsQuery{query: q.query, start: start, end: end, step: q.step})
+		if end.Equal(q.end) {
+			break
+		}
+		start = end
+	}
+	return chunks
+}
+
+func (s *Service) runMetricsQuery(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) (*backend.DataResponse, error) {
+	ctxLogger := s.logger.FromContext(ctx)
+	ctxLogger.Debug("Running TraceQL metrics query", "function", logEntrypoint())
+
+	ctx, span := tracing.DefaultTracer().Start(ctx, "datasource.tempo.runMetricsQuery", trace.WithAttributes(
+		attribute.String("queryType", query.QueryType),
+	))
+	defer span.End()
+
+	mq, err := newMetricsQuery(query)
+	if err != nil {
+		ctxLogger.Error("Failed to parse metrics query", "error", err, "function", logEntrypoint())
+		return &backend.DataResponse{}, err
+	}
+
+	dsInfo, err := s.getDSInfo(ctx, pCtx)
+	if err != nil {
+		ctxLogger.Error("Failed to get datasource information", "error", err, "function", logEntrypoint())
+		return nil, err
+	}
+
+	res, err := s.queryRange(ctx, dsInfo, mq)
+	if err != nil {
+		ctxLogger.Error("Failed to run metrics query", "error", err, "function", logEntrypoint())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return &backend.DataResponse{Error: err}, nil
+	}
+
+	return &backend.DataResponse{Frames: metricsToFrames(res.Series, query.RefID, mq.query)}, nil
+}
+
+// queryRange runs a metrics query with the /api/metrics/query_range endpoint.
+func (s *Service) queryRange(ctx context.Context, dsInfo *Datasource, mq *metricsQuery) (*metricsQueryRangeResponse, error) {
+	ctxLogger := s.logger.FromContext(ctx)
+
+	params := url.Values{}
+	params.Set("q", mq.query)
+	params.Set("start", strconv.FormatInt(mq.start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(mq.end.Unix(), 10))
+	params.Set("step", fmt.Sprintf("%ds", int64(mq.step/time.Second)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dsInfo.URL+"/api/metrics/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed get to tempo: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			ctxLogger.Error("Failed to close response body", "error", err, "function", logEntrypoint())
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to run metrics query %q Status: %s Body: %s", mq.query, resp.Status, string(body))
+	}
+
+	res := &metricsQueryRangeResponse{}
+	if err := json.Unmarshal(body, res); err != nil {
+		return nil, fmt.Errorf("failed to decode metrics response: %w", err)
+	}
+	return res, nil
+}
+
+// metricsToFrames converts the series to frames of the multi time series format of the data plane contract.
+func metricsToFrames(series []*metricsSeries, refID string, query string) data.Frames {
+	sortSeries(series)
+	frames := make(data.Frames, 0, len(series))
+	for _, s := range series {
+		times := make([]time.Time, 0, len(s.Samples))
+		values := make([]float64, 0, len(s.Samples))
+		for _, sample := range s.Samples {
+			ts, err := sample.TimestampMs.Int64()
+			if err != nil {
+				continue
+			}
+			times = append(times, time.UnixMilli(ts).UTC())
+			values = append(values, sample.Value)
+		}
+
+		frame := data.NewFrame("",
+			data.NewField(data.TimeSeriesTimeFieldName, nil, times),
+			data.NewField(data.TimeSeriesValueFieldName, s.labels(), values),
+		)
+		frames = append(frames, frame)
+	}
+
+	if len(frames) == 0 {
+		// an empty response is a frame without fields
+		frames = append(frames, data.NewFrame(""))
+	}
+	for _, frame := range frames {
+		frame.RefID = refID
+		frame.Meta = &data.FrameMeta{
+			Type:                data.FrameTypeTimeSeriesMulti,
+			TypeVersion:         data.FrameTypeVersion{0, 1},
+			ExecutedQueryString: query,
+		}
+	}
+	return frames
+}
+
+func sortSeries(series []*metricsSeries) {
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].PromLabels < series[j].PromLabels
+	})
+	for _, s := range series {
+		sort.SliceStable(s.Samples, func(i, j int) bool {
+			a, _ := s.Samples[i].TimestampMs.Int64()
+			b, _ := s.Samples[j].TimestampMs.Int64()
+			return a < b
+		})
+	}
+}
+
+// mergeSeries adds the samples of a partial result to the series, the samples of the same time are replaced.
+func mergeSeries(series map[string]*metricsSeries, partial []*metricsSeries) {
+	for _, p := range partial {
+		s, ok := series[p.PromLabels]
+		if !ok {
+			series[p.PromLabels] = p
+			continue
+		}
+		seen := make(map[string]int, len(s.Samples))
+		for i, sample := range s.Samples {
+			seen[sample.TimestampMs.String()] = i
+		}
+		for _, sample := range p.Samples {
+			if i, ok := seen[sample.TimestampMs.String()]; ok {
+				s.Samples[i] = sample
+				continue
+			}
+			s.Samples = append(s.Samples, sample)
+		}
+	}
+}
+
+// MetricsStreamingMeta is the custom metadata of the frames of a streamed metrics query.
+type MetricsStreamingMeta struct {
+	State dataquery.SearchStreamingState `json:"state"`
+	Error string                         `json:"error,omitempty"`
+}
+
+// runMetricsStream runs a metrics query over consecutive ranges of the time range and sends the series received so
+// far after each range.
+func (s *Service) runMetricsStream(ctx context.Context, req *backend.RunStreamRequest, sender StreamSender, datasource *Datasource) error {
+	ctx, span := tracing.DefaultTracer().Start(ctx, "datasource.tempo.runMetricsStream")
+	defer span.End()
+
+	var backendQuery backend.DataQuery
+	if err := json.Unmarshal(req.Data, &backendQuery); err != nil {
+		return fmt.Errorf("error unmarshaling backend query model: %v", err)
+	}
+	backendQuery.JSON = req.Data
+
+	mq, err := newMetricsQuery(backendQuery)
+	if err != nil {
+		return err
+	}
+
+	series := map[string]*metricsSeries{}
+	chunks := mq.chunks(metricsStreamChunks)
+	for i, chunk := range chunks {
+		res, err := s.queryRange(ctx, datasource, chunk)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		mergeSeries(series, res.Series)
+
+		state := dataquery.SearchStreamingStateStreaming
+		if i == len(chunks)-1 {
+			state = dataquery.SearchStreamingStateDone
+		}
+		if err := sender.SendFrame(metricsStreamFrame(series, mq.query, MetricsStreamingMeta{State: state}), data.IncludeAll); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// metricsStreamFrame converts the series to a single wide frame, so each message of the stream holds all the series.
+func metricsStreamFrame(series map[string]*metricsSeries, query string, meta MetricsStreamingMeta) *data.Frame {
+	list := make([]*metricsSeries, 0, len(series))
+	for _, s := range series {
+		list = append(list, s)
+	}
+	sortSeries(list)
+
+	var timestamps []int64
+	seen := map[int64]bool{}
+	for _, s := range list {
+		for _, sample := range s.Samples {
+			ts, err := sample.TimestampMs.Int64()
+			if err == nil && !seen[ts] {
+				seen[ts] = true
+				timestamps = append(timestamps, ts)
+			}
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	index := make(map[int64]int, len(timestamps))
+	times := make([]time.Time, len(timestamps))
+	for i, ts := range timestamps {
+		index[ts] = i
+		times[i] = time.UnixMilli(ts).UTC()
+	}
+
+	frame := data.NewFrame("response", data.NewField(data.TimeSeriesTimeFieldName, nil, times))
+	for _, s := range list {
+		values := make([]*float64, len(timestamps))
+		for _, sample := range s.Samples {
+			if ts, err := sample.TimestampMs.Int64(); err == nil {
+				v := sample.Value
+				values[index[ts]] = &v
+			}
+		}
+		frame.Fields = append(frame.Fields, data.NewField(data.TimeSeriesValueFieldName, s.labels(), values))
+	}
+	frame.Meta = &data.FrameMeta{
+		Type:                data.FrameTypeTimeSeriesWide,
+		TypeVersion:         data.FrameTypeVersion{0, 1},
+		ExecutedQueryString: query,
+		Custom:              meta,
+	}
+	return frame
+}
+
+func sendMetricsError(err error, sender StreamSender) error {
+	frame := data.NewFrame("response")
+	frame.Meta = &data.FrameMeta{Custom: MetricsStreamingMeta{State: dataquery.SearchStreamingStateError, Error: err.Error()}}
+	return sender.SendFrame(frame, data.IncludeAll)
+}
@@ -0,0 +1,148 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/tsdb/tempo/kinds/dataquery"
+)
+
+// metricsServer returns one sample per step of the requested range for two services.
+func metricsServer(t *testing.T, requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/metrics/query_range", r.URL.Path)
+		*requests = append(*requests, r.URL.RawQuery)
+
+		start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+		step, _ := time.ParseDuration(r.URL.Query().Get("step"))
+		body := `{"series": [`
+		for i, service := range []string{"b", "a"} {
+			if i > 0 {
+				body += ","
+			}
+			body += fmt.Sprintf(`{"promLabels": "{resource.service.name=\"%s\"}", "labels": [{"key": "resource.service.name", "value": {"stringValue": "%s"}}, {"key": "status", "value": {"intValue": "2"}}], "samples": [`, service, service)
+			for ts := start; ts < end; ts += int64(step.Seconds()) {
+				if ts > start {
+					body += ","
+				}
+				body += fmt.Sprintf(`{"timestampMs": "%d", "value": %d}`, ts*1000, i+1)
+			}
+			body += "]}"
+		}
+		_, _ = w.Write([]byte(body + "]}"))
+	}))
+}
+
+func TestMetricsQuery(t *testing.T) {
+	timeRange := backend.TimeRange{From: time.Unix(1000, 0), To: time.Unix(1240, 0)}
+
+	t.Run("newMetricsQuery uses the step, the interval or a default step", func(t *testing.T) {
+		mq, err := newMetricsQuery(backend.DataQuery{TimeRange: timeRange, Interval: time.Minute, JSON: []byte(`{"query": "{} | rate()", "step": "30s"}`)})
+		require.NoError(t, err)
+		require.Equal(t, 30*time.Second, mq.step)
+
+		mq, err = newMetricsQuery(backend.DataQuery{TimeRange: timeRange, Interval: time.Minute, JSON: []byte(`{"query": "{} | rate()"}`)})
+		require.NoError(t, err)
+		require.Equal(t, time.Minute, mq.step)
+
+		mq, err = newMetricsQuery(backend.DataQuery{TimeRange: timeRange, JSON: []byte(`{"query": "{} | rate()"}`)})
+		require.NoError(t, err)
+		require.Equal(t, 2*time.Second, mq.step)
+
+		_, err = newMetricsQuery(backend.DataQuery{TimeRange: timeRange, JSON: []byte(`{"query": ""}`)})
+		require.EqualError(t, err, "query is required")
+	})
+
+	t.Run("chunks split the range on the step", func(t *testing.T) {
+		mq := &metricsQuery{query: "{} | rate()", start: timeRange.From, end: timeRange.To, step: 25 * time.Second}
+		chunks := mq.chunks(4)
+		require.Len(t, chunks, 4)
+		require.Equal(t, timeRange.From, chunks[0].start)
+		for i := 1; i < len(chunks); i++ {
+			require.Equal(t, chunks[i-1].end, chunks[i].start)
+			require.Equal(t, time.Duration(0), chunks[i].start.Sub(timeRange.From)%mq.step)
+		}
+		require.Equal(t, timeRange.To, chunks[3].end)
+	})
+
+	t.Run("chunks are aligned on the multiples of the step", func(t *testing.T) {
+		mq := &metricsQuery{query: "{} | rate()", start: timeRange.From, end: timeRange.To, step: 30 * time.Second}
+		chunks := mq.chunks(4)
+		require.Len(t, chunks, 4)
+		require.Equal(t, timeRange.From, chunks[0].start)
+		for i := 1; i < len(chunks); i++ {
+			require.Equal(t, chunks[i-1].end, chunks[i].start)
+			require.Equal(t, int64(0), chunks[i].start.UnixMilli()%mq.step.Milliseconds())
+		}
+		require.Equal(t, timeRange.To, chunks[3].end)
+	})
+
+	t.Run("converts the series to multi time series frames", func(t *testing.T) {
+		var requests []string
+		srv := metricsServer(t, &requests)
+		defer srv.Close()
+
+		service := &Service{logger: backend.NewLoggerWith("logger", "tempo-test")}
+		mq := &metricsQuery{query: "{} | rate() by (resource.service.name)", start: timeRange.From, end: timeRange.To, step: time.Minute}
+		res, err := service.queryRange(context.Background(), &Datasource{HTTPClient: srv.Client(), URL: srv.URL}, mq)
+		require.NoError(t, err)
+		require.Equal(t, []string{"end=1240&q=%7B%7D+%7C+rate%28%29+by+%28resource.service.name%29&start=1000&step=60s"}, requests)
+
+		frames := metricsToFrames(res.Series, "A", mq.query)
+		require.Len(t, frames, 2)
+		for i, service := range []string{"a", "b"} {
+			frame := frames[i]
+			require.Equal(t, "A", frame.RefID)
+			require.Equal(t, data.FrameTypeTimeSeriesMulti, frame.Meta.Type)
+			require.Equal(t, data.Labels{"resource.service.name": service, "status": "2"}, frame.Fields[1].Labels)
+			require.Equal(t, 4, frame.Rows())
+			require.Equal(t, time.Unix(1000, 0).UTC(), frame.Fields[0].At(0))
+		}
+		require.Equal(t, 2.0, frames[0].Fields[1].At(0))
+	})
+
+	t.Run("returns an empty frame without series", func(t *testing.T) {
+		frames := metricsToFrames(nil, "A", "{} | rate()")
+		require.Len(t, frames, 1)
+		require.Empty(t, frames[0].Fields)
+		require.Equal(t, data.FrameTypeTimeSeriesMulti, frames[0].Meta.Type)
+	})
+}
+
+func TestRunMetricsStream(t *testing.T) {
+	var requests []string
+	srv := metricsServer(t, &requests)
+	defer srv.Close()
+
+	service := &Service{logger: backend.NewLoggerWith("logger", "tempo-test")}
+	sender := &mockSender{}
+	err := service.runMetricsStream(context.Background(), &backend.RunStreamRequest{
+		Path: MetricsPathPrefix + "abc",
+		Data: []byte(`{"refId": "A", "query": "{} | rate() by (resource.service.name)", "step": "10s", "timeRange": {"from": "1970-01-01T00:16:40Z", "to": "1970-01-01T00:20:40Z"}}`),
+	}, sender, &Datasource{HTTPClient: srv.Client(), URL: srv.URL})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 4)
+	require.Len(t, sender.responses, 4)
+	for i, frame := range sender.responses {
+		require.Equal(t, data.FrameTypeTimeSeriesWide, frame.Meta.Type)
+		require.Len(t, frame.Fields, 3)
+		// every partial result holds the samples received so far
+		require.Equal(t, (i+1)*6, frame.Rows())
+		state := dataquery.SearchStreamingStateStreaming
+		if i == 3 {
+			state = dataquery.SearchStreamingStateDone
+		}
+		require.Equal(t, MetricsStreamingMeta{State: state}, frame.Meta.Custom)
+	}
+}
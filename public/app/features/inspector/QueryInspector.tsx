@@ -5,8 +5,8 @@ import { Subscription } from 'rxjs';
 import { LoadingState, PanelData } from '@grafana/data';
 import { selectors } from '@grafana/e2e-selectors';
 import { config } from '@grafana/runtime';
-import { Button, ClipboardButton, JSONFormatter, LoadingPlaceholder, Stack } from '@grafana/ui';
-import { Trans } from 'app/core/internationalization';
+import { Button, ClipboardButton, InlineSwitch, JSONFormatter, LoadingPlaceholder, Stack } from '@grafana/ui';
+import { t, Trans } from 'app/core/internationalization';
 import { backendSrv } from 'app/core/services/backend_srv';
 
 import { getPanelInspectorStyles2 } from './styles';
@@ -27,6 +27,7 @@ interface Props {
 interface State {
   allNodesExpanded: boolean | null;
   isMocking: boolean;
+  isRecording: boolean;
   mockedResponse: string;
   response: {};
   executedQueries: ExecutedQueryInfo[];
@@ -42,6 +43,7 @@ export class QueryInspector extends PureComponent<Props, State> {
       executedQueries: [],
       allNodesExpanded: null,
       isMocking: false,
+      isRecording: false,
       mockedResponse: '',
       response: {},
     };
@@ -153,6 +155,27 @@ export class QueryInspector extends PureComponent<Props, State> {
     this.setState({
       response: response,
     });
+
+    if (this.state.isRecording && response.request?.url?.startsWith('/api/ds/query')) {
+      this.recordResponse(response);
+    }
+  }
+
+  /**
+   * Send the request again with the record option, the recording replaces the response so it can be copied
+   * and replayed with the TestData data source
+   */
+  recordResponse(response: any) {
+    const { url, method, data } = response.request;
+    const setRecording = (recording: any) => this.setState({ response: { ...response, response: recording } });
+
+    this.subs.add(
+      backendSrv.fetch({ url, method, data: { ...data, record: true }, hideFromInspector: true }).subscribe({
+        next: (rsp) => setRecording(rsp.data),
+        // the recording of a response with errors is sent with an error status
+        error: (err) => err?.data && setRecording(err.data),
+      })
+    );
   }
 
   setFormattedJson = (formattedJson: {}) => {
@@ -177,6 +200,13 @@ export class QueryInspector extends PureComponent<Props, State> {
     }));
   };
 
+  onToggleRecording = () => {
+    this.setState((prevState) => ({
+      ...prevState,
+      isRecording: !this.state.isRecording,
+    }));
+  };
+
   getNrOfOpenNodes = () => {
     if (this.state.allNodesExpanded === null) {
       return 3; // 3 is default, ie when state is null
@@ -218,7 +248,7 @@ export class QueryInspector extends PureComponent<Props, State> {
   }
 
   render() {
-    const { allNodesExpanded, executedQueries, response } = this.state;
+    const { allNodesExpanded, executedQueries, isRecording, response } = this.state;
     const { onRefreshQuery, data } = this.props;
     const openNodes = this.getNrOfOpenNodes();
     const styles = getPanelInspectorStyles2(config.theme2);
@@ -268,6 +298,13 @@ export class QueryInspector extends PureComponent<Props, State> {
             </ClipboardButton>
           )}
           <div className="flex-grow-1" />
+          <InlineSwitch
+            label={t('inspector.query.record', 'Record response')}
+            showLabel={true}
+            id="query-inspector-record"
+            value={isRecording}
+            onChange={this.onToggleRecording}
+          />
         </div>
         <div className={styles.content}>
           {isLoading && <LoadingPlaceholder text="Loading query inspector..." />}
@@ -8,7 +8,7 @@ import { ElasticsearchQuery } from '../../types';
 
 import { createReducer as createBucketAggsReducer } from './BucketAggregationsEditor/state/reducer';
 import { reducer as metricsReducer } from './MetricAggregationsEditor/state/reducer';
-import { aliasPatternReducer, queryReducer, initQuery } from './state';
+import { aliasPatternReducer, formatReducer, queryLanguageReducer, queryReducer, initQuery } from './state';
 
 const DatasourceContext = createContext<ElasticDatasource | undefined>(undefined);
 const QueryContext = createContext<ElasticsearchQuery | undefined>(undefined);
@@ -38,11 +38,15 @@ export const ElasticsearchProvider = ({
     [onChange, onRunQuery]
   );
 
-  const reducer = combineReducers<Pick<ElasticsearchQuery, 'query' | 'alias' | 'metrics' | 'bucketAggs'>>({
+  const reducer = combineReducers<
+    Pick<ElasticsearchQuery, 'query' | 'alias' | 'metrics' | 'bucketAggs' | 'queryType' | 'format'>
+  >({
     query: queryReducer,
     alias: aliasPatternReducer,
     metrics: metricsReducer,
     bucketAggs: createBucketAggsReducer(datasource.timeField),
+    queryType: queryLanguageReducer,
+    format: formatReducer,
   });
 
   const dispatch = useStatelessReducer(
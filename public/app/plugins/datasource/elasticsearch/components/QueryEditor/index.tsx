@@ -2,13 +2,23 @@ import { css } from '@emotion/css';
 import React, { useEffect, useId, useState } from 'react';
 import { SemVer } from 'semver';
 
-import { getDefaultTimeRange, GrafanaTheme2, QueryEditorProps } from '@grafana/data';
-import { Alert, InlineField, InlineLabel, Input, QueryField, useStyles2 } from '@grafana/ui';
+import { getDefaultTimeRange, GrafanaTheme2, QueryEditorProps, SelectableValue } from '@grafana/data';
+import { config } from '@grafana/runtime';
+import {
+  Alert,
+  InlineField,
+  InlineLabel,
+  Input,
+  QueryField,
+  RadioButtonGroup,
+  TextArea,
+  useStyles2,
+} from '@grafana/ui';
 
 import { ElasticDatasource } from '../../datasource';
 import { useNextId } from '../../hooks/useNextId';
 import { useDispatch } from '../../hooks/useStatelessReducer';
-import { ElasticsearchOptions, ElasticsearchQuery } from '../../types';
+import { ElasticsearchOptions, ElasticsearchQuery, QueryFormat, QueryLanguage } from '../../types';
 import { isSupportedVersion, isTimeSeriesQuery, unsupportedVersionMessage } from '../../utils';
 
 import { BucketAggregationsEditor } from './BucketAggregationsEditor';
@@ -16,7 +26,7 @@ import { ElasticsearchProvider } from './ElasticsearchQueryContext';
 import { MetricAggregationsEditor } from './MetricAggregationsEditor';
 import { metricAggregationConfig } from './MetricAggregationsEditor/utils';
 import { QueryTypeSelector } from './QueryTypeSelector';
-import { changeAliasPattern, changeQuery } from './state';
+import { changeAliasPattern, changeFormat, changeQuery, changeQueryLanguage } from './state';
 
 export type ElasticQueryEditorProps = QueryEditorProps<ElasticDatasource, ElasticsearchQuery, ElasticsearchOptions>;
 
@@ -77,6 +87,23 @@ interface Props {
   value: ElasticsearchQuery;
 }
 
+type Language = 'lucene' | QueryLanguage;
+
+const LANGUAGE_OPTIONS: Array<SelectableValue<Language>> = [
+  { value: 'lucene', label: 'Lucene' },
+  { value: 'sql', label: 'SQL' },
+  { value: 'esql', label: 'ES|QL' },
+];
+
+const FORMAT_OPTIONS: Array<SelectableValue<QueryFormat>> = [
+  { value: 'table', label: 'Table' },
+  { value: 'time_series', label: 'Time series' },
+];
+
+function isTextQuery(query: ElasticsearchQuery): boolean {
+  return query.queryType === 'sql' || query.queryType === 'esql';
+}
+
 export const ElasticSearchQueryField = ({ value, onChange }: { value?: string; onChange: (v: string) => void }) => {
   const styles = useStyles2(getStyles);
 
@@ -99,8 +126,58 @@ const QueryEditorForm = ({ value }: Props) => {
     (metric) => metricAggregationConfig[metric.type].impliedQueryType === 'metrics'
   );
 
+  const languageSelector = config.featureToggles.enableElasticsearchBackendQuerying && (
+    <div className={styles.root}>
+      <InlineLabel width={17}>Query language</InlineLabel>
+      <div className={styles.queryItem}>
+        <RadioButtonGroup<Language>
+          options={LANGUAGE_OPTIONS}
+          value={isTextQuery(value) ? (value.queryType as QueryLanguage) : 'lucene'}
+          onChange={(language) => dispatch(changeQueryLanguage(language === 'lucene' ? undefined : language))}
+        />
+      </div>
+    </div>
+  );
+
+  if (isTextQuery(value)) {
+    return (
+      <>
+        {languageSelector}
+        <div className={styles.root}>
+          <InlineLabel width={17} tooltip="The FROM clause always reads the index of the data source.">
+            {value.queryType === 'esql' ? 'ES|QL query' : 'SQL query'}
+          </InlineLabel>
+          <div className={styles.queryItem}>
+            <TextArea
+              key={value.queryType}
+              rows={4}
+              placeholder={
+                value.queryType === 'esql'
+                  ? 'FROM logs | STATS count = COUNT(*) BY host'
+                  : 'SELECT host, COUNT(*) FROM logs GROUP BY host'
+              }
+              defaultValue={value.query}
+              onBlur={(e) => dispatch(changeQuery(e.currentTarget.value))}
+            />
+          </div>
+        </div>
+        <div className={styles.root}>
+          <InlineLabel width={17}>Format</InlineLabel>
+          <div className={styles.queryItem}>
+            <RadioButtonGroup<QueryFormat>
+              options={FORMAT_OPTIONS}
+              value={value.format ?? 'table'}
+              onChange={(format) => dispatch(changeFormat(format))}
+            />
+          </div>
+        </div>
+      </>
+    );
+  }
+
   return (
     <>
+      {languageSelector}
       <div className={styles.root}>
         <InlineLabel width={17}>Query type</InlineLabel>
         <div className={styles.queryItem}>
@@ -1,6 +1,6 @@
 import { Action, createAction } from '@reduxjs/toolkit';
 
-import { ElasticsearchQuery } from '../../types';
+import { ElasticsearchQuery, QueryLanguage } from '../../types';
 
 /**
  * When the `initQuery` Action is dispatched, the query gets populated with default values where values are not present.
@@ -12,11 +12,20 @@ export const changeQuery = createAction<ElasticsearchQuery['query']>('change_que
 
 export const changeAliasPattern = createAction<ElasticsearchQuery['alias']>('change_alias_pattern');
 
+export const changeQueryLanguage = createAction<QueryLanguage | undefined>('change_query_language');
+
+export const changeFormat = createAction<ElasticsearchQuery['format']>('change_format');
+
 export const queryReducer = (prevQuery: ElasticsearchQuery['query'], action: Action) => {
   if (changeQuery.match(action)) {
     return action.payload;
   }
 
+  // a Lucene query is not a valid SQL or ES|QL query and the other way around
+  if (changeQueryLanguage.match(action)) {
+    return '';
+  }
+
   if (initQuery.match(action)) {
     return prevQuery || '';
   }
@@ -35,3 +44,19 @@ export const aliasPatternReducer = (prevAliasPattern: ElasticsearchQuery['alias'
 
   return prevAliasPattern;
 };
+
+export const queryLanguageReducer = (prevQueryType: ElasticsearchQuery['queryType'], action: Action) => {
+  if (changeQueryLanguage.match(action)) {
+    return action.payload;
+  }
+
+  return prevQueryType;
+};
+
+export const formatReducer = (prevFormat: ElasticsearchQuery['format'], action: Action) => {
+  if (changeFormat.match(action)) {
+    return action.payload;
+  }
+
+  return prevFormat;
+};
@@ -37,6 +37,12 @@ composableKinds: DataQuery: {
 				bucketAggs?: [...#BucketAggregation]
 				// List of metric aggregations
 				metrics?: [...#MetricAggregation]
+				// Format of the results of the sql and esql queries
+				format?: #QueryFormat
+
+				// The query type of the queries sent as text to the SQL and ES|QL endpoints, it is empty for the Lucene queries
+				#QueryLanguage: "sql" | "esql" @cuetsy(kind="type")
+				#QueryFormat:   "table" | "time_series" @cuetsy(kind="type")
 
 				#BucketAggregation: #DateHistogram | #Histogram | #Terms | #Filters | #GeoHashGrid | #Nested @cuetsy(kind="type")
 				#MetricAggregation: #Count | #PipelineMetricAggregation | #MetricAggregationWithSettings     @cuetsy(kind="type")
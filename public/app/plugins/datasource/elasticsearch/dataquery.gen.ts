@@ -10,6 +10,13 @@
 
 import * as common from '@grafana/schema';
 
+/**
+ * The query type of the queries sent as text to the SQL and ES|QL endpoints, it is empty for the Lucene queries
+ */
+export type QueryLanguage = ('sql' | 'esql');
+
+export type QueryFormat = ('table' | 'time_series');
+
 export type BucketAggregation = (DateHistogram | Histogram | Terms | Filters | GeoHashGrid | Nested);
 
 export type MetricAggregation = (Count | PipelineMetricAggregation | MetricAggregationWithSettings);
@@ -385,6 +392,10 @@ export interface ElasticsearchDataQuery extends common.DataQuery {
    * List of bucket aggregations
    */
   bucketAggs?: Array<BucketAggregation>;
+  /**
+   * Format of the results of the sql and esql queries
+   */
+  format?: QueryFormat;
   /**
    * List of metric aggregations
    */
@@ -998,6 +998,15 @@ export class ElasticDatasource
     scopedVars: ScopedVars,
     filters?: AdHocVariableFilter[]
   ): ElasticsearchQuery {
+    // SQL and ES|QL queries are sent as text, the Lucene escaping and the ad hoc filters do not apply to them
+    if (query.queryType === 'sql' || query.queryType === 'esql') {
+      return {
+        ...query,
+        datasource: this.getRef(),
+        query: this.templateSrv.replace(query.query ?? '', scopedVars),
+      };
+    }
+
     // We need a separate interpolation format for lucene queries, therefore we first interpolate any
     // lucene query string and then everything else
     const interpolateBucketAgg = (bucketAgg: BucketAggregation): BucketAggregation => {
@@ -0,0 +1,61 @@
+import React, { ChangeEvent, useId } from 'react';
+
+import { DataSourcePluginOptionsEditorProps } from '@grafana/data';
+import { config } from '@grafana/runtime';
+import { DataSourceHttpSettings, Field, FieldSet, Input } from '@grafana/ui';
+
+import { HttpJsonOptions } from '../types';
+
+export const ConfigEditor = (props: DataSourcePluginOptionsEditorProps<HttpJsonOptions>) => {
+  const { options, onOptionsChange } = props;
+  const idSuffix = useId();
+
+  const onJsonDataChange = (key: keyof HttpJsonOptions) => (event: ChangeEvent<HTMLInputElement>) => {
+    onOptionsChange({
+      ...options,
+      jsonData: {
+        ...options.jsonData,
+        [key]: event.currentTarget.value,
+      },
+    });
+  };
+
+  return (
+    <>
+      <DataSourceHttpSettings
+        defaultUrl="https://api.example.com"
+        dataSourceConfig={options}
+        onChange={onOptionsChange}
+        secureSocksDSProxyEnabled={config.secureSocksDSProxyEnabled}
+      />
+      <FieldSet label="HTTP JSON settings">
+        <Field
+          htmlFor={`cache-ttl-${idSuffix}`}
+          label="Cache TTL"
+          description="How long the responses are cached, like 30s or 5m. The responses aren't cached when it is empty."
+        >
+          <Input
+            id={`cache-ttl-${idSuffix}`}
+            value={options.jsonData.cacheTTL ?? ''}
+            onChange={onJsonDataChange('cacheTTL')}
+            placeholder="30s"
+            width={20}
+          />
+        </Field>
+        <Field
+          htmlFor={`health-check-path-${idSuffix}`}
+          label="Health check path"
+          description="The path, relative to the URL, requested by Save & test."
+        >
+          <Input
+            id={`health-check-path-${idSuffix}`}
+            value={options.jsonData.healthCheckPath ?? ''}
+            onChange={onJsonDataChange('healthCheckPath')}
+            placeholder="/health"
+            width={40}
+          />
+        </Field>
+      </FieldSet>
+    </>
+  );
+};
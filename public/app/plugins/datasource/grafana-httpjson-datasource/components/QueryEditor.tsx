@@ -0,0 +1,287 @@
+import React from 'react';
+
+import { QueryEditorProps, SelectableValue } from '@grafana/data';
+import { EditorField, EditorRow, EditorRows } from '@grafana/experimental';
+import { Button, IconButton, Input, RadioButtonGroup, Select, Stack, TextArea } from '@grafana/ui';
+
+import { HttpJsonDatasource } from '../datasource';
+import {
+  FieldModel,
+  FieldType,
+  HttpJsonOptions,
+  HttpJsonQuery,
+  KeyValue,
+  Language,
+  Method,
+  Pagination,
+  PaginationMode,
+} from '../types';
+
+type Props = QueryEditorProps<HttpJsonDatasource, HttpJsonQuery, HttpJsonOptions>;
+
+const methods: Array<SelectableValue<Method>> = [
+  { label: 'GET', value: 'GET' },
+  { label: 'POST', value: 'POST' },
+];
+
+const languages: Array<SelectableValue<Language>> = [
+  { label: 'JSONPath', value: 'jsonpath' },
+  { label: 'JMESPath', value: 'jmespath' },
+];
+
+const fieldTypes: Array<SelectableValue<FieldType>> = [
+  { label: 'String', value: 'string' },
+  { label: 'Number', value: 'number' },
+  { label: 'Boolean', value: 'boolean' },
+  { label: 'Time', value: 'time' },
+];
+
+const paginationModes: Array<SelectableValue<PaginationMode | ''>> = [
+  { label: 'None', value: '' },
+  { label: 'Page', value: 'page' },
+  { label: 'Offset', value: 'offset' },
+  { label: 'Cursor', value: 'cursor' },
+];
+
+export const QueryEditor = ({ query, onChange, onRunQuery }: Props) => {
+  const method = query.method ?? 'GET';
+  const fields = query.fields?.length ? query.fields : [{ path: '' }];
+  const pagination = query.pagination ?? {};
+
+  const update = (changes: Partial<HttpJsonQuery>, run = true) => {
+    onChange({ ...query, ...changes });
+    if (run) {
+      onRunQuery();
+    }
+  };
+
+  const updateField = (index: number, changes: Partial<FieldModel>) => {
+    update({ fields: fields.map((field, i) => (i === index ? { ...field, ...changes } : field)) }, false);
+  };
+
+  const updatePagination = (changes: Partial<Pagination>, run = false) => {
+    update({ pagination: { ...pagination, ...changes } }, run);
+  };
+
+  return (
+    <EditorRows>
+      <EditorRow>
+        <EditorField label="Method" width={12}>
+          <RadioButtonGroup options={methods} value={method} onChange={(value) => update({ method: value })} />
+        </EditorField>
+        <EditorField label="Path" tooltip="The path and query parameters, relative to the URL of the data source">
+          <Input
+            value={query.path ?? ''}
+            placeholder="/api/metrics"
+            width={60}
+            onChange={(e) => update({ path: e.currentTarget.value }, false)}
+            onBlur={onRunQuery}
+          />
+        </EditorField>
+      </EditorRow>
+      <EditorRow>
+        <KeyValueEditor
+          label="Query parameters"
+          values={query.params ?? []}
+          onChange={(params) => update({ params }, false)}
+          onBlur={onRunQuery}
+        />
+        <KeyValueEditor
+          label="Headers"
+          values={query.headers ?? []}
+          onChange={(headers) => update({ headers }, false)}
+          onBlur={onRunQuery}
+        />
+      </EditorRow>
+      {method === 'POST' && (
+        <EditorRow>
+          <EditorField label="Body" tooltip="The JSON body of the request, which can use the template variables" width={80}>
+            <TextArea
+              value={query.body ?? ''}
+              rows={4}
+              onChange={(e) => update({ body: e.currentTarget.value }, false)}
+              onBlur={onRunQuery}
+            />
+          </EditorField>
+        </EditorRow>
+      )}
+      <EditorRow>
+        <EditorField label="Language" width={20}>
+          <RadioButtonGroup
+            options={languages}
+            value={query.language ?? 'jsonpath'}
+            onChange={(value) => update({ language: value })}
+          />
+        </EditorField>
+        <EditorField label="Fields" tooltip="The expressions selecting the values of the fields in the response">
+          <Stack direction="column" gap={1}>
+            {fields.map((field, index) => (
+              <Stack key={index} gap={1} alignItems="center">
+                <Input
+                  aria-label="Field name"
+                  value={field.name ?? ''}
+                  placeholder="Name"
+                  width={20}
+                  onChange={(e) => updateField(index, { name: e.currentTarget.value })}
+                  onBlur={onRunQuery}
+                />
+                <Input
+                  aria-label="Field path"
+                  value={field.path}
+                  placeholder={query.language === 'jmespath' ? 'items[].value' : '$.items[*].value'}
+                  width={40}
+                  onChange={(e) => updateField(index, { path: e.currentTarget.value })}
+                  onBlur={onRunQuery}
+                />
+                <Select
+                  aria-label="Field type"
+                  options={fieldTypes}
+                  value={field.type}
+                  placeholder="Auto"
+                  isClearable
+                  width={16}
+                  onChange={(value) => {
+                    update({
+                      fields: fields.map((f, i) => (i === index ? { ...f, type: value?.value } : f)),
+                    });
+                  }}
+                />
+                <IconButton
+                  name="trash-alt"
+                  tooltip="Remove field"
+                  onClick={() => update({ fields: fields.filter((_, i) => i !== index) })}
+                />
+              </Stack>
+            ))}
+            <div>
+              <Button
+                icon="plus"
+                variant="secondary"
+                size="sm"
+                onClick={() => update({ fields: [...fields, { path: '' }] }, false)}
+              >
+                Add field
+              </Button>
+            </div>
+          </Stack>
+        </EditorField>
+      </EditorRow>
+      <EditorRow>
+        <EditorField label="Pagination">
+          <RadioButtonGroup
+            options={paginationModes}
+            value={pagination.mode ?? ''}
+            onChange={(mode) => updatePagination({ mode: mode || undefined }, true)}
+          />
+        </EditorField>
+        {pagination.mode && (
+          <>
+            <EditorField label="Parameter" tooltip="The query parameter of the page number, the offset or the cursor">
+              <Input
+                value={pagination.param ?? ''}
+                width={16}
+                onChange={(e) => updatePagination({ param: e.currentTarget.value })}
+                onBlur={onRunQuery}
+              />
+            </EditorField>
+            <EditorField label="Size parameter" optional>
+              <Input
+                value={pagination.sizeParam ?? ''}
+                width={16}
+                onChange={(e) => updatePagination({ sizeParam: e.currentTarget.value })}
+                onBlur={onRunQuery}
+              />
+            </EditorField>
+            <EditorField label="Page size" tooltip="The last page has less rows than the page size">
+              <Input
+                type="number"
+                value={pagination.size ?? ''}
+                width={12}
+                onChange={(e) => updatePagination({ size: toNumber(e.currentTarget.value) })}
+                onBlur={onRunQuery}
+              />
+            </EditorField>
+            {pagination.mode === 'cursor' && (
+              <EditorField label="Cursor path" tooltip="The expression selecting the cursor of the next page">
+                <Input
+                  value={pagination.cursorPath ?? ''}
+                  width={24}
+                  onChange={(e) => updatePagination({ cursorPath: e.currentTarget.value })}
+                  onBlur={onRunQuery}
+                />
+              </EditorField>
+            )}
+            <EditorField label="Max pages" tooltip="At most 100 pages are requested">
+              <Input
+                type="number"
+                value={pagination.maxPages ?? ''}
+                placeholder="10"
+                width={12}
+                onChange={(e) => updatePagination({ maxPages: toNumber(e.currentTarget.value) })}
+                onBlur={onRunQuery}
+              />
+            </EditorField>
+          </>
+        )}
+      </EditorRow>
+    </EditorRows>
+  );
+};
+
+interface KeyValueEditorProps {
+  label: string;
+  values: KeyValue[];
+  onChange: (values: KeyValue[]) => void;
+  onBlur: () => void;
+}
+
+const KeyValueEditor = ({ label, values, onChange, onBlur }: KeyValueEditorProps) => {
+  const updateValue = (index: number, changes: Partial<KeyValue>) => {
+    onChange(values.map((kv, i) => (i === index ? { ...kv, ...changes } : kv)));
+  };
+
+  return (
+    <EditorField label={label}>
+      <Stack direction="column" gap={1}>
+        {values.map((kv, index) => (
+          <Stack key={index} gap={1} alignItems="center">
+            <Input
+              aria-label={`${label} key`}
+              value={kv.key}
+              placeholder="Key"
+              width={20}
+              onChange={(e) => updateValue(index, { key: e.currentTarget.value })}
+              onBlur={onBlur}
+            />
+            <Input
+              aria-label={`${label} value`}
+              value={kv.value}
+              placeholder="Value"
+              width={30}
+              onChange={(e) => updateValue(index, { value: e.currentTarget.value })}
+              onBlur={onBlur}
+            />
+            <IconButton
+              name="trash-alt"
+              tooltip="Remove"
+              onClick={() => {
+                onChange(values.filter((_, i) => i !== index));
+                onBlur();
+              }}
+            />
+          </Stack>
+        ))}
+        <div>
+          <Button icon="plus" variant="secondary" size="sm" onClick={() => onChange([...values, { key: '', value: '' }])}>
+            Add
+          </Button>
+        </div>
+      </Stack>
+    </EditorField>
+  );
+};
+
+function toNumber(value: string): number | undefined {
+  const n = parseInt(value, 10);
+  return isNaN(n) ? undefined : n;
+}
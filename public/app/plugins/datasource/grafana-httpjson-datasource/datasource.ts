@@ -0,0 +1,32 @@
+import { DataSourceInstanceSettings, ScopedVars } from '@grafana/data';
+import { DataSourceWithBackend, getTemplateSrv, TemplateSrv } from '@grafana/runtime';
+
+import { HttpJsonOptions, HttpJsonQuery, KeyValue } from './types';
+
+export class HttpJsonDatasource extends DataSourceWithBackend<HttpJsonQuery, HttpJsonOptions> {
+  constructor(
+    instanceSettings: DataSourceInstanceSettings<HttpJsonOptions>,
+    private readonly templateSrv: TemplateSrv = getTemplateSrv()
+  ) {
+    super(instanceSettings);
+  }
+
+  filterQuery(query: HttpJsonQuery): boolean {
+    return !query.hide && Boolean(query.fields?.some((field) => field.path));
+  }
+
+  // The backend replaces the global time variables too, so that the alert rules get the same requests.
+  applyTemplateVariables(query: HttpJsonQuery, scopedVars: ScopedVars): HttpJsonQuery {
+    const replace = (value?: string) => (value ? this.templateSrv.replace(value, scopedVars) : value);
+    const replaceAll = (values?: KeyValue[]) =>
+      values?.map((kv) => ({ key: kv.key, value: this.templateSrv.replace(kv.value, scopedVars) }));
+
+    return {
+      ...query,
+      path: replace(query.path),
+      params: replaceAll(query.params),
+      headers: replaceAll(query.headers),
+      body: replace(query.body),
+    };
+  }
+}
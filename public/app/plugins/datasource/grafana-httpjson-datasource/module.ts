@@ -0,0 +1,10 @@
+import { DataSourcePlugin } from '@grafana/data';
+
+import { ConfigEditor } from './components/ConfigEditor';
+import { QueryEditor } from './components/QueryEditor';
+import { HttpJsonDatasource } from './datasource';
+import { HttpJsonOptions, HttpJsonQuery } from './types';
+
+export const plugin = new DataSourcePlugin<HttpJsonDatasource, HttpJsonQuery, HttpJsonOptions>(HttpJsonDatasource)
+  .setQueryEditor(QueryEditor)
+  .setConfigEditor(ConfigEditor);
@@ -0,0 +1,46 @@
+import { DataQuery, DataSourceJsonData } from '@grafana/data';
+
+export type Method = 'GET' | 'POST';
+
+export type Language = 'jsonpath' | 'jmespath';
+
+export type FieldType = 'string' | 'number' | 'boolean' | 'time';
+
+export type PaginationMode = 'page' | 'offset' | 'cursor';
+
+export interface KeyValue {
+  key: string;
+  value: string;
+}
+
+export interface FieldModel {
+  name?: string;
+  path: string;
+  // The type of the first value is used when the type isn't set
+  type?: FieldType;
+}
+
+export interface Pagination {
+  mode?: PaginationMode;
+  param?: string;
+  sizeParam?: string;
+  size?: number;
+  cursorPath?: string;
+  maxPages?: number;
+}
+
+export interface HttpJsonQuery extends DataQuery {
+  method?: Method;
+  path?: string;
+  params?: KeyValue[];
+  headers?: KeyValue[];
+  body?: string;
+  language?: Language;
+  fields?: FieldModel[];
+  pagination?: Pagination;
+}
+
+export interface HttpJsonOptions extends DataSourceJsonData {
+  cacheTTL?: string;
+  healthCheckPath?: string;
+}
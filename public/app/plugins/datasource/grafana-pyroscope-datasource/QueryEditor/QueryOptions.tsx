@@ -21,6 +21,7 @@ const typeOptions: Array<{ value: Query['queryType']; label: string; description
   { value: 'metrics', label: 'Metric', description: 'Return aggregated metrics' },
   { value: 'profile', label: 'Profile', description: 'Return profile' },
   { value: 'both', label: 'Both', description: 'Return both metric and profile data' },
+  { value: 'diff', label: 'Diff', description: 'Return the difference between a baseline and the queried profile' },
 ];
 
 function getTypeOptions(app?: CoreApp) {
@@ -53,6 +54,12 @@ export function QueryOptions({ query, onQueryChange, app, labels }: Props) {
   if (query.maxNodes) {
     collapsedInfo.push(`Max nodes: ${query.maxNodes}`);
   }
+  if (query.queryType === 'diff' && query.baselineLabelSelector) {
+    collapsedInfo.push(`Baseline: ${query.baselineLabelSelector}`);
+  }
+  if (query.queryType === 'diff' && query.baselineTimeShift) {
+    collapsedInfo.push(`Baseline time shift: ${query.baselineTimeShift}`);
+  }
 
   return (
     <Stack gap={0} direction="column">
@@ -111,6 +118,36 @@ export function QueryOptions({ query, onQueryChange, app, labels }: Props) {
               }}
             />
           </EditorField>
+          {query.queryType === 'diff' && (
+            <>
+              <EditorField
+                label={'Baseline label selector'}
+                tooltip={<>Selects the baseline profile. Defaults to the label selector of the query.</>}
+              >
+                <Input
+                  value={query.baselineLabelSelector || ''}
+                  type="string"
+                  placeholder='{service_name="app", version="1.0"}'
+                  onChange={(event: React.SyntheticEvent<HTMLInputElement>) => {
+                    onQueryChange({ ...query, baselineLabelSelector: event.currentTarget.value });
+                  }}
+                />
+              </EditorField>
+              <EditorField
+                label={'Baseline time shift'}
+                tooltip={<>Shifts the time range of the baseline profile back, for example 7d.</>}
+              >
+                <Input
+                  value={query.baselineTimeShift || ''}
+                  type="string"
+                  placeholder="7d"
+                  onChange={(event: React.SyntheticEvent<HTMLInputElement>) => {
+                    onQueryChange({ ...query, baselineTimeShift: event.currentTarget.value });
+                  }}
+                />
+              </EditorField>
+            </>
+          )}
         </div>
       </QueryOptionGroup>
     </Stack>
@@ -38,8 +38,12 @@ composableKinds: DataQuery: {
 				// Allows to group the results.
 				groupBy: [...string]
 				// Sets the maximum number of nodes in the flamegraph.
-				maxNodes?:           int64
-				#PyroscopeQueryType: "metrics" | "profile" | *"both" @cuetsy(kind="type")
+				maxNodes?: int64
+				// Specifies the label selectors of the baseline profile of a diff query. Defaults to the label selectors of the query.
+				baselineLabelSelector?: string
+				// Shifts the time range of the baseline profile of a diff query back by this duration, for example 7d.
+				baselineTimeShift?:  string
+				#PyroscopeQueryType: "metrics" | "profile" | *"both" | "diff" @cuetsy(kind="type")
 			}
 		}]
 		lenses: []
@@ -10,11 +10,19 @@
 
 import * as common from '@grafana/schema';
 
-export type PyroscopeQueryType = ('metrics' | 'profile' | 'both');
+export type PyroscopeQueryType = ('metrics' | 'profile' | 'both' | 'diff');
 
 export const defaultPyroscopeQueryType: PyroscopeQueryType = 'both';
 
 export interface GrafanaPyroscopeDataQuery extends common.DataQuery {
+  /**
+   * Specifies the label selectors of the baseline profile of a diff query. Defaults to the label selectors of the query.
+   */
+  baselineLabelSelector?: string;
+  /**
+   * Shifts the time range of the baseline profile of a diff query back by this duration, for example 7d.
+   */
+  baselineTimeShift?: string;
   /**
    * Allows to group the results.
    */
@@ -76,6 +76,12 @@ export class PyroscopeDataSource extends DataSourceWithBackend<Query, PyroscopeD
       ...query,
       labelSelector: this.templateSrv.replace(query.labelSelector ?? '', scopedVars),
       profileTypeId: this.templateSrv.replace(query.profileTypeId ?? '', scopedVars),
+      ...(query.baselineLabelSelector && {
+        baselineLabelSelector: this.templateSrv.replace(query.baselineLabelSelector, scopedVars),
+      }),
+      ...(query.baselineTimeShift && {
+        baselineTimeShift: this.templateSrv.replace(query.baselineTimeShift, scopedVars),
+      }),
     };
   }
 
@@ -14,6 +14,7 @@ import { GrafanaLiveEditor } from './components/GrafanaLiveEditor';
 import { NodeGraphEditor } from './components/NodeGraphEditor';
 import { PredictablePulseEditor } from './components/PredictablePulseEditor';
 import { RawFrameEditor } from './components/RawFrameEditor';
+import { RecordingEditor } from './components/RecordingEditor';
 import { SimulationQueryEditor } from './components/SimulationQueryEditor';
 import { USAQueryEditor, usaQueryModes } from './components/USAQueryEditor';
 import { defaultCSVWaveQuery, defaultPulseQuery, defaultQuery } from './constants';
@@ -289,6 +290,7 @@ export const QueryEditor = ({ query, datasource, onChange, onRunQuery }: Props)
       {scenarioId === TestDataQueryType.CSVContent && (
         <CSVContentEditor onChange={onUpdate} query={query} ds={datasource} />
       )}
+      {scenarioId === TestDataQueryType.Replay && <RecordingEditor onChange={onUpdate} query={query} ds={datasource} />}
       {scenarioId === TestDataQueryType.Logs && (
         <InlineFieldRow>
           <InlineField label="Lines" labelWidth={14}>
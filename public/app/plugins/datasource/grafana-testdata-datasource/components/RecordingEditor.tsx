@@ -0,0 +1,23 @@
+import React from 'react';
+
+import { CodeEditor } from '@grafana/ui';
+
+import { EditorProps } from '../QueryEditor';
+
+export const RecordingEditor = ({ onChange, query }: EditorProps) => {
+  const onSaveRecording = (recording: string) => {
+    onChange({ ...query, recording });
+  };
+
+  return (
+    <CodeEditor
+      height={300}
+      language="json"
+      value={query.recording ?? ''}
+      onBlur={onSaveRecording}
+      onSave={onSaveRecording}
+      showMiniMap={false}
+      showLineNumbers={true}
+    />
+  );
+};
@@ -69,8 +69,28 @@ export class UnthemedQueryEditor extends PureComponent<Props, State> {
       value: GrafanaQueryType.List,
       description: 'Show directory listings for public resources',
     },
+    {
+      label: 'Alert states',
+      value: GrafanaQueryType.AlertStates,
+      description: 'Current state of the alert instances',
+    },
+    {
+      label: 'Alert state history',
+      value: GrafanaQueryType.StateHistory,
+      description: 'State changes of the alert rules within the selected time range',
+    },
+    {
+      label: 'Usage statistics',
+      value: GrafanaQueryType.Usage,
+      description: 'Usage statistics of the organization',
+    },
   ];
 
+  alertStates: Array<SelectableValue<string>> = ['Normal', 'Alerting', 'Pending', 'NoData', 'Error'].map((value) => ({
+    label: value,
+    value,
+  }));
+
   constructor(props: Props) {
     super(props);
 
@@ -346,6 +366,69 @@ export class UnthemedQueryEditor extends PureComponent<Props, State> {
     );
   }
 
+  onRuleUIDChange = (e: React.FormEvent<HTMLInputElement>) => {
+    const { onChange, query } = this.props;
+    onChange({ ...query, ruleUID: e.currentTarget.value || undefined });
+  };
+
+  onAlertStateChange = (sel: SelectableValue<string> | null) => {
+    const { onChange, query, onRunQuery } = this.props;
+    onChange({ ...query, state: sel?.value });
+    onRunQuery();
+  };
+
+  onLabelsChange = (e: React.FormEvent<HTMLInputElement>) => {
+    const { onChange, query } = this.props;
+    const labels: Record<string, string> = {};
+    for (const matcher of e.currentTarget.value.split(',')) {
+      const [name, value] = matcher.split('=').map((v) => v.trim());
+      if (name) {
+        labels[name] = value ?? '';
+      }
+    }
+    onChange({ ...query, labels: Object.keys(labels).length ? labels : undefined });
+  };
+
+  renderAlertingQuery() {
+    const { query, onRunQuery } = this.props;
+    const labels = Object.entries(query.labels ?? {})
+      .map(([name, value]) => `${name}=${value}`)
+      .join(', ');
+
+    return (
+      <InlineFieldRow>
+        <InlineField label="Rule UID" labelWidth={labelWidth} tooltip="Only the instances of this alert rule">
+          <Input
+            width={20}
+            defaultValue={query.ruleUID}
+            placeholder="All rules"
+            onChange={this.onRuleUIDChange}
+            onBlur={onRunQuery}
+          />
+        </InlineField>
+        {query.queryType === GrafanaQueryType.AlertStates && (
+          <InlineField label="State">
+            <Select
+              width={20}
+              options={this.alertStates}
+              value={query.state ?? null}
+              onChange={this.onAlertStateChange}
+              placeholder="All states"
+              isClearable={true}
+            />
+          </InlineField>
+        )}
+        <InlineField
+          label="Labels"
+          grow={true}
+          tooltip="Only the instances with these labels, for example team=a, env=prod"
+        >
+          <Input defaultValue={labels} placeholder="name=value" onChange={this.onLabelsChange} onBlur={onRunQuery} />
+        </InlineField>
+      </InlineFieldRow>
+    );
+  }
+
   // Skip rendering the file list as we're handling that in this component instead.
   fileListRenderer = (file: DropzoneFile, removeFile: (file: DropzoneFile) => void) => {
     return null;
@@ -471,6 +554,8 @@ export class UnthemedQueryEditor extends PureComponent<Props, State> {
         {queryType === GrafanaQueryType.LiveMeasurements && this.renderMeasurementsQuery()}
         {queryType === GrafanaQueryType.List && this.renderListPublicFiles()}
         {queryType === GrafanaQueryType.Snapshot && this.renderSnapshotQuery()}
+        {(queryType === GrafanaQueryType.AlertStates || queryType === GrafanaQueryType.StateHistory) &&
+          this.renderAlertingQuery()}
         {queryType === GrafanaQueryType.Search && (
           <SearchEditor value={query.search ?? {}} onChange={this.onSearchChange} />
         )}
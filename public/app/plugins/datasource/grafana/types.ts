@@ -19,6 +19,9 @@ export enum GrafanaQueryType {
   List = 'list',
   Read = 'read',
   Search = 'search',
+  AlertStates = 'alertStates',
+  StateHistory = 'stateHistory',
+  Usage = 'usage',
 }
 
 export interface GrafanaQuery extends DataQuery {
@@ -31,6 +34,9 @@ export interface GrafanaQuery extends DataQuery {
   snapshot?: DataFrameJSON[];
   timeRegion?: TimeRegionConfig;
   file?: GrafanaQueryFile;
+  ruleUID?: string; // for alert states and state history
+  state?: string; // for alert states
+  labels?: Record<string, string>; // for alert states and state history
 }
 
 export interface GrafanaQueryFile {
@@ -103,6 +103,16 @@ export class ConfigEditor extends PureComponent<Props, State> {
               />
             </Field>
           )}
+          <Field
+            label="Evaluate functions in Grafana"
+            description="Evaluates aliasByNode, sumSeries, scale, movingAverage, perSecond and groupByNode in Grafana for alerts and expressions, for stores that only return raw series. Targets with other functions are sent to Graphite."
+          >
+            <Switch
+              id="server-side-functions"
+              value={!!options.jsonData.serverSideFunctions}
+              onChange={onUpdateDatasourceJsonDataOptionChecked(this.props, 'serverSideFunctions')}
+            />
+          </Field>
         </FieldSet>
         <MappingsConfiguration
           mappings={(options.jsonData.importConfiguration?.loki?.mappings || []).map(toString)}
@@ -43,7 +43,25 @@ describe('flightsql datasource', () => {
   const ds = new FlightSQLDatasource(instanceSettings, templateSrv);
 
   it('should add template variables to the responses', async () => {
+    jest.spyOn(ds, 'getResource').mockResolvedValue([{ name: 'host', type: 'Dictionary(Int32, Utf8)' }]);
     const fields = await ds.fetchFields({ dataset: 'test', table: 'table' });
     expect(fields[0].name).toBe('$templateVar');
   });
+
+  it('should read the schema with the resources of the backend', async () => {
+    const getResource = jest.spyOn(ds, 'getResource');
+
+    getResource.mockResolvedValue(['cpu', 'my table']);
+    expect(await ds.fetchTables('iox')).toEqual(['$templateVar', 'cpu', '"my table"']);
+    expect(getResource).toHaveBeenLastCalledWith('tables');
+
+    getResource.mockResolvedValue([{ name: 'host', type: 'Dictionary(Int32, Utf8)' }]);
+    const fields = await ds.fetchFields({ dataset: 'iox', table: 'iox."my table"' });
+    expect(getResource).toHaveBeenLastCalledWith('columns', { table: 'my table' });
+    expect(fields.map((f) => f.name)).toEqual(['$templateVar', 'host']);
+
+    getResource.mockResolvedValue(['web', 'db']);
+    expect(await ds.fetchTagValues('cpu', 'host')).toEqual(['web', 'db']);
+    expect(getResource).toHaveBeenLastCalledWith('tagValues', { table: 'cpu', tag: 'host' });
+  });
 });
@@ -4,9 +4,8 @@ import { getTemplateSrv, TemplateSrv } from '@grafana/runtime';
 import { DB, formatSQL, SqlDatasource, SQLQuery } from '@grafana/sql';
 
 import { mapFieldsToTypes } from './fields';
-import { buildColumnQuery, buildTableQuery } from './flightsqlMetaQuery';
 import { getSqlCompletionProvider } from './sqlCompletionProvider';
-import { quoteIdentifierIfNecessary, quoteLiteral, toRawSql } from './sqlUtil';
+import { quoteIdentifierIfNecessary, quoteLiteral, toRawSql, unquoteIdentifier } from './sqlUtil';
 import { FlightSQLOptions } from './types';
 
 export class FlightSQLDatasource extends SqlDatasource {
@@ -30,6 +29,7 @@ export class FlightSQLDatasource extends SqlDatasource {
 
     const args = {
       getMeta: (identifier?: TableIdentifier) => this.fetchMeta(identifier),
+      getTagValues: (table: string, tag: string) => this.fetchTagValues(table, tag),
     };
     this.sqlLanguageDefinition = {
       id: 'flightsql',
@@ -43,10 +43,10 @@ export class FlightSQLDatasource extends SqlDatasource {
     return Promise.resolve(['iox']);
   }
 
+  // The tables, columns and tag values are read with the schema resources of the backend.
   async fetchTables(dataset?: string): Promise<string[]> {
-    const query = buildTableQuery(dataset);
-    const tables = await this.runSql<string[]>(query, { refId: 'tables' });
-    const tableNames = tables.map((t) => quoteIdentifierIfNecessary(t[0]));
+    const tables = await this.getResource<string[]>('tables');
+    const tableNames = tables.map((t) => quoteIdentifierIfNecessary(t));
     tableNames.unshift(...this.getTemplateVariables());
     return tableNames;
   }
@@ -56,14 +56,15 @@ export class FlightSQLDatasource extends SqlDatasource {
       return [];
     }
     const interpolatedTable = this.templateSrv.replace(query.table);
-    const queryString = buildColumnQuery(interpolatedTable, query.dataset);
-    const frame = await this.runSql<string[]>(queryString, { refId: 'fields' });
-    const fields = frame.map((f) => ({
-      name: f[0],
-      text: f[0],
-      value: quoteIdentifierIfNecessary(f[0]),
-      type: f[1],
-      label: f[0],
+    // the table can be qualified by the database, e.g. iox.cpu
+    const table = unquoteIdentifier(interpolatedTable.split('.').pop() ?? interpolatedTable);
+    const columns = await this.getResource<Array<{ name: string; type: string }>>('columns', { table });
+    const fields = columns.map((c) => ({
+      name: c.name,
+      text: c.name,
+      value: quoteIdentifierIfNecessary(c.name),
+      type: c.type,
+      label: c.name,
     }));
     fields.unshift(
       ...this.getTemplateVariables().map((v) => ({
@@ -77,6 +78,13 @@ export class FlightSQLDatasource extends SqlDatasource {
     return mapFieldsToTypes(fields);
   }
 
+  async fetchTagValues(table: string, tag: string): Promise<string[]> {
+    return this.getResource<string[]>('tagValues', {
+      table: unquoteIdentifier(this.templateSrv.replace(table)),
+      tag: unquoteIdentifier(tag),
+    });
+  }
+
   getTemplateVariables() {
     return this.templateSrv.getVariables().map((v) => `$${v.name}`);
   }
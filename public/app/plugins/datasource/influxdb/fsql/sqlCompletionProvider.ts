@@ -13,27 +13,33 @@ import {
   TokenType,
 } from '@grafana/experimental';
 
+import { quoteLiteral } from './sqlUtil';
+
 interface CompletionProviderGetterArgs {
   getMeta: (t?: TableIdentifier) => Promise<TableDefinition[]>;
+  getTagValues: (table: string, tag: string) => Promise<string[]>;
 }
 
 export const getSqlCompletionProvider: (args: CompletionProviderGetterArgs) => LanguageCompletionProvider =
-  ({ getMeta }) =>
+  ({ getMeta, getTagValues }) =>
   (monaco, language) => ({
     ...(language && getStandardSQLCompletionProvider(monaco, language)),
     customStatementPlacement: customStatementPlacementProvider,
-    customSuggestionKinds: customSuggestionKinds(getMeta),
+    customSuggestionKinds: customSuggestionKinds(getMeta, getTagValues),
   });
 
 const customStatementPlacement = {
   afterDatabase: 'afterDatabase',
+  afterTagComparison: 'afterTagComparison',
 };
 
 const customSuggestionKind = {
   tablesWithinDatabase: 'tablesWithinDatabase',
+  tagValues: 'tagValues',
 };
 
 const FROMKEYWORD = 'FROM';
+const CONDITION_KEYWORDS = ['WHERE', 'AND', 'OR'];
 
 export const customStatementPlacementProvider: StatementPlacementProvider = () => [
   {
@@ -50,10 +56,23 @@ export const customStatementPlacementProvider: StatementPlacementProvider = () =
       );
     },
   },
+  {
+    // e.g. WHERE host = |
+    id: customStatementPlacement.afterTagComparison,
+    resolve: (currentToken, previousKeyword, previousNonWhiteSpace) => {
+      return Boolean(
+        CONDITION_KEYWORDS.includes(previousKeyword?.value.toUpperCase() ?? '') &&
+          previousNonWhiteSpace?.is(TokenType.Operator, '=') &&
+          previousNonWhiteSpace.getPreviousNonWhiteSpaceToken()?.isIdentifier()
+      );
+    },
+  },
 ];
 
-export const customSuggestionKinds: (getMeta: CompletionProviderGetterArgs['getMeta']) => SuggestionKindProvider =
-  (getMeta) => () => [
+export const customSuggestionKinds: (
+  getMeta: CompletionProviderGetterArgs['getMeta'],
+  getTagValues: CompletionProviderGetterArgs['getTagValues']
+) => SuggestionKindProvider = (getMeta, getTagValues) => () => [
     {
       id: SuggestionKind.Tables,
       overrideDefault: true,
@@ -93,6 +112,35 @@ export const customSuggestionKinds: (getMeta: CompletionProviderGetterArgs['getM
         return suggestions.map(mapToSuggestion(ctx));
       },
     },
+    {
+      id: customSuggestionKind.tagValues,
+      applyTo: [customStatementPlacement.afterTagComparison],
+      suggestionsResolver: async (ctx) => {
+        const table = getQueryTableName(ctx.currentToken);
+        const operator = ctx.currentToken?.is(TokenType.Operator, '=')
+          ? ctx.currentToken
+          : ctx.currentToken?.getPreviousNonWhiteSpaceToken();
+        const tag = operator?.getPreviousNonWhiteSpaceToken()?.value;
+
+        if (!table || !tag) {
+          return [];
+        }
+
+        const values = await getTagValues(table, tag);
+
+        return values.map((value) => ({
+          label: value,
+          insertText: quoteLiteral(value),
+          kind: CompletionItemKind.Value,
+          sortText: CompletionItemPriority.High,
+          range: {
+            ...ctx.range,
+            startColumn: ctx.range.endColumn,
+            endColumn: ctx.range.endColumn,
+          },
+        }));
+      },
+    },
   ];
 
 function mapToSuggestion(ctx: PositionContext) {
@@ -137,6 +185,15 @@ const getFromKeywordToken = (currentToken: LinkedToken | null) => {
   return selectToken?.getNextOfType(TokenType.Keyword, FROMKEYWORD);
 };
 
+// getQueryTableName returns the table of the FROM clause, which is qualified by the database or not.
+const getQueryTableName = (currentToken: LinkedToken | null) => {
+  const token = getDatabaseToken(currentToken);
+  if (token?.next?.is(TokenType.Delimiter, '.')) {
+    return token.next.getNextOfType(TokenType.Identifier)?.value;
+  }
+  return token?.value;
+};
+
 const getDatabaseToken = (currentToken: LinkedToken | null) => {
   const fromToken = getFromKeywordToken(currentToken);
   const nextIdentifier = fromToken?.getNextOfType(TokenType.Identifier);
@@ -61,6 +61,22 @@ export const LokiQueryBuilderOptions = React.memo<Props>(
       }
     }
 
+    function onLiveLineFilterChange(e: React.SyntheticEvent<HTMLInputElement>) {
+      onChange({ ...query, liveLineFilter: e.currentTarget.value || undefined });
+      onRunQuery();
+    }
+
+    function onLiveLineFilterRegexChange(e: React.SyntheticEvent<HTMLInputElement>) {
+      onChange({ ...query, liveLineFilterRegex: e.currentTarget.value || undefined });
+      onRunQuery();
+    }
+
+    function onLiveMaxLinesPerSecondChange(e: React.SyntheticEvent<HTMLInputElement>) {
+      const value = parseInt(e.currentTarget.value, 10);
+      onChange({ ...query, liveMaxLinesPerSecond: value > 0 ? value : undefined });
+      onRunQuery();
+    }
+
     function onStepChange(e: React.SyntheticEvent<HTMLInputElement>) {
       onChange({ ...query, step: trim(e.currentTarget.value) });
       onRunQuery();
@@ -68,6 +84,8 @@ export const LokiQueryBuilderOptions = React.memo<Props>(
 
     const queryType = getLokiQueryType(query);
     const isLogQuery = isLogsQuery(query.expr);
+    const isLiveTail =
+      config.featureToggles.lokiExperimentalStreaming && queryType === LokiQueryType.Stream && isLogQuery;
 
     const isValidStep = useMemo(() => {
       if (!query.step || isValidGrafanaDuration(query.step) || !isNaN(Number(query.step))) {
@@ -110,6 +128,42 @@ export const LokiQueryBuilderOptions = React.memo<Props>(
               />
             </EditorField>
           )}
+          {isLiveTail && (
+            <>
+              <EditorField label="Live line filter" tooltip="Only streams the lines containing the text.">
+                <AutoSizeInput
+                  minWidth={14}
+                  type="string"
+                  defaultValue={query.liveLineFilter ?? ''}
+                  onCommitChange={onLiveLineFilterChange}
+                />
+              </EditorField>
+              <EditorField
+                label="Live line filter regex"
+                tooltip="Only streams the lines matching the regular expression. It is used instead of the line filter."
+              >
+                <AutoSizeInput
+                  minWidth={14}
+                  type="string"
+                  defaultValue={query.liveLineFilterRegex ?? ''}
+                  onCommitChange={onLiveLineFilterRegexChange}
+                />
+              </EditorField>
+              <EditorField
+                label="Live lines per second"
+                tooltip="Upper limit of the lines streamed per second, the lines over the limit are dropped."
+              >
+                <AutoSizeInput
+                  className="width-4"
+                  placeholder="no limit"
+                  type="number"
+                  min={0}
+                  defaultValue={query.liveMaxLinesPerSecond?.toString() ?? ''}
+                  onCommitChange={onLiveMaxLinesPerSecondChange}
+                />
+              </EditorField>
+            </>
+          )}
           {!isLogQuery && (
             <>
               <EditorField
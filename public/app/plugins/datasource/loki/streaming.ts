@@ -16,10 +16,16 @@ import { LokiQuery } from './types';
 /**
  * Calculate a unique key for the query.  The key is used to pick a channel and should
  * be unique for each distinct query execution plan.  This key is not secure and is only picked to avoid
- * possible collisions
+ * possible collisions. The backend reads the live tail options from the first subscriber of the channel,
+ * so they are part of the key.
  */
 export async function getLiveStreamKey(query: LokiQuery): Promise<string> {
-  const str = JSON.stringify({ expr: query.expr });
+  const str = JSON.stringify({
+    expr: query.expr,
+    liveLineFilter: query.liveLineFilter,
+    liveLineFilterRegex: query.liveLineFilterRegex,
+    liveMaxLinesPerSecond: query.liveMaxLinesPerSecond,
+  });
 
   const msgUint8 = new TextEncoder().encode(str); // encode as (utf-8) Uint8Array
   const hashBuffer = await crypto.subtle.digest('SHA-1', msgUint8); // hash the message
@@ -36,6 +36,14 @@ export interface LokiQuery extends LokiQueryFromSchema {
    * @experimental
    */
   splitDuration?: string;
+
+  /**
+   * Options of the live tail of stream queries, the backend applies them to the tailed lines.
+   * @experimental
+   */
+  liveLineFilter?: string;
+  liveLineFilterRegex?: string;
+  liveMaxLinesPerSecond?: number;
 }
 
 export interface LokiOptions extends DataSourceJsonData {
@@ -30,7 +30,7 @@ import { getTemplateSrv, TemplateSrv } from 'app/features/templating/template_sr
 
 import { AnnotationEditor } from './components/AnnotationEditor';
 import { prepareAnnotation } from './migrations';
-import { OpenTsdbFilter, OpenTsdbOptions, OpenTsdbQuery } from './types';
+import { OpenTsdbFilter, OpenTsdbLookupResult, OpenTsdbOptions, OpenTsdbQuery } from './types';
 
 export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenTsdbOptions> {
   type: any;
@@ -260,8 +260,8 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
   }
 
   _performSuggestQuery(query: string, type: string): Observable<any> {
-    return this._get('/api/suggest', { type, q: query, max: this.lookupLimit }).pipe(
-      map((result: any) => {
+    return this._getResource('suggest', { type, q: query, max: this.lookupLimit }).pipe(
+      map((result: FetchResponse<string[]>) => {
         return result.data;
       })
     );
@@ -284,16 +284,9 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
 
     const m = metric + '{' + keysQuery + '}';
 
-    return this._get('/api/search/lookup', { m: m, limit: this.lookupLimit }).pipe(
-      map((result: any) => {
-        result = result.data.results;
-        const tagvs: any[] = [];
-        each(result, (r) => {
-          if (tagvs.indexOf(r.tags[key]) === -1) {
-            tagvs.push(r.tags[key]);
-          }
-        });
-        return tagvs;
+    return this._getResource('lookup', { m: m, limit: this.lookupLimit }).pipe(
+      map((result: FetchResponse<OpenTsdbLookupResult>) => {
+        return result.data.tags[key] ?? [];
       })
     );
   }
@@ -303,22 +296,26 @@ export default class OpenTsDatasource extends DataSourceApi<OpenTsdbQuery, OpenT
       return of([]);
     }
 
-    return this._get('/api/search/lookup', { m: metric, limit: 1000 }).pipe(
-      map((result: any) => {
-        result = result.data.results;
-        const tagks: any[] = [];
-        each(result, (r) => {
-          each(r.tags, (tagv, tagk) => {
-            if (tagks.indexOf(tagk) === -1) {
-              tagks.push(tagk);
-            }
-          });
-        });
-        return tagks;
+    return this._getResource('lookup', { m: metric, limit: 1000 }).pipe(
+      map((result: FetchResponse<OpenTsdbLookupResult>) => {
+        return Object.keys(result.data.tags);
       })
     );
   }
 
+  // _getResource calls the suggest and lookup resources of the backend, which send the requests to OpenTSDB with the
+  // authentication of the data source.
+  _getResource(
+    path: 'suggest' | 'lookup',
+    params: { type?: string; q?: string; max?: number; m?: string; limit?: number }
+  ): Observable<FetchResponse> {
+    return getBackendSrv().fetch({
+      method: 'GET',
+      url: `/api/datasources/uid/${this.uid}/resources/${path}`,
+      params: params,
+    });
+  }
+
   _get(
     relativeUrl: string,
     params?: { type?: string; q?: string; max?: number; m?: any; limit?: number }
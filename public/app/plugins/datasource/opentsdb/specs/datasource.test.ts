@@ -23,13 +23,15 @@ const metricFindQueryData = [
   },
 ];
 
+const lookupData = { tags: { hostname: ['web-1', 'web-2'] } };
+
 describe('opentsdb', () => {
   function getTestcontext({ data = metricFindQueryData }: { data?: unknown } = {}) {
     jest.clearAllMocks();
     const fetchMock = jest.spyOn(backendSrv, 'fetch');
     fetchMock.mockImplementation(() => of(createFetchResponse(data)));
 
-    const instanceSettings = { url: '', jsonData: { tsdbVersion: 1 } };
+    const instanceSettings = { uid: 'opentsdb', url: '', jsonData: { tsdbVersion: 1 } };
     const replace = jest.fn((value) => value);
     const templateSrv = {
       replace,
@@ -47,52 +49,52 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('metrics(pew)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/suggest');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/uid/opentsdb/resources/suggest');
       expect(fetchMock.mock.calls[0][0].params?.type).toBe('metrics');
       expect(fetchMock.mock.calls[0][0].params?.q).toBe('pew');
       expect(results).not.toBe(null);
     });
 
     it('tag_names(cpu) should generate lookup query', async () => {
-      const { ds, fetchMock } = getTestcontext();
+      const { ds, fetchMock } = getTestcontext({ data: lookupData });
 
       const results = await ds.metricFindQuery('tag_names(cpu)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/search/lookup');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/uid/opentsdb/resources/lookup');
       expect(fetchMock.mock.calls[0][0].params?.m).toBe('cpu');
-      expect(results).not.toBe(null);
+      expect(results).toEqual([{ text: 'hostname' }]);
     });
 
     it('tag_values(cpu, test) should generate lookup query', async () => {
-      const { ds, fetchMock } = getTestcontext();
+      const { ds, fetchMock } = getTestcontext({ data: lookupData });
 
       const results = await ds.metricFindQuery('tag_values(cpu, hostname)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/search/lookup');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/uid/opentsdb/resources/lookup');
       expect(fetchMock.mock.calls[0][0].params?.m).toBe('cpu{hostname=*}');
-      expect(results).not.toBe(null);
+      expect(results).toEqual([{ text: 'web-1' }, { text: 'web-2' }]);
     });
 
     it('tag_values(cpu, test) should generate lookup query', async () => {
-      const { ds, fetchMock } = getTestcontext();
+      const { ds, fetchMock } = getTestcontext({ data: lookupData });
 
       const results = await ds.metricFindQuery('tag_values(cpu, hostname, env=$env)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/search/lookup');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/uid/opentsdb/resources/lookup');
       expect(fetchMock.mock.calls[0][0].params?.m).toBe('cpu{hostname=*,env=$env}');
       expect(results).not.toBe(null);
     });
 
     it('tag_values(cpu, test) should generate lookup query', async () => {
-      const { ds, fetchMock } = getTestcontext();
+      const { ds, fetchMock } = getTestcontext({ data: lookupData });
 
       const results = await ds.metricFindQuery('tag_values(cpu, hostname, env=$env, region=$region)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/search/lookup');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/uid/opentsdb/resources/lookup');
       expect(fetchMock.mock.calls[0][0].params?.m).toBe('cpu{hostname=*,env=$env,region=$region}');
       expect(results).not.toBe(null);
     });
@@ -103,7 +105,7 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('suggest_tagk(foo)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/suggest');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/uid/opentsdb/resources/suggest');
       expect(fetchMock.mock.calls[0][0].params?.type).toBe('tagk');
       expect(fetchMock.mock.calls[0][0].params?.q).toBe('foo');
       expect(results).not.toBe(null);
@@ -115,7 +117,7 @@ describe('opentsdb', () => {
       const results = await ds.metricFindQuery('suggest_tagv(bar)');
 
       expect(fetchMock).toHaveBeenCalledTimes(1);
-      expect(fetchMock.mock.calls[0][0].url).toBe('/api/suggest');
+      expect(fetchMock.mock.calls[0][0].url).toBe('/api/datasources/uid/opentsdb/resources/suggest');
       expect(fetchMock.mock.calls[0][0].params?.type).toBe('tagv');
       expect(fetchMock.mock.calls[0][0].params?.q).toBe('bar');
       expect(results).not.toBe(null);
@@ -52,3 +52,8 @@ export type OpenTsdbFilter = {
   filter: string;
   groupBy: boolean;
 };
+
+// OpenTsdbLookupResult is the response of the lookup resource, the distinct values of every tag key of the series.
+export type OpenTsdbLookupResult = {
+  tags: Record<string, string[]>;
+};
@@ -161,12 +161,14 @@ export const PromSettings = (props: Props) => {
     timeInterval: string;
     queryTimeout: string;
     incrementalQueryOverlapWindow: string;
+    querySplitInterval: string;
   };
 
   const [validDuration, updateValidDuration] = useState<ValidDuration>({
     timeInterval: '',
     queryTimeout: '',
     incrementalQueryOverlapWindow: '',
+    querySplitInterval: '',
   });
 
   return (
@@ -482,6 +484,75 @@ export const PromSettings = (props: Props) => {
               </InlineField>
             </div>
           </div>
+
+          <div className="gf-form-inline">
+            <div className="gf-form max-width-30">
+              <InlineField
+                label="Query concurrency"
+                labelWidth={PROM_CONFIG_LABEL_WIDTH}
+                tooltip={
+                  <>
+                    The maximum number of requests sent to Prometheus at the same time for the queries of a panel,
+                    including the parts of split queries. Defaults to 4.
+                  </>
+                }
+                interactive={true}
+                disabled={options.readOnly}
+              >
+                <Input
+                  className="width-20"
+                  type="number"
+                  min={1}
+                  value={options.jsonData.queryConcurrency ?? ''}
+                  onChange={(e) =>
+                    updateDatasourcePluginJsonDataOption(
+                      { onOptionsChange, options },
+                      'queryConcurrency',
+                      e.currentTarget.value ? Number(e.currentTarget.value) : undefined
+                    )
+                  }
+                  spellCheck={false}
+                  placeholder="4"
+                  data-testid={selectors.components.DataSource.Prometheus.configPage.queryConcurrency}
+                />
+              </InlineField>
+            </div>
+          </div>
+
+          <div className="gf-form-inline">
+            <div className="gf-form max-width-30">
+              <InlineField
+                label="Query split interval"
+                labelWidth={PROM_CONFIG_LABEL_WIDTH}
+                tooltip={
+                  <>
+                    Split range queries longer than this duration, like 1d or 12h, into parts that are sent
+                    concurrently. Leave empty to disable splitting.
+                  </>
+                }
+                interactive={true}
+                disabled={options.readOnly}
+              >
+                <>
+                  <Input
+                    className="width-20"
+                    value={options.jsonData.querySplitInterval}
+                    onChange={onChangeHandler('querySplitInterval', options, onOptionsChange)}
+                    onBlur={(e) =>
+                      updateValidDuration({
+                        ...validDuration,
+                        querySplitInterval: e.currentTarget.value,
+                      })
+                    }
+                    spellCheck={false}
+                    placeholder="1d"
+                    data-testid={selectors.components.DataSource.Prometheus.configPage.querySplitInterval}
+                  />
+                  {validateInput(validDuration.querySplitInterval, DURATION_REGEX, durationError)}
+                </>
+              </InlineField>
+            </div>
+          </div>
         </div>
       </ConfigSubSection>
 
@@ -56,6 +56,10 @@ export interface TempoQuery extends common.DataQuery {
    * Defines the maximum number of spans per spanset that are returned from Tempo
    */
   spss?: number;
+  /**
+   * For metrics queries, the step of the returned time series. Use duration format, for example: 30s, 1m
+   */
+  step?: string;
   /**
    * The type of the table that is used to display the search results
    */
@@ -70,7 +74,7 @@ export const defaultTempoQuery: Partial<TempoQuery> = {
 /**
  * nativeSearch = Tempo search for backwards compatibility
  */
-export type TempoQueryType = ('traceql' | 'traceqlSearch' | 'serviceMap' | 'upload' | 'nativeSearch' | 'traceId' | 'clear');
+export type TempoQueryType = ('traceql' | 'traceqlSearch' | 'serviceMap' | 'upload' | 'nativeSearch' | 'traceId' | 'clear' | 'traceqlMetrics');
 
 /**
  * The state of the TraceQL streaming search query